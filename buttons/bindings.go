@@ -0,0 +1,152 @@
+package buttons
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bpicode/fritzctl/fritz"
+	"gopkg.in/yaml.v2"
+)
+
+// Defaults applied when parsing bindings.
+const (
+	DefaultMaxAge  = 5 * time.Minute
+	DefaultTimeout = 30 * time.Second
+)
+
+// Bindings is the content of a bindings file.
+type Bindings struct {
+	MaxAge   time.Duration // Presses older than this when detected, e.g. after downtime, are not acted upon.
+	Bindings []Binding     // The bindings.
+}
+
+// Binding ties an action to presses of a button.
+// codebeat:disable[TOO_MANY_IVARS]
+type Binding struct {
+	Name        string        // Unique name of the binding, used in logs.
+	Button      string        // Name of the button device.
+	Unit        string        // Optional button unit, matched against its AIN, its name or the name suffix, e.g. "kurz".
+	Press       string        // Optional "short" or "long", for devices reporting them as separate units.
+	Action      string        // One of "on", "off", "toggle", "temperature", "manifest", "shell" or "webhook".
+	Temperature float64       // The temperature in °C, only for the "temperature" action.
+	Devices     []string      // Names of the devices, for the "on", "off", "toggle" and "temperature" actions.
+	Manifest    string        // Path to the manifest file, only for the "manifest" action.
+	Command     string        // Shell command, only for the "shell" action.
+	URL         string        // URL of the webhook, only for the "webhook" action.
+	Method      string        // HTTP method of the webhook, defaults to POST.
+	Timeout     time.Duration // Limit for shell commands and webhooks, defaults to 30s.
+}
+
+// codebeat:enable[TOO_MANY_IVARS]
+
+// ParseFile reads the bindings from a YAML file.
+func ParseFile(filename string) (*Bindings, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return Parse(file)
+}
+
+// Parse reads the bindings from YAML, validates them and applies the defaults.
+func Parse(r io.Reader) (*Bindings, error) {
+	bytes, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var bs Bindings
+	if err := yaml.Unmarshal(bytes, &bs); err != nil {
+		return nil, err
+	}
+	if bs.MaxAge == 0 {
+		bs.MaxAge = DefaultMaxAge
+	}
+	for i := range bs.Bindings {
+		b := &bs.Bindings[i]
+		if b.Timeout == 0 {
+			b.Timeout = DefaultTimeout
+		}
+		if b.Action == "webhook" && b.Method == "" {
+			b.Method = "POST"
+		}
+	}
+	return &bs, bs.validate()
+}
+
+func (bs *Bindings) validate() error {
+	names := make(map[string]bool)
+	for i, b := range bs.Bindings {
+		if b.Name == "" {
+			return fmt.Errorf("binding #%d has no name", i+1)
+		}
+		if names[b.Name] {
+			return fmt.Errorf("binding name '%s' is not unique", b.Name)
+		}
+		names[b.Name] = true
+		if err := b.validate(); err != nil {
+			return fmt.Errorf("binding '%s': %v", b.Name, err)
+		}
+	}
+	return nil
+}
+
+func (b Binding) validate() error {
+	if b.Button == "" {
+		return fmt.Errorf("no button given")
+	}
+	if b.Press != "" && b.Press != fritz.ShortPress && b.Press != fritz.LongPress {
+		return fmt.Errorf("unknown press '%s', expected short or long", b.Press)
+	}
+	switch b.Action {
+	case "on", "off", "toggle", "temperature":
+		if len(b.Devices) == 0 {
+			return fmt.Errorf("no devices given")
+		}
+	case "manifest":
+		if b.Manifest == "" {
+			return fmt.Errorf("no manifest given")
+		}
+	case "shell":
+		if b.Command == "" {
+			return fmt.Errorf("no command given")
+		}
+	case "webhook":
+		if !strings.HasPrefix(b.URL, "http://") && !strings.HasPrefix(b.URL, "https://") {
+			return fmt.Errorf("webhook url '%s' is not an http(s) URL", b.URL)
+		}
+	default:
+		return fmt.Errorf("unknown action '%s', expected one of on, off, toggle, temperature, manifest, shell, webhook", b.Action)
+	}
+	return nil
+}
+
+// Matches returns true if the binding applies to the press.
+func (b Binding) Matches(p Press) bool {
+	if p.Device.Name != b.Button {
+		return false
+	}
+	if b.Press != "" && b.Press != p.Unit.PressType() {
+		return false
+	}
+	return b.Unit == "" || b.Unit == p.Unit.Identifier || b.Unit == p.Unit.Name || strings.EqualFold(b.Unit, p.Unit.UnitName())
+}
+
+// String describes the action of the binding.
+func (b Binding) String() string {
+	switch b.Action {
+	case "temperature":
+		return fmt.Sprintf("%s: temperature %.1f°C %s", b.Name, b.Temperature, strings.Join(b.Devices, ", "))
+	case "manifest":
+		return fmt.Sprintf("%s: manifest %s", b.Name, b.Manifest)
+	case "shell":
+		return fmt.Sprintf("%s: shell %s", b.Name, b.Command)
+	case "webhook":
+		return fmt.Sprintf("%s: webhook %s %s", b.Name, b.Method, b.URL)
+	}
+	return fmt.Sprintf("%s: %s %s", b.Name, b.Action, strings.Join(b.Devices, ", "))
+}
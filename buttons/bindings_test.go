@@ -0,0 +1,96 @@
+package buttons
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bpicode/fritzctl/fritz"
+	"github.com/stretchr/testify/assert"
+)
+
+const exampleBindings = `
+bindings:
+  - name: light
+    button: BTN_1
+    action: toggle
+    devices: [SWITCH_1]
+  - name: night
+    button: DECT 400
+    press: long
+    action: manifest
+    manifest: night.yml
+  - name: hook
+    button: DECT 440
+    unit: oben rechts
+    action: webhook
+    url: http://localhost/hook
+    timeout: 5s
+`
+
+// TestParse tests the parsing of a bindings file and the defaults.
+func TestParse(t *testing.T) {
+	bs, err := Parse(strings.NewReader(exampleBindings))
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultMaxAge, bs.MaxAge)
+	assert.Len(t, bs.Bindings, 3)
+	assert.Equal(t, DefaultTimeout, bs.Bindings[0].Timeout)
+	assert.Equal(t, 5*time.Second, bs.Bindings[2].Timeout)
+	assert.Equal(t, "POST", bs.Bindings[2].Method)
+	assert.Equal(t, "light: toggle SWITCH_1", bs.Bindings[0].String())
+	assert.Equal(t, "night: manifest night.yml", bs.Bindings[1].String())
+	assert.Equal(t, "hook: webhook POST http://localhost/hook", bs.Bindings[2].String())
+}
+
+// TestParseInvalid tests the validation of bindings.
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		bindings string
+	}{
+		{name: "no name", bindings: `bindings: [{button: b, action: on, devices: [a]}]`},
+		{name: "duplicate name", bindings: `bindings: [{name: a, button: b, action: on, devices: [a]}, {name: a, button: b, action: on, devices: [a]}]`},
+		{name: "no button", bindings: `bindings: [{name: a, action: on, devices: [a]}]`},
+		{name: "bad press", bindings: `bindings: [{name: a, button: b, press: double, action: on, devices: [a]}]`},
+		{name: "bad action", bindings: `bindings: [{name: a, button: b, action: dim, devices: [a]}]`},
+		{name: "no devices", bindings: `bindings: [{name: a, button: b, action: on}]`},
+		{name: "no manifest", bindings: `bindings: [{name: a, button: b, action: manifest}]`},
+		{name: "no command", bindings: `bindings: [{name: a, button: b, action: shell}]`},
+		{name: "bad url", bindings: `bindings: [{name: a, button: b, action: webhook, url: "ftp://x"}]`},
+		{name: "no yaml", bindings: `bindings: [`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tc.bindings))
+			assert.Error(t, err)
+		})
+	}
+}
+
+// TestParseFile tests reading the bindings from a file.
+func TestParseFile(t *testing.T) {
+	bs, err := ParseFile("../testdata/button_bindings.yml")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, bs.Bindings)
+	_, err = ParseFile("/does/not/exist.yml")
+	assert.Error(t, err)
+}
+
+// TestMatches tests the selection of presses by button, unit and press type.
+func TestMatches(t *testing.T) {
+	single := Press{Device: fritz.Device{Name: "BTN_1"}}
+	short := Press{Device: fritz.Device{Name: "DECT 400"}, Unit: fritz.Button{Identifier: "1-1", Name: "DECT 400: kurz"}}
+	long := Press{Device: fritz.Device{Name: "DECT 400"}, Unit: fritz.Button{Identifier: "1-9", Name: "DECT 400: lang"}}
+
+	assert.True(t, Binding{Button: "BTN_1"}.Matches(single))
+	assert.False(t, Binding{Button: "BTN_2"}.Matches(single))
+	assert.False(t, Binding{Button: "BTN_1", Press: "long"}.Matches(single))
+
+	assert.True(t, Binding{Button: "DECT 400"}.Matches(short))
+	assert.True(t, Binding{Button: "DECT 400", Press: "long"}.Matches(long))
+	assert.False(t, Binding{Button: "DECT 400", Press: "long"}.Matches(short))
+	assert.True(t, Binding{Button: "DECT 400", Unit: "1-9"}.Matches(long))
+	assert.True(t, Binding{Button: "DECT 400", Unit: "DECT 400: lang"}.Matches(long))
+	assert.True(t, Binding{Button: "DECT 400", Unit: "Kurz"}.Matches(short))
+	assert.False(t, Binding{Button: "DECT 400", Unit: "kurz"}.Matches(long))
+}
@@ -0,0 +1,105 @@
+package buttons
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bpicode/fritzctl/fritz"
+	"github.com/bpicode/fritzctl/internal/statefile"
+	"github.com/bpicode/fritzctl/logger"
+)
+
+// Press is a detected press of a button unit.
+type Press struct {
+	Device fritz.Device // The button device.
+	Unit   fritz.Button // The unit that was pressed. For devices with a single button, this is Device.Button.
+	Time   time.Time    // When the button was pressed, according to the FRITZ!Box.
+}
+
+// String describes the press.
+func (p Press) String() string {
+	if p.Unit.Name != "" {
+		return fmt.Sprintf("%s (%s) pressed at %s", p.Device.Name, p.Unit.UnitName(), p.Time.Format(time.RFC3339))
+	}
+	return fmt.Sprintf("%s pressed at %s", p.Device.Name, p.Time.Format(time.RFC3339))
+}
+
+// State records the last seen press timestamp (in epoch seconds) of every button unit, keyed by the AIN of the unit.
+type State map[string]int64
+
+// LoadState reads the state from a JSON file. A missing file yields an empty state.
+func LoadState(filename string) (State, error) {
+	s := make(State)
+	if err := statefile.Load(filename, &s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Save writes the state to a JSON file. The file is replaced atomically, so a crash does not leave a corrupted state.
+func (s State) Save(filename string) error {
+	return statefile.Save(filename, s)
+}
+
+// Detector finds new presses by comparing the reported timestamps to the state.
+type Detector struct {
+	state  State
+	maxAge time.Duration
+}
+
+// NewDetector creates a Detector which updates the given state. Presses older than maxAge at the time they are
+// detected are recorded, but not reported.
+func NewDetector(state State, maxAge time.Duration) *Detector {
+	return &Detector{state: state, maxAge: maxAge}
+}
+
+// State returns the current state.
+func (d *Detector) State() State {
+	return d.state
+}
+
+// Detect returns the presses of all button units whose timestamp is newer than the recorded one. Units without a
+// recorded timestamp only establish the baseline. It returns true if the state changed.
+func (d *Detector) Detect(l *fritz.Devicelist, now time.Time) ([]Press, bool) {
+	var presses []Press
+	changed := false
+	for _, dev := range l.Devices {
+		for _, unit := range units(dev) {
+			t := unit.LastPressed()
+			if t == nil || t.Unix() == 0 {
+				continue
+			}
+			key := unitKey(dev, unit)
+			last, ok := d.state[key]
+			if ok && last == t.Unix() {
+				continue
+			}
+			d.state[key] = t.Unix()
+			changed = true
+			if !ok || t.Unix() < last {
+				continue
+			}
+			p := Press{Device: dev, Unit: unit, Time: *t}
+			if now.Sub(*t) > d.maxAge {
+				logger.Warn("Ignoring stale press:", p)
+				continue
+			}
+			presses = append(presses, p)
+		}
+	}
+	return presses, changed
+}
+
+func units(d fritz.Device) []fritz.Button {
+	if len(d.Buttons) > 0 {
+		return d.Buttons
+	}
+	return []fritz.Button{d.Button}
+}
+
+func unitKey(d fritz.Device, b fritz.Button) string {
+	if b.Identifier != "" {
+		return b.Identifier
+	}
+	return d.Identifier + "#" + d.ID
+}
@@ -0,0 +1,89 @@
+package buttons
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bpicode/fritzctl/fritz"
+	"github.com/stretchr/testify/assert"
+)
+
+func dect400(short, long string) fritz.Device {
+	return fritz.Device{Identifier: "13096 0007307", Name: "DECT 400", Buttons: []fritz.Button{
+		{Identifier: "13096 0007307-1", Name: "DECT 400: kurz", LastPressedTimestamp: short},
+		{Identifier: "13096 0007307-9", Name: "DECT 400: lang", LastPressedTimestamp: long},
+	}}
+}
+
+func btn1(ts string) fritz.Device {
+	return fritz.Device{Identifier: "214124 34625478542353", ID: "19", Name: "BTN_1", Button: fritz.Button{LastPressedTimestamp: ts}}
+}
+
+// TestDetect tests the detection of presses over several polls.
+func TestDetect(t *testing.T) {
+	now := time.Unix(1545160500, 0)
+	d := NewDetector(make(State), time.Hour)
+
+	presses, changed := d.Detect(&fritz.Devicelist{Devices: []fritz.Device{dect400("1545160100", "1545160200"), btn1("1545160121")}}, now)
+	assert.Empty(t, presses, "the first poll establishes the baseline")
+	assert.True(t, changed)
+	assert.Equal(t, State{"13096 0007307-1": 1545160100, "13096 0007307-9": 1545160200, "214124 34625478542353#19": 1545160121}, d.State())
+
+	presses, changed = d.Detect(&fritz.Devicelist{Devices: []fritz.Device{dect400("1545160100", "1545160200"), btn1("1545160121")}}, now)
+	assert.Empty(t, presses)
+	assert.False(t, changed)
+
+	presses, changed = d.Detect(&fritz.Devicelist{Devices: []fritz.Device{dect400("1545160100", "1545160400"), btn1("1545160450")}}, now)
+	assert.True(t, changed)
+	assert.Len(t, presses, 2)
+	assert.Equal(t, fritz.LongPress, presses[0].Unit.PressType())
+	assert.Equal(t, time.Unix(1545160400, 0), presses[0].Time)
+	assert.Equal(t, "BTN_1", presses[1].Device.Name)
+	assert.Contains(t, presses[0].String(), "DECT 400 (lang) pressed at")
+	assert.Contains(t, presses[1].String(), "BTN_1 pressed at")
+}
+
+// TestDetectIgnoresStaleAndUnknown tests that old presses, timestamps going back and units without presses are not reported.
+func TestDetectIgnoresStaleAndUnknown(t *testing.T) {
+	now := time.Unix(1545160500, 0)
+	d := NewDetector(State{"214124 34625478542353#19": 1545150000}, time.Minute)
+
+	presses, changed := d.Detect(&fritz.Devicelist{Devices: []fritz.Device{btn1("1545160000")}}, now)
+	assert.Empty(t, presses, "pressed more than a minute ago")
+	assert.True(t, changed)
+
+	presses, changed = d.Detect(&fritz.Devicelist{Devices: []fritz.Device{btn1("1545100000")}}, now)
+	assert.Empty(t, presses, "timestamp went back")
+	assert.True(t, changed)
+
+	presses, changed = d.Detect(&fritz.Devicelist{Devices: []fritz.Device{btn1("0"), {Name: "SWITCH"}}}, now)
+	assert.Empty(t, presses)
+	assert.False(t, changed)
+}
+
+// TestDetectAcrossRestarts tests that a persisted state suppresses duplicates.
+func TestDetectAcrossRestarts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "buttons")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	stateFile := filepath.Join(dir, "state.json")
+	now := time.Unix(1545160500, 0)
+	l := &fritz.Devicelist{Devices: []fritz.Device{btn1("1545160121")}}
+
+	first, err := LoadState(stateFile)
+	assert.NoError(t, err)
+	d := NewDetector(first, time.Hour)
+	d.Detect(&fritz.Devicelist{Devices: []fritz.Device{btn1("1545160000")}}, now)
+	presses, _ := d.Detect(l, now)
+	assert.Len(t, presses, 1)
+	assert.NoError(t, d.State().Save(stateFile))
+
+	second, err := LoadState(stateFile)
+	assert.NoError(t, err)
+	presses, changed := NewDetector(second, time.Hour).Detect(l, now)
+	assert.Empty(t, presses)
+	assert.False(t, changed)
+}
@@ -0,0 +1,4 @@
+// Package buttons detects presses of smart home buttons and runs the actions bound to them. Presses are recognized
+// by the timestamps the FRITZ!Box reports for every button unit, which are persisted, so a press is handled only once
+// even across restarts.
+package buttons
@@ -0,0 +1,126 @@
+package buttons
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bpicode/fritzctl/fritz"
+	"github.com/bpicode/fritzctl/internal/errors"
+	"github.com/bpicode/fritzctl/internal/shell"
+	"github.com/bpicode/fritzctl/manifest"
+)
+
+// Executor runs the actions of bindings.
+type Executor struct {
+	homeAuto fritz.HomeAuto
+	client   *http.Client
+}
+
+// NewExecutor creates an Executor which operates on the given HomeAuto client, which is expected to be logged in.
+func NewExecutor(h fritz.HomeAuto) *Executor {
+	return &Executor{homeAuto: h, client: &http.Client{}}
+}
+
+// Execute runs the action of the binding for a press. The device list is the one the press was detected in, it
+// serves as the current state when applying manifests.
+func (e *Executor) Execute(b Binding, p Press, l *fritz.Devicelist) error {
+	switch b.Action {
+	case "on":
+		return e.homeAuto.On(b.Devices...)
+	case "off":
+		return e.homeAuto.Off(b.Devices...)
+	case "toggle":
+		return e.homeAuto.Toggle(b.Devices...)
+	case "temperature":
+		return e.homeAuto.Temp(b.Temperature, b.Devices...)
+	case "manifest":
+		return e.applyManifest(b.Manifest, l)
+	case "shell":
+		return e.shell(b, p)
+	case "webhook":
+		return e.webhook(b, p)
+	}
+	return fmt.Errorf("unknown action '%s'", b.Action)
+}
+
+// The manifest is read on every press, so it can be edited without restarting.
+func (e *Executor) applyManifest(filename string, l *fritz.Devicelist) error {
+	parsed, err := manifest.ParseFile(filename)
+	if err != nil {
+		return errors.Wrapf(err, "cannot parse manifest file '%s'", filename)
+	}
+	target, _, err := parsed.Resolve(l)
+	if err != nil {
+		return errors.Wrapf(err, "cannot evaluate the rules of manifest file '%s'", filename)
+	}
+	src := manifest.ConvertDevicelist(l)
+	if r, ok := e.homeAuto.(fritz.ThermostatConfigurer); ok {
+		if err := manifest.LoadSchedules(r, src, target); err != nil {
+			return errors.Wrapf(err, "cannot obtain heating schedules")
+		}
+	}
+	return manifest.NewApplier(e.homeAuto).Apply(src, target)
+}
+
+func (e *Executor) shell(b Binding, p Press) error {
+	ctx, cancel := context.WithTimeout(context.Background(), b.Timeout)
+	defer cancel()
+	cmd := shell.Command(ctx, b.Command,
+		"FRITZCTL_BINDING="+b.Name,
+		"FRITZCTL_BUTTON="+p.Device.Name,
+		"FRITZCTL_BUTTON_AIN="+p.Device.Identifier,
+		"FRITZCTL_BUTTON_UNIT="+p.Unit.UnitName(),
+		"FRITZCTL_PRESS="+p.Unit.PressType(),
+		"FRITZCTL_PRESSED_AT="+p.Time.Format(time.RFC3339))
+	out, err := cmd.CombinedOutput()
+	if out = bytes.TrimSpace(out); len(out) > 0 {
+		return errors.Wrapf(err, "command failed with output '%s'", out)
+	}
+	return errors.Wrapf(err, "command failed")
+}
+
+// webhookPayload is the JSON body sent to webhooks.
+type webhookPayload struct {
+	Binding   string    `json:"binding"`
+	Button    string    `json:"button"`
+	AIN       string    `json:"ain"`
+	Unit      string    `json:"unit,omitempty"`
+	UnitAIN   string    `json:"unitAin,omitempty"`
+	Press     string    `json:"press,omitempty"`
+	PressedAt time.Time `json:"pressedAt"`
+}
+
+func (e *Executor) webhook(b Binding, p Press) error {
+	body, err := json.Marshal(webhookPayload{
+		Binding:   b.Name,
+		Button:    p.Device.Name,
+		AIN:       p.Device.Identifier,
+		Unit:      p.Unit.UnitName(),
+		UnitAIN:   p.Unit.Identifier,
+		Press:     p.Unit.PressType(),
+		PressedAt: p.Time,
+	})
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), b.Timeout)
+	defer cancel()
+	req, err := http.NewRequest(b.Method, b.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := e.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook responded with status %s", resp.Status)
+	}
+	return nil
+}
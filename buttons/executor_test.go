@@ -0,0 +1,99 @@
+package buttons
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/bpicode/fritzctl/fritz"
+	"github.com/bpicode/fritzctl/fritz/fritztest"
+	"github.com/stretchr/testify/assert"
+)
+
+var press = Press{
+	Device: fritz.Device{Identifier: "13096 0007307", Name: "DECT 400"},
+	Unit:   fritz.Button{Identifier: "13096 0007307-9", Name: "DECT 400: lang"},
+	Time:   time.Unix(1545160400, 0).UTC(),
+}
+
+// TestExecuteHomeAuto tests the switch and temperature actions.
+func TestExecuteHomeAuto(t *testing.T) {
+	h := &fritztest.HomeAuto{}
+	e := NewExecutor(h)
+	l := &fritz.Devicelist{}
+	assert.NoError(t, e.Execute(Binding{Action: "on", Devices: []string{"a"}}, press, l))
+	assert.NoError(t, e.Execute(Binding{Action: "off", Devices: []string{"a"}}, press, l))
+	assert.NoError(t, e.Execute(Binding{Action: "toggle", Devices: []string{"a", "b"}}, press, l))
+	assert.NoError(t, e.Execute(Binding{Action: "temperature", Temperature: 21.5, Devices: []string{"c"}}, press, l))
+	assert.Error(t, e.Execute(Binding{Action: "dim"}, press, l))
+	assert.Equal(t, []string{"on [a]", "off [a]", "toggle [a b]", "temp 21.5 [c]"}, h.Commands())
+}
+
+// TestExecuteManifest tests that manifests are applied against the polled device list.
+func TestExecuteManifest(t *testing.T) {
+	file, err := ioutil.TempFile("", "manifest")
+	assert.NoError(t, err)
+	defer os.Remove(file.Name())
+	file.WriteString("switches:\n  - name: SWITCH_1\n    state: on\n")
+	file.Close()
+
+	h := &fritztest.HomeAuto{}
+	l := &fritz.Devicelist{Devices: []fritz.Device{{Name: "SWITCH_1", Functionbitmask: "896", Switch: fritz.Switch{State: "0"}}}}
+	assert.NoError(t, NewExecutor(h).Execute(Binding{Action: "manifest", Manifest: file.Name()}, press, l))
+	assert.Equal(t, []string{"on [SWITCH_1]"}, h.Commands())
+
+	assert.Error(t, NewExecutor(h).Execute(Binding{Action: "manifest", Manifest: "/does/not/exist.yml"}, press, l))
+}
+
+// TestExecuteShell tests that shell commands see the press in their environment.
+func TestExecuteShell(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires sh")
+	}
+	dir, err := ioutil.TempDir("", "buttons")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	out := filepath.Join(dir, "out")
+	e := NewExecutor(&fritztest.HomeAuto{})
+
+	b := Binding{Name: "log", Action: "shell", Command: `echo "$FRITZCTL_BUTTON $FRITZCTL_BUTTON_UNIT $FRITZCTL_PRESS $FRITZCTL_PRESSED_AT" > ` + out, Timeout: time.Minute}
+	assert.NoError(t, e.Execute(b, press, &fritz.Devicelist{}))
+	bs, err := ioutil.ReadFile(out)
+	assert.NoError(t, err)
+	assert.Equal(t, "DECT 400 lang long 2018-12-18T19:13:20Z\n", string(bs))
+
+	err = e.Execute(Binding{Action: "shell", Command: "echo oops; exit 3", Timeout: time.Minute}, press, &fritz.Devicelist{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "oops")
+}
+
+// TestExecuteWebhook tests the request sent to webhooks.
+func TestExecuteWebhook(t *testing.T) {
+	var payload map[string]interface{}
+	var method string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		json.NewDecoder(r.Body).Decode(&payload)
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+	e := NewExecutor(&fritztest.HomeAuto{})
+
+	assert.NoError(t, e.Execute(Binding{Name: "hook", Action: "webhook", URL: srv.URL, Method: "PUT", Timeout: time.Minute}, press, &fritz.Devicelist{}))
+	assert.Equal(t, "PUT", method)
+	assert.Equal(t, "hook", payload["binding"])
+	assert.Equal(t, "DECT 400", payload["button"])
+	assert.Equal(t, "13096 0007307-9", payload["unitAin"])
+	assert.Equal(t, "long", payload["press"])
+	assert.Equal(t, "2018-12-18T19:13:20Z", payload["pressedAt"])
+
+	assert.Error(t, e.Execute(Binding{Action: "webhook", URL: srv.URL + "/fail", Method: "POST", Timeout: time.Minute}, press, &fritz.Devicelist{}))
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"sync"
+
+	"github.com/bpicode/fritzctl/fritz"
+)
+
+// boxSession is a long-lived, lazily established session with the FRITZ!Box, shared by the servers. The login is
+// only repeated if obtaining data fails. Calls to the FRITZ!Box are serialized.
+// codebeat:disable[TOO_MANY_IVARS]
+type boxSession struct {
+	mu            sync.Mutex
+	homeAuto      homeAutomation
+	client        *fritz.Client
+	internal      fritz.Internal
+	phone         fritz.Phone
+	loggedIn      bool
+	loginFailures int
+}
+
+// codebeat:enable[TOO_MANY_IVARS]
+
+func newBoxSession() *boxSession {
+	s, err := openBoxSession()
+	assertNoErr(err, "cannot parse configuration")
+	return s
+}
+
+// openBoxSession is like newBoxSession, but returns an error if there is no configuration.
+func openBoxSession() (*boxSession, error) {
+	conf, err := cfg(defaultConfigPlaces...)
+	if err != nil {
+		return nil, err
+	}
+	client := fritz.NewClientFromConfig(conf)
+	return &boxSession{
+		homeAuto: fritz.NewHomeAuto(optsFromPlaces(defaultConfigPlaces...)...).(homeAutomation),
+		client:   client,
+		internal: fritz.NewInternal(client),
+		phone:    fritz.NewPhone(client),
+	}, nil
+}
+
+// do runs f, logging in before if there is no session yet. If the FRITZ!Box rejects the session, as it may have
+// expired, f is retried once after a new login. Other failures are not retried, f may have taken effect partially.
+func (s *boxSession) do(f func() error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.loggedIn {
+		if err := s.login(); err != nil {
+			return err
+		}
+	}
+	err := f()
+	if !fritz.IsSessionError(err) {
+		return err
+	}
+	if err := s.login(); err != nil {
+		return err
+	}
+	return f()
+}
+
+func (s *boxSession) login() error {
+	err := s.homeAuto.Login()
+	if err == nil {
+		err = s.client.Login()
+	}
+	s.loggedIn = err == nil
+	if err != nil {
+		s.loginFailures++
+	}
+	return err
+}
+
+// failedLogins returns the number of failed login attempts.
+func (s *boxSession) failedLogins() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.loginFailures
+}
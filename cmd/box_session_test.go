@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/bpicode/fritzctl/config"
+	"github.com/bpicode/fritzctl/httpread"
+	"github.com/bpicode/fritzctl/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBoxSessionRetries tests that only calls rejected for an invalid session are retried.
+func TestBoxSessionRetries(t *testing.T) {
+	oldPlaces := defaultConfigPlaces
+	defer func() { defaultConfigPlaces = oldPlaces }()
+	defaultConfigPlaces = append([]config.Place{config.InDir("../testdata/config", "config_localhost_http_test.json", config.JSON())}, defaultConfigPlaces...)
+	srv := mock.New().UnstartedServer()
+	var err error
+	srv.Listener, err = net.Listen("tcp", ":61666")
+	assert.NoError(t, err)
+	srv.Start()
+	defer srv.Close()
+
+	s := newBoxSession()
+	calls := 0
+	assert.NoError(t, s.do(func() error {
+		if calls++; calls == 1 {
+			return &httpread.StatusError{Code: http.StatusForbidden, Status: "403 Forbidden"}
+		}
+		return nil
+	}))
+	assert.Equal(t, 2, calls)
+
+	calls = 0
+	assert.Error(t, s.do(func() error {
+		calls++
+		return fmt.Errorf("not all operations could be completed")
+	}))
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, 0, s.failedLogins())
+}
@@ -36,10 +36,19 @@ func clientLogin() *fritz.Client {
 	return client
 }
 
-func homeAutoClient(overrides ...fritz.Option) fritz.HomeAuto {
+// homeAutomation is the complete API of the client created by fritz.NewHomeAuto.
+type homeAutomation interface {
+	fritz.HomeAuto
+	fritz.StatsReader
+	fritz.ThermostatConfigurer
+	fritz.LightController
+	fritz.Renamer
+}
+
+func homeAutoClient(overrides ...fritz.Option) homeAutomation {
 	opts := optsFromPlaces(defaultConfigPlaces...)
 	opts = append(opts, overrides...)
-	h := fritz.NewHomeAuto(opts...)
+	h := fritz.NewHomeAuto(opts...).(homeAutomation)
 	err := h.Login()
 	assertNoErr(err, "login failed")
 	return h
@@ -37,6 +37,24 @@ func TestCommands(t *testing.T) {
 		{cmd: planManifestCmd, args: []string{"../testdata/devicelist_fritzos06.83_plan.yml"}, srv: mock.New().UnstartedServer()},
 		{cmd: exportManifestCmd, srv: mock.New().UnstartedServer()},
 		{cmd: applyManifestCmd, args: []string{"../testdata/devicelist_fritzos06.83_plan.yml"}, srv: mock.New().UnstartedServer()},
+		{cmd: planManifestCmd, args: []string{"../testdata/thermostat_manifest.yml"}, srv: mock.New().UnstartedServer()},
+		{cmd: applyManifestCmd, args: []string{"../testdata/thermostat_manifest.yml"}, srv: mock.New().UnstartedServer()},
+		{cmd: planManifestCmd, args: []string{"../testdata/groups_bulbs_manifest.yml"}, srv: mock.New().UnstartedServer()},
+		{cmd: applyManifestCmd, args: []string{"../testdata/groups_bulbs_manifest.yml"}, srv: mock.New().UnstartedServer()},
+		{cmd: planManifestCmd, args: []string{"../testdata/staged_manifest.yml"}, srv: mock.New().UnstartedServer()},
+		{cmd: applyManifestCmd, args: []string{"../testdata/staged_manifest.yml"}, srv: mock.New().UnstartedServer()},
+		{cmd: planManifestCmd, args: []string{"../testdata/formats/manifest.toml"}, srv: mock.New().UnstartedServer()},
+		{cmd: planManifestCmd, args: []string{"../testdata/rules_manifest.yml"}, srv: mock.New().UnstartedServer()},
+		{cmd: applyManifestCmd, args: []string{"../testdata/rules_manifest.yml"}, srv: mock.New().UnstartedServer()},
+		{cmd: exportManifestCmd, args: []string{"--schedules"}, srv: mock.New().UnstartedServer()},
+		{cmd: thermostatScheduleGetCmd, args: []string{"HKR_1"}, srv: mock.New().UnstartedServer()},
+		{cmd: thermostatScheduleGetCmd, args: []string{"HKR_1", "--output=json"}, srv: mock.New().UnstartedServer()},
+		{cmd: thermostatScheduleSetCmd, args: []string{"HKR_1", "../testdata/heating_schedule.yml"}, srv: mock.New().UnstartedServer()},
+		{cmd: thermostatComfortCmd, args: []string{"21.5", "HKR_1"}, srv: mock.New().UnstartedServer()},
+		{cmd: thermostatSavingCmd, args: []string{"16", "HKR_1", "HKR_2"}, srv: mock.New().UnstartedServer()},
+		{cmd: thermostatOffsetCmd, args: []string{"1.5", "HKR_1"}, srv: mock.New().UnstartedServer()},
+		{cmd: thermostatLockCmd, args: []string{"on", "HKR_1"}, srv: mock.New().UnstartedServer()},
+		{cmd: thermostatDeviceLockCmd, args: []string{"off", "HKR_1"}, srv: mock.New().UnstartedServer()},
 		{cmd: listAlertsCmd, srv: mock.New().UnstartedServer()},
 		{cmd: listAlertsCmd, args: []string{"--output=json"}, srv: mock.New().UnstartedServer()},
 		{cmd: listButtonsCmd, srv: mock.New().UnstartedServer()},
@@ -48,8 +66,13 @@ func TestCommands(t *testing.T) {
 		{cmd: listCallsCmd, srv: mock.New().UnstartedServer()},
 		{cmd: listSwitchesCmd, srv: mock.New().UnstartedServer()},
 		{cmd: listSwitchesCmd, args: []string{"--output=json"}, srv: mock.New().UnstartedServer()},
+		{cmd: listSwitchesCmd, args: []string{"--output=influx"}, srv: mock.New().UnstartedServer()},
+		{cmd: exportMetricsCmd, srv: mock.New().UnstartedServer()},
+		{cmd: exportMetricsCmd, args: []string{"--output=graphite"}, srv: mock.New().UnstartedServer()},
 		{cmd: listThermostatsCmd, srv: mock.New().UnstartedServer()},
 		{cmd: listThermostatsCmd, args: []string{"--output=json"}, srv: mock.New().UnstartedServer()},
+		{cmd: listThermostatsCmd, args: []string{"--output=graphite", "--metric-prefix=home"}, srv: mock.New().UnstartedServer()},
+		{cmd: serveSchedulerCmd, args: []string{"--rules=../testdata/scheduler_rules.yml", "--once", "--dry-run"}, srv: mock.New().UnstartedServer()},
 		{cmd: docManCmd, srv: mock.New().UnstartedServer()},
 		{cmd: boxInfoCmd, srv: mock.New().UnstartedServer()},
 		{cmd: aboutCmd, srv: mock.New().UnstartedServer()},
@@ -0,0 +1,15 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export [subcommand]",
+	Short: "See subcommands",
+	Long:  "See subcommands. Run with --help to list the available commands.",
+}
+
+func init() {
+	RootCmd.AddCommand(exportCmd)
+}
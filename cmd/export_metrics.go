@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bpicode/fritzctl/cmd/metrics"
+	"github.com/bpicode/fritzctl/cmd/printer"
+	"github.com/spf13/cobra"
+)
+
+var exportMetricsCmd = &cobra.Command{
+	Use:   "metrics",
+	Short: "Export device and FRITZ!Box metrics once",
+	Long: "Obtain the smart home device measurements, the current internet traffic rates and the FRITZ!Box runtime " +
+		"and write them to stdout in the InfluxDB line protocol or the Graphite plaintext protocol. " +
+		"Device samples are tagged with the device name, AIN, manufacturer and product. " +
+		"The output can be piped to Telegraf, carbon or similar.",
+	Example: `fritzctl export metrics
+fritzctl export metrics --output=graphite --metric-prefix=home.fritz | nc -q0 carbon 2003
+fritzctl export metrics --output=influx --metric-prefix=fritz`,
+	RunE: exportMetrics,
+}
+
+func init() {
+	exportMetricsCmd.Flags().StringP("output", "o", "influx", "specify output format, one of influx, graphite")
+	exportMetricsCmd.Flags().String("metric-prefix", defaultMetricPrefix, "prefix of the measurements")
+	exportCmd.AddCommand(exportMetricsCmd)
+}
+
+func exportMetrics(cmd *cobra.Command, _ []string) error {
+	samples := newMetricsCollector().collect()
+	now := time.Now()
+	switch format := cmd.Flag("output").Value.String(); format {
+	case "influx":
+		printer.Print(metrics.Influx{Prefix: metricPrefix(cmd), Time: now, Samples: samples}, os.Stdout)
+	case "graphite":
+		printer.Print(metrics.Graphite{Prefix: metricPrefix(cmd), Time: now, Samples: samples}, os.Stdout)
+	default:
+		return fmt.Errorf("unknown output format '%s'", format)
+	}
+	return nil
+}
@@ -1,8 +1,15 @@
 package cmd
 
 import (
+	"encoding/csv"
 	"fmt"
+	"io"
+	"os"
 	"strconv"
+
+	"github.com/bpicode/fritzctl/cmd/printer"
+	"github.com/bpicode/fritzctl/internal/console"
+	"github.com/spf13/cobra"
 )
 
 func fmtUnit(f func() string, unit string) string {
@@ -13,3 +20,31 @@ func fmtUnit(f func() string, unit string) string {
 	}
 	return fmt.Sprintf("%s %s", s, unit)
 }
+
+func printQueryResult(cmd *cobra.Command, data interface{}, headers []string, rows [][]string) {
+	switch output := cmd.Flag("output").Value.String(); output {
+	case "json":
+		printer.Print(data, os.Stdout)
+	case "csv":
+		assertNoErr(writeCSV(os.Stdout, headers, rows), "cannot write CSV")
+	case "table":
+		table := console.NewTable(console.Headers(headers...))
+		for _, row := range rows {
+			table.Append(row)
+		}
+		table.Print(os.Stdout)
+	default:
+		assertTrue(false, fmt.Errorf("unknown output format '%s', expected one of table, csv, json", output))
+	}
+}
+
+func writeCSV(w io.Writer, headers []string, rows [][]string) error {
+	cw := csv.NewWriter(w)
+	cw.Write(headers)
+	cw.WriteAll(rows)
+	return cw.Error()
+}
+
+func fmtFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', 2, 64)
+}
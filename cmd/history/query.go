@@ -0,0 +1,181 @@
+package history
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// bucket formats the start of an aggregation period in the local time zone.
+type bucket struct {
+	sql    string // Format as understood by SQLite's strftime.
+	layout string // Equivalent layout for time.Format.
+}
+
+// Buckets of the aggregation periods.
+var buckets = map[string]bucket{
+	"hour":  {sql: "%Y-%m-%d %H:00", layout: "2006-01-02 15:00"},
+	"day":   {sql: "%Y-%m-%d", layout: "2006-01-02"},
+	"month": {sql: "%Y-%m", layout: "2006-01"},
+	"all":   {},
+}
+
+// Query selects the samples to aggregate.
+type Query struct {
+	Metric  string    // The measurement, e.g. temperature_celsius. Ignored for Energy.
+	Per     string    // Aggregation period, one of "hour", "day", "month" or "all".
+	From    time.Time // Start of the time range, inclusive.
+	To      time.Time // End of the time range, exclusive.
+	Devices []string  // Names of the devices, all devices if empty.
+}
+
+// Aggregate summarizes the values of a metric in one period for one device.
+type Aggregate struct {
+	Period string  `json:"period"` // Start of the period in local time, e.g. "2018-12-18 19:00" for hourly aggregates, empty for "all".
+	Name   string  `json:"name"`   // Name of the device.
+	AIN    string  `json:"ain"`    // Identifier of the device.
+	Min    float64 `json:"min"`    // Smallest value.
+	Max    float64 `json:"max"`    // Largest value.
+	Avg    float64 `json:"avg"`    // Arithmetic mean of the values.
+	Count  int     `json:"count"`  // Number of samples.
+}
+
+// Consumption is the energy consumed by one device in one period.
+type Consumption struct {
+	Period    string  `json:"period"`    // Start of the period in local time, empty for "all".
+	Name      string  `json:"name"`      // Name of the device.
+	AIN       string  `json:"ain"`       // Identifier of the device.
+	WattHours float64 `json:"wattHours"` // Energy consumed in the period.
+	FirstSeen int64   `json:"firstSeen"` // Unix time of the first sample the energy was computed from.
+	LastSeen  int64   `json:"lastSeen"`  // Unix time of the last sample in the period.
+	AvgPowerW float64 `json:"avgPowerW"` // Average power in the period.
+}
+
+func (q Query) where(metric string) (string, []interface{}, error) {
+	if _, ok := buckets[q.Per]; !ok {
+		return "", nil, fmt.Errorf("unknown period '%s', expected one of hour, day, month, all", q.Per)
+	}
+	clauses := []string{"metric = ?", "time >= ?", "time < ?"}
+	args := []interface{}{metric, q.From.Unix(), q.To.Unix()}
+	if len(q.Devices) > 0 {
+		clauses = append(clauses, "name IN (?"+strings.Repeat(", ?", len(q.Devices)-1)+")")
+		for _, d := range q.Devices {
+			args = append(args, d)
+		}
+	}
+	return strings.Join(clauses, " AND "), args, nil
+}
+
+func period(per string) string {
+	if buckets[per].sql == "" {
+		return "''"
+	}
+	return fmt.Sprintf("strftime('%s', time, 'unixepoch', 'localtime')", buckets[per].sql)
+}
+
+// Aggregate computes minimum, maximum and average of a metric per device and period.
+func (s *Store) Aggregate(q Query) ([]Aggregate, error) {
+	where, args, err := q.where(q.Metric)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.db.Query(`SELECT `+period(q.Per)+` AS period, ain, MAX(name), MIN(value), MAX(value), AVG(value), COUNT(*)
+		FROM device_samples WHERE `+where+` GROUP BY period, ain ORDER BY MAX(name), ain, period`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	as := []Aggregate{}
+	for rows.Next() {
+		var a Aggregate
+		if err := rows.Scan(&a.Period, &a.AIN, &a.Name, &a.Min, &a.Max, &a.Avg, &a.Count); err != nil {
+			return nil, err
+		}
+		as = append(as, a)
+	}
+	return as, rows.Err()
+}
+
+// Energy computes the energy consumed per device and period from the increases of the energy counter between
+// subsequent samples, see Readings. Each increase is attributed to the period of its later sample, so that no energy is
+// lost at the boundaries of the periods.
+func (s *Store) Energy(q Query) ([]Consumption, error) {
+	b, ok := buckets[q.Per]
+	if !ok {
+		return nil, fmt.Errorf("unknown period '%s', expected one of hour, day, month, all", q.Per)
+	}
+	rs, err := s.Readings(q)
+	if err != nil {
+		return nil, err
+	}
+	cs := []Consumption{}
+	index := make(map[[2]string]int)
+	for _, r := range rs {
+		key := [2]string{r.AIN, r.To.Local().Format(b.layout)}
+		i, ok := index[key]
+		if !ok {
+			i = len(cs)
+			index[key] = i
+			cs = append(cs, Consumption{Period: key[1], Name: r.Name, AIN: r.AIN, FirstSeen: r.From.Unix()})
+		}
+		cs[i].WattHours += r.WattHours
+		cs[i].LastSeen = r.To.Unix()
+	}
+	for i, c := range cs {
+		if hours := float64(c.LastSeen-c.FirstSeen) / 3600; hours > 0 {
+			cs[i].AvgPowerW = c.WattHours / hours
+		}
+	}
+	sort.SliceStable(cs, func(i, j int) bool {
+		if cs[i].Name != cs[j].Name {
+			return cs[i].Name < cs[j].Name
+		}
+		return cs[i].AIN < cs[j].AIN
+	})
+	return cs, nil
+}
+
+// Reading is the energy consumed by one device between two subsequent samples.
+type Reading struct {
+	Name      string    // Name of the device.
+	AIN       string    // Identifier of the device.
+	From      time.Time // Time of the earlier sample.
+	To        time.Time // Time of the later sample.
+	WattHours float64   // Increase of the energy counter.
+}
+
+// Readings returns the increases of the energy counter between subsequent samples per device. A decreasing counter,
+// e.g. when the device was replaced, is taken as a new start and yields no reading. The period of the query is ignored.
+func (s *Store) Readings(q Query) ([]Reading, error) {
+	q.Per = "all"
+	where, args, err := q.where("energy_watt_hours")
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.db.Query(`SELECT time, ain, name, value FROM device_samples WHERE `+where+` ORDER BY ain, time`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	rs := []Reading{}
+	var prev struct {
+		ain   string
+		time  int64
+		value float64
+	}
+	for rows.Next() {
+		var r Reading
+		var t int64
+		var value float64
+		if err := rows.Scan(&t, &r.AIN, &r.Name, &value); err != nil {
+			return nil, err
+		}
+		if r.AIN == prev.ain && t > prev.time && value >= prev.value {
+			r.From, r.To, r.WattHours = time.Unix(prev.time, 0), time.Unix(t, 0), value-prev.value
+			rs = append(rs, r)
+		}
+		prev.ain, prev.time, prev.value = r.AIN, t, value
+	}
+	return rs, rows.Err()
+}
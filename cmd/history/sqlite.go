@@ -0,0 +1,15 @@
+//go:build cgo
+// +build cgo
+
+package history
+
+import (
+	// Registers the "sqlite3" driver, which requires cgo.
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Supported tells whether this build can access SQLite databases. It requires cgo, which is disabled when
+// cross-compiling.
+const Supported = true
+
+var errUnsupported error
@@ -0,0 +1,12 @@
+//go:build !cgo
+// +build !cgo
+
+package history
+
+import "errors"
+
+// Supported tells whether this build can access SQLite databases. It requires cgo, which is disabled when
+// cross-compiling.
+const Supported = false
+
+var errUnsupported = errors.New("SQLite databases are not supported by this build of fritzctl, it has to be built with cgo enabled (CGO_ENABLED=1)")
@@ -0,0 +1,183 @@
+// Package history persists measurements of the FRITZ!Box in an embedded SQLite database and aggregates them.
+//
+// The database contains the following tables, all times are Unix times in seconds:
+//
+//	device_samples(time, ain, name, metric, value)
+//	    One row per poll, device and measurement. The metrics are the ones of "fritzctl export metrics", e.g.
+//	    power_watts, energy_watt_hours, temperature_celsius, thermostat_goal_celsius or switch_state.
+//	traffic_samples(time, direction, channel, bits_per_second)
+//	    One row per poll and series of the internet traffic monitor, e.g. downstream/internet.
+//	lan_device_samples(time, uid, name, mac, ip, online)
+//	    One row per poll and LAN device, online is 1 or 0.
+//	calls(time, date, type, caller, phone_number, extension, own_phone_number, duration, recorded)
+//	    One row per entry of the call list, recorded once. time is parsed from date in the local time zone,
+//	    recorded is the time the call was first seen.
+package history
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/bpicode/fritzctl/cmd/metrics"
+	"github.com/bpicode/fritzctl/fritz"
+	"github.com/bpicode/fritzctl/internal/errors"
+)
+
+// Schema creates the tables and indexes, it is applied when opening a database.
+const Schema = `
+CREATE TABLE IF NOT EXISTS device_samples (
+	time   INTEGER NOT NULL,
+	ain    TEXT    NOT NULL,
+	name   TEXT    NOT NULL,
+	metric TEXT    NOT NULL,
+	value  REAL    NOT NULL
+);
+CREATE INDEX IF NOT EXISTS device_samples_metric_time ON device_samples (metric, time);
+CREATE TABLE IF NOT EXISTS traffic_samples (
+	time            INTEGER NOT NULL,
+	direction       TEXT    NOT NULL,
+	channel         TEXT    NOT NULL,
+	bits_per_second REAL    NOT NULL
+);
+CREATE INDEX IF NOT EXISTS traffic_samples_time ON traffic_samples (time);
+CREATE TABLE IF NOT EXISTS lan_device_samples (
+	time   INTEGER NOT NULL,
+	uid    TEXT    NOT NULL,
+	name   TEXT    NOT NULL,
+	mac    TEXT    NOT NULL,
+	ip     TEXT    NOT NULL,
+	online INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS lan_device_samples_time ON lan_device_samples (time);
+CREATE TABLE IF NOT EXISTS calls (
+	time             INTEGER NOT NULL,
+	date             TEXT    NOT NULL,
+	type             TEXT    NOT NULL,
+	caller           TEXT    NOT NULL,
+	phone_number     TEXT    NOT NULL,
+	extension        TEXT    NOT NULL,
+	own_phone_number TEXT    NOT NULL,
+	duration         TEXT    NOT NULL,
+	recorded         INTEGER NOT NULL,
+	UNIQUE (date, type, phone_number, extension, own_phone_number, duration)
+);
+`
+
+// Store is a history database.
+type Store struct {
+	db *sql.DB
+}
+
+// Open opens or creates the SQLite database in the given file and applies the schema.
+func Open(filename string) (*Store, error) {
+	if !Supported {
+		return nil, errUnsupported
+	}
+	db, err := sql.Open("sqlite3", filename)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(Schema); err != nil {
+		db.Close()
+		return nil, errors.Wrapf(err, "cannot apply schema to '%s'", filename)
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// AddDevices records the measurements of the devices.
+func (s *Store) AddDevices(t time.Time, ds []fritz.Device) error {
+	return s.insert("INSERT INTO device_samples (time, ain, name, metric, value) VALUES (?, ?, ?, ?, ?)", func(stmt *sql.Stmt) error {
+		for _, sample := range metrics.FromDevices(ds) {
+			if _, err := stmt.Exec(t.Unix(), label(sample, "ain"), label(sample, "name"), sample.Name, sample.Value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// AddTraffic records the latest sample of every series of the traffic monitor.
+func (s *Store) AddTraffic(t time.Time, data *fritz.TrafficMonitoringData) error {
+	return s.insert("INSERT INTO traffic_samples (time, direction, channel, bits_per_second) VALUES (?, ?, ?, ?)", func(stmt *sql.Stmt) error {
+		for _, sample := range metrics.FromTraffic(data) {
+			if _, err := stmt.Exec(t.Unix(), label(sample, "direction"), label(sample, "channel"), sample.Value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// AddLanDevices records the online state of the LAN devices.
+func (s *Store) AddLanDevices(t time.Time, l *fritz.LanDevices) error {
+	return s.insert("INSERT INTO lan_device_samples (time, uid, name, mac, ip, online) VALUES (?, ?, ?, ?, ?, ?)", func(stmt *sql.Stmt) error {
+		for _, n := range l.Network {
+			online, _ := strconv.Atoi(n.Online)
+			if _, err := stmt.Exec(t.Unix(), n.UID, n.Name, n.Mac, n.IP, online); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// AddCalls records the calls not recorded before. It returns the number of new calls.
+func (s *Store) AddCalls(t time.Time, cs []fritz.Call) (int, error) {
+	added := 0
+	err := s.insert(`INSERT OR IGNORE INTO calls (time, date, type, caller, phone_number, extension, own_phone_number, duration, recorded)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`, func(stmt *sql.Stmt) error {
+		for _, c := range cs {
+			res, err := stmt.Exec(callTime(c).Unix(), c.Date, c.Type, c.Caller, c.PhoneNumber, c.Extension, c.OwnPhoneNumber, c.Duration, t.Unix())
+			if err != nil {
+				return err
+			}
+			n, _ := res.RowsAffected()
+			added += int(n)
+		}
+		return nil
+	})
+	return added, err
+}
+
+// insert runs the prepared statement in a transaction.
+func (s *Store) insert(query string, f func(stmt *sql.Stmt) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+	if err := f(stmt); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func label(s metrics.Sample, name string) string {
+	for _, l := range s.Labels {
+		if l.Name == name {
+			return l.Value
+		}
+	}
+	return ""
+}
+
+// callTime parses the date of a call list entry, e.g. "25.04.13 15:15". Unparsable dates yield the zero Unix time.
+func callTime(c fritz.Call) time.Time {
+	t, err := time.ParseInLocation("02.01.06 15:04", c.Date, time.Local)
+	if err != nil {
+		return time.Unix(0, 0)
+	}
+	return t
+}
@@ -0,0 +1,145 @@
+//go:build cgo
+// +build cgo
+
+package history
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bpicode/fritzctl/fritz"
+	"github.com/stretchr/testify/assert"
+)
+
+func openTemp(t *testing.T) (*Store, func()) {
+	dir, err := ioutil.TempDir("", "history")
+	assert.NoError(t, err)
+	s, err := Open(filepath.Join(dir, "fritz.sqlite"))
+	assert.NoError(t, err)
+	return s, func() {
+		s.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func plug(power, energy string) fritz.Device {
+	return fritz.Device{Identifier: "12345 6789", Name: "PLUG", Present: 1, Functionbitmask: "896",
+		Powermeter: fritz.Powermeter{Power: power, Energy: energy}}
+}
+
+// TestAggregateAndEnergy records device samples and aggregates them.
+func TestAggregateAndEnergy(t *testing.T) {
+	s, cleanup := openTemp(t)
+	defer cleanup()
+	t0 := time.Date(2018, 12, 18, 10, 0, 0, 0, time.Local)
+	assert.NoError(t, s.AddDevices(t0, []fritz.Device{plug("10000", "1000")}))
+	assert.NoError(t, s.AddDevices(t0.Add(30*time.Minute), []fritz.Device{plug("30000", "1010")}))
+	assert.NoError(t, s.AddDevices(t0.Add(90*time.Minute), []fritz.Device{plug("20000", "1040")}))
+
+	as, err := s.Aggregate(Query{Metric: "power_watts", Per: "hour", From: t0, To: t0.Add(2 * time.Hour)})
+	assert.NoError(t, err)
+	assert.Equal(t, []Aggregate{
+		{Period: "2018-12-18 10:00", Name: "PLUG", AIN: "12345 6789", Min: 10, Max: 30, Avg: 20, Count: 2},
+		{Period: "2018-12-18 11:00", Name: "PLUG", AIN: "12345 6789", Min: 20, Max: 20, Avg: 20, Count: 1},
+	}, as)
+
+	as, err = s.Aggregate(Query{Metric: "power_watts", Per: "all", From: t0, To: t0.Add(time.Hour), Devices: []string{"PLUG", "OTHER"}})
+	assert.NoError(t, err)
+	assert.Len(t, as, 1)
+	assert.Equal(t, "", as[0].Period)
+
+	as, err = s.Aggregate(Query{Metric: "power_watts", Per: "all", From: t0, To: t0.Add(time.Hour), Devices: []string{"OTHER"}})
+	assert.NoError(t, err)
+	assert.Empty(t, as)
+
+	cs, err := s.Energy(Query{Per: "day", From: t0, To: t0.Add(2 * time.Hour)})
+	assert.NoError(t, err)
+	assert.Equal(t, []Consumption{{Period: "2018-12-18", Name: "PLUG", AIN: "12345 6789", WattHours: 40, FirstSeen: t0.Unix(), LastSeen: t0.Add(90 * time.Minute).Unix(), AvgPowerW: 40 / 1.5}}, cs)
+
+	_, err = s.Energy(Query{Per: "week"})
+	assert.Error(t, err)
+}
+
+// TestEnergyAcrossPeriods tests that the consumption between samples of adjacent periods is not lost.
+func TestEnergyAcrossPeriods(t *testing.T) {
+	s, cleanup := openTemp(t)
+	defer cleanup()
+	t0 := time.Date(2018, 12, 18, 10, 50, 0, 0, time.Local)
+	assert.NoError(t, s.AddDevices(t0, []fritz.Device{plug("0", "1000")}))
+	assert.NoError(t, s.AddDevices(t0.Add(20*time.Minute), []fritz.Device{plug("0", "1010")}))
+	assert.NoError(t, s.AddDevices(t0.Add(80*time.Minute), []fritz.Device{plug("0", "1030")}))
+
+	cs, err := s.Energy(Query{Per: "hour", From: t0, To: t0.Add(2 * time.Hour)})
+	assert.NoError(t, err)
+	assert.Equal(t, []Consumption{
+		{Period: "2018-12-18 11:00", Name: "PLUG", AIN: "12345 6789", WattHours: 10, FirstSeen: t0.Unix(), LastSeen: t0.Add(20 * time.Minute).Unix(), AvgPowerW: 30},
+		{Period: "2018-12-18 12:00", Name: "PLUG", AIN: "12345 6789", WattHours: 20, FirstSeen: t0.Add(20 * time.Minute).Unix(), LastSeen: t0.Add(80 * time.Minute).Unix(), AvgPowerW: 20},
+	}, cs)
+
+	cs, err = s.Energy(Query{Per: "all", From: t0, To: t0.Add(2 * time.Hour)})
+	assert.NoError(t, err)
+	assert.Len(t, cs, 1)
+	assert.Equal(t, 30.0, cs[0].WattHours)
+}
+
+// TestReadings tests the energy increases between subsequent samples.
+func TestReadings(t *testing.T) {
+	s, cleanup := openTemp(t)
+	defer cleanup()
+	t0 := time.Date(2018, 12, 18, 10, 0, 0, 0, time.Local)
+	assert.NoError(t, s.AddDevices(t0, []fritz.Device{plug("0", "1000")}))
+	assert.NoError(t, s.AddDevices(t0.Add(time.Hour), []fritz.Device{plug("0", "1010")}))
+	assert.NoError(t, s.AddDevices(t0.Add(2*time.Hour), []fritz.Device{plug("0", "5")}))
+	assert.NoError(t, s.AddDevices(t0.Add(3*time.Hour), []fritz.Device{plug("0", "25")}))
+
+	rs, err := s.Readings(Query{From: t0, To: t0.Add(4 * time.Hour)})
+	assert.NoError(t, err)
+	assert.Equal(t, []Reading{
+		{Name: "PLUG", AIN: "12345 6789", From: t0, To: t0.Add(time.Hour), WattHours: 10},
+		{Name: "PLUG", AIN: "12345 6789", From: t0.Add(2 * time.Hour), To: t0.Add(3 * time.Hour), WattHours: 20},
+	}, rs)
+}
+
+// TestAddCalls tests that calls are recorded only once.
+func TestAddCalls(t *testing.T) {
+	s, cleanup := openTemp(t)
+	defer cleanup()
+	calls := []fritz.Call{
+		{Type: "1", Date: "25.04.13 15:15", Caller: "A", PhoneNumber: "0123", Duration: "0:03"},
+		{Type: "4", Date: "24.04.13 15:53", Caller: "B", PhoneNumber: "0456", Duration: "0:07"},
+	}
+	added, err := s.AddCalls(time.Now(), calls)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, added)
+	added, err = s.AddCalls(time.Now(), append(calls, fritz.Call{Type: "2", Date: "26.04.13 08:00", Caller: "C"}))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, added)
+
+	var unix int64
+	assert.NoError(t, s.db.QueryRow("SELECT time FROM calls WHERE caller = 'A'").Scan(&unix))
+	assert.Equal(t, time.Date(2013, 4, 25, 15, 15, 0, 0, time.Local).Unix(), unix)
+}
+
+// TestAddTrafficAndLanDevices tests the recording of network data.
+func TestAddTrafficAndLanDevices(t *testing.T) {
+	s, cleanup := openTemp(t)
+	defer cleanup()
+	now := time.Now()
+	assert.NoError(t, s.AddTraffic(now, &fritz.TrafficMonitoringData{DownstreamInternet: []float64{1000, 2000}, UpstreamRealtime: []float64{5}}))
+	assert.NoError(t, s.AddLanDevices(now, &fritz.LanDevices{Network: []fritz.NetworkElement{{Name: "laptop", Mac: "AA", Online: "1"}, {Name: "tv", Online: "0"}}}))
+
+	var n int
+	assert.NoError(t, s.db.QueryRow("SELECT COUNT(*) FROM traffic_samples").Scan(&n))
+	assert.True(t, n >= 2)
+	assert.NoError(t, s.db.QueryRow("SELECT SUM(online) FROM lan_device_samples").Scan(&n))
+	assert.Equal(t, 1, n)
+}
+
+// TestOpenFails tests that unusable files are reported.
+func TestOpenFails(t *testing.T) {
+	_, err := Open("/does/not/exist/fritz.sqlite")
+	assert.Error(t, err)
+}
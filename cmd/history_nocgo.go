@@ -0,0 +1,35 @@
+//go:build !cgo
+// +build !cgo
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/bpicode/fritzctl/cmd/history"
+	"github.com/spf13/cobra"
+)
+
+// Without cgo, the commands operating on the SQLite history are only stubs explaining why they are not available.
+var (
+	recordCmd = unsupportedHistoryCmd("record", "Record the history of measurements in a local database")
+	queryCmd  = unsupportedHistoryCmd("query", "Evaluate the history recorded by 'fritzctl record'")
+)
+
+func init() {
+	RootCmd.AddCommand(recordCmd, queryCmd)
+}
+
+func unsupportedHistoryCmd(use, short string) *cobra.Command {
+	return &cobra.Command{
+		Use:   use,
+		Short: short + " (not supported by this build)",
+		Long: short + ". This build of fritzctl does not support SQLite databases, which requires cgo. " +
+			"Cross-compiled release binaries are built without cgo, build fritzctl on the target platform instead.",
+		DisableFlagParsing: true,
+		RunE: func(*cobra.Command, []string) error {
+			assertTrue(history.Supported, fmt.Errorf("'%s' requires SQLite, which is not supported by this build of fritzctl; build it with cgo enabled (CGO_ENABLED=1)", use))
+			return nil
+		},
+	}
+}
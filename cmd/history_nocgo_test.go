@@ -0,0 +1,18 @@
+//go:build !cgo
+// +build !cgo
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestHistoryCommandsUnsupported tests that the history commands explain why they are not available without cgo.
+func TestHistoryCommandsUnsupported(t *testing.T) {
+	assert.PanicsWithError(t, "'record' requires SQLite, which is not supported by this build of fritzctl; build it with cgo enabled (CGO_ENABLED=1)", func() {
+		recordCmd.RunE(recordCmd, []string{"--once"})
+	})
+	assert.Panics(t, func() { queryCmd.RunE(queryCmd, nil) })
+}
@@ -100,6 +100,7 @@ func (m *mapper) mapMeasurements(target *Device, src *fritz.Device) {
 	meas := &Measurements{}
 	if src.Temperature.Celsius != "" {
 		meas.Temperature = src.Temperature.FmtCelsius()
+		meas.TemperatureUnit = Celsius
 	}
 	if src.Powermeter.Power != "" {
 		meas.PowerConsumption = src.Powermeter.FmtPowerW()
@@ -133,7 +134,7 @@ var windowStateLookup = map[string]string{
 }
 
 func (m *mapper) mapThermostat(target *State, src *fritz.Device) {
-	tc := &TemperatureControl{}
+	tc := &TemperatureControl{Unit: Celsius}
 	tc.Goal = src.Thermostat.FmtGoalTemperature()
 	tc.Saving = src.Thermostat.FmtSavingTemperature()
 	tc.Comfort = src.Thermostat.FmtComfortTemperature()
@@ -0,0 +1,20 @@
+package jsonapi
+
+import "github.com/bpicode/fritzctl/fritz"
+
+// ConvertBox translates the runtime information of the FRITZ!Box.
+func ConvertBox(b *fritz.BoxData) Box {
+	return Box{
+		Model:    b.Model.Name,
+		Annex:    b.Model.Annex,
+		Branding: b.Model.Branding,
+		Firmware: b.FirmwareVersion.String(),
+		Runtime: &BoxRuntime{
+			Hours:   b.Runtime.Hours,
+			Days:    b.Runtime.Days,
+			Months:  b.Runtime.Months,
+			Years:   b.Runtime.Years,
+			Reboots: b.Runtime.Reboots,
+		},
+	}
+}
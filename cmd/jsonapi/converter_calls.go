@@ -0,0 +1,27 @@
+package jsonapi
+
+import "github.com/bpicode/fritzctl/fritz"
+
+var callTypeLookup = map[string]string{
+	"1": "INCOMING",
+	"2": "MISSED",
+	"3": "REJECTED",
+	"4": "OUTGOING",
+}
+
+// ConvertCalls translates phone call records into a CallList.
+func ConvertCalls(cs []fritz.Call) CallList {
+	l := CallList{Calls: []Call{}, NumberOfItems: len(cs)}
+	for _, c := range cs {
+		l.Calls = append(l.Calls, Call{
+			Type:           callTypeLookup[c.Type],
+			Date:           c.Date,
+			Caller:         c.Caller,
+			PhoneNumber:    c.PhoneNumber,
+			Extension:      c.Extension,
+			OwnPhoneNumber: c.OwnPhoneNumber,
+			Duration:       c.Duration,
+		})
+	}
+	return l
+}
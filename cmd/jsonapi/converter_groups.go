@@ -0,0 +1,41 @@
+package jsonapi
+
+import (
+	"sort"
+
+	"github.com/bpicode/fritzctl/fritz"
+)
+
+// ConvertGroups translates the groups of a Devicelist into a GroupList. Members are referenced by name.
+func ConvertGroups(l *fritz.Devicelist) GroupList {
+	gl := GroupList{Groups: []Group{}}
+	for _, g := range l.Groups {
+		gl.Groups = append(gl.Groups, convertGroup(g, l))
+	}
+	gl.NumberOfItems = len(gl.Groups)
+	return gl
+}
+
+func convertGroup(g fritz.Group, l *fritz.Devicelist) Group {
+	target := Group{ID: g.Identifier, InternalID: g.ID, Name: g.Name, Members: []string{}}
+	for _, id := range g.Members() {
+		if d, ok := l.DeviceWithID(id); ok {
+			target.Members = append(target.Members, d.Name)
+		}
+	}
+	sort.Strings(target.Members)
+	if master, ok := l.DeviceWithID(g.GroupInfo.MasterDeviceID); ok {
+		target.Master = master.Name
+	}
+	st := &State{Connected: g.Present == 1, Switch: switchStateLookup[g.Switch.State]}
+	if g.MadeFromThermostats() {
+		st.TemperatureControl = &TemperatureControl{
+			Goal:    g.Thermostat.FmtGoalTemperature(),
+			Saving:  g.Thermostat.FmtSavingTemperature(),
+			Comfort: g.Thermostat.FmtComfortTemperature(),
+			Unit:    Celsius,
+		}
+	}
+	target.State = st
+	return target
+}
@@ -25,6 +25,35 @@ func Test_thMapper_Convert(t *testing.T) {
 	assert.Equal(t, l.NumberOfItems, len(devices))
 }
 
+// TestConvertGroups probes the group converter.
+func TestConvertGroups(t *testing.T) {
+	l := &fritz.Devicelist{
+		Devices: []fritz.Device{{ID: "16", Name: "b"}, {ID: "17", Name: "a"}},
+		Groups:  []fritz.Group{{Name: "g", Present: 1, Switch: fritz.Switch{State: "1"}, GroupInfo: fritz.GroupInfo{MasterDeviceID: "16", Members: "16,17,18"}}},
+	}
+	gl := ConvertGroups(l)
+	assert.Equal(t, 1, gl.NumberOfItems)
+	assert.Equal(t, []string{"a", "b"}, gl.Groups[0].Members)
+	assert.Equal(t, "b", gl.Groups[0].Master)
+	assert.Equal(t, "ON", gl.Groups[0].State.Switch)
+}
+
+// TestConvertCalls probes the call converter.
+func TestConvertCalls(t *testing.T) {
+	l := ConvertCalls([]fritz.Call{{Type: "2", PhoneNumber: "0123"}, {Type: "9"}})
+	assert.Equal(t, 2, l.NumberOfItems)
+	assert.Equal(t, "MISSED", l.Calls[0].Type)
+	assert.Equal(t, "0123", l.Calls[0].PhoneNumber)
+	assert.Empty(t, l.Calls[1].Type)
+}
+
+// TestConvertBox probes the box converter.
+func TestConvertBox(t *testing.T) {
+	b := ConvertBox(&fritz.BoxData{Model: fritz.Model{Name: "FRITZ!Box 7490"}, Runtime: fritz.Runtime{Reboots: 3}})
+	assert.Equal(t, "FRITZ!Box 7490", b.Model)
+	assert.Equal(t, uint64(3), b.Runtime.Reboots)
+}
+
 func simpleHkr() fritz.Device {
 	return fritz.Device{
 		Name:            "myhkr",
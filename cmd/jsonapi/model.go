@@ -3,6 +3,10 @@ package jsonapi
 
 import "time"
 
+// Celsius is the unit of all temperatures. They are never converted to the unit system of the user, so the JSON
+// documents do not depend on it.
+const Celsius = "celsius"
+
 // codebeat:disable[TOO_MANY_IVARS]
 
 // DeviceList wraps a collection of devices.
@@ -43,7 +47,8 @@ type Lock struct {
 
 // Measurements indicate runtime data obtained by device senors.
 type Measurements struct {
-	Temperature       string     `json:"temperature,omitempty"`       // Temperature measured in °C.
+	Temperature       string     `json:"temperature,omitempty"`       // Temperature measured, in TemperatureUnit.
+	TemperatureUnit   string     `json:"temperatureUnit,omitempty"`   // Unit of Temperature, always "celsius".
 	PowerConsumption  string     `json:"powerConsumption,omitempty"`  // Current power in W.
 	EnergyConsumption string     `json:"energyConsumption,omitempty"` // Absolute energy consumption in Wh since the device started operating.
 	AlertSignal       string     `json:"alertSignal,omitempty"`       // "ON", "OFF" (if the device reports an alert) or "" (if unknown or does not apply).
@@ -66,6 +71,7 @@ type TemperatureControl struct {
 	Comfort    string      `json:"comfort,omitempty"`    // Comfortable temperature.
 	NextChange *NextChange `json:"nextChange,omitempty"` // Comfortable temperature.
 	Window     string      `json:"window,omitempty"`     // "OPEN", "CLOSED" or "" (if unknown).
+	Unit       string      `json:"unit"`                 // Unit of the temperatures, always "celsius".
 }
 
 // NextChange indicates the upcoming scheduled temperature change.
@@ -75,3 +81,58 @@ type NextChange struct {
 }
 
 // codebeat:enable[TOO_MANY_IVARS]
+
+// GroupList wraps a collection of device groups.
+type GroupList struct {
+	NumberOfItems int     `json:"numberOfItems"` // Number of items.
+	Groups        []Group `json:"groups"`        // The groups.
+}
+
+// Group is a set of devices that are controlled together.
+type Group struct {
+	ID         string   `json:"id,omitempty"`         // A unique ID like AIN, MAC address, etc.
+	InternalID string   `json:"internalId,omitempty"` // Internal ID of the FRITZ!Box.
+	Name       string   `json:"name,omitempty"`       // The name of the group.
+	Members    []string `json:"members"`              // Names of the member devices.
+	Master     string   `json:"master,omitempty"`     // Name of the master device, empty if there is none.
+	State      *State   `json:"state,omitempty"`      // State of the group.
+}
+
+// CallList wraps a collection of phone calls.
+type CallList struct {
+	NumberOfItems int    `json:"numberOfItems"` // Number of items.
+	Calls         []Call `json:"calls"`         // The calls.
+}
+
+// codebeat:disable[TOO_MANY_IVARS]
+
+// Call is an entry of the phone call list.
+type Call struct {
+	Type           string `json:"type"`                     // "INCOMING", "MISSED", "REJECTED", "OUTGOING" or "" (if unknown).
+	Date           string `json:"date"`                     // Date and time of the call, as reported by the FRITZ!Box.
+	Caller         string `json:"caller,omitempty"`         // Name of the other party, if known.
+	PhoneNumber    string `json:"phoneNumber,omitempty"`    // Number of the other party.
+	Extension      string `json:"extension,omitempty"`      // Extension that took or made the call.
+	OwnPhoneNumber string `json:"ownPhoneNumber,omitempty"` // Own number that was used.
+	Duration       string `json:"duration,omitempty"`       // Duration of the call, as reported by the FRITZ!Box.
+}
+
+// Box contains runtime information of the FRITZ!Box.
+type Box struct {
+	Model    string      `json:"model"`    // Name of the model.
+	Annex    string      `json:"annex"`    // ADSL standard.
+	Branding string      `json:"branding"` // Branding, e.g. "avm".
+	Firmware string      `json:"firmware"` // FRITZ!OS version.
+	Runtime  *BoxRuntime `json:"runtime"`  // How long the box has been running.
+}
+
+// BoxRuntime contains data on how long the FRITZ!Box has been running.
+type BoxRuntime struct {
+	Hours   uint64 `json:"hours"`   // Hours, in addition to the other fields.
+	Days    uint64 `json:"days"`    // Days, in addition to the other fields.
+	Months  uint64 `json:"months"`  // Months, in addition to the other fields.
+	Years   uint64 `json:"years"`   // Years, in addition to the other fields.
+	Reboots uint64 `json:"reboots"` // Number of reboots.
+}
+
+// codebeat:enable[TOO_MANY_IVARS]
@@ -12,19 +12,24 @@ import (
 )
 
 var listAlertsCmd = &cobra.Command{
-	Use:     "alerts",
-	Short:   "List recent alert sensor reports",
-	Long:    "List the most recently reported state of all devices with an alert sensor.",
-	Example: "fritzctl list alerts",
-	RunE:    listAlerts,
+	Use:   "alerts",
+	Short: "List recent alert sensor reports",
+	Long:  "List the most recently reported state of all devices with an alert sensor.",
+	Example: `fritzctl list alerts
+fritzctl list alerts --watch=5s`,
+	RunE: listAlerts,
 }
 
 func init() {
-	listAlertsCmd.Flags().StringP("output", "o", "", "specify output format")
+	addOutputFlags(listAlertsCmd)
+	addWatchFlag(listAlertsCmd)
 	listCmd.AddCommand(listAlertsCmd)
 }
 
 func listAlerts(cmd *cobra.Command, _ []string) error {
+	if interval := watchInterval(cmd); interval > 0 {
+		return watchDevices(cmd, interval, func(d fritz.Device) bool { return d.HasAlertSensor() }, alertSensorsTable)
+	}
 	devs := mustList()
 	data := selectFmt(cmd, devs.AlertSensors(), alertSensorsTable)
 	logger.Success("Device data:")
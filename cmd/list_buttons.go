@@ -12,19 +12,24 @@ import (
 )
 
 var listButtonsCmd = &cobra.Command{
-	Use:     "buttons",
-	Short:   "List the smart-home buttons",
-	Long:    "List the all smart-home devices recognized as pressable buttons.",
-	Example: "fritzctl list buttons",
-	RunE:    listButtons,
+	Use:   "buttons",
+	Short: "List the smart-home buttons",
+	Long:  "List the all smart-home devices recognized as pressable buttons.",
+	Example: `fritzctl list buttons
+fritzctl list buttons --watch`,
+	RunE: listButtons,
 }
 
 func init() {
-	listButtonsCmd.Flags().StringP("output", "o", "", "specify output format")
+	addOutputFlags(listButtonsCmd)
+	addWatchFlag(listButtonsCmd)
 	listCmd.AddCommand(listButtonsCmd)
 }
 
 func listButtons(cmd *cobra.Command, _ []string) error {
+	if interval := watchInterval(cmd); interval > 0 {
+		return watchDevices(cmd, interval, func(d fritz.Device) bool { return d.HasButton() }, buttonsTable)
+	}
 	devs := mustList()
 	data := selectFmt(cmd, devs.Buttons(), buttonsTable)
 	logger.Success("Device data:")
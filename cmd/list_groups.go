@@ -8,6 +8,7 @@ import (
 	"github.com/bpicode/fritzctl/fritz"
 	"github.com/bpicode/fritzctl/internal/console"
 	"github.com/bpicode/fritzctl/internal/stringutils"
+	"github.com/bpicode/fritzctl/internal/units"
 	"github.com/spf13/cobra"
 )
 
@@ -49,21 +50,22 @@ func (p byGroupName) Less(i, j int) bool {
 func printGroups(list *fritz.Devicelist) {
 	groups := list.DeviceGroups()
 	sort.Sort(byGroupName(groups))
-	table := console.NewTable(console.Headers("NAME", "MEMBERS", "MASTER", "PRESENT", "STATE", "TEMP (MEAS/WANT/SAV/COMF) [°C]"))
+	u := unitSystem()
+	table := console.NewTable(console.Headers("NAME", "MEMBERS", "MASTER", "PRESENT", "STATE", "TEMP (MEAS/WANT/SAV/COMF) ["+u.Symbol()+"]"))
 	for _, g := range groups {
-		table.Append(groupColumns(g, list))
+		table.Append(groupColumns(g, list, u))
 	}
 	table.Print(os.Stdout)
 }
 
-func groupColumns(group fritz.DeviceGroup, list *fritz.Devicelist) []string {
+func groupColumns(group fritz.DeviceGroup, list *fritz.Devicelist, u units.System) []string {
 	return []string{
 		group.Group.Name,
 		strings.Join(memberNames(group, list), ", "),
 		masterName(group, list),
 		console.IntToCheckmark(group.Group.Present),
 		console.StringToCheckmark(group.Group.Switch.State),
-		joinTemperatures(group.Group.Thermostat),
+		joinTemperatures(group.Group.Thermostat, u),
 	}
 }
 
@@ -86,16 +88,16 @@ func masterName(group fritz.DeviceGroup, list *fritz.Devicelist) string {
 	return master.Name
 }
 
-func joinTemperatures(th fritz.Thermostat) string {
+func joinTemperatures(th fritz.Thermostat, u units.System) string {
 	return strings.Join([]string{
-		valueOrQm(th.FmtMeasuredTemperature),
-		valueOrQm(th.FmtGoalTemperature),
-		valueOrQm(th.FmtSavingTemperature),
-		valueOrQm(th.FmtComfortTemperature)},
+		valueOrQm(th.FmtMeasuredTemperature, u),
+		valueOrQm(th.FmtGoalTemperature, u),
+		valueOrQm(th.FmtSavingTemperature, u),
+		valueOrQm(th.FmtComfortTemperature, u)},
 		"/")
 }
 
-func valueOrQm(f func() string) string {
+func valueOrQm(f func() string, u units.System) string {
 	yellowQm := console.Yellow("?")
-	return stringutils.DefaultIfEmpty(f(), yellowQm)
+	return stringutils.DefaultIfEmpty(u.Convert(f()), yellowQm)
 }
@@ -7,6 +7,7 @@ import (
 	"github.com/bpicode/fritzctl/cmd/printer"
 	"github.com/bpicode/fritzctl/fritz"
 	"github.com/bpicode/fritzctl/internal/console"
+	"github.com/bpicode/fritzctl/internal/units"
 	"github.com/bpicode/fritzctl/logger"
 	"github.com/spf13/cobra"
 )
@@ -16,16 +17,22 @@ var listSwitchesCmd = &cobra.Command{
 	Short: "List the available smart home switches",
 	Long:  "List the available smart home devices [switches] and associated data.",
 	Example: `fritzctl list switches
-fritzctl list switches --output=json`,
+fritzctl list switches --output=json
+fritzctl list switches --output=influx --metric-prefix=home
+fritzctl list switches --watch=30s`,
 	RunE: listSwitches,
 }
 
 func init() {
-	listSwitchesCmd.Flags().StringP("output", "o", "", "specify output format")
+	addOutputFlags(listSwitchesCmd)
+	addWatchFlag(listSwitchesCmd)
 	listCmd.AddCommand(listSwitchesCmd)
 }
 
 func listSwitches(cmd *cobra.Command, _ []string) error {
+	if interval := watchInterval(cmd); interval > 0 {
+		return watchDevices(cmd, interval, func(d fritz.Device) bool { return d.IsSwitch() }, switchTable)
+	}
 	devs := mustList()
 	logger.Success("Device data:")
 	data := selectFmt(cmd, devs.Switches(), switchTable)
@@ -46,17 +53,17 @@ func switchTable(devs []fritz.Device) interface{} {
 		"TEMP",
 		"OFFSET",
 	))
-	appendSwitches(devs, table)
+	appendSwitches(devs, unitSystem(), table)
 	return table
 }
 
-func appendSwitches(devs []fritz.Device, table *console.Table) {
+func appendSwitches(devs []fritz.Device, u units.System, table *console.Table) {
 	for _, dev := range devs {
-		table.Append(switchColumns(dev))
+		table.Append(switchColumns(dev, u))
 	}
 }
 
-func switchColumns(dev fritz.Device) []string {
+func switchColumns(dev fritz.Device, u units.System) []string {
 	return []string{
 		dev.Name,
 		fmt.Sprintf("%s %s", dev.Manufacturer, dev.Productname),
@@ -66,7 +73,7 @@ func switchColumns(dev fritz.Device) []string {
 		dev.Switch.Mode,
 		fmtUnit(dev.Powermeter.FmtPowerW, "W"),
 		fmtUnit(dev.Powermeter.FmtEnergyWh, "Wh"),
-		fmtUnit(dev.Temperature.FmtCelsius, "°C"),
-		fmtUnit(dev.Temperature.FmtOffset, "°C"),
+		fmtTemperature(u, dev.Temperature.FmtCelsius),
+		fmtTemperatureDelta(u, dev.Temperature.FmtOffset),
 	}
 }
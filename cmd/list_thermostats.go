@@ -8,6 +8,7 @@ import (
 	"github.com/bpicode/fritzctl/cmd/printer"
 	"github.com/bpicode/fritzctl/fritz"
 	"github.com/bpicode/fritzctl/internal/console"
+	"github.com/bpicode/fritzctl/internal/units"
 	"github.com/bpicode/fritzctl/logger"
 	"github.com/spf13/cobra"
 )
@@ -17,16 +18,21 @@ var listThermostatsCmd = &cobra.Command{
 	Short: "List the available smart home thermostats",
 	Long:  "List the available smart home devices [thermostats] and associated data.",
 	Example: `fritzctl list thermostats
-fritzctl list thermostats --output=json`,
+fritzctl list thermostats --output=json
+fritzctl list thermostats --watch`,
 	RunE: listThermostats,
 }
 
 func init() {
-	listThermostatsCmd.Flags().StringP("output", "o", "", "specify output format")
+	addOutputFlags(listThermostatsCmd)
+	addWatchFlag(listThermostatsCmd)
 	listCmd.AddCommand(listThermostatsCmd)
 }
 
 func listThermostats(cmd *cobra.Command, _ []string) error {
+	if interval := watchInterval(cmd); interval > 0 {
+		return watchDevices(cmd, interval, func(d fritz.Device) bool { return d.IsThermostat() }, thermostatsTable)
+	}
 	devs := mustList()
 	data := selectFmt(cmd, devs.Thermostats(), thermostatsTable)
 	logger.Success("Device data:")
@@ -49,22 +55,22 @@ func thermostatsTable(devs []fritz.Device) interface{} {
 		"STATE",
 		"BATTERY",
 	))
-	appendThermostats(devs, table)
+	appendThermostats(devs, unitSystem(), table)
 	return table
 }
 
-func appendThermostats(devs []fritz.Device, table *console.Table) {
+func appendThermostats(devs []fritz.Device, u units.System, table *console.Table) {
 	for _, dev := range devs {
-		columns := thermostatColumns(dev)
+		columns := thermostatColumns(dev, u)
 		table.Append(columns)
 	}
 }
 
-func thermostatColumns(dev fritz.Device) []string {
+func thermostatColumns(dev fritz.Device, u units.System) []string {
 	var columnValues []string
 	columnValues = appendMetadata(columnValues, dev)
 	columnValues = appendRuntimeFlags(columnValues, dev)
-	columnValues = appendTemperatureValues(columnValues, dev)
+	columnValues = appendTemperatureValues(columnValues, dev, u)
 	columnValues = appendRuntimeWarnings(columnValues, dev)
 	return columnValues
 }
@@ -83,22 +89,22 @@ func appendRuntimeWarnings(cols []string, dev fritz.Device) []string {
 	return append(cols, errorCode(dev.Thermostat.ErrorCode), batteryState(dev.Thermostat))
 }
 
-func appendTemperatureValues(cols []string, dev fritz.Device) []string {
+func appendTemperatureValues(cols []string, dev fritz.Device, u units.System) []string {
 	return append(cols,
-		fmtUnit(dev.Thermostat.FmtMeasuredTemperature, "°C"),
-		fmtUnit(dev.Temperature.FmtOffset, "°C"),
-		fmtUnit(dev.Thermostat.FmtGoalTemperature, "°C"),
-		fmtUnit(dev.Thermostat.FmtSavingTemperature, "°C"),
-		fmtUnit(dev.Thermostat.FmtComfortTemperature, "°C"),
-		fmtNextChange(dev.Thermostat.NextChange))
+		fmtTemperature(u, dev.Thermostat.FmtMeasuredTemperature),
+		fmtTemperatureDelta(u, dev.Temperature.FmtOffset),
+		fmtTemperature(u, dev.Thermostat.FmtGoalTemperature),
+		fmtTemperature(u, dev.Thermostat.FmtSavingTemperature),
+		fmtTemperature(u, dev.Thermostat.FmtComfortTemperature),
+		fmtNextChange(dev.Thermostat.NextChange, u))
 }
 
-func fmtNextChange(n fritz.NextChange) string {
+func fmtNextChange(n fritz.NextChange, u units.System) string {
 	ts := n.FmtTimestamp(time.Now())
 	if ts == "" {
 		return "?"
 	}
-	return ts + " -> " + fmtUnit(n.FmtGoalTemperature, "°C")
+	return ts + " -> " + fmtTemperature(u, n.FmtGoalTemperature)
 }
 
 func errorCode(ec string) string {
@@ -1,29 +1,84 @@
 package cmd
 
 import (
+	"os"
+
 	"github.com/bpicode/fritzctl/fritz"
 	"github.com/bpicode/fritzctl/manifest"
 	"github.com/spf13/cobra"
 )
 
 var applyManifestCmd = &cobra.Command{
-	Use:     "apply [manifest file]",
-	Short:   "Apply a given manifest",
-	Long:    "Apply a given manifest against the state of the FRITZ!Box.",
-	Example: "fritzctl manifest apply /path/to/manifest.yml",
-	RunE:    apply,
+	Use:   "apply [manifest file]",
+	Short: "Apply a given manifest",
+	Long: "Apply a given manifest against the state of the FRITZ!Box. " +
+		"With --strict, the manifest is the full desired state: devices missing in the manifest are handled according to " +
+		"its 'unmanaged' policy, which is 'fail' (default), 'warn' or 'off'. " +
+		"With --plan, a plan written by 'fritzctl manifest plan --out' is applied instead of a manifest. " +
+		"It is refused if the state of the devices has drifted or the outcome of any rule has changed since planning. " +
+		"Devices with an 'ain' in the manifest are addressed by it, if their name differs from the manifest, " +
+		"--update-names renames them on the FRITZ!Box. " +
+		"Manifests are written in YAML, JSON or TOML, detected by the file extension or else by the content. " +
+		"They can include other manifests ('include'), define variables ('vars', referenced as ${name}, " +
+		"falling back to environment variables) and be overlaid by further manifests (-f), which take precedence. " +
+		"Entries can declare a 'stage' and the entries they 'depends_on', the stages are applied in ascending order, " +
+		"separated by the 'stage_delay' of the manifest. If a stage fails, the later stages are skipped. " +
+		"Rules apply entries only if their conditions on the live state hold ('rules' with 'when' and 'then'), " +
+		"e.g. on the state of a switch, measured temperatures or power, the presence of a device or an alert. " +
+		"With --atomic, the successful changes are reverted in reverse order if any change fails.",
+	Example: `fritzctl manifest apply /path/to/manifest.yml
+fritzctl manifest apply --strict /path/to/manifest.yml
+fritzctl manifest apply --update-names /path/to/manifest.yml
+fritzctl manifest apply --atomic /path/to/manifest.yml
+fritzctl manifest apply /path/to/base.yml -f /path/to/winter.yml
+fritzctl manifest apply --plan=plan.json`,
+	RunE: apply,
 }
 
 func init() {
+	addStrictFlag(applyManifestCmd)
+	addUpdateNamesFlag(applyManifestCmd)
+	addOverlayFlag(applyManifestCmd)
+	applyManifestCmd.Flags().String("plan", "", "apply a plan written by 'fritzctl manifest plan --out'")
+	applyManifestCmd.Flags().Bool("atomic", false, "revert the successful changes if any change fails")
 	manifestCmd.AddCommand(applyManifestCmd)
 }
 
-func apply(_ *cobra.Command, args []string) error {
-	assertMinLen(args, 1, "insufficient input: path to input manifest expected")
-	target := parseManifest(args[0])
+func apply(cmd *cobra.Command, args []string) error {
+	planFile, err := cmd.Flags().GetString("plan")
+	assertNoErr(err, "cannot parse plan flag")
+	atomic, err := cmd.Flags().GetBool("atomic")
+	assertNoErr(err, "cannot parse atomic flag")
+	var cs *manifest.Changeset
+	var target *manifest.Plan
+	var opts []manifest.Option
+	if planFile != "" {
+		cs = parseChangeset(planFile)
+		opts = cs.Options()
+	} else {
+		assertMinLen(args, 1, "insufficient input: path to input manifest expected")
+		target, opts = parseManifest(cmd, args[0]), applierOptions(cmd)
+	}
+	if atomic {
+		opts = append(opts, manifest.Atomic())
+	}
 	h := homeAutoClient(fritz.Caching(true))
-	src := obtainSourcePlan(h)
-	err := manifest.NewApplier(h).Apply(src, target)
+	var src *manifest.Plan
+	if cs != nil {
+		src, target = obtainChangesetPlan(h, cs)
+	} else {
+		src, target, _ = obtainSourcePlan(h, target)
+	}
+	err = manifest.NewApplier(h, opts...).Apply(src, target)
 	assertNoErr(err, "application of manifest was not successful")
 	return nil
 }
+
+func parseChangeset(filename string) *manifest.Changeset {
+	file, err := os.Open(filename)
+	assertNoErr(err, "cannot open plan file '%s'", filename)
+	defer file.Close()
+	cs, err := manifest.ParseChangeset(file)
+	assertNoErr(err, "cannot parse plan file '%s'", filename)
+	return cs
+}
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bpicode/fritzctl/config"
+	"github.com/bpicode/fritzctl/mock"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestManifestStrict tests planning and applying manifests as the full desired state.
+func TestManifestStrict(t *testing.T) {
+	oldPlaces := defaultConfigPlaces
+	defer func() { defaultConfigPlaces = oldPlaces }()
+	defaultConfigPlaces = append([]config.Place{config.InDir("../testdata/config", "config_localhost_http_test.json", config.JSON())}, defaultConfigPlaces...)
+	srv := mock.New().UnstartedServer()
+	var err error
+	srv.Listener, err = net.Listen("tcp", ":61666")
+	assert.NoError(t, err)
+	srv.Start()
+	defer srv.Close()
+
+	for _, cmd := range []*cobra.Command{planManifestCmd, applyManifestCmd} {
+		assert.NoError(t, cmd.ParseFlags([]string{"--strict"}))
+		defer cmd.Flags().Set("strict", "false")
+		assert.NoError(t, cmd.RunE(cmd, []string{"../testdata/strict_manifest.yml"}))
+		assert.Panics(t, func() { cmd.RunE(cmd, []string{"../testdata/thermostat_manifest.yml"}) }, "unmanaged devices fail by default")
+	}
+}
+
+// TestManifestPlanFile tests writing a plan to a file and applying it.
+func TestManifestPlanFile(t *testing.T) {
+	oldPlaces := defaultConfigPlaces
+	defer func() { defaultConfigPlaces = oldPlaces }()
+	defaultConfigPlaces = append([]config.Place{config.InDir("../testdata/config", "config_localhost_http_test.json", config.JSON())}, defaultConfigPlaces...)
+	srv := mock.New().UnstartedServer()
+	var err error
+	srv.Listener, err = net.Listen("tcp", ":61666")
+	assert.NoError(t, err)
+	srv.Start()
+	defer srv.Close()
+	dir, err := ioutil.TempDir("", "plan")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	defer planManifestCmd.Flags().Set("out", "")
+	defer applyManifestCmd.Flags().Set("plan", "")
+
+	for _, name := range []string{"plan.json", "plan.yml"} {
+		out := filepath.Join(dir, name)
+		assert.NoError(t, planManifestCmd.Flags().Set("out", out))
+		assert.NoError(t, planManifestCmd.RunE(planManifestCmd, []string{"../testdata/thermostat_manifest.yml"}))
+		assert.NoError(t, applyManifestCmd.Flags().Set("plan", out))
+		assert.NoError(t, applyManifestCmd.RunE(applyManifestCmd, nil))
+	}
+
+	rules := filepath.Join(dir, "rules.json")
+	assert.NoError(t, planManifestCmd.Flags().Set("out", rules))
+	assert.NoError(t, planManifestCmd.RunE(planManifestCmd, []string{"../testdata/rules_manifest.yml"}))
+	assert.NoError(t, applyManifestCmd.Flags().Set("plan", rules))
+	assert.NoError(t, applyManifestCmd.RunE(applyManifestCmd, nil))
+	bs, err := ioutil.ReadFile(rules)
+	assert.NoError(t, err)
+	assert.Contains(t, string(bs), `"rules"`, "the plan keeps the rules")
+	changed := strings.Replace(string(bs), `"held": true`, `"held": false`, 1)
+	assert.NotEqual(t, string(bs), changed)
+	assert.NoError(t, ioutil.WriteFile(rules, []byte(changed), 0600))
+	assert.PanicsWithError(t, "the plan cannot be applied: the outcomes of rules changed since planning: rule 'window open' holds now", func() { applyManifestCmd.RunE(applyManifestCmd, nil) })
+
+	drifted := filepath.Join(dir, "drifted.json")
+	assert.NoError(t, ioutil.WriteFile(drifted, []byte(`{"target": {"switches": [{"name": "SWITCH_1", "state": true}]}, "changes": []}`), 0600))
+	assert.NoError(t, applyManifestCmd.Flags().Set("plan", drifted))
+	assert.Panics(t, func() { applyManifestCmd.RunE(applyManifestCmd, nil) })
+}
+
+// TestManifestUpdateNames tests addressing devices by AIN and renaming them.
+func TestManifestUpdateNames(t *testing.T) {
+	oldPlaces := defaultConfigPlaces
+	defer func() { defaultConfigPlaces = oldPlaces }()
+	defaultConfigPlaces = append([]config.Place{config.InDir("../testdata/config", "config_localhost_http_test.json", config.JSON())}, defaultConfigPlaces...)
+	srv := mock.New().UnstartedServer()
+	var err error
+	srv.Listener, err = net.Listen("tcp", ":61666")
+	assert.NoError(t, err)
+	srv.Start()
+	defer srv.Close()
+
+	for _, cmd := range []*cobra.Command{planManifestCmd, applyManifestCmd} {
+		assert.NoError(t, cmd.RunE(cmd, []string{"../testdata/ain_manifest.yml"}))
+		assert.NoError(t, cmd.Flags().Set("update-names", "true"))
+		defer cmd.Flags().Set("update-names", "false")
+		assert.NoError(t, cmd.RunE(cmd, []string{"../testdata/ain_manifest.yml"}))
+	}
+	assert.NoError(t, exportManifestCmd.Flags().Set("ains", "false"))
+	defer exportManifestCmd.Flags().Set("ains", "true")
+	assert.NoError(t, exportManifestCmd.RunE(exportManifestCmd, nil))
+}
+
+// TestManifestOverlay tests planning and applying a manifest with overlays.
+func TestManifestOverlay(t *testing.T) {
+	oldPlaces := defaultConfigPlaces
+	defer func() { defaultConfigPlaces = oldPlaces }()
+	defaultConfigPlaces = append([]config.Place{config.InDir("../testdata/config", "config_localhost_http_test.json", config.JSON())}, defaultConfigPlaces...)
+	defer os.Unsetenv("FRITZCTL_TEST_SWITCH_2")
+	os.Setenv("FRITZCTL_TEST_SWITCH_2", "off")
+	srv := mock.New().UnstartedServer()
+	var err error
+	srv.Listener, err = net.Listen("tcp", ":61666")
+	assert.NoError(t, err)
+	srv.Start()
+	defer srv.Close()
+
+	for _, cmd := range []*cobra.Command{planManifestCmd, applyManifestCmd} {
+		assert.NoError(t, cmd.ParseFlags([]string{"-f", "../testdata/compose/winter.yml"}))
+		defer cmd.Flags().Lookup("overlay").Value.(pflag.SliceValue).Replace(nil)
+		assert.NoError(t, cmd.RunE(cmd, []string{"../testdata/compose/base.yml"}))
+	}
+}
+
+// TestManifestAtomic tests applying a manifest atomically.
+func TestManifestAtomic(t *testing.T) {
+	oldPlaces := defaultConfigPlaces
+	defer func() { defaultConfigPlaces = oldPlaces }()
+	defaultConfigPlaces = append([]config.Place{config.InDir("../testdata/config", "config_localhost_http_test.json", config.JSON())}, defaultConfigPlaces...)
+	srv := mock.New().UnstartedServer()
+	var err error
+	srv.Listener, err = net.Listen("tcp", ":61666")
+	assert.NoError(t, err)
+	srv.Start()
+	defer srv.Close()
+
+	assert.NoError(t, applyManifestCmd.Flags().Set("atomic", "true"))
+	defer applyManifestCmd.Flags().Set("atomic", "false")
+	assert.NoError(t, applyManifestCmd.RunE(applyManifestCmd, []string{"../testdata/thermostat_manifest.yml"}))
+	assert.Panics(t, func() { applyManifestCmd.RunE(applyManifestCmd, []string{"../testdata/atomic_manifest.yml"}) })
+}
@@ -8,20 +8,63 @@ import (
 )
 
 var exportManifestCmd = &cobra.Command{
-	Use:     "export",
-	Short:   "Export the current state of the FRITZ!Box in manifest format",
-	Long:    "Export the current state of the FRITZ!Box in manifest format and print it to stdout.",
-	Example: "fritzctl --loglevel=error manifest export > current_state.yml",
-	RunE:    export,
+	Use:   "export",
+	Short: "Export the current state of the FRITZ!Box in manifest format",
+	Long: "Export the current state of the FRITZ!Box in manifest format and print it to stdout. " +
+		"The export can be restricted to kinds of devices (--only), names matching a glob pattern (--names) and " +
+		"devices connected to the FRITZ!Box (--present-only). It is written as YAML, JSON or TOML (--output), " +
+		"all of which are accepted by 'fritzctl manifest plan/apply'.",
+	Example: `fritzctl --loglevel=error manifest export > current_state.yml
+fritzctl --loglevel=error manifest export --schedules > current_state.yml
+fritzctl --loglevel=error manifest export --ains=false > current_state.yml
+fritzctl --loglevel=error manifest export --only switches,bulbs --names 'Kitchen*' --present-only > kitchen.yml
+fritzctl --loglevel=error manifest export --output toml > current_state.toml`,
+	RunE: export,
 }
 
 func init() {
+	exportManifestCmd.Flags().Bool("schedules", false, "include the heating schedules of the thermostats")
+	exportManifestCmd.Flags().Bool("ains", true, "include the AINs of the devices, which address them regardless of their name")
+	exportManifestCmd.Flags().StringSlice("only", nil, "export only the given kinds of devices: switches, thermostats, groups, bulbs")
+	exportManifestCmd.Flags().String("names", "", "export only the devices with names matching the glob pattern, e.g. 'Kitchen*'")
+	exportManifestCmd.Flags().Bool("present-only", false, "export only the devices connected to the FRITZ!Box")
+	exportManifestCmd.Flags().StringP("output", "o", "yaml", "specify output format, one of yaml, json, toml")
 	manifestCmd.AddCommand(exportManifestCmd)
 }
 
-func export(_ *cobra.Command, _ []string) error {
-	l := mustList()
-	plan := manifest.ConvertDevicelist(l)
-	manifest.ExporterTo(os.Stdout).Export(plan)
+func export(cmd *cobra.Command, _ []string) error {
+	schedules, err := cmd.Flags().GetBool("schedules")
+	assertNoErr(err, "cannot parse schedules flag")
+	ains, err := cmd.Flags().GetBool("ains")
+	assertNoErr(err, "cannot parse ains flag")
+	filter := exportFilter(cmd)
+	format, err := manifest.ParseFormat(cmd.Flag("output").Value.String())
+	assertNoErr(err, "cannot parse output flag")
+	h := homeAutoClient()
+	l, err := h.List()
+	assertNoErr(err, "cannot obtain device data")
+	plan, err := filter.Convert(l)
+	assertNoErr(err, "cannot select the devices to export")
+	if !ains {
+		plan.WithoutAINs()
+	}
+	if schedules {
+		for i, t := range plan.Thermostats {
+			plan.Thermostats[i].Schedule, err = h.Schedule(t.Name)
+			assertNoErr(err, "cannot obtain heating schedule of '%s'", t.Name)
+		}
+	}
+	err = manifest.ExporterTo(os.Stdout).ExportAs(plan, format)
+	assertNoErr(err, "cannot export the manifest")
 	return nil
 }
+
+func exportFilter(cmd *cobra.Command) manifest.Filter {
+	only, err := cmd.Flags().GetStringSlice("only")
+	assertNoErr(err, "cannot parse only flag")
+	names, err := cmd.Flags().GetString("names")
+	assertNoErr(err, "cannot parse names flag")
+	presentOnly, err := cmd.Flags().GetBool("present-only")
+	assertNoErr(err, "cannot parse present-only flag")
+	return manifest.Filter{Kinds: only, Names: names, PresentOnly: presentOnly}
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"net"
+	"testing"
+
+	"github.com/bpicode/fritzctl/config"
+	"github.com/bpicode/fritzctl/mock"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestManifestExportFiltersAndFormats tests exporting selected devices in several formats.
+func TestManifestExportFiltersAndFormats(t *testing.T) {
+	oldPlaces := defaultConfigPlaces
+	defer func() { defaultConfigPlaces = oldPlaces }()
+	defaultConfigPlaces = append([]config.Place{config.InDir("../testdata/config", "config_localhost_http_test.json", config.JSON())}, defaultConfigPlaces...)
+	srv := mock.New().UnstartedServer()
+	var err error
+	srv.Listener, err = net.Listen("tcp", ":61666")
+	assert.NoError(t, err)
+	srv.Start()
+	defer srv.Close()
+
+	defer exportManifestCmd.Flags().Lookup("only").Value.(pflag.SliceValue).Replace(nil)
+	defer exportManifestCmd.Flags().Set("names", "")
+	defer exportManifestCmd.Flags().Set("present-only", "false")
+	defer exportManifestCmd.Flags().Set("output", "yaml")
+	assert.NoError(t, exportManifestCmd.Flags().Set("only", "switches,bulbs"))
+	assert.NoError(t, exportManifestCmd.Flags().Set("names", "SWITCH_*"))
+	assert.NoError(t, exportManifestCmd.Flags().Set("present-only", "true"))
+	for _, format := range []string{"json", "toml"} {
+		assert.NoError(t, exportManifestCmd.Flags().Set("output", format))
+		assert.NoError(t, exportManifestCmd.RunE(exportManifestCmd, nil))
+	}
+
+	assert.NoError(t, exportManifestCmd.Flags().Set("output", "xml"))
+	assert.Panics(t, func() { exportManifestCmd.RunE(exportManifestCmd, nil) })
+	assert.NoError(t, exportManifestCmd.Flags().Set("output", "yaml"))
+	assert.NoError(t, exportManifestCmd.Flags().Set("only", "plugs"))
+	assert.Panics(t, func() { exportManifestCmd.RunE(exportManifestCmd, nil) })
+}
@@ -1,28 +1,57 @@
 package cmd
 
 import (
+	"os"
+
 	"github.com/bpicode/fritzctl/manifest"
 	"github.com/spf13/cobra"
 )
 
 var planManifestCmd = &cobra.Command{
-	Use:     "plan [manifest file]",
-	Short:   "Plan a given manifest (dry-run)",
-	Long:    "Plan/dry-run a given manifest against the state of the FRITZ!Box. No changes will be applied.",
-	Example: "fritzctl manifest plan /path/to/manifest.yml",
-	RunE:    plan,
+	Use:   "plan [manifest file]",
+	Short: "Plan a given manifest (dry-run)",
+	Long: "Plan/dry-run a given manifest against the state of the FRITZ!Box. No changes will be applied. " +
+		"With --strict, the manifest is the full desired state, see 'fritzctl manifest apply --help'. " +
+		"With --out, the plan is written to a file as JSON (or YAML for .yml/.yaml): each change with device, attribute, " +
+		"the values before and after, the reason and the stage, along with the manifest and the outcomes of its rules. " +
+		"It can be applied by 'fritzctl manifest apply --plan'. " +
+		"The changes are listed by stage if the manifest declares stages or dependencies. " +
+		"For each rule of the manifest, it is shown whether its conditions held.",
+	Example: `fritzctl manifest plan /path/to/manifest.yml
+fritzctl manifest plan --strict /path/to/manifest.yml
+fritzctl manifest plan /path/to/base.yml -f /path/to/winter.yml
+fritzctl manifest plan --out=plan.json /path/to/manifest.yml`,
+	RunE: plan,
 }
 
 func init() {
+	addStrictFlag(planManifestCmd)
+	addUpdateNamesFlag(planManifestCmd)
+	addOverlayFlag(planManifestCmd)
+	planManifestCmd.Flags().String("out", "", "write the plan to a file, as JSON or, for .yml/.yaml, as YAML")
 	manifestCmd.AddCommand(planManifestCmd)
 }
 
-func plan(_ *cobra.Command, args []string) error {
+func plan(cmd *cobra.Command, args []string) error {
 	assertMinLen(args, 1, "insufficient input: path to input manifest expected")
-	target := parseManifest(args[0])
+	out, err := cmd.Flags().GetString("out")
+	assertNoErr(err, "cannot parse out flag")
+	target := parseManifest(cmd, args[0])
 	h := homeAutoClient()
-	src := obtainSourcePlan(h)
-	err := manifest.DryRunner().Apply(src, target)
+	src, _, outcomes := obtainSourcePlan(h, target)
+	cs, err := manifest.NewChangeset(src, target, outcomes, applierOptions(cmd)...)
 	assertNoErr(err, "plan (dry-run) of manifest was not successful")
+	cs.Print()
+	if out != "" {
+		writeChangeset(cs, out)
+	}
 	return nil
 }
+
+func writeChangeset(cs *manifest.Changeset, filename string) {
+	f, err := os.Create(filename)
+	assertNoErr(err, "cannot create plan file '%s'", filename)
+	defer f.Close()
+	err = cs.Write(f, filename)
+	assertNoErr(err, "cannot write plan file '%s'", filename)
+}
@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/bpicode/fritzctl/manifest"
+	"github.com/spf13/cobra"
+)
+
+var schemaManifestCmd = &cobra.Command{
+	Use:     "schema",
+	Short:   "Print the JSON Schema of manifests",
+	Long:    "Print the JSON Schema of manifests, for completion and validation in editors. It applies to YAML and JSON manifests.",
+	Example: `fritzctl manifest schema > manifest.schema.json`,
+	RunE:    schema,
+}
+
+func init() {
+	manifestCmd.AddCommand(schemaManifestCmd)
+}
+
+func schema(_ *cobra.Command, _ []string) error {
+	fmt.Print(manifest.Schema)
+	return nil
+}
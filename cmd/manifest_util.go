@@ -1,23 +1,88 @@
 package cmd
 
 import (
-	"os"
+	"fmt"
 
 	"github.com/bpicode/fritzctl/fritz"
+	"github.com/bpicode/fritzctl/internal/console"
 	"github.com/bpicode/fritzctl/manifest"
+	"github.com/spf13/cobra"
 )
 
-func parseManifest(filename string) *manifest.Plan {
-	file, err := os.Open(filename)
-	assertNoErr(err, "cannot open manifest file '%s'", filename)
-	defer file.Close()
-	p, err := manifest.Parse(file)
+func parseManifest(cmd *cobra.Command, filename string) *manifest.Plan {
+	overlays, err := cmd.Flags().GetStringArray("overlay")
+	assertNoErr(err, "cannot parse overlay flag")
+	p, err := manifest.ParseFile(filename, overlays...)
 	assertNoErr(err, "cannot parse manifest file '%s'", filename)
 	return p
 }
 
-func obtainSourcePlan(h fritz.HomeAuto) *manifest.Plan {
+func addOverlayFlag(cmd *cobra.Command) {
+	cmd.Flags().StringArrayP("overlay", "f", nil, "manifest merged over the given one, its entries replace the ones for the same device, repeatable")
+}
+
+// obtainSourcePlan reads the state of the devices and resolves the rules of the target against it. It returns the
+// source plan, the resolved target and the outcomes of the rules.
+func obtainSourcePlan(h homeAutomation, target *manifest.Plan) (*manifest.Plan, *manifest.Plan, []manifest.Outcome) {
+	l, err := h.List()
+	assertNoErr(err, "cannot obtain device data")
+	resolved, outcomes, err := target.Resolve(l)
+	assertNoErr(err, "cannot evaluate the rules of the manifest")
+	printOutcomes(outcomes)
+	return sourcePlan(h, l, resolved), resolved, outcomes
+}
+
+// obtainChangesetPlan reads the state of the devices and resolves the rules of the target of the changeset against it,
+// which fails if their outcomes differ from the planned ones. It returns the source plan and the resolved target.
+func obtainChangesetPlan(h homeAutomation, cs *manifest.Changeset) (*manifest.Plan, *manifest.Plan) {
 	l, err := h.List()
 	assertNoErr(err, "cannot obtain device data")
-	return manifest.ConvertDevicelist(l)
+	resolved, err := cs.Resolve(l)
+	assertNoErr(err, "the plan cannot be applied")
+	printOutcomes(cs.Outcomes)
+	return sourcePlan(h, l, resolved), resolved
+}
+
+func sourcePlan(h homeAutomation, l *fritz.Devicelist, target *manifest.Plan) *manifest.Plan {
+	src := manifest.ConvertDevicelist(l)
+	err := manifest.LoadSchedules(h, src, target)
+	assertNoErr(err, "cannot obtain heating schedules")
+	return src
+}
+
+func printOutcomes(outcomes []manifest.Outcome) {
+	if len(outcomes) == 0 {
+		return
+	}
+	fmt.Println("Conditions of the rules of the manifest:")
+	for _, o := range outcomes {
+		status := console.Red("NOT HELD")
+		if o.Held {
+			status = console.Green("HELD")
+		}
+		fmt.Printf("\t[%s]\t%s\n", status, o)
+	}
+}
+
+func addStrictFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("strict", false, "treat the manifest as the full desired state, devices missing in it are handled by its 'unmanaged' policy")
+}
+
+func addUpdateNamesFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("update-names", false, "rename devices addressed by AIN in the manifest to the name given there")
+}
+
+func applierOptions(cmd *cobra.Command) []manifest.Option {
+	var opts []manifest.Option
+	strict, err := cmd.Flags().GetBool("strict")
+	assertNoErr(err, "cannot parse strict flag")
+	if strict {
+		opts = append(opts, manifest.Strict())
+	}
+	updateNames, err := cmd.Flags().GetBool("update-names")
+	assertNoErr(err, "cannot parse update-names flag")
+	if updateNames {
+		opts = append(opts, manifest.UpdateNames())
+	}
+	return opts
 }
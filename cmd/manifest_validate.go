@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"github.com/bpicode/fritzctl/logger"
+	"github.com/bpicode/fritzctl/manifest"
+	"github.com/spf13/cobra"
+)
+
+var validateManifestCmd = &cobra.Command{
+	Use:   "validate [manifest file]",
+	Short: "Validate a given manifest",
+	Long: "Validate a given manifest without applying it. Unknown fields, values of the wrong type, duplicate devices " +
+		"and temperatures or settings the FRITZ!Box does not accept are reported with file and line where possible. " +
+		"With --against-live, the entries are also checked against the devices of the FRITZ!Box, e.g. that a switch " +
+		"entry does not point at a thermostat, and the conditions of the rules are evaluated. The JSON Schema of manifests is printed by 'fritzctl manifest schema'.",
+	Example: `fritzctl manifest validate /path/to/manifest.yml
+fritzctl manifest validate --against-live /path/to/base.yml -f /path/to/winter.yml`,
+	RunE: validate,
+}
+
+func init() {
+	addOverlayFlag(validateManifestCmd)
+	validateManifestCmd.Flags().Bool("against-live", false, "check the entries against the devices of the FRITZ!Box")
+	manifestCmd.AddCommand(validateManifestCmd)
+}
+
+func validate(cmd *cobra.Command, args []string) error {
+	assertMinLen(args, 1, "insufficient input: path to input manifest expected")
+	live, err := cmd.Flags().GetBool("against-live")
+	assertNoErr(err, "cannot parse against-live flag")
+	target := parseManifest(cmd, args[0])
+	if live {
+		l, err := homeAutoClient().List()
+		assertNoErr(err, "cannot obtain device data")
+		err = manifest.Validate(target, manifest.ConvertDevicelist(l))
+		assertNoErr(err, "manifest does not match the devices of the FRITZ!Box")
+		_, _, err = target.Resolve(l)
+		assertNoErr(err, "the conditions of the rules do not match the devices of the FRITZ!Box")
+	}
+	logger.Success("Manifest is valid")
+	return nil
+}
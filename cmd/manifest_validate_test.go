@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"net"
+	"testing"
+
+	"github.com/bpicode/fritzctl/config"
+	"github.com/bpicode/fritzctl/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestManifestValidate tests the validation of manifests, with and without the live state.
+func TestManifestValidate(t *testing.T) {
+	oldPlaces := defaultConfigPlaces
+	defer func() { defaultConfigPlaces = oldPlaces }()
+	defaultConfigPlaces = append([]config.Place{config.InDir("../testdata/config", "config_localhost_http_test.json", config.JSON())}, defaultConfigPlaces...)
+	srv := mock.New().UnstartedServer()
+	var err error
+	srv.Listener, err = net.Listen("tcp", ":61666")
+	assert.NoError(t, err)
+	srv.Start()
+	defer srv.Close()
+
+	assert.NoError(t, validateManifestCmd.RunE(validateManifestCmd, []string{"../testdata/invalid/live_manifest.yml"}))
+	assert.Panics(t, func() {
+		validateManifestCmd.RunE(validateManifestCmd, []string{"../testdata/invalid/typo_manifest.yml"})
+	})
+	assert.Panics(t, func() { validateManifestCmd.RunE(validateManifestCmd, nil) })
+
+	assert.NoError(t, validateManifestCmd.Flags().Set("against-live", "true"))
+	defer validateManifestCmd.Flags().Set("against-live", "false")
+	assert.NoError(t, validateManifestCmd.RunE(validateManifestCmd, []string{"../testdata/thermostat_manifest.yml"}))
+	assert.NoError(t, validateManifestCmd.RunE(validateManifestCmd, []string{"../testdata/rules_manifest.yml"}))
+	assert.Panics(t, func() {
+		validateManifestCmd.RunE(validateManifestCmd, []string{"../testdata/invalid/live_manifest.yml"})
+	})
+	assert.Panics(t, func() {
+		validateManifestCmd.RunE(validateManifestCmd, []string{"../testdata/invalid/rules_manifest.yml"})
+	})
+}
+
+// TestManifestSchema tests printing the JSON Schema.
+func TestManifestSchema(t *testing.T) {
+	assert.NoError(t, schemaManifestCmd.RunE(schemaManifestCmd, nil))
+}
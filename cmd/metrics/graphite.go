@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Graphite renders samples in the Graphite plaintext protocol, using tagged series. Every sample becomes one line
+// with the path named after the prefixed metric and the labels as tags.
+type Graphite struct {
+	Prefix  string    // Prefix of the paths, separated by a dot. May be empty.
+	Time    time.Time // Timestamp of the samples.
+	Samples []Sample  // Samples to be written.
+}
+
+// WriteTo writes the samples in the plaintext protocol to w. Non-finite values and empty tag values are left out.
+// Characters that are not allowed in tags are replaced by underscores.
+func (f Graphite) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	ts := strconv.FormatInt(f.Time.Unix(), 10)
+	for _, s := range f.Samples {
+		if math.IsNaN(s.Value) || math.IsInf(s.Value, 0) {
+			continue
+		}
+		bw.WriteString(graphiteSanitizer.Replace(prefixed(f.Prefix, ".", s.Name)))
+		for _, l := range s.Labels {
+			if l.Value == "" {
+				continue
+			}
+			bw.WriteString(";" + graphiteSanitizer.Replace(l.Name) + "=" + graphiteSanitizer.Replace(l.Value))
+		}
+		bw.WriteString(" " + strconv.FormatFloat(s.Value, 'g', -1, 64) + " " + ts + "\n")
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+var graphiteSanitizer = strings.NewReplacer(" ", "_", "\t", "_", "\n", "_", ";", "_", "=", "_", "~", "_", "!", "_", "^", "_")
@@ -0,0 +1,21 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGraphite tests the plaintext protocol output.
+func TestGraphite(t *testing.T) {
+	buf := new(bytes.Buffer)
+	n, err := Graphite{Prefix: "fritz", Time: time.Unix(1545160121, 0), Samples: []Sample{
+		{Name: "power_watts", Labels: []Label{{Name: "name", Value: "Living room;TV"}, {Name: "product", Value: ""}}, Value: 12.5},
+		{Name: "up", Value: 1},
+	}}.WriteTo(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(buf.Len()), n)
+	assert.Equal(t, "fritz.power_watts;name=Living_room_TV 12.5 1545160121\nfritz.up 1 1545160121\n", buf.String())
+}
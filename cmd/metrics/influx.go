@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Influx renders samples in the InfluxDB line protocol. Every sample becomes one line with the measurement named
+// after the prefixed metric, the labels as tags and a single field "value".
+type Influx struct {
+	Prefix  string    // Prefix of the measurement names, separated by an underscore. May be empty.
+	Time    time.Time // Timestamp of the samples.
+	Samples []Sample  // Samples to be written.
+}
+
+// WriteTo writes the samples in the line protocol to w. Non-finite values and empty tag values are left out, as the
+// line protocol does not support them.
+func (f Influx) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	ts := strconv.FormatInt(f.Time.UnixNano(), 10)
+	for _, s := range f.Samples {
+		if math.IsNaN(s.Value) || math.IsInf(s.Value, 0) {
+			continue
+		}
+		bw.WriteString(influxMeasurementEscaper.Replace(prefixed(f.Prefix, "_", s.Name)))
+		for _, l := range s.Labels {
+			if l.Value == "" {
+				continue
+			}
+			bw.WriteString("," + influxTagEscaper.Replace(l.Name) + "=" + influxTagEscaper.Replace(l.Value))
+		}
+		bw.WriteString(" value=" + strconv.FormatFloat(s.Value, 'g', -1, 64) + " " + ts + "\n")
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+var influxMeasurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+
+var influxTagEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+
+func prefixed(prefix, separator, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + separator + name
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
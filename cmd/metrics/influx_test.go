@@ -0,0 +1,25 @@
+package metrics
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestInflux tests the line protocol output.
+func TestInflux(t *testing.T) {
+	buf := new(bytes.Buffer)
+	n, err := Influx{Prefix: "fritz", Time: time.Unix(1545160121, 0), Samples: []Sample{
+		{Name: "power_watts", Labels: []Label{{Name: "name", Value: "Living room, TV"}, {Name: "product", Value: ""}, {Name: "ain", Value: "a=b"}}, Value: 12.5},
+		{Name: "nan", Value: math.NaN()},
+		{Name: "up", Value: 1},
+	}}.WriteTo(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(buf.Len()), n)
+	assert.Equal(t, `fritz_power_watts,name=Living\ room\,\ TV,ain=a\=b value=12.5 1545160121000000000
+fritz_up value=1 1545160121000000000
+`, buf.String())
+}
@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// WritePrometheus renders the samples in the Prometheus text exposition format. Metric names are prefixed with
+// namespace and an underscore, unless namespace is empty. Samples of the same metric are expected to be adjacent.
+func WritePrometheus(w io.Writer, namespace string, samples []Sample) error {
+	bw := bufio.NewWriter(w)
+	var last string
+	for _, s := range samples {
+		name := s.Name
+		if namespace != "" {
+			name = namespace + "_" + name
+		}
+		if name != last {
+			bw.WriteString("# HELP " + name + " " + escapeHelp(s.Help) + "\n")
+			bw.WriteString("# TYPE " + name + " " + string(s.Type) + "\n")
+			last = name
+		}
+		bw.WriteString(name)
+		writePrometheusLabels(bw, s.Labels)
+		bw.WriteString(" " + formatPrometheusValue(s.Value) + "\n")
+	}
+	return bw.Flush()
+}
+
+func writePrometheusLabels(w *bufio.Writer, labels []Label) {
+	if len(labels) == 0 {
+		return
+	}
+	w.WriteByte('{')
+	for i, l := range labels {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		w.WriteString(l.Name + `="` + escapeLabelValue(l.Value) + `"`)
+	}
+	w.WriteByte('}')
+}
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escapeLabelValue(s string) string {
+	return labelValueEscaper.Replace(s)
+}
+
+func formatPrometheusValue(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
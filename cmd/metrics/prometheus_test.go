@@ -0,0 +1,28 @@
+package metrics
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWritePrometheus tests the text exposition format.
+func TestWritePrometheus(t *testing.T) {
+	buf := new(bytes.Buffer)
+	err := WritePrometheus(buf, "ns", []Sample{
+		{Name: "a", Help: "Help with \\ and\nnewline.", Type: Gauge, Labels: []Label{{Name: "name", Value: `say "hi"`}, {Name: "x", Value: "y"}}, Value: 1.5},
+		{Name: "a", Help: "Help with \\ and\nnewline.", Type: Gauge, Labels: []Label{{Name: "name", Value: "other"}}, Value: math.NaN()},
+		{Name: "b_total", Help: "Counter.", Type: Counter, Value: 3},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, `# HELP ns_a Help with \\ and\nnewline.
+# TYPE ns_a gauge
+ns_a{name="say \"hi\"",x="y"} 1.5
+ns_a{name="other"} NaN
+# HELP ns_b_total Counter.
+# TYPE ns_b_total counter
+ns_b_total 3
+`, buf.String())
+}
@@ -0,0 +1,130 @@
+// Package metrics converts FRITZ!Box data to samples and renders them in formats understood by monitoring systems.
+package metrics
+
+import (
+	"strconv"
+
+	"github.com/bpicode/fritzctl/fritz"
+)
+
+// Type is the kind of a metric, in the sense of the Prometheus exposition format.
+type Type string
+
+// Known metric types.
+const (
+	Gauge   Type = "gauge"
+	Counter Type = "counter"
+)
+
+// Label is a name-value pair qualifying a sample.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Sample is a single measured value.
+type Sample struct {
+	Name   string  // Name of the metric, without any prefix or namespace.
+	Help   string  // Short description of the metric.
+	Type   Type    // Kind of the metric.
+	Labels []Label // Qualifiers, in order.
+	Value  float64 // Measured value.
+}
+
+type deviceMetric struct {
+	name    string
+	help    string
+	applies func(d *fritz.Device) bool
+	value   func(d *fritz.Device) string
+}
+
+var deviceMetrics = []deviceMetric{
+	{name: "device_present", help: "Whether the device is connected (1) or not (0).", applies: always, value: func(d *fritz.Device) string { return strconv.Itoa(d.Present) }},
+	{name: "switch_state", help: "Whether the switch is on (1) or off (0).", applies: (*fritz.Device).IsSwitch, value: func(d *fritz.Device) string { return d.Switch.State }},
+	{name: "power_watts", help: "Current electric power.", applies: (*fritz.Device).CanMeasurePower, value: func(d *fritz.Device) string { return d.Powermeter.FmtPowerW() }},
+	{name: "energy_watt_hours", help: "Energy consumed since the device started operating.", applies: (*fritz.Device).CanMeasurePower, value: func(d *fritz.Device) string { return d.Powermeter.FmtEnergyWh() }},
+	{name: "temperature_celsius", help: "Temperature measured at the device, offset included.", applies: (*fritz.Device).CanMeasureTemp, value: func(d *fritz.Device) string { return d.Temperature.FmtCelsius() }},
+	{name: "thermostat_measured_celsius", help: "Temperature measured by the thermostat.", applies: (*fritz.Device).IsThermostat, value: func(d *fritz.Device) string { return d.Thermostat.FmtMeasuredTemperature() }},
+	{name: "thermostat_goal_celsius", help: "Desired temperature of the thermostat.", applies: (*fritz.Device).IsThermostat, value: func(d *fritz.Device) string { return d.Thermostat.FmtGoalTemperature() }},
+	{name: "battery_level_percent", help: "Battery charge level.", applies: always, value: func(d *fritz.Device) string { return d.Thermostat.BatteryChargeLevel }},
+	{name: "battery_low", help: "Whether the battery is running low on capacity (1) or not (0).", applies: always, value: func(d *fritz.Device) string { return d.Thermostat.BatteryLow }},
+	{name: "alert_state", help: "Whether the alert sensor reports an alert (1) or not (0).", applies: (*fritz.Device).HasAlertSensor, value: func(d *fritz.Device) string { return d.AlertSensor.State }},
+}
+
+func always(*fritz.Device) bool {
+	return true
+}
+
+// FromDevices extracts the measurements of the given devices. Values that are not known or not numerical, like the
+// "ON"/"OFF" goal temperature of a thermostat, are left out.
+func FromDevices(ds []fritz.Device) []Sample {
+	var samples []Sample
+	for _, m := range deviceMetrics {
+		for i := range ds {
+			d := &ds[i]
+			if !m.applies(d) {
+				continue
+			}
+			v, err := strconv.ParseFloat(m.value(d), 64)
+			if err != nil {
+				continue
+			}
+			samples = append(samples, Sample{Name: m.name, Help: m.help, Type: Gauge, Labels: DeviceLabels(*d), Value: v})
+		}
+	}
+	return samples
+}
+
+// DeviceLabels are the labels identifying a device: its name, AIN, manufacturer and product.
+func DeviceLabels(d fritz.Device) []Label {
+	return []Label{
+		{Name: "name", Value: d.Name},
+		{Name: "ain", Value: d.Identifier},
+		{Name: "manufacturer", Value: d.Manufacturer},
+		{Name: "product", Value: d.Productname},
+	}
+}
+
+// FromTraffic extracts the most recent traffic rates, i.e. the first element of every time series.
+func FromTraffic(data *fritz.TrafficMonitoringData) []Sample {
+	series := []struct {
+		direction string
+		channel   string
+		values    []float64
+	}{
+		{direction: "downstream", channel: "internet", values: data.DownstreamInternet},
+		{direction: "downstream", channel: "media", values: data.DownStreamMedia},
+		{direction: "downstream", channel: "guest", values: data.DownStreamGuest},
+		{direction: "upstream", channel: "realtime", values: data.UpstreamRealtime},
+		{direction: "upstream", channel: "high_priority", values: data.UpstreamHighPriority},
+		{direction: "upstream", channel: "default_priority", values: data.UpstreamDefaultPriority},
+		{direction: "upstream", channel: "low_priority", values: data.UpstreamLowPriority},
+		{direction: "upstream", channel: "guest", values: data.UpstreamGuest},
+	}
+	var samples []Sample
+	for _, s := range series {
+		if len(s.values) == 0 {
+			continue
+		}
+		samples = append(samples, Sample{
+			Name:   "traffic_bits_per_second",
+			Help:   "Current internet traffic rate.",
+			Type:   Gauge,
+			Labels: []Label{{Name: "direction", Value: s.direction}, {Name: "channel", Value: s.channel}},
+			Value:  s.values[0],
+		})
+	}
+	return samples
+}
+
+// FromBoxData extracts the runtime of the FRITZ!Box. The runtime in hours is approximated, assuming months of 30 and
+// years of 365 days.
+func FromBoxData(data *fritz.BoxData) []Sample {
+	r := data.Runtime
+	labels := []Label{{Name: "model", Value: data.Model.Name}, {Name: "firmware", Value: data.FirmwareVersion.String()}}
+	hours := r.Hours + 24*(r.Days+30*r.Months+365*r.Years)
+	return []Sample{
+		{Name: "box_runtime_hours", Help: "Approximate time the FRITZ!Box has been running.", Type: Gauge, Labels: labels, Value: float64(hours)},
+		{Name: "box_reboots", Help: "Number of reboots of the FRITZ!Box.", Type: Gauge, Labels: labels, Value: float64(r.Reboots)},
+	}
+}
@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/bpicode/fritzctl/fritz"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFromDevices tests the extraction of device measurements.
+func TestFromDevices(t *testing.T) {
+	ds := []fritz.Device{
+		{Identifier: "1", Name: "SWITCH", Present: 1, Functionbitmask: "896", Switch: fritz.Switch{State: "1"}, Powermeter: fritz.Powermeter{Power: "12500", Energy: "3000"}, Temperature: fritz.Temperature{Celsius: "215"}},
+		{Identifier: "2", Name: "HKR", Present: 0, Functionbitmask: "320", Thermostat: fritz.Thermostat{Measured: "40", Goal: "253", BatteryChargeLevel: "80", BatteryLow: "0"}},
+	}
+	values := make(map[string]float64)
+	for _, s := range FromDevices(ds) {
+		values[s.Name+"/"+s.Labels[0].Value] = s.Value
+		assert.Equal(t, Gauge, s.Type)
+	}
+	assert.Equal(t, map[string]float64{
+		"device_present/SWITCH":           1,
+		"device_present/HKR":              0,
+		"switch_state/SWITCH":             1,
+		"power_watts/SWITCH":              12.5,
+		"energy_watt_hours/SWITCH":        3000,
+		"temperature_celsius/SWITCH":      21.5,
+		"thermostat_measured_celsius/HKR": 20,
+		"battery_level_percent/HKR":       80,
+		"battery_low/HKR":                 0,
+	}, values)
+}
+
+// TestFromTraffic tests that the most recent rates are taken.
+func TestFromTraffic(t *testing.T) {
+	samples := FromTraffic(&fritz.TrafficMonitoringData{DownstreamInternet: []float64{100, 200}, UpstreamGuest: []float64{3}})
+	assert.Len(t, samples, 2)
+	assert.Equal(t, 100.0, samples[0].Value)
+	assert.Equal(t, []Label{{Name: "direction", Value: "downstream"}, {Name: "channel", Value: "internet"}}, samples[0].Labels)
+	assert.Equal(t, 3.0, samples[1].Value)
+}
+
+// TestFromBoxData tests the runtime approximation.
+func TestFromBoxData(t *testing.T) {
+	samples := FromBoxData(&fritz.BoxData{Runtime: fritz.Runtime{Hours: 1, Days: 2, Months: 1, Years: 1, Reboots: 7}})
+	assert.Len(t, samples, 2)
+	assert.Equal(t, float64(1+24*(2+30+365)), samples[0].Value)
+	assert.Equal(t, 7.0, samples[1].Value)
+}
@@ -0,0 +1,158 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bpicode/fritzctl/cmd/jsonapi"
+	"github.com/bpicode/fritzctl/fritz"
+)
+
+// Status tells whether a condition started or stopped to hold.
+type Status string
+
+// Known statuses.
+const (
+	Firing   Status = "firing"   // The condition holds.
+	Resolved Status = "resolved" // The condition no longer holds.
+)
+
+// Notification describes a condition of a device.
+type Notification struct {
+	Rule      string         `json:"rule"`      // Name of the rule raising the notification.
+	Condition string         `json:"condition"` // The condition of the rule.
+	Status    Status         `json:"status"`    // Whether the condition holds.
+	Message   string         `json:"message"`   // Human-readable summary.
+	Since     time.Time      `json:"since"`     // When the condition was first observed.
+	Time      time.Time      `json:"time"`      // When the notification was raised.
+	Device    jsonapi.Device `json:"device"`    // The device, as in the JSON output of the list commands.
+	Sinks     []string       `json:"-"`         // Names of the sinks of the rule.
+}
+
+// condition checks a device. If the condition holds, it returns a description of it.
+type condition func(d *fritz.Device) (string, bool)
+
+var conditions = map[string]condition{
+	"alert": func(d *fritz.Device) (string, bool) {
+		return "alert", d.HasAlertSensor() && d.AlertSensor.State == "1"
+	},
+	"battery-low": func(d *fritz.Device) (string, bool) {
+		return "battery low", d.Thermostat.BatteryLow == "1"
+	},
+	"error": func(d *fritz.Device) (string, bool) {
+		code := d.Thermostat.ErrorCode
+		if desc := fritz.HkrErrorDescriptions[code]; desc != "" {
+			return "error: " + strings.TrimSpace(desc), true
+		}
+		return "error " + code, code != "" && code != "0"
+	},
+	"absent": func(d *fritz.Device) (string, bool) {
+		return "absent", d.Present == 0
+	},
+}
+
+// Notifier evaluates the rules against the polled devices and decides when to notify.
+type Notifier struct {
+	rules     []Rule
+	incidents map[string]*incident
+	known     map[string]fritz.Device
+	mapper    jsonapi.Mapper
+}
+
+// incident is a condition that holds for a device.
+type incident struct {
+	since    time.Time
+	notified time.Time
+}
+
+// NewNotifier creates a Notifier for validated rules.
+func NewNotifier(rules []Rule) *Notifier {
+	return &Notifier{
+		rules:     rules,
+		incidents: make(map[string]*incident),
+		known:     make(map[string]fritz.Device),
+		mapper:    jsonapi.NewMapper(),
+	}
+}
+
+// Evaluate checks the rules against the devices and returns the notifications due. Devices that vanished from the
+// list since an earlier evaluation are considered absent.
+func (n *Notifier) Evaluate(l *fritz.Devicelist, now time.Time) []Notification {
+	seen := make(map[string]bool, len(l.Devices))
+	ds := make([]fritz.Device, 0, len(l.Devices))
+	for _, d := range l.Devices {
+		key := d.Identifier + "#" + d.ID
+		seen[key] = true
+		n.known[key] = d
+		ds = append(ds, d)
+	}
+	for key, d := range n.known {
+		if !seen[key] {
+			d.Present = 0
+			ds = append(ds, d)
+		}
+	}
+	var ns []Notification
+	for _, r := range n.rules {
+		for i := range ds {
+			if !r.applies(&ds[i]) {
+				continue
+			}
+			if note, ok := n.check(r, &ds[i], now); ok {
+				ns = append(ns, note)
+			}
+		}
+	}
+	return ns
+}
+
+func (r Rule) applies(d *fritz.Device) bool {
+	if len(r.Devices) == 0 {
+		return true
+	}
+	for _, name := range r.Devices {
+		if name == d.Name {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *Notifier) check(r Rule, d *fritz.Device, now time.Time) (Notification, bool) {
+	key := r.Name + "/" + d.Identifier + "#" + d.ID
+	desc, holds := conditions[r.Condition](d)
+	inc, ok := n.incidents[key]
+	if !holds {
+		delete(n.incidents, key)
+		if !ok || inc.notified.IsZero() || !r.Resolved {
+			return Notification{}, false
+		}
+		return n.notification(r, d, Resolved, fmt.Sprintf("%s: %s resolved", d.Name, r.Condition), inc.since, now), true
+	}
+	if !ok {
+		inc = &incident{since: now}
+		n.incidents[key] = inc
+	}
+	if now.Sub(inc.since) < r.Debounce {
+		return Notification{}, false
+	}
+	if !inc.notified.IsZero() && (r.Renotify <= 0 || now.Sub(inc.notified) < r.Renotify) {
+		return Notification{}, false
+	}
+	inc.notified = now
+	return n.notification(r, d, Firing, fmt.Sprintf("%s: %s", d.Name, desc), inc.since, now), true
+}
+
+func (n *Notifier) notification(r Rule, d *fritz.Device, s Status, msg string, since, now time.Time) Notification {
+	return Notification{
+		Rule:      r.Name,
+		Condition: r.Condition,
+		Status:    s,
+		Message:   msg,
+		Since:     since,
+		Time:      now,
+		Device:    n.mapper.Convert([]fritz.Device{*d}).Devices[0],
+		Sinks:     r.Sinks,
+	}
+}
@@ -0,0 +1,73 @@
+package notify
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bpicode/fritzctl/fritz"
+	"github.com/stretchr/testify/assert"
+)
+
+func alarm(state string) *fritz.Devicelist {
+	return &fritz.Devicelist{Devices: []fritz.Device{
+		{Identifier: "1", Name: "SEC_1", Present: 1, Functionbitmask: "16", AlertSensor: fritz.AlertSensor{State: state}},
+		{Identifier: "2", Name: "SEC_2", Present: 1, Functionbitmask: "16", AlertSensor: fritz.AlertSensor{State: "1"}},
+	}}
+}
+
+func messages(ns []Notification) []string {
+	var ms []string
+	for _, n := range ns {
+		ms = append(ms, string(n.Status)+" "+n.Message)
+	}
+	return ms
+}
+
+// TestNotifierDebounceRenotifyResolve walks an alert through its life cycle.
+func TestNotifierDebounceRenotifyResolve(t *testing.T) {
+	t0 := time.Unix(1545160121, 0)
+	n := NewNotifier([]Rule{{Name: "alarm", Condition: "alert", Devices: []string{"SEC_1"}, Debounce: time.Minute, Renotify: time.Hour, Resolved: true}})
+
+	assert.Empty(t, n.Evaluate(alarm("1"), t0), "debounced")
+	assert.Empty(t, n.Evaluate(alarm("0"), t0.Add(30*time.Second)), "flapped back")
+	assert.Empty(t, n.Evaluate(alarm("1"), t0.Add(40*time.Second)))
+	ns := n.Evaluate(alarm("1"), t0.Add(100*time.Second))
+	assert.Equal(t, []string{"firing SEC_1: alert"}, messages(ns))
+	assert.Equal(t, t0.Add(40*time.Second), ns[0].Since)
+	assert.Equal(t, "SEC_1", ns[0].Device.Name)
+	assert.Equal(t, "ON", ns[0].Device.Measurements.AlertSignal)
+
+	assert.Empty(t, n.Evaluate(alarm("1"), t0.Add(30*time.Minute)))
+	assert.Equal(t, []string{"firing SEC_1: alert"}, messages(n.Evaluate(alarm("1"), t0.Add(100*time.Second+time.Hour))), "renotified")
+	assert.Equal(t, []string{"resolved SEC_1: alert resolved"}, messages(n.Evaluate(alarm("0"), t0.Add(2*time.Hour))))
+	assert.Empty(t, n.Evaluate(alarm("0"), t0.Add(3*time.Hour)))
+}
+
+// TestNotifierConditions tests the supported conditions.
+func TestNotifierConditions(t *testing.T) {
+	t0 := time.Unix(1545160121, 0)
+	n := NewNotifier([]Rule{
+		{Name: "battery", Condition: "battery-low"},
+		{Name: "error", Condition: "error"},
+		{Name: "absent", Condition: "absent"},
+	})
+	l := &fritz.Devicelist{Devices: []fritz.Device{
+		{Identifier: "1", Name: "HKR_1", Present: 1, Functionbitmask: "320", Thermostat: fritz.Thermostat{BatteryLow: "1", ErrorCode: "3"}},
+		{Identifier: "2", Name: "HKR_2", Present: 1, Functionbitmask: "320", Thermostat: fritz.Thermostat{BatteryLow: "0", ErrorCode: "0"}},
+		{Identifier: "3", Name: "SWITCH_1", Present: 0},
+	}}
+	assert.Equal(t, []string{
+		"firing HKR_1: battery low",
+		"firing HKR_1: error: Valve plunger cannot be moved. Is it blocked?",
+		"firing SWITCH_1: absent",
+	}, messages(n.Evaluate(l, t0)))
+	assert.Empty(t, n.Evaluate(l, t0.Add(time.Hour)), "no renotify configured")
+}
+
+// TestNotifierVanishedDevice tests that devices disappearing from the list are considered absent.
+func TestNotifierVanishedDevice(t *testing.T) {
+	t0 := time.Unix(1545160121, 0)
+	n := NewNotifier([]Rule{{Name: "absent", Condition: "absent"}})
+	assert.Empty(t, n.Evaluate(&fritz.Devicelist{Devices: []fritz.Device{{Identifier: "1", Name: "SWITCH_1", Present: 1}}}, t0))
+	assert.Equal(t, []string{"firing SWITCH_1: absent"}, messages(n.Evaluate(&fritz.Devicelist{}, t0.Add(time.Minute))))
+}
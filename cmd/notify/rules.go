@@ -0,0 +1,151 @@
+// Package notify raises notifications for device conditions worth being paged for and delivers them to sinks.
+package notify
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// DefaultTimeout limits the delivery to a sink, unless configured otherwise.
+const DefaultTimeout = 30 * time.Second
+
+// Config is the content of a notification rules file.
+type Config struct {
+	Sinks []Sink // Where notifications are delivered to.
+	Rules []Rule // When notifications are raised.
+}
+
+// Rule raises a notification when a condition holds for a device.
+// codebeat:disable[TOO_MANY_IVARS]
+type Rule struct {
+	Name      string        // Unique name of the rule.
+	Condition string        // One of "alert", "battery-low", "error" or "absent".
+	Devices   []string      // Names of the devices, all devices if empty.
+	Debounce  time.Duration // The condition has to hold this long before a notification is raised.
+	Renotify  time.Duration // Repeat the notification in this interval while the condition holds, never if zero.
+	Resolved  bool          // Also notify when the condition no longer holds.
+	Sinks     []string      // Names of the sinks.
+}
+
+// Sink delivers notifications.
+type Sink struct {
+	Name     string        // Unique name of the sink.
+	Type     string        // One of "webhook", "shell" or "smtp".
+	URL      string        // URL of the webhook.
+	Method   string        // HTTP method of the webhook, defaults to POST.
+	Command  string        // Shell command.
+	Relay    string        // SMTP relay as host:port.
+	From     string        // Sender address of mails.
+	To       []string      // Recipient addresses of mails.
+	Username string        // Username for the SMTP relay, no authentication if empty.
+	Password string        // Password for the SMTP relay.
+	Timeout  time.Duration // Limit for the delivery, defaults to 30s.
+}
+
+// codebeat:enable[TOO_MANY_IVARS]
+
+// ParseFile reads the configuration from a YAML file.
+func ParseFile(filename string) (*Config, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return Parse(file)
+}
+
+// Parse reads the configuration from YAML, validates it and applies the defaults.
+func Parse(r io.Reader) (*Config, error) {
+	bytes, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var c Config
+	if err := yaml.Unmarshal(bytes, &c); err != nil {
+		return nil, err
+	}
+	for i := range c.Sinks {
+		s := &c.Sinks[i]
+		if s.Timeout == 0 {
+			s.Timeout = DefaultTimeout
+		}
+		if s.Type == "webhook" && s.Method == "" {
+			s.Method = "POST"
+		}
+	}
+	return &c, c.validate()
+}
+
+func (c *Config) validate() error {
+	sinks := make(map[string]bool)
+	for i, s := range c.Sinks {
+		if s.Name == "" {
+			return fmt.Errorf("sink #%d has no name", i+1)
+		}
+		if sinks[s.Name] {
+			return fmt.Errorf("sink name '%s' is not unique", s.Name)
+		}
+		sinks[s.Name] = true
+		if err := s.validate(); err != nil {
+			return fmt.Errorf("sink '%s': %v", s.Name, err)
+		}
+	}
+	rules := make(map[string]bool)
+	for i, r := range c.Rules {
+		if r.Name == "" {
+			return fmt.Errorf("rule #%d has no name", i+1)
+		}
+		if rules[r.Name] {
+			return fmt.Errorf("rule name '%s' is not unique", r.Name)
+		}
+		rules[r.Name] = true
+		if _, ok := conditions[r.Condition]; !ok {
+			return fmt.Errorf("rule '%s': unknown condition '%s', expected one of alert, battery-low, error, absent", r.Name, r.Condition)
+		}
+		if len(r.Sinks) == 0 {
+			return fmt.Errorf("rule '%s' has no sinks", r.Name)
+		}
+		for _, s := range r.Sinks {
+			if !sinks[s] {
+				return fmt.Errorf("rule '%s': unknown sink '%s'", r.Name, s)
+			}
+		}
+	}
+	return nil
+}
+
+func (s Sink) validate() error {
+	switch s.Type {
+	case "webhook":
+		if !strings.HasPrefix(s.URL, "http://") && !strings.HasPrefix(s.URL, "https://") {
+			return fmt.Errorf("webhook url '%s' is not an http(s) URL", s.URL)
+		}
+	case "shell":
+		if s.Command == "" {
+			return fmt.Errorf("no command given")
+		}
+	case "smtp":
+		if s.Relay == "" || s.From == "" || len(s.To) == 0 {
+			return fmt.Errorf("relay, from and to are required")
+		}
+	default:
+		return fmt.Errorf("unknown type '%s', expected one of webhook, shell, smtp", s.Type)
+	}
+	return nil
+}
+
+// Sink returns the sink with the given name, or nil if there is none.
+func (c *Config) Sink(name string) *Sink {
+	for i := range c.Sinks {
+		if c.Sinks[i].Name == name {
+			return &c.Sinks[i]
+		}
+	}
+	return nil
+}
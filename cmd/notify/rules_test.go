@@ -0,0 +1,73 @@
+package notify
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const exampleConfig = `
+sinks:
+  - name: pager
+    type: webhook
+    url: http://localhost/hook
+  - name: mail
+    type: smtp
+    relay: localhost:25
+    from: a@example.com
+    to: [b@example.com]
+    timeout: 5s
+rules:
+  - name: alarm
+    condition: alert
+    devices: [SEC_1]
+    debounce: 1m
+    renotify: 1h
+    resolved: true
+    sinks: [pager, mail]
+`
+
+// TestParse tests the parsing of a rules file and the defaults.
+func TestParse(t *testing.T) {
+	c, err := Parse(strings.NewReader(exampleConfig))
+	assert.NoError(t, err)
+	assert.Len(t, c.Sinks, 2)
+	assert.Equal(t, "POST", c.Sinks[0].Method)
+	assert.Equal(t, DefaultTimeout, c.Sinks[0].Timeout)
+	assert.Equal(t, 5*time.Second, c.Sinks[1].Timeout)
+	assert.Equal(t, Rule{Name: "alarm", Condition: "alert", Devices: []string{"SEC_1"}, Debounce: time.Minute, Renotify: time.Hour, Resolved: true, Sinks: []string{"pager", "mail"}}, c.Rules[0])
+	assert.Equal(t, "smtp", c.Sink("mail").Type)
+	assert.Nil(t, c.Sink("nope"))
+}
+
+// TestParseInvalid tests the validation of sinks and rules.
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+	}{
+		{name: "sink without name", config: `sinks: [{type: shell, command: x}]`},
+		{name: "duplicate sink", config: `sinks: [{name: a, type: shell, command: x}, {name: a, type: shell, command: x}]`},
+		{name: "bad sink type", config: `sinks: [{name: a, type: pigeon}]`},
+		{name: "bad url", config: `sinks: [{name: a, type: webhook, url: "ftp://x"}]`},
+		{name: "no command", config: `sinks: [{name: a, type: shell}]`},
+		{name: "incomplete smtp", config: `sinks: [{name: a, type: smtp, relay: "x:25"}]`},
+		{name: "rule without name", config: `sinks: [{name: a, type: shell, command: x}]
+rules: [{condition: alert, sinks: [a]}]`},
+		{name: "duplicate rule", config: `sinks: [{name: a, type: shell, command: x}]
+rules: [{name: r, condition: alert, sinks: [a]}, {name: r, condition: alert, sinks: [a]}]`},
+		{name: "bad condition", config: `sinks: [{name: a, type: shell, command: x}]
+rules: [{name: r, condition: fire, sinks: [a]}]`},
+		{name: "no sinks", config: `rules: [{name: r, condition: alert}]`},
+		{name: "unknown sink", config: `rules: [{name: r, condition: alert, sinks: [b]}]`},
+		{name: "no yaml", config: `rules: [`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tc.config))
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/bpicode/fritzctl/internal/errors"
+	"github.com/bpicode/fritzctl/internal/shell"
+)
+
+// Send delivers the notification. Webhooks receive it as JSON body, shell commands on stdin and in environment
+// variables, mails in a readable form.
+func (s *Sink) Send(n Notification) error {
+	switch s.Type {
+	case "webhook":
+		return s.webhook(n)
+	case "shell":
+		return s.shell(n)
+	case "smtp":
+		return s.mail(n)
+	}
+	return fmt.Errorf("unknown sink type '%s'", s.Type)
+}
+
+func (s *Sink) webhook(n Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), s.Timeout)
+	defer cancel()
+	req, err := http.NewRequest(s.Method, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook responded with status %s", resp.Status)
+	}
+	return nil
+}
+
+func (s *Sink) shell(n Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), s.Timeout)
+	defer cancel()
+	cmd := shell.Command(ctx, s.Command,
+		"FRITZCTL_RULE="+n.Rule,
+		"FRITZCTL_CONDITION="+n.Condition,
+		"FRITZCTL_STATUS="+string(n.Status),
+		"FRITZCTL_MESSAGE="+n.Message,
+		"FRITZCTL_DEVICE="+n.Device.Name,
+		"FRITZCTL_AIN="+n.Device.ID)
+	cmd.Stdin = bytes.NewReader(body)
+	out, err := cmd.CombinedOutput()
+	if out = bytes.TrimSpace(out); len(out) > 0 {
+		return errors.Wrapf(err, "command failed with output '%s'", out)
+	}
+	return errors.Wrapf(err, "command failed")
+}
+
+// mail sends the notification via the SMTP relay. Like smtp.SendMail, it switches to TLS if the relay supports
+// STARTTLS, but the whole conversation is limited by the timeout of the sink.
+func (s *Sink) mail(n Notification) error {
+	conn, err := net.DialTimeout("tcp", s.Relay, s.Timeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(s.Timeout))
+	host, _, err := net.SplitHostPort(s.Relay)
+	if err != nil {
+		return err
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if s.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.Username, s.Password, host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(s.From); err != nil {
+		return err
+	}
+	for _, to := range s.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(s.message(n)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+func (s *Sink) message(n Notification) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", s.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(s.To, ", "))
+	fmt.Fprintf(&buf, "Subject: [fritzctl] %s\r\n", n.Message)
+	fmt.Fprintf(&buf, "Date: %s\r\n", n.Time.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	fmt.Fprintf(&buf, "%s\r\n\r\n", n.Message)
+	fmt.Fprintf(&buf, "Rule:      %s\r\n", n.Rule)
+	fmt.Fprintf(&buf, "Condition: %s (%s)\r\n", n.Condition, n.Status)
+	fmt.Fprintf(&buf, "Since:     %s\r\n", n.Since.Format(time.RFC3339))
+	fmt.Fprintf(&buf, "Device:    %s (%s)\r\n", n.Device.Name, n.Device.ID)
+	return buf.Bytes()
+}
@@ -0,0 +1,185 @@
+package notify
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bpicode/fritzctl/cmd/jsonapi"
+	"github.com/stretchr/testify/assert"
+)
+
+var notification = Notification{
+	Rule:      "alarm",
+	Condition: "alert",
+	Status:    Firing,
+	Message:   "SEC_1: alert",
+	Since:     time.Unix(1545160000, 0).UTC(),
+	Time:      time.Unix(1545160121, 0).UTC(),
+	Device:    jsonapi.Device{ID: "1234 567", Name: "SEC_1"},
+}
+
+// TestSendWebhook tests the JSON document posted to webhooks.
+func TestSendWebhook(t *testing.T) {
+	var got map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		json.NewDecoder(r.Body).Decode(&got)
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	s := Sink{Type: "webhook", URL: srv.URL, Method: "POST", Timeout: time.Minute}
+	assert.NoError(t, s.Send(notification))
+	assert.Equal(t, "firing", got["status"])
+	assert.Equal(t, "SEC_1", got["device"].(map[string]interface{})["name"])
+
+	s.URL = srv.URL + "/fail"
+	assert.Error(t, s.Send(notification))
+}
+
+// TestSendShell tests that shell commands receive the notification on stdin and in the environment.
+func TestSendShell(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires sh")
+	}
+	dir, err := ioutil.TempDir("", "notify")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	out := filepath.Join(dir, "out")
+
+	s := Sink{Type: "shell", Command: `echo "$FRITZCTL_STATUS $FRITZCTL_DEVICE" > ` + out + ` && cat >> ` + out, Timeout: time.Minute}
+	assert.NoError(t, s.Send(notification))
+	bs, err := ioutil.ReadFile(out)
+	assert.NoError(t, err)
+	lines := strings.SplitN(string(bs), "\n", 2)
+	assert.Equal(t, "firing SEC_1", lines[0])
+	assert.Contains(t, lines[1], `"rule":"alarm"`)
+
+	s.Command = "echo broken >&2; exit 1"
+	err = s.Send(notification)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "broken")
+}
+
+// TestSendMail tests the delivery to a local stand-in SMTP relay.
+func TestSendMail(t *testing.T) {
+	relay := startSMTPStandIn(t)
+	defer relay.Close()
+
+	s := Sink{Type: "smtp", Relay: relay.Addr().String(), From: "fritz@example.com", To: []string{"a@example.com", "b@example.com"}, Username: "u", Password: "p", Timeout: time.Minute}
+	assert.NoError(t, s.Send(notification))
+	assert.Equal(t, []string{
+		"EHLO", "AUTH PLAIN", "MAIL FROM:<fritz@example.com>", "RCPT TO:<a@example.com>", "RCPT TO:<b@example.com>", "DATA", "QUIT",
+	}, relay.verbs())
+	mail := relay.data()
+	assert.Contains(t, mail, "Subject: [fritzctl] SEC_1: alert\r\n")
+	assert.Contains(t, mail, "To: a@example.com, b@example.com\r\n")
+	assert.Contains(t, mail, "Device:    SEC_1 (1234 567)")
+
+	s.Relay = "127.0.0.1:1"
+	assert.Error(t, s.Send(notification))
+}
+
+// smtpStandIn is a minimal SMTP relay accepting one mail per connection.
+type smtpStandIn struct {
+	net.Listener
+	mu       sync.Mutex
+	commands []string
+	body     string
+}
+
+func startSMTPStandIn(t *testing.T) *smtpStandIn {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	s := &smtpStandIn{Listener: l}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpStandIn) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) {
+		conn.Write([]byte(line + "\r\n"))
+	}
+	reply("220 localhost ESMTP stand-in")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		s.record(line)
+		switch verb := strings.ToUpper(strings.Fields(line + " x")[0]); verb {
+		case "EHLO":
+			reply("250-localhost")
+			reply("250 AUTH PLAIN")
+		case "AUTH":
+			reply("235 2.7.0 Authentication successful")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var body strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil || l == ".\r\n" {
+					break
+				}
+				body.WriteString(l)
+			}
+			s.mu.Lock()
+			s.body = body.String()
+			s.mu.Unlock()
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func (s *smtpStandIn) record(line string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case strings.HasPrefix(line, "EHLO"):
+		line = "EHLO"
+	case strings.HasPrefix(line, "AUTH PLAIN"):
+		line = "AUTH PLAIN"
+	}
+	s.commands = append(s.commands, line)
+}
+
+func (s *smtpStandIn) verbs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.commands...)
+}
+
+func (s *smtpStandIn) data() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.body
+}
@@ -1,16 +1,37 @@
 package cmd
 
 import (
+	"time"
+
 	"github.com/bpicode/fritzctl/cmd/jsonapi"
+	"github.com/bpicode/fritzctl/cmd/metrics"
 	"github.com/bpicode/fritzctl/fritz"
 	"github.com/spf13/cobra"
 )
 
+const defaultMetricPrefix = "fritzctl"
+
+func addOutputFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("output", "o", "", "specify output format, one of json, influx, graphite")
+	cmd.Flags().String("metric-prefix", defaultMetricPrefix, "prefix of the measurements for --output=influx|graphite")
+}
+
 func selectFmt(cmd *cobra.Command, ds []fritz.Device, defaultF func([]fritz.Device) interface{}) interface{} {
 	switch cmd.Flag("output").Value.String() {
 	case "json":
 		return jsonapi.NewMapper().Convert(ds)
+	case "influx":
+		return metrics.Influx{Prefix: metricPrefix(cmd), Time: time.Now(), Samples: metrics.FromDevices(ds)}
+	case "graphite":
+		return metrics.Graphite{Prefix: metricPrefix(cmd), Time: time.Now(), Samples: metrics.FromDevices(ds)}
 	default:
 		return defaultF(ds)
 	}
 }
+
+func metricPrefix(cmd *cobra.Command) string {
+	if f := cmd.Flag("metric-prefix"); f != nil {
+		return f.Value.String()
+	}
+	return defaultMetricPrefix
+}
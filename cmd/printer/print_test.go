@@ -24,3 +24,10 @@ func TestPrintTable(t *testing.T) {
 	assert.Contains(t, capt.String(), "X")
 	assert.Contains(t, capt.String(), "+--")
 }
+
+// TestPrintWriterTo probes the io.WriterTo sector of Print.
+func TestPrintWriterTo(t *testing.T) {
+	capt := bytes.NewBuffer(nil)
+	Print(bytes.NewBufferString("raw output"), capt)
+	assert.Equal(t, "raw output", capt.String())
+}
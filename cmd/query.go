@@ -0,0 +1,95 @@
+//go:build cgo
+// +build cgo
+
+package cmd
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/bpicode/fritzctl/cmd/history"
+	"github.com/spf13/cobra"
+)
+
+var queryCmd = &cobra.Command{
+	Use:   "query [subcommand]",
+	Short: "Evaluate the history recorded by 'fritzctl record'",
+	Long:  "Evaluate the history recorded by 'fritzctl record'. Run with --help to list the available commands.",
+}
+
+var queryAggregateCmd = &cobra.Command{
+	Use:   "aggregate",
+	Short: "Show minimum, maximum and average of a measurement",
+	Long: "Show minimum, maximum and average of a recorded measurement per device and period. " +
+		"The measurements are named as in 'fritzctl export metrics', e.g. temperature_celsius, power_watts, " +
+		"thermostat_goal_celsius or switch_state. Periods start in the local time zone.",
+	Example: `fritzctl query aggregate --metric=temperature_celsius
+fritzctl query aggregate --metric=power_watts --per=day --since=168h --device=SWITCH_1 --output=csv`,
+	RunE: queryAggregate,
+}
+
+var queryEnergyCmd = &cobra.Command{
+	Use:   "energy",
+	Short: "Show the energy consumed per device",
+	Long: "Show the energy consumed per device and period, computed from the recorded energy counters. " +
+		"Periods start in the local time zone.",
+	Example: `fritzctl query energy --since=720h
+fritzctl query energy --per=day --since=168h --output=json`,
+	RunE: queryEnergy,
+}
+
+func init() {
+	for _, c := range []*cobra.Command{queryAggregateCmd, queryEnergyCmd} {
+		c.Flags().String("db", "fritz.sqlite", "SQLite database file written by 'fritzctl record'")
+		c.Flags().Duration("since", 24*time.Hour, "evaluate the samples recorded at most this long ago")
+		c.Flags().StringSlice("device", nil, "restrict to the devices with these names")
+		c.Flags().StringP("output", "o", "table", "specify output format, one of table, csv, json")
+		queryCmd.AddCommand(c)
+	}
+	queryAggregateCmd.Flags().String("metric", "temperature_celsius", "name of the measurement")
+	queryAggregateCmd.Flags().String("per", "hour", "aggregation period, one of hour, day, month, all")
+	queryEnergyCmd.Flags().String("per", "all", "aggregation period, one of hour, day, month, all")
+	RootCmd.AddCommand(queryCmd)
+}
+
+func queryAggregate(cmd *cobra.Command, _ []string) error {
+	store, q := openQuery(cmd)
+	defer store.Close()
+	q.Metric = cmd.Flag("metric").Value.String()
+	as, err := store.Aggregate(q)
+	assertNoErr(err, "cannot query database")
+	var rows [][]string
+	for _, a := range as {
+		rows = append(rows, []string{a.Period, a.Name, a.AIN, fmtFloat(a.Min), fmtFloat(a.Max), fmtFloat(a.Avg), strconv.Itoa(a.Count)})
+	}
+	printQueryResult(cmd, as, []string{"PERIOD", "NAME", "AIN", "MIN", "MAX", "AVG", "SAMPLES"}, rows)
+	return nil
+}
+
+func queryEnergy(cmd *cobra.Command, _ []string) error {
+	store, q := openQuery(cmd)
+	defer store.Close()
+	cs, err := store.Energy(q)
+	assertNoErr(err, "cannot query database")
+	var rows [][]string
+	for _, c := range cs {
+		rows = append(rows, []string{c.Period, c.Name, c.AIN, fmtFloat(c.WattHours), fmtFloat(c.AvgPowerW)})
+	}
+	printQueryResult(cmd, cs, []string{"PERIOD", "NAME", "AIN", "ENERGY [Wh]", "AVG POWER [W]"}, rows)
+	return nil
+}
+
+func openQuery(cmd *cobra.Command) (*history.Store, history.Query) {
+	since, err := cmd.Flags().GetDuration("since")
+	assertNoErr(err, "cannot parse since duration")
+	devices, err := cmd.Flags().GetStringSlice("device")
+	assertNoErr(err, "cannot parse device names")
+	db := cmd.Flag("db").Value.String()
+	_, err = os.Stat(db)
+	assertNoErr(err, "cannot open database")
+	store, err := history.Open(db)
+	assertNoErr(err, "cannot open database")
+	now := time.Now()
+	return store, history.Query{Per: cmd.Flag("per").Value.String(), From: now.Add(-since), To: now.Add(time.Second), Devices: devices}
+}
@@ -0,0 +1,145 @@
+//go:build cgo
+// +build cgo
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bpicode/fritzctl/cmd/history"
+	"github.com/bpicode/fritzctl/fritz"
+	"github.com/bpicode/fritzctl/logger"
+	"github.com/spf13/cobra"
+)
+
+var recordCmd = &cobra.Command{
+	Use:   "record",
+	Short: "Record the history of measurements in a local database",
+	Long: "Poll the FRITZ!Box periodically and persist the measurements of the smart home devices, the internet " +
+		"traffic, the online state of the LAN devices and new entries of the call list in an embedded SQLite " +
+		"database. The database can be evaluated with 'fritzctl query' or any SQLite client. Its tables are " +
+		"device_samples(time, ain, name, metric, value), traffic_samples(time, direction, channel, bits_per_second), " +
+		"lan_device_samples(time, uid, name, mac, ip, online) and " +
+		"calls(time, date, type, caller, phone_number, extension, own_phone_number, duration, recorded), " +
+		"where times are Unix times in seconds and the metrics are named as in 'fritzctl export metrics'. " +
+		"With --once, a single recording is made, e.g. for use in a cron job.",
+	Example: `fritzctl record --db fritz.sqlite --interval 1m
+fritzctl record --db /var/lib/fritzctl/history.sqlite --once`,
+	RunE: record,
+}
+
+func init() {
+	recordCmd.Flags().String("db", "fritz.sqlite", "SQLite database file, created if it does not exist")
+	recordCmd.Flags().Duration("interval", time.Minute, "time between two subsequent recordings")
+	recordCmd.Flags().Bool("once", false, "make one recording and exit")
+	RootCmd.AddCommand(recordCmd)
+}
+
+func record(cmd *cobra.Command, _ []string) error {
+	interval, err := cmd.Flags().GetDuration("interval")
+	assertNoErr(err, "cannot parse interval")
+	assertTrue(interval > 0, fmt.Errorf("interval must be positive, got %s", interval))
+	once, err := cmd.Flags().GetBool("once")
+	assertNoErr(err, "cannot parse once flag")
+	store, err := history.Open(cmd.Flag("db").Value.String())
+	assertNoErr(err, "cannot open database")
+	defer store.Close()
+
+	r := &recorder{session: newBoxSession(), store: store}
+	if once {
+		r.record(time.Now())
+		return nil
+	}
+	ctx, cancel := interruptContext()
+	defer cancel()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		r.record(time.Now())
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// recorder obtains the data from the FRITZ!Box and persists it. Failing sources are logged and skipped.
+type recorder struct {
+	session *boxSession
+	store   *history.Store
+}
+
+func (r *recorder) record(now time.Time) {
+	sources := []struct {
+		name   string
+		record func(time.Time) error
+	}{
+		{name: "devices", record: r.devices},
+		{name: "traffic", record: r.traffic},
+		{name: "LAN devices", record: r.lanDevices},
+		{name: "calls", record: r.calls},
+	}
+	for _, s := range sources {
+		if err := s.record(now); err != nil {
+			logger.Warn("Recording", s.name, "failed:", err)
+		}
+	}
+}
+
+func (r *recorder) devices(now time.Time) error {
+	var l *fritz.Devicelist
+	err := r.session.do(func() error {
+		var err error
+		l, err = r.session.homeAuto.List()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return r.store.AddDevices(now, l.Devices)
+}
+
+func (r *recorder) traffic(now time.Time) error {
+	var data *fritz.TrafficMonitoringData
+	err := r.session.do(func() error {
+		var err error
+		data, err = r.session.internal.InternetStats()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return r.store.AddTraffic(now, data)
+}
+
+func (r *recorder) lanDevices(now time.Time) error {
+	var l *fritz.LanDevices
+	err := r.session.do(func() error {
+		var err error
+		l, err = r.session.internal.ListLanDevices()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return r.store.AddLanDevices(now, l)
+}
+
+func (r *recorder) calls(now time.Time) error {
+	var cs []fritz.Call
+	err := r.session.do(func() error {
+		var err error
+		cs, err = r.session.phone.Calls()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	added, err := r.store.AddCalls(now, cs)
+	if added > 0 {
+		logger.Info("Recorded", added, "new calls")
+	}
+	return err
+}
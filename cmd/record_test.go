@@ -0,0 +1,64 @@
+//go:build cgo
+// +build cgo
+
+package cmd
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bpicode/fritzctl/config"
+	"github.com/bpicode/fritzctl/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRecordAndQuery records once against the mock and queries the database in all output formats.
+func TestRecordAndQuery(t *testing.T) {
+	oldPlaces := defaultConfigPlaces
+	defer func() { defaultConfigPlaces = oldPlaces }()
+	defaultConfigPlaces = append([]config.Place{config.InDir("../testdata/config", "config_localhost_http_test.json", config.JSON())}, defaultConfigPlaces...)
+	srv := mock.New().UnstartedServer()
+	var err error
+	srv.Listener, err = net.Listen("tcp", ":61666")
+	assert.NoError(t, err)
+	srv.Start()
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "record")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	db := filepath.Join(dir, "fritz.sqlite")
+
+	assert.NoError(t, recordCmd.ParseFlags([]string{"--db=" + db, "--once"}))
+	assert.NoError(t, recordCmd.RunE(recordCmd, nil))
+
+	tests := []struct {
+		cmd  string
+		args []string
+	}{
+		{cmd: "aggregate", args: []string{"--db=" + db, "--metric=power_watts"}},
+		{cmd: "aggregate", args: []string{"--db=" + db, "--metric=temperature_celsius", "--per=day", "--output=csv"}},
+		{cmd: "aggregate", args: []string{"--db=" + db, "--device=SWITCH_1", "--per=all", "--output=json"}},
+		{cmd: "energy", args: []string{"--db=" + db}},
+		{cmd: "energy", args: []string{"--db=" + db, "--per=month", "--output=csv"}},
+	}
+	for _, tc := range tests {
+		c := queryAggregateCmd
+		if tc.cmd == "energy" {
+			c = queryEnergyCmd
+		}
+		assert.NoError(t, c.ParseFlags(tc.args))
+		assert.NoError(t, c.RunE(c, nil))
+	}
+}
+
+// TestQueryMissingDatabase tests that a missing database is not created.
+func TestQueryMissingDatabase(t *testing.T) {
+	assert.NoError(t, queryEnergyCmd.ParseFlags([]string{"--db=/does/not/exist.sqlite"}))
+	assert.Panics(t, func() {
+		queryEnergyCmd.RunE(queryEnergyCmd, nil)
+	})
+}
@@ -0,0 +1,132 @@
+// Package report computes the energy consumption and its cost per device, per group and in total.
+package report
+
+import (
+	"sort"
+	"time"
+
+	"github.com/bpicode/fritzctl/fritz"
+)
+
+// Line kinds.
+const (
+	KindDevice = "device"
+	KindGroup  = "group"
+	KindTotal  = "total"
+)
+
+// Reading is the energy consumed by one device within a time range.
+type Reading struct {
+	Name      string    // Name of the device.
+	AIN       string    // Identifier of the device.
+	From      time.Time // Start of the time range.
+	To        time.Time // End of the time range.
+	WattHours float64   // Energy consumed.
+}
+
+// Params determine the report.
+type Params struct {
+	From     time.Time // Start of the period, inclusive.
+	To       time.Time // End of the period, exclusive.
+	Currency string    // Currency of the prices.
+	Tariff   Tariff    // Prices per kWh.
+}
+
+// Line is the consumption and cost of a device, a group or of all devices.
+type Line struct {
+	Kind      string  `json:"kind"`          // One of "device", "group" or "total".
+	Name      string  `json:"name"`          // Name of the device or group, empty for the total.
+	AIN       string  `json:"ain,omitempty"` // Identifier of the device or group.
+	WattHours float64 `json:"wattHours"`     // Energy consumed in the period.
+	Cost      float64 `json:"cost"`          // Cost of the energy.
+}
+
+// Energy is the energy report of a period.
+type Energy struct {
+	From     time.Time `json:"from"`
+	To       time.Time `json:"to"`
+	Currency string    `json:"currency"`
+	Tariff   Tariff    `json:"tariff"`
+	Devices  []Line    `json:"devices"`
+	Groups   []Line    `json:"groups"`
+	Total    Line      `json:"total"`
+}
+
+// Compute builds the report from the readings that start within the period. The groups are taken from the device
+// list, it may be nil. A group contains the consumption of those of its members that have readings.
+func Compute(p Params, rs []Reading, l *fritz.Devicelist) *Energy {
+	e := &Energy{From: p.From, To: p.To, Currency: p.Currency, Tariff: p.Tariff, Devices: []Line{}, Groups: []Line{}, Total: Line{Kind: KindTotal}}
+	byAin := make(map[string]*Line)
+	var ains []string
+	for _, r := range rs {
+		if r.From.Before(p.From) || !r.From.Before(p.To) {
+			continue
+		}
+		line, ok := byAin[r.AIN]
+		if !ok {
+			line = &Line{Kind: KindDevice, Name: r.Name, AIN: r.AIN}
+			byAin[r.AIN] = line
+			ains = append(ains, r.AIN)
+		}
+		cost := p.Tariff.Cost(r.WattHours, r.From, r.To)
+		line.WattHours += r.WattHours
+		line.Cost += cost
+		e.Total.WattHours += r.WattHours
+		e.Total.Cost += cost
+	}
+	for _, ain := range ains {
+		e.Devices = append(e.Devices, *byAin[ain])
+	}
+	sort.SliceStable(e.Devices, func(i, j int) bool { return e.Devices[i].Name < e.Devices[j].Name })
+	if l != nil {
+		e.Groups = groups(l, byAin)
+	}
+	return e
+}
+
+func groups(l *fritz.Devicelist, byAin map[string]*Line) []Line {
+	ainByID := make(map[string]string)
+	for _, d := range l.Devices {
+		ainByID[d.ID] = d.Identifier
+	}
+	lines := []Line{}
+	for _, g := range l.Groups {
+		line := Line{Kind: KindGroup, Name: g.Name, AIN: g.Identifier}
+		found := false
+		for _, id := range g.Members() {
+			if m, ok := byAin[ainByID[id]]; ok {
+				line.WattHours += m.WattHours
+				line.Cost += m.Cost
+				found = true
+			}
+		}
+		if found {
+			lines = append(lines, line)
+		}
+	}
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].Name < lines[j].Name })
+	return lines
+}
+
+// FromStats converts the daily energy values kept by the FRITZ!Box into readings. The most recent value is the
+// consumption of the current day up to now.
+func FromStats(name, ain string, stats *fritz.DeviceStats, now time.Time) []Reading {
+	series, ok := stats.EnergySeries(24 * time.Hour)
+	if !ok {
+		return nil
+	}
+	var rs []Reading
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for i, v := range series.Parse() {
+		if v == nil {
+			continue
+		}
+		from := today.AddDate(0, 0, -i)
+		to := from.AddDate(0, 0, 1)
+		if i == 0 {
+			to = now
+		}
+		rs = append(rs, Reading{Name: name, AIN: ain, From: from, To: to, WattHours: *v})
+	}
+	return rs
+}
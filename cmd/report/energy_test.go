@@ -0,0 +1,60 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bpicode/fritzctl/fritz"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCompute tests the consumption and cost per device, group and in total.
+func TestCompute(t *testing.T) {
+	from := time.Date(2018, 12, 1, 0, 0, 0, 0, time.Local)
+	p := Params{From: from, To: from.AddDate(0, 1, 0), Currency: "EUR", Tariff: Tariff{Price: 0.3}}
+	rs := []Reading{
+		{Name: "B", AIN: "2", From: from, To: from.Add(time.Hour), WattHours: 1000},
+		{Name: "A", AIN: "1", From: from, To: from.Add(time.Hour), WattHours: 500},
+		{Name: "A", AIN: "1", From: from.Add(time.Hour), To: from.Add(2 * time.Hour), WattHours: 1500},
+		{Name: "A", AIN: "1", From: from.Add(-time.Hour), To: from, WattHours: 100},
+		{Name: "C", AIN: "3", From: p.To, To: p.To.Add(time.Hour), WattHours: 100},
+	}
+	l := &fritz.Devicelist{
+		Devices: []fritz.Device{{ID: "11", Identifier: "1"}, {ID: "12", Identifier: "2"}, {ID: "13", Identifier: "3"}},
+		Groups: []fritz.Group{
+			{Name: "G", Identifier: "G:1", GroupInfo: fritz.GroupInfo{Members: "11,12"}},
+			{Name: "EMPTY", Identifier: "G:2", GroupInfo: fritz.GroupInfo{Members: "13"}},
+		},
+	}
+	e := Compute(p, rs, l)
+	assert.Len(t, e.Devices, 2)
+	assert.Equal(t, "A", e.Devices[0].Name)
+	assert.InDelta(t, 2000, e.Devices[0].WattHours, 1e-9)
+	assert.InDelta(t, 0.6, e.Devices[0].Cost, 1e-9)
+	assert.Equal(t, "B", e.Devices[1].Name)
+	assert.Len(t, e.Groups, 1)
+	assert.Equal(t, Line{Kind: KindGroup, Name: "G", AIN: "G:1", WattHours: 3000, Cost: e.Groups[0].Cost}, e.Groups[0])
+	assert.InDelta(t, 0.9, e.Groups[0].Cost, 1e-9)
+	assert.Equal(t, KindTotal, e.Total.Kind)
+	assert.InDelta(t, 3000, e.Total.WattHours, 1e-9)
+
+	e = Compute(p, nil, nil)
+	assert.Empty(t, e.Devices)
+	assert.Empty(t, e.Groups)
+}
+
+// TestFromStats tests the conversion of the daily values kept by the FRITZ!Box.
+func TestFromStats(t *testing.T) {
+	now := time.Date(2018, 12, 18, 10, 0, 0, 0, time.Local)
+	stats := &fritz.DeviceStats{Energy: []fritz.StatsSeries{
+		{Count: 2, Grid: 2678400, Values: "3000,2000"},
+		{Count: 3, Grid: 86400, Values: "40,-,110"},
+	}}
+	rs := FromStats("A", "1", stats, now)
+	today := time.Date(2018, 12, 18, 0, 0, 0, 0, time.Local)
+	assert.Equal(t, []Reading{
+		{Name: "A", AIN: "1", From: today, To: now, WattHours: 40},
+		{Name: "A", AIN: "1", From: today.AddDate(0, 0, -2), To: today.AddDate(0, 0, -1), WattHours: 110},
+	}, rs)
+	assert.Empty(t, FromStats("A", "1", &fritz.DeviceStats{}, now))
+}
@@ -0,0 +1,108 @@
+package report
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Window is a daily time range with its own price per kWh, e.g. a night tariff.
+type Window struct {
+	Start int     `json:"start"` // Start of the window in minutes after midnight, inclusive.
+	End   int     `json:"end"`   // End of the window in minutes after midnight, exclusive. Windows with End <= Start span midnight.
+	Price float64 `json:"price"` // Price per kWh within the window.
+}
+
+// ParseWindow parses a window of the form "22:00-06:00=0.25".
+func ParseWindow(s string) (Window, error) {
+	var w Window
+	parts := strings.SplitN(s, "=", 2)
+	bounds := strings.SplitN(parts[0], "-", 2)
+	if len(parts) != 2 || len(bounds) != 2 {
+		return w, fmt.Errorf("invalid tariff window '%s', expected e.g. 22:00-06:00=0.25", s)
+	}
+	var err error
+	if w.Start, err = minuteOfDay(bounds[0]); err != nil {
+		return w, fmt.Errorf("invalid start of tariff window '%s': %v", s, err)
+	}
+	if w.End, err = minuteOfDay(bounds[1]); err != nil {
+		return w, fmt.Errorf("invalid end of tariff window '%s': %v", s, err)
+	}
+	if w.Price, err = strconv.ParseFloat(strings.TrimSpace(parts[1]), 64); err != nil || w.Price < 0 {
+		return w, fmt.Errorf("invalid price of tariff window '%s'", s)
+	}
+	return w, nil
+}
+
+func minuteOfDay(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// String formats the window like it is parsed.
+func (w Window) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d=%s", w.Start/60, w.Start%60, w.End/60, w.End%60, strconv.FormatFloat(w.Price, 'f', -1, 64))
+}
+
+func (w Window) contains(t time.Time) bool {
+	m := t.Hour()*60 + t.Minute()
+	if w.Start < w.End {
+		return m >= w.Start && m < w.End
+	}
+	return m >= w.Start || m < w.End
+}
+
+// Tariff determines the price of energy depending on the time of the day.
+type Tariff struct {
+	Price   float64  `json:"price"`             // Price per kWh outside of the windows.
+	Windows []Window `json:"windows,omitempty"` // Windows with different prices, the first matching window applies.
+}
+
+// PriceAt returns the price per kWh at the given time.
+func (t Tariff) PriceAt(tm time.Time) float64 {
+	for _, w := range t.Windows {
+		if w.contains(tm) {
+			return w.Price
+		}
+	}
+	return t.Price
+}
+
+// Cost returns the cost of energy consumed uniformly within the given time range.
+func (t Tariff) Cost(wattHours float64, from, to time.Time) float64 {
+	if !to.After(from) {
+		return wattHours / 1000 * t.PriceAt(from)
+	}
+	total := to.Sub(from).Seconds()
+	cost := 0.0
+	for cur := from; cur.Before(to); {
+		next := t.nextChange(cur)
+		if next.After(to) {
+			next = to
+		}
+		cost += wattHours / 1000 * t.PriceAt(cur) * next.Sub(cur).Seconds() / total
+		cur = next
+	}
+	return cost
+}
+
+// nextChange returns the next time after tm at which a window starts or ends.
+func (t Tariff) nextChange(tm time.Time) time.Time {
+	next := tm.AddDate(0, 0, 1)
+	for _, w := range t.Windows {
+		for _, m := range []int{w.Start, w.End} {
+			c := time.Date(tm.Year(), tm.Month(), tm.Day(), m/60, m%60, 0, 0, tm.Location())
+			if !c.After(tm) {
+				c = time.Date(tm.Year(), tm.Month(), tm.Day()+1, m/60, m%60, 0, 0, tm.Location())
+			}
+			if c.Before(next) {
+				next = c
+			}
+		}
+	}
+	return next
+}
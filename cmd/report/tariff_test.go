@@ -0,0 +1,44 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseWindow tests parsing of tariff windows.
+func TestParseWindow(t *testing.T) {
+	w, err := ParseWindow("22:00-06:30=0.25")
+	assert.NoError(t, err)
+	assert.Equal(t, Window{Start: 22 * 60, End: 6*60 + 30, Price: 0.25}, w)
+	assert.Equal(t, "22:00-06:30=0.25", w.String())
+
+	for _, s := range []string{"", "22:00-06:00", "22:00=0.25", "25:00-06:00=0.25", "22:00-6=0.25", "22:00-06:00=cheap", "22:00-06:00=-1"} {
+		_, err := ParseWindow(s)
+		assert.Error(t, err, s)
+	}
+}
+
+// TestPriceAt tests the selection of the price by the time of the day.
+func TestPriceAt(t *testing.T) {
+	tariff := Tariff{Price: 0.32, Windows: []Window{{Start: 22 * 60, End: 6 * 60, Price: 0.25}, {Start: 12 * 60, End: 13 * 60, Price: 0.1}}}
+	at := func(h, m int) time.Time { return time.Date(2018, 12, 18, h, m, 0, 0, time.Local) }
+	assert.Equal(t, 0.25, tariff.PriceAt(at(23, 0)))
+	assert.Equal(t, 0.25, tariff.PriceAt(at(5, 59)))
+	assert.Equal(t, 0.32, tariff.PriceAt(at(6, 0)))
+	assert.Equal(t, 0.1, tariff.PriceAt(at(12, 30)))
+	assert.Equal(t, 0.32, tariff.PriceAt(at(13, 0)))
+}
+
+// TestCost tests that energy is distributed uniformly over the windows.
+func TestCost(t *testing.T) {
+	tariff := Tariff{Price: 0.4, Windows: []Window{{Start: 22 * 60, End: 6 * 60, Price: 0.2}}}
+	from := time.Date(2018, 12, 18, 20, 0, 0, 0, time.Local)
+	assert.InDelta(t, 0.4, tariff.Cost(1000, from, from.Add(2*time.Hour)), 1e-9)
+	assert.InDelta(t, 0.3, tariff.Cost(1000, from, from.Add(4*time.Hour)), 1e-9)
+	day := time.Date(2018, 12, 18, 0, 0, 0, 0, time.Local)
+	assert.InDelta(t, 0.2*8/24*2.4+0.4*16/24*2.4, tariff.Cost(2400, day, day.AddDate(0, 0, 1)), 1e-9)
+	assert.InDelta(t, 0.2, tariff.Cost(1000, day, day), 1e-9)
+	assert.InDelta(t, 0.64, Tariff{Price: 0.32}.Cost(2000, day, day.AddDate(0, 0, 3)), 1e-9)
+}
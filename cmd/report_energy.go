@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bpicode/fritzctl/cmd/history"
+	"github.com/bpicode/fritzctl/cmd/report"
+	"github.com/bpicode/fritzctl/fritz"
+	"github.com/bpicode/fritzctl/logger"
+	"github.com/spf13/cobra"
+)
+
+var reportEnergyCmd = &cobra.Command{
+	Use:   "energy",
+	Short: "Report the energy consumption and its cost",
+	Long: "Report the energy consumed and its cost per device, per group and in total within a period. " +
+		"With --source=history (default), the consumption is computed from the energy counters recorded by " +
+		"'fritzctl record'. With --source=box, the daily values kept by the FRITZ!Box for the last 31 days are used. " +
+		"Prices are per kWh. Tariff windows like 22:00-06:00=0.25 set a different price for a time of the day, " +
+		"energy is assumed to be consumed uniformly between two samples. " +
+		"Groups are obtained from the FRITZ!Box and omitted if it cannot be reached. " +
+		"Times are given as 2006-01-02, '2006-01-02 15:04' or in RFC 3339 format, in the local time zone if no offset is given. " +
+		"The period defaults to the current month.",
+	Example: `fritzctl report energy --price=0.32 --currency=EUR
+fritzctl report energy --from=2018-12-01 --to=2019-01-01 --price=0.32 --tariff=22:00-06:00=0.25 --output=csv
+fritzctl report energy --source=box --from=2018-12-10 --price=0.32 --device=SWITCH_1 --output=json`,
+	RunE: reportEnergy,
+}
+
+func init() {
+	reportEnergyCmd.Flags().String("from", "", "start of the period, inclusive (default start of the current month)")
+	reportEnergyCmd.Flags().String("to", "", "end of the period, exclusive (default now)")
+	reportEnergyCmd.Flags().Float64("price", 0, "price per kWh")
+	reportEnergyCmd.Flags().String("currency", "EUR", "currency of the prices")
+	reportEnergyCmd.Flags().StringArray("tariff", nil, "price per kWh within a time of the day, e.g. 22:00-06:00=0.25, repeatable")
+	reportEnergyCmd.Flags().String("source", "history", "source of the consumption, one of history, box")
+	reportEnergyCmd.Flags().String("db", "fritz.sqlite", "SQLite database file written by 'fritzctl record'")
+	reportEnergyCmd.Flags().StringSlice("device", nil, "restrict to the devices with these names")
+	reportEnergyCmd.Flags().StringP("output", "o", "table", "specify output format, one of table, csv, json")
+	reportCmd.AddCommand(reportEnergyCmd)
+}
+
+func reportEnergy(cmd *cobra.Command, _ []string) error {
+	p := reportParams(cmd)
+	devices, err := cmd.Flags().GetStringSlice("device")
+	assertNoErr(err, "cannot parse device names")
+	var session *boxSession
+	var rs []report.Reading
+	switch source := cmd.Flag("source").Value.String(); source {
+	case "history":
+		rs = historyReadings(cmd.Flag("db").Value.String(), history.Query{From: p.From, To: p.To, Devices: devices})
+	case "box":
+		session = newBoxSession()
+		rs = boxReadings(session, devices)
+	default:
+		assertTrue(false, fmt.Errorf("unknown source '%s', expected one of history, box", source))
+	}
+	l, err := energyGroups(session, rs)
+	if err != nil {
+		logger.Warn("Cannot obtain the groups:", err)
+	}
+	e := report.Compute(p, rs, l)
+	printQueryResult(cmd, e, energyReportHeaders(e.Currency), energyReportRows(e))
+	return nil
+}
+
+func reportParams(cmd *cobra.Command) report.Params {
+	now := time.Now()
+	from, err := reportTime(cmd.Flag("from").Value.String(), time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local))
+	assertNoErr(err, "cannot parse start of the period")
+	to, err := reportTime(cmd.Flag("to").Value.String(), now)
+	assertNoErr(err, "cannot parse end of the period")
+	assertTrue(from.Before(to), fmt.Errorf("start of the period %s is not before its end %s", from.Format(time.RFC3339), to.Format(time.RFC3339)))
+	price, err := cmd.Flags().GetFloat64("price")
+	assertNoErr(err, "cannot parse price")
+	assertTrue(price >= 0, fmt.Errorf("price must not be negative, got %v", price))
+	tariffs, err := cmd.Flags().GetStringArray("tariff")
+	assertNoErr(err, "cannot parse tariff windows")
+	t := report.Tariff{Price: price}
+	for _, s := range tariffs {
+		w, err := report.ParseWindow(s)
+		assertNoErr(err, "cannot parse tariff window")
+		t.Windows = append(t.Windows, w)
+	}
+	return report.Params{From: from, To: to, Currency: cmd.Flag("currency").Value.String(), Tariff: t}
+}
+
+// reportTime parses a point in time in one of the accepted layouts, empty values yield the default.
+func reportTime(s string, def time.Time) (time.Time, error) {
+	if s == "" {
+		return def, nil
+	}
+	for _, layout := range []string{"2006-01-02", "2006-01-02 15:04", time.RFC3339} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("'%s' is not of the form 2006-01-02, '2006-01-02 15:04' or RFC 3339", s)
+}
+
+func historyReadings(db string, q history.Query) []report.Reading {
+	_, err := os.Stat(db)
+	assertNoErr(err, "cannot open database")
+	store, err := history.Open(db)
+	assertNoErr(err, "cannot open database")
+	defer store.Close()
+	hs, err := store.Readings(q)
+	assertNoErr(err, "cannot query database")
+	rs := make([]report.Reading, 0, len(hs))
+	for _, h := range hs {
+		rs = append(rs, report.Reading{Name: h.Name, AIN: h.AIN, From: h.From, To: h.To, WattHours: h.WattHours})
+	}
+	return rs
+}
+
+func boxReadings(session *boxSession, devices []string) []report.Reading {
+	now := time.Now()
+	var rs []report.Reading
+	err := session.do(func() error {
+		rs = nil
+		l, err := session.homeAuto.List()
+		if err != nil {
+			return err
+		}
+		for _, d := range l.Devices {
+			if !d.CanMeasurePower() || !selected(devices, d.Name) {
+				continue
+			}
+			stats, err := session.homeAuto.Stats(d.Name)
+			if err != nil {
+				return err
+			}
+			rs = append(rs, report.FromStats(d.Name, d.Identifier, stats, now)...)
+		}
+		return nil
+	})
+	assertNoErr(err, "cannot obtain device statistics")
+	return rs
+}
+
+// energyGroups obtains the device list to resolve the groups. The FRITZ!Box is only contacted if there is any
+// consumption, reusing the session of --source=box.
+func energyGroups(session *boxSession, rs []report.Reading) (*fritz.Devicelist, error) {
+	if len(rs) == 0 {
+		return nil, nil
+	}
+	if session == nil {
+		var err error
+		if session, err = openBoxSession(); err != nil {
+			return nil, err
+		}
+	}
+	var l *fritz.Devicelist
+	err := session.do(func() error {
+		var err error
+		l, err = session.homeAuto.List()
+		return err
+	})
+	return l, err
+}
+
+// selected returns true if no names are given or the name is among them.
+func selected(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return len(names) == 0
+}
+
+func energyReportHeaders(currency string) []string {
+	return []string{"KIND", "NAME", "AIN", "ENERGY [kWh]", "COST [" + currency + "]"}
+}
+
+func energyReportRows(e *report.Energy) [][]string {
+	var rows [][]string
+	for _, ls := range [][]report.Line{e.Devices, e.Groups, {e.Total}} {
+		for _, l := range ls {
+			rows = append(rows, []string{l.Kind, l.Name, l.AIN, fmt.Sprintf("%.3f", l.WattHours/1000), fmtFloat(l.Cost)})
+		}
+	}
+	return rows
+}
@@ -0,0 +1,97 @@
+//go:build cgo
+// +build cgo
+
+package cmd
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bpicode/fritzctl/cmd/history"
+	"github.com/bpicode/fritzctl/config"
+	"github.com/bpicode/fritzctl/fritz"
+	"github.com/bpicode/fritzctl/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestReportEnergy tests the energy report from the history and from the statistics of the FRITZ!Box.
+func TestReportEnergy(t *testing.T) {
+	oldPlaces := defaultConfigPlaces
+	defer func() { defaultConfigPlaces = oldPlaces }()
+	defaultConfigPlaces = append([]config.Place{config.InDir("../testdata/config", "config_localhost_http_test.json", config.JSON())}, defaultConfigPlaces...)
+	srv := mock.New().UnstartedServer()
+	var err error
+	srv.Listener, err = net.Listen("tcp", ":61666")
+	assert.NoError(t, err)
+	srv.Start()
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "report")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	db := filepath.Join(dir, "fritz.sqlite")
+	store, err := history.Open(db)
+	assert.NoError(t, err)
+	plug := func(energy string) []fritz.Device {
+		return []fritz.Device{{Identifier: "12324 2211244", ID: "20", Name: "SWITCH_2", Present: 1, Functionbitmask: "2944", Powermeter: fritz.Powermeter{Energy: energy}}}
+	}
+	t0 := time.Now().Add(-2 * time.Hour)
+	assert.NoError(t, store.AddDevices(t0, plug("4000")))
+	assert.NoError(t, store.AddDevices(t0.Add(time.Hour), plug("4500")))
+	assert.NoError(t, store.Close())
+
+	from := t0.Add(-time.Hour).Format(time.RFC3339)
+	for _, args := range [][]string{
+		{"--db=" + db, "--from=" + from, "--price=0.32"},
+		{"--db=" + db, "--from=" + from, "--price=0.32", "--tariff=22:00-06:00=0.25", "--output=csv"},
+		{"--db=" + db, "--from=" + from, "--device=SWITCH_2", "--output=json"},
+		{"--source=box", "--from=" + time.Now().AddDate(0, 0, -7).Format("2006-01-02"), "--price=0.3", "--device=SWITCH_1,SWITCH_2"},
+	} {
+		assert.NoError(t, reportEnergyCmd.ParseFlags(args))
+		assert.NoError(t, reportEnergyCmd.RunE(reportEnergyCmd, nil))
+	}
+}
+
+// TestReportEnergyWithoutBox tests that the report from the history does not require a configured FRITZ!Box.
+func TestReportEnergyWithoutBox(t *testing.T) {
+	oldPlaces := defaultConfigPlaces
+	defer func() { defaultConfigPlaces = oldPlaces }()
+	defaultConfigPlaces = []config.Place{config.InDir("/does/not/exist", "config.yml", config.YAML())}
+
+	dir, err := ioutil.TempDir("", "report")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	db := filepath.Join(dir, "fritz.sqlite")
+	store, err := history.Open(db)
+	assert.NoError(t, err)
+	t0 := time.Now().Add(-2 * time.Hour)
+	assert.NoError(t, store.AddDevices(t0, []fritz.Device{{Identifier: "1", Name: "SWITCH_2", Present: 1, Functionbitmask: "896", Powermeter: fritz.Powermeter{Energy: "10"}}}))
+	assert.NoError(t, store.AddDevices(t0.Add(time.Hour), []fritz.Device{{Identifier: "1", Name: "SWITCH_2", Present: 1, Functionbitmask: "896", Powermeter: fritz.Powermeter{Energy: "20"}}}))
+	assert.NoError(t, store.Close())
+
+	assert.NoError(t, reportEnergyCmd.ParseFlags([]string{"--source=history", "--db=" + db, "--from=" + t0.Add(-time.Hour).Format(time.RFC3339), "--device=SWITCH_2", "--output=json"}))
+	assert.NotPanics(t, func() {
+		assert.NoError(t, reportEnergyCmd.RunE(reportEnergyCmd, nil))
+	})
+}
+
+// TestReportEnergyInvalidFlags tests that invalid flags are rejected.
+func TestReportEnergyInvalidFlags(t *testing.T) {
+	for _, args := range [][]string{
+		{"--source=history", "--from=yesterday"},
+		{"--from=2018-12-02", "--to=2018-12-01"},
+		{"--from=2018-12-01", "--to=2018-12-02", "--price=-1"},
+		{"--price=0", "--tariff=night"},
+		{"--tariff=22:00-06:00=0.25", "--source=elsewhere"},
+		{"--source=history", "--db=/does/not/exist.sqlite"},
+	} {
+		assert.NoError(t, reportEnergyCmd.ParseFlags(args))
+		assert.Panics(t, func() {
+			reportEnergyCmd.RunE(reportEnergyCmd, nil)
+		}, "%v", args)
+	}
+}
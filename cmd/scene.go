@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"os/user"
+	"path/filepath"
+
+	"github.com/bpicode/fritzctl/scene"
+	"github.com/spf13/cobra"
+)
+
+var sceneCmd = &cobra.Command{
+	Use:   "scene [subcommand]",
+	Short: "See subcommands",
+	Long: "See subcommands. Run with --help to list the available commands. " +
+		"Scenes are named snapshots of the states of switches, thermostats and lights. They are stored as manifests " +
+		"in a directory, ~/.fritzctl/scenes by default, and can be put under version control.",
+}
+
+func init() {
+	sceneCmd.PersistentFlags().String("dir", "", "directory of the scenes, defaults to ~/.fritzctl/scenes")
+	RootCmd.AddCommand(sceneCmd)
+}
+
+func sceneStore(cmd *cobra.Command) *scene.Store {
+	dir := cmd.Flag("dir").Value.String()
+	if dir == "" {
+		u, err := user.Current()
+		assertNoErr(err, "cannot determine the home directory")
+		dir = filepath.Join(u.HomeDir, ".fritzctl", "scenes")
+	}
+	return scene.NewStore(dir)
+}
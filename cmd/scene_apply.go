@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"github.com/bpicode/fritzctl/fritz"
+	"github.com/bpicode/fritzctl/manifest"
+	"github.com/spf13/cobra"
+)
+
+var sceneApplyCmd = &cobra.Command{
+	Use:   "apply [name]",
+	Short: "Restore the state of the devices saved in a scene",
+	Long: "Restore the state of the devices saved in the scene with the given name, like 'fritzctl manifest apply'. " +
+		"Only the devices whose state differs from the scene are changed. With --dry-run, the changes are only " +
+		"listed. With --atomic, the successful changes are reverted if any change fails.",
+	Example: `fritzctl scene apply evening
+fritzctl scene apply evening --dry-run`,
+	RunE: sceneApply,
+}
+
+func init() {
+	sceneApplyCmd.Flags().Bool("dry-run", false, "list the changes instead of applying them")
+	sceneApplyCmd.Flags().Bool("atomic", false, "revert the successful changes if any change fails")
+	sceneCmd.AddCommand(sceneApplyCmd)
+}
+
+func sceneApply(cmd *cobra.Command, args []string) error {
+	assertMinLen(args, 1, "insufficient input: name of the scene expected")
+	dryRun, err := cmd.Flags().GetBool("dry-run")
+	assertNoErr(err, "cannot parse dry-run flag")
+	atomic, err := cmd.Flags().GetBool("atomic")
+	assertNoErr(err, "cannot parse atomic flag")
+	target, err := sceneStore(cmd).Load(args[0])
+	assertNoErr(err, "cannot load scene '%s'", args[0])
+	var opts []manifest.Option
+	if atomic {
+		opts = append(opts, manifest.Atomic())
+	}
+	h := homeAutoClient(fritz.Caching(true))
+	src, target, _ := obtainSourcePlan(h, target)
+	applier := manifest.NewApplier(h, opts...)
+	if dryRun {
+		applier = manifest.DryRunner(opts...)
+	}
+	err = applier.Apply(src, target)
+	assertNoErr(err, "application of scene '%s' was not successful", args[0])
+	return nil
+}
@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"github.com/bpicode/fritzctl/logger"
+	"github.com/spf13/cobra"
+)
+
+var sceneDeleteCmd = &cobra.Command{
+	Use:     "delete [name]",
+	Short:   "Delete a saved scene",
+	Long:    "Delete the scene with the given name from the scene directory.",
+	Example: "fritzctl scene delete evening",
+	RunE:    sceneDelete,
+}
+
+func init() {
+	sceneCmd.AddCommand(sceneDeleteCmd)
+}
+
+func sceneDelete(cmd *cobra.Command, args []string) error {
+	assertMinLen(args, 1, "insufficient input: name of the scene expected")
+	err := sceneStore(cmd).Delete(args[0])
+	assertNoErr(err, "cannot delete scene '%s'", args[0])
+	logger.Success("Deleted scene", args[0])
+	return nil
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/bpicode/fritzctl/internal/console"
+	"github.com/spf13/cobra"
+)
+
+var sceneListCmd = &cobra.Command{
+	Use:     "list",
+	Short:   "List the saved scenes",
+	Long:    "List the saved scenes with the time they were saved and the number of devices. Scenes that cannot be loaded are listed with the reason.",
+	Example: "fritzctl scene list",
+	RunE:    sceneList,
+}
+
+func init() {
+	sceneCmd.AddCommand(sceneListCmd)
+}
+
+func sceneList(cmd *cobra.Command, _ []string) error {
+	infos, err := sceneStore(cmd).List()
+	assertNoErr(err, "cannot list scenes")
+	table := console.NewTable(console.Headers("NAME", "SAVED", "DEVICES", "PROBLEM"))
+	for _, i := range infos {
+		devices, problem := strconv.Itoa(i.Devices), ""
+		if i.Err != nil {
+			devices, problem = "?", i.Err.Error()
+		}
+		table.Append([]string{i.Name, i.Saved.Format(time.RFC3339), devices, problem})
+	}
+	table.Print(os.Stdout)
+	return nil
+}
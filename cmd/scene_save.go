@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"github.com/bpicode/fritzctl/logger"
+	"github.com/bpicode/fritzctl/scene"
+	"github.com/spf13/cobra"
+)
+
+var sceneSaveCmd = &cobra.Command{
+	Use:   "save [name]",
+	Short: "Capture the current state of the devices as a scene",
+	Long: "Capture the states of the switches, the goal temperatures of the thermostats and the settings of the " +
+		"lights as a scene with the given name. With --devices, only the given devices are captured. " +
+		"An existing scene is replaced only with --force.",
+	Example: `fritzctl scene save evening
+fritzctl scene save movie --devices SWITCH_1,BULB_1
+fritzctl scene save evening --force`,
+	RunE: sceneSave,
+}
+
+func init() {
+	sceneSaveCmd.Flags().StringSlice("devices", nil, "capture only the given devices")
+	sceneSaveCmd.Flags().Bool("force", false, "replace an existing scene")
+	sceneCmd.AddCommand(sceneSaveCmd)
+}
+
+func sceneSave(cmd *cobra.Command, args []string) error {
+	assertMinLen(args, 1, "insufficient input: name of the scene expected")
+	devices, err := cmd.Flags().GetStringSlice("devices")
+	assertNoErr(err, "cannot parse devices flag")
+	force, err := cmd.Flags().GetBool("force")
+	assertNoErr(err, "cannot parse force flag")
+	p, err := scene.Capture(mustList(), devices...)
+	assertNoErr(err, "cannot capture scene")
+	err = sceneStore(cmd).Save(args[0], p, force)
+	assertNoErr(err, "cannot save scene '%s'", args[0])
+	logger.Success("Saved scene", args[0])
+	return nil
+}
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var sceneShowCmd = &cobra.Command{
+	Use:     "show [name]",
+	Short:   "Print a saved scene",
+	Long:    "Print the manifest of the scene with the given name.",
+	Example: "fritzctl scene show evening",
+	RunE:    sceneShow,
+}
+
+func init() {
+	sceneCmd.AddCommand(sceneShowCmd)
+}
+
+func sceneShow(cmd *cobra.Command, args []string) error {
+	assertMinLen(args, 1, "insufficient input: name of the scene expected")
+	bs, err := sceneStore(cmd).Read(args[0])
+	assertNoErr(err, "cannot read scene '%s'", args[0])
+	os.Stdout.Write(bs)
+	return nil
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"testing"
+
+	"github.com/bpicode/fritzctl/config"
+	"github.com/bpicode/fritzctl/mock"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSceneLifecycle tests saving, listing, showing, applying and deleting scenes.
+func TestSceneLifecycle(t *testing.T) {
+	oldPlaces := defaultConfigPlaces
+	defer func() { defaultConfigPlaces = oldPlaces }()
+	defaultConfigPlaces = append([]config.Place{config.InDir("../testdata/config", "config_localhost_http_test.json", config.JSON())}, defaultConfigPlaces...)
+	srv := mock.New().UnstartedServer()
+	var err error
+	srv.Listener, err = net.Listen("tcp", ":61666")
+	assert.NoError(t, err)
+	srv.Start()
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "scenes")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	defer sceneCmd.PersistentFlags().Set("dir", "")
+	assert.NoError(t, sceneCmd.PersistentFlags().Set("dir", dir))
+
+	assert.NoError(t, sceneSaveCmd.RunE(sceneSaveCmd, []string{"everything"}))
+	assert.Panics(t, func() { sceneSaveCmd.RunE(sceneSaveCmd, []string{"everything"}) })
+	defer sceneSaveCmd.Flags().Set("force", "false")
+	defer sceneSaveCmd.Flags().Lookup("devices").Value.(pflag.SliceValue).Replace(nil)
+	assert.NoError(t, sceneSaveCmd.Flags().Set("force", "true"))
+	assert.NoError(t, sceneSaveCmd.Flags().Set("devices", "SWITCH_1,HKR_1"))
+	assert.NoError(t, sceneSaveCmd.RunE(sceneSaveCmd, []string{"everything"}))
+	assert.NoError(t, sceneSaveCmd.Flags().Set("devices", "NO_SUCH_DEVICE"))
+	assert.Panics(t, func() { sceneSaveCmd.RunE(sceneSaveCmd, []string{"missing"}) })
+
+	assert.NoError(t, sceneListCmd.RunE(sceneListCmd, nil))
+	assert.NoError(t, sceneShowCmd.RunE(sceneShowCmd, []string{"everything"}))
+
+	defer sceneApplyCmd.Flags().Set("dry-run", "false")
+	assert.NoError(t, sceneApplyCmd.Flags().Set("dry-run", "true"))
+	assert.NoError(t, sceneApplyCmd.RunE(sceneApplyCmd, []string{"everything"}))
+	assert.NoError(t, sceneApplyCmd.Flags().Set("dry-run", "false"))
+	assert.NoError(t, sceneApplyCmd.RunE(sceneApplyCmd, []string{"everything"}))
+
+	assert.NoError(t, sceneDeleteCmd.RunE(sceneDeleteCmd, []string{"everything"}))
+	for _, c := range []*cobra.Command{sceneShowCmd, sceneApplyCmd, sceneDeleteCmd} {
+		assert.Panics(t, func() { c.RunE(c, []string{"everything"}) })
+		assert.Panics(t, func() { c.RunE(c, nil) })
+	}
+}
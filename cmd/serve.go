@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/bpicode/fritzctl/logger"
+	"github.com/spf13/cobra"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve [subcommand]",
+	Short: "See subcommands",
+	Long:  "See subcommands. Run with --help to list the available commands.",
+}
+
+func init() {
+	RootCmd.AddCommand(serveCmd)
+}
+
+// listenAndServe runs the server until the process is interrupted, then shuts it down gracefully.
+func listenAndServe(srv *http.Server) error {
+	ctx, cancel := interruptContext()
+	defer cancel()
+	errs := make(chan error, 1)
+	go func() {
+		errs <- srv.ListenAndServe()
+	}()
+	logger.Info("Listening on", srv.Addr)
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+	logger.Info("Shutting down")
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelShutdown()
+	return srv.Shutdown(shutdownCtx)
+}
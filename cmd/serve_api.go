@@ -0,0 +1,268 @@
+package cmd
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/bpicode/fritzctl/cmd/jsonapi"
+	"github.com/bpicode/fritzctl/fritz"
+	"github.com/bpicode/fritzctl/logger"
+	"github.com/julienschmidt/httprouter"
+	"github.com/spf13/cobra"
+)
+
+const apiTokenEnv = "FRITZCTL_API_TOKEN"
+
+var serveAPICmd = &cobra.Command{
+	Use:   "api",
+	Short: "Serve a REST API for the FRITZ!Box",
+	Long: "Start an HTTP server acting as a gateway to the FRITZ!Box. It keeps one session with the FRITZ!Box and " +
+		"serves the smart home devices, groups, LAN devices, phone calls and box information as JSON. " +
+		"Switches and thermostats can be controlled by PUT requests. " +
+		"Requests have to carry the token given by --token or the environment variable " + apiTokenEnv + " as bearer token. " +
+		"Without a token the server refuses to start, unless unauthenticated access is allowed explicitly with --insecure. " +
+		"By default the server only listens on the loopback interface. " +
+		"The OpenAPI document is served on /openapi.json.",
+	Example: `fritzctl serve api --token=secret
+FRITZCTL_API_TOKEN=secret fritzctl serve api --listen=:8080
+curl -H "Authorization: Bearer secret" localhost:8080/devices
+curl -X PUT -H "Authorization: Bearer secret" -d '{"state":"ON"}' localhost:8080/devices/SWITCH_1/switch`,
+	RunE: serveAPI,
+}
+
+func init() {
+	serveAPICmd.Flags().String("listen", "127.0.0.1:8080", "address to listen on, e.g. :8080 for all interfaces")
+	serveAPICmd.Flags().Bool("insecure", false, "allow unauthenticated access if no token is configured")
+	serveAPICmd.Flags().String("token", "", "bearer token required from clients, defaults to $"+apiTokenEnv)
+	serveCmd.AddCommand(serveAPICmd)
+}
+
+func serveAPI(cmd *cobra.Command, _ []string) error {
+	addr, err := cmd.Flags().GetString("listen")
+	assertNoErr(err, "cannot parse listen address")
+	token, err := cmd.Flags().GetString("token")
+	assertNoErr(err, "cannot parse token")
+	if token == "" {
+		token = os.Getenv(apiTokenEnv)
+	}
+	insecure, err := cmd.Flags().GetBool("insecure")
+	assertNoErr(err, "cannot parse insecure flag")
+	if token == "" {
+		assertTrue(insecure, fmt.Errorf("no token configured, pass --token or set %s, or allow unauthenticated access with --insecure", apiTokenEnv))
+		logger.Warn("No token configured, the API is accessible without authentication")
+	}
+	api := &apiServer{session: newBoxSession(), token: token}
+	return listenAndServe(&http.Server{Addr: addr, Handler: api.routes()})
+}
+
+type apiServer struct {
+	session *boxSession
+	token   string
+}
+
+func (a *apiServer) routes() http.Handler {
+	r := httprouter.New()
+	r.GET("/devices", a.authorized(a.devices))
+	r.GET("/devices/:name", a.authorized(a.device))
+	r.PUT("/devices/:name/switch", a.authorized(a.switchDevice))
+	r.PUT("/devices/:name/temperature", a.authorized(a.setTemperature))
+	r.GET("/groups", a.authorized(a.groups))
+	r.GET("/landevices", a.authorized(a.lanDevices))
+	r.GET("/calls", a.authorized(a.calls))
+	r.GET("/box", a.authorized(a.box))
+	r.GET("/openapi.json", func(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(openAPIDocument))
+	})
+	return r
+}
+
+// apiError is an error with the HTTP status code to be reported.
+type apiError struct {
+	status int
+	msg    string
+}
+
+// Error returns the message.
+func (e *apiError) Error() string {
+	return e.msg
+}
+
+func (a *apiServer) authorized(h func(r *http.Request, ps httprouter.Params) (interface{}, error)) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		if !a.validToken(r.Header.Get("Authorization")) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "missing or invalid bearer token"})
+			return
+		}
+		body, err := h(r, ps)
+		if err == nil && body == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if err == nil {
+			writeJSON(w, http.StatusOK, body)
+			return
+		}
+		status := http.StatusBadGateway
+		if e, ok := err.(*apiError); ok {
+			status = e.status
+		} else {
+			logger.Warn("Request", r.Method, r.URL.Path, "failed:", err)
+		}
+		writeJSON(w, status, map[string]string{"error": err.Error()})
+	}
+}
+
+func (a *apiServer) validToken(header string) bool {
+	if a.token == "" {
+		return true
+	}
+	const prefix = "Bearer "
+	if !strings.HasPrefix(header, prefix) {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(header, prefix)), []byte(a.token)) == 1
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func (a *apiServer) list() (*fritz.Devicelist, error) {
+	var l *fritz.Devicelist
+	err := a.session.do(func() error {
+		var err error
+		l, err = a.session.homeAuto.List()
+		return err
+	})
+	return l, err
+}
+
+func (a *apiServer) devices(_ *http.Request, _ httprouter.Params) (interface{}, error) {
+	l, err := a.list()
+	if err != nil {
+		return nil, err
+	}
+	return jsonapi.NewMapper().Convert(l.Devices), nil
+}
+
+func (a *apiServer) device(_ *http.Request, ps httprouter.Params) (interface{}, error) {
+	d, err := a.deviceNamed(ps.ByName("name"))
+	if err != nil {
+		return nil, err
+	}
+	return jsonapi.NewMapper().Convert([]fritz.Device{*d}).Devices[0], nil
+}
+
+func (a *apiServer) deviceNamed(name string) (*fritz.Device, error) {
+	l, err := a.list()
+	if err != nil {
+		return nil, err
+	}
+	d, err := deviceWithName(name, l.Devices)
+	if err != nil {
+		return nil, &apiError{status: http.StatusNotFound, msg: err.Error()}
+	}
+	return d, nil
+}
+
+func (a *apiServer) switchDevice(r *http.Request, ps httprouter.Params) (interface{}, error) {
+	var body struct {
+		State string `json:"state"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return nil, &apiError{status: http.StatusBadRequest, msg: "cannot decode request body: " + err.Error()}
+	}
+	actions := map[string]func(names ...string) error{
+		"ON":     a.session.homeAuto.On,
+		"OFF":    a.session.homeAuto.Off,
+		"TOGGLE": a.session.homeAuto.Toggle,
+	}
+	action, ok := actions[strings.ToUpper(body.State)]
+	if !ok {
+		return nil, &apiError{status: http.StatusBadRequest, msg: fmt.Sprintf("invalid state '%s', expected ON, OFF or TOGGLE", body.State)}
+	}
+	d, err := a.deviceNamed(ps.ByName("name"))
+	if err != nil {
+		return nil, err
+	}
+	if !d.IsSwitch() {
+		return nil, &apiError{status: http.StatusBadRequest, msg: fmt.Sprintf("device '%s' is not a switch", d.Name)}
+	}
+	return nil, a.session.do(func() error { return action(d.Name) })
+}
+
+func (a *apiServer) setTemperature(r *http.Request, ps httprouter.Params) (interface{}, error) {
+	var body struct {
+		Goal *float64 `json:"goal"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return nil, &apiError{status: http.StatusBadRequest, msg: "cannot decode request body: " + err.Error()}
+	}
+	if body.Goal == nil {
+		return nil, &apiError{status: http.StatusBadRequest, msg: "goal temperature is missing"}
+	}
+	// Checked upfront to answer with 400 rather than with a failed request to the FRITZ!Box.
+	if err := fritz.ValidateTemperature(*body.Goal); err != nil {
+		return nil, &apiError{status: http.StatusBadRequest, msg: err.Error()}
+	}
+	d, err := a.deviceNamed(ps.ByName("name"))
+	if err != nil {
+		return nil, err
+	}
+	if !d.IsThermostat() {
+		return nil, &apiError{status: http.StatusBadRequest, msg: fmt.Sprintf("device '%s' is not a thermostat", d.Name)}
+	}
+	return nil, a.session.do(func() error { return a.session.homeAuto.Temp(*body.Goal, d.Name) })
+}
+
+func (a *apiServer) groups(_ *http.Request, _ httprouter.Params) (interface{}, error) {
+	l, err := a.list()
+	if err != nil {
+		return nil, err
+	}
+	return jsonapi.ConvertGroups(l), nil
+}
+
+func (a *apiServer) lanDevices(_ *http.Request, _ httprouter.Params) (interface{}, error) {
+	var devs *fritz.LanDevices
+	err := a.session.do(func() error {
+		var err error
+		devs, err = a.session.internal.ListLanDevices()
+		return err
+	})
+	return devs, err
+}
+
+func (a *apiServer) calls(_ *http.Request, _ httprouter.Params) (interface{}, error) {
+	var calls []fritz.Call
+	err := a.session.do(func() error {
+		var err error
+		calls, err = a.session.phone.Calls()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return jsonapi.ConvertCalls(calls), nil
+}
+
+func (a *apiServer) box(_ *http.Request, _ httprouter.Params) (interface{}, error) {
+	var data *fritz.BoxData
+	err := a.session.do(func() error {
+		var err error
+		data, err = a.session.internal.BoxInfo()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return jsonapi.ConvertBox(data), nil
+}
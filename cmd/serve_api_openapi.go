@@ -0,0 +1,216 @@
+package cmd
+
+// openAPIDocument describes the REST API of "fritzctl serve api", see https://spec.openapis.org/oas/v3.0.3.
+const openAPIDocument = `{
+  "openapi": "3.0.3",
+  "info": {
+    "title": "fritzctl API",
+    "description": "REST gateway to the AVM FRITZ!Box, served by fritzctl.",
+    "version": "1.0.0"
+  },
+  "security": [{"bearer": []}],
+  "paths": {
+    "/devices": {
+      "get": {
+        "summary": "List the smart home devices",
+        "responses": {
+          "200": {"description": "The devices.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/DeviceList"}}}},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "502": {"$ref": "#/components/responses/BadGateway"}
+        }
+      }
+    },
+    "/devices/{name}": {
+      "parameters": [{"$ref": "#/components/parameters/name"}],
+      "get": {
+        "summary": "Get a smart home device by name",
+        "responses": {
+          "200": {"description": "The device.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Device"}}}},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "404": {"$ref": "#/components/responses/NotFound"},
+          "502": {"$ref": "#/components/responses/BadGateway"}
+        }
+      }
+    },
+    "/devices/{name}/switch": {
+      "parameters": [{"$ref": "#/components/parameters/name"}],
+      "put": {
+        "summary": "Switch a device on or off, or toggle it",
+        "requestBody": {
+          "required": true,
+          "content": {"application/json": {"schema": {
+            "type": "object",
+            "required": ["state"],
+            "properties": {"state": {"type": "string", "enum": ["ON", "OFF", "TOGGLE"]}}
+          }}}
+        },
+        "responses": {
+          "204": {"description": "The device was switched."},
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "404": {"$ref": "#/components/responses/NotFound"},
+          "502": {"$ref": "#/components/responses/BadGateway"}
+        }
+      }
+    },
+    "/devices/{name}/temperature": {
+      "parameters": [{"$ref": "#/components/parameters/name"}],
+      "put": {
+        "summary": "Set the goal temperature of a thermostat",
+        "requestBody": {
+          "required": true,
+          "content": {"application/json": {"schema": {
+            "type": "object",
+            "required": ["goal"],
+            "properties": {"goal": {"type": "number", "description": "Temperature in °C, 8 to 28 in steps of 0.5. 126.5 turns the thermostat off, 127 on."}}
+          }}}
+        },
+        "responses": {
+          "204": {"description": "The temperature was set."},
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "404": {"$ref": "#/components/responses/NotFound"},
+          "502": {"$ref": "#/components/responses/BadGateway"}
+        }
+      }
+    },
+    "/groups": {
+      "get": {
+        "summary": "List the device groups",
+        "responses": {
+          "200": {"description": "The groups.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/GroupList"}}}},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "502": {"$ref": "#/components/responses/BadGateway"}
+        }
+      }
+    },
+    "/landevices": {
+      "get": {
+        "summary": "List the LAN devices",
+        "responses": {
+          "200": {"description": "The LAN devices, as reported by the FRITZ!Box.", "content": {"application/json": {"schema": {"type": "object"}}}},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "502": {"$ref": "#/components/responses/BadGateway"}
+        }
+      }
+    },
+    "/calls": {
+      "get": {
+        "summary": "List the recent phone calls",
+        "responses": {
+          "200": {"description": "The calls.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/CallList"}}}},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "502": {"$ref": "#/components/responses/BadGateway"}
+        }
+      }
+    },
+    "/box": {
+      "get": {
+        "summary": "Get information about the FRITZ!Box",
+        "responses": {
+          "200": {"description": "Model, firmware and runtime.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Box"}}}},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "502": {"$ref": "#/components/responses/BadGateway"}
+        }
+      }
+    },
+    "/openapi.json": {
+      "get": {
+        "summary": "This document",
+        "security": [],
+        "responses": {"200": {"description": "The OpenAPI document."}}
+      }
+    }
+  },
+  "components": {
+    "securitySchemes": {
+      "bearer": {"type": "http", "scheme": "bearer"}
+    },
+    "parameters": {
+      "name": {"name": "name", "in": "path", "required": true, "description": "Name of the device.", "schema": {"type": "string"}}
+    },
+    "responses": {
+      "BadRequest": {"description": "The request is invalid.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}},
+      "Unauthorized": {"description": "The bearer token is missing or wrong.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}},
+      "NotFound": {"description": "There is no such device.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}},
+      "BadGateway": {"description": "The FRITZ!Box could not be reached or rejected the request.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}}
+    },
+    "schemas": {
+      "Error": {"type": "object", "properties": {"error": {"type": "string"}}},
+      "DeviceList": {"type": "object", "properties": {
+        "numberOfItems": {"type": "integer"},
+        "devices": {"type": "array", "items": {"$ref": "#/components/schemas/Device"}}
+      }},
+      "Device": {"type": "object", "properties": {
+        "id": {"type": "string", "description": "AIN of the device."},
+        "internalId": {"type": "string"},
+        "name": {"type": "string"},
+        "properties": {"type": "object", "properties": {
+          "vendor": {"type": "object", "properties": {"manufacturer": {"type": "string"}, "productName": {"type": "string"}, "firmwareVersion": {"type": "string"}}},
+          "lock": {"type": "object", "properties": {"hwLock": {"type": "string"}, "swLock": {"type": "string"}}},
+          "warnings": {"type": "array", "items": {"type": "string"}}
+        }},
+        "measurements": {"type": "object", "properties": {
+          "temperature": {"type": "string"},
+          "temperatureUnit": {"type": "string", "enum": ["celsius"]},
+          "powerConsumption": {"type": "string"},
+          "energyConsumption": {"type": "string"},
+          "alertSignal": {"type": "string", "enum": ["ON", "OFF"]},
+          "buttonLastPressed": {"type": "string", "format": "date-time"}
+        }},
+        "state": {"$ref": "#/components/schemas/State"}
+      }},
+      "State": {"type": "object", "properties": {
+        "connected": {"type": "boolean"},
+        "switch": {"type": "string", "enum": ["ON", "OFF"]},
+        "temperatureControl": {"type": "object", "properties": {
+          "goal": {"type": "string"},
+          "saving": {"type": "string"},
+          "comfort": {"type": "string"},
+          "nextChange": {"type": "object", "properties": {"at": {"type": "string", "format": "date-time"}, "goal": {"type": "string"}}},
+          "window": {"type": "string", "enum": ["OPEN", "CLOSED"]},
+          "unit": {"type": "string", "enum": ["celsius"]}
+        }},
+        "batteryState": {"type": "string", "enum": ["OK", "LOW"]},
+        "batteryChargeLevel": {"type": "string"}
+      }},
+      "GroupList": {"type": "object", "properties": {
+        "numberOfItems": {"type": "integer"},
+        "groups": {"type": "array", "items": {"type": "object", "properties": {
+          "id": {"type": "string"},
+          "internalId": {"type": "string"},
+          "name": {"type": "string"},
+          "members": {"type": "array", "items": {"type": "string"}},
+          "master": {"type": "string"},
+          "state": {"$ref": "#/components/schemas/State"}
+        }}}
+      }},
+      "CallList": {"type": "object", "properties": {
+        "numberOfItems": {"type": "integer"},
+        "calls": {"type": "array", "items": {"type": "object", "properties": {
+          "type": {"type": "string", "enum": ["INCOMING", "MISSED", "REJECTED", "OUTGOING"]},
+          "date": {"type": "string"},
+          "caller": {"type": "string"},
+          "phoneNumber": {"type": "string"},
+          "extension": {"type": "string"},
+          "ownPhoneNumber": {"type": "string"},
+          "duration": {"type": "string"}
+        }}}
+      }},
+      "Box": {"type": "object", "properties": {
+        "model": {"type": "string"},
+        "annex": {"type": "string"},
+        "branding": {"type": "string"},
+        "firmware": {"type": "string"},
+        "runtime": {"type": "object", "properties": {
+          "hours": {"type": "integer"},
+          "days": {"type": "integer"},
+          "months": {"type": "integer"},
+          "years": {"type": "integer"},
+          "reboots": {"type": "integer"}
+        }}
+      }}
+    }
+  }
+}
+`
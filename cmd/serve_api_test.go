@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/bpicode/fritzctl/config"
+	"github.com/bpicode/fritzctl/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAPIServer runs requests against the API backed by the mock.
+func TestAPIServer(t *testing.T) {
+	oldPlaces := defaultConfigPlaces
+	defer func() { defaultConfigPlaces = oldPlaces }()
+	defaultConfigPlaces = append([]config.Place{config.InDir("../testdata/config", "config_localhost_http_test.json", config.JSON())}, defaultConfigPlaces...)
+	srv := mock.New().UnstartedServer()
+	var err error
+	srv.Listener, err = net.Listen("tcp", ":61666")
+	assert.NoError(t, err)
+	srv.Start()
+	defer srv.Close()
+
+	api := httptest.NewServer((&apiServer{session: newBoxSession(), token: "secret"}).routes())
+	defer api.Close()
+
+	tests := []struct {
+		method   string
+		path     string
+		token    string
+		body     string
+		status   int
+		contains string
+	}{
+		{method: "GET", path: "/devices", token: "wrong", status: 401},
+		{method: "GET", path: "/devices", status: 401},
+		{method: "GET", path: "/openapi.json", status: 200, contains: `"openapi": "3.0.3"`},
+		{method: "GET", path: "/devices", token: "secret", status: 200, contains: `"name":"SWITCH_1"`},
+		{method: "GET", path: "/devices/HKR_1", token: "secret", status: 200, contains: `"temperatureControl"`},
+		{method: "GET", path: "/devices/NOPE", token: "secret", status: 404, contains: "not found"},
+		{method: "PUT", path: "/devices/SWITCH_1/switch", token: "secret", body: `{"state":"on"}`, status: 204},
+		{method: "PUT", path: "/devices/SWITCH_1/switch", token: "secret", body: `{"state":"dim"}`, status: 400},
+		{method: "PUT", path: "/devices/SWITCH_1/switch", token: "secret", body: `not json`, status: 400},
+		{method: "PUT", path: "/devices/HKR_1/switch", token: "secret", body: `{"state":"OFF"}`, status: 400, contains: "not a switch"},
+		{method: "PUT", path: "/devices/HKR_1/temperature", token: "secret", body: `{"goal":21.5}`, status: 204},
+		{method: "PUT", path: "/devices/HKR_1/temperature", token: "secret", body: `{"goal":35}`, status: 400, contains: "invalid temperature value: 35.0°C"},
+		{method: "PUT", path: "/devices/HKR_1/temperature", token: "secret", body: `{}`, status: 400, contains: "goal temperature is missing"},
+		{method: "PUT", path: "/devices/SWITCH_1/temperature", token: "secret", body: `{"goal":20}`, status: 400, contains: "not a thermostat"},
+		{method: "GET", path: "/groups", token: "secret", status: 200, contains: `"members":[`},
+		{method: "GET", path: "/landevices", token: "secret", status: 200, contains: `"network"`},
+		{method: "GET", path: "/calls", token: "secret", status: 200, contains: `"calls"`},
+		{method: "GET", path: "/box", token: "secret", status: 200, contains: `"model":"FRITZ!Box 7490"`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, api.URL+tc.path, strings.NewReader(tc.body))
+			assert.NoError(t, err)
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			body, err := ioutil.ReadAll(resp.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.status, resp.StatusCode, string(body))
+			assert.Contains(t, string(body), tc.contains)
+		})
+	}
+}
+
+// TestServeAPIRequiresToken tests that the server does not start without a token unless --insecure is given.
+func TestServeAPIRequiresToken(t *testing.T) {
+	defer os.Setenv(apiTokenEnv, os.Getenv(apiTokenEnv))
+	assert.NoError(t, os.Unsetenv(apiTokenEnv))
+	assert.PanicsWithError(t, "no token configured, pass --token or set FRITZCTL_API_TOKEN, or allow unauthenticated access with --insecure", func() {
+		serveAPI(serveAPICmd, nil)
+	})
+	assert.Equal(t, "127.0.0.1:8080", serveAPICmd.Flag("listen").DefValue)
+}
+
+// TestOpenAPIDocumentIsValidJSON ensures that the document can be parsed.
+func TestOpenAPIDocumentIsValidJSON(t *testing.T) {
+	var doc map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(openAPIDocument), &doc))
+	assert.Contains(t, doc["paths"], "/devices/{name}/switch")
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bpicode/fritzctl/buttons"
+	"github.com/bpicode/fritzctl/fritz"
+	"github.com/bpicode/fritzctl/logger"
+	"github.com/spf13/cobra"
+)
+
+var serveButtonsCmd = &cobra.Command{
+	Use:   "buttons",
+	Short: "Run actions when smart home buttons are pressed",
+	Long: "Poll the buttons periodically and run the actions bound to them in a YAML file whenever a new press is " +
+		"detected. Devices with several buttons, like the FRITZ!DECT 440, or with separate short and long presses, " +
+		"like the FRITZ!DECT 400, report one unit per button, which is selected by 'unit' (AIN, name or name suffix) " +
+		"or 'press' (short, long). " +
+		"Actions switch devices (on, off, toggle), set temperatures (temperature), apply a manifest (manifest), " +
+		"run a shell command (shell) or call a webhook with a JSON description of the press (webhook). " +
+		"Shell commands see the press in the environment variables FRITZCTL_BINDING, FRITZCTL_BUTTON, " +
+		"FRITZCTL_BUTTON_AIN, FRITZCTL_BUTTON_UNIT, FRITZCTL_PRESS and FRITZCTL_PRESSED_AT. " +
+		"The last seen press of every unit is persisted in a state file before the actions run, so a press is " +
+		"handled at most once, even across restarts. Presses older than 'maxage' when detected are ignored.",
+	Example: `fritzctl serve buttons --bindings=buttons.yml
+fritzctl serve buttons --bindings=buttons.yml --interval=2s --state=/var/lib/fritzctl/buttons.json
+
+buttons.yml:
+  maxage: 2m
+  bindings:
+    - name: hallway-light
+      button: BTN_1
+      action: toggle
+      devices: [SWITCH_1]
+    - name: good-night
+      button: DECT 400
+      press: long
+      action: manifest
+      manifest: /etc/fritzctl/night.yml
+    - name: doorbell
+      button: DECT 440
+      unit: Oben rechts
+      action: webhook
+      url: https://example.com/hooks/doorbell
+    - name: backup
+      button: DECT 440
+      unit: Unten links
+      action: shell
+      command: echo "$FRITZCTL_BUTTON pressed" >> /tmp/presses.log`,
+	RunE: serveButtons,
+}
+
+func init() {
+	serveButtonsCmd.Flags().String("bindings", "", "YAML file binding actions to buttons")
+	serveButtonsCmd.Flags().String("state", "", "file recording the last seen presses, defaults to the bindings file with suffix .state.json")
+	serveButtonsCmd.Flags().Duration("interval", 5*time.Second, "time between two subsequent polls of the FRITZ!Box")
+	serveButtonsCmd.Flags().Bool("dry-run", false, "log the actions instead of executing them, do not write the state file")
+	serveCmd.AddCommand(serveButtonsCmd)
+}
+
+func serveButtons(cmd *cobra.Command, _ []string) error {
+	bindingsFile := cmd.Flag("bindings").Value.String()
+	assertTrue(bindingsFile != "", fmt.Errorf("no bindings file given, use --bindings"))
+	stateFile := cmd.Flag("state").Value.String()
+	if stateFile == "" {
+		stateFile = bindingsFile + ".state.json"
+	}
+	interval, err := cmd.Flags().GetDuration("interval")
+	assertNoErr(err, "cannot parse interval")
+	assertTrue(interval > 0, fmt.Errorf("interval must be positive, got %s", interval))
+	dryRun, err := cmd.Flags().GetBool("dry-run")
+	assertNoErr(err, "cannot parse dry-run flag")
+
+	bs, err := buttons.ParseFile(bindingsFile)
+	assertNoErr(err, "cannot parse bindings file")
+	state, err := buttons.LoadState(stateFile)
+	assertNoErr(err, "cannot read state file")
+	h := homeAutoClient()
+	handler := &buttonHandler{
+		bindings: bs.Bindings,
+		detector: buttons.NewDetector(state, bs.MaxAge),
+		execute:  bindingExecutor(h, dryRun),
+		save: func(s buttons.State) error {
+			if dryRun {
+				return nil
+			}
+			return s.Save(stateFile)
+		},
+	}
+	ctx, cancel := interruptContext()
+	defer cancel()
+	logger.Info("Watching buttons for", len(bs.Bindings), "bindings")
+	w := fritz.NewWatcher(h, fritz.PollInterval(interval), fritz.OnList(handler.handle))
+	emitAll(w.Watch(ctx), func(fritz.Event) {})
+	return nil
+}
+
+func bindingExecutor(h fritz.HomeAuto, dryRun bool) func(buttons.Binding, buttons.Press, *fritz.Devicelist) error {
+	if dryRun {
+		return func(b buttons.Binding, _ buttons.Press, _ *fritz.Devicelist) error {
+			logger.Info("Dry run, skipping", b)
+			return nil
+		}
+	}
+	e := buttons.NewExecutor(h)
+	return func(b buttons.Binding, p buttons.Press, l *fritz.Devicelist) error {
+		logger.Info("Running", b)
+		return e.Execute(b, p, l)
+	}
+}
+
+// buttonHandler detects the presses in every polled device list and runs the matching bindings.
+type buttonHandler struct {
+	bindings []buttons.Binding
+	detector *buttons.Detector
+	execute  func(buttons.Binding, buttons.Press, *fritz.Devicelist) error
+	save     func(buttons.State) error
+}
+
+// handle saves the state before running any action. A crash while acting thus loses the press instead of repeating
+// it after the restart.
+func (h *buttonHandler) handle(l *fritz.Devicelist) {
+	presses, changed := h.detector.Detect(l, time.Now())
+	if changed {
+		if err := h.save(h.detector.State()); err != nil {
+			logger.Warn("Cannot write state file:", err)
+		}
+	}
+	for _, p := range presses {
+		logger.Info(p)
+		for _, b := range h.bindings {
+			if !b.Matches(p) {
+				continue
+			}
+			if err := h.execute(b, p, l); err != nil {
+				logger.Warn("Binding", b.Name, "failed:", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bpicode/fritzctl/buttons"
+	"github.com/bpicode/fritzctl/fritz"
+	"github.com/bpicode/fritzctl/fritz/fritztest"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestButtonHandler runs the matching bindings of new presses and saves the state before.
+func TestButtonHandler(t *testing.T) {
+	bs, err := buttons.ParseFile("../testdata/button_bindings.yml")
+	assert.NoError(t, err)
+	h := &fritztest.HomeAuto{}
+	var saved []buttons.State
+	handler := &buttonHandler{
+		bindings: bs.Bindings,
+		detector: buttons.NewDetector(buttons.State{"214124 34625478542353#19": time.Now().Add(-time.Hour).Unix()}, bs.MaxAge),
+		execute:  bindingExecutor(h, false),
+		save: func(s buttons.State) error {
+			assert.Empty(t, h.Commands(), "state is saved before acting")
+			saved = append(saved, s)
+			return nil
+		},
+	}
+	pressed := strconv.FormatInt(time.Now().Add(-10*time.Second).Unix(), 10)
+	l := &fritz.Devicelist{Devices: []fritz.Device{
+		{Identifier: "214124 34625478542353", ID: "19", Name: "BTN_1", Button: fritz.Button{LastPressedTimestamp: pressed}},
+		{Identifier: "12324 2131421", ID: "16", Name: "SWITCH_1"},
+	}}
+	handler.handle(l)
+	handler.handle(l)
+	assert.Equal(t, []string{"toggle [SWITCH_1]", "temp 21 [HKR_1]"}, h.Commands())
+	assert.Len(t, saved, 1)
+}
+
+// TestBindingExecutorDryRun tests that dry runs do not act.
+func TestBindingExecutorDryRun(t *testing.T) {
+	h := &fritztest.HomeAuto{}
+	err := bindingExecutor(h, true)(buttons.Binding{Name: "a", Action: "on", Devices: []string{"SWITCH_1"}}, buttons.Press{}, &fritz.Devicelist{})
+	assert.NoError(t, err)
+	assert.Empty(t, h.Commands())
+}
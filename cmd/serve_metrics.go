@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bpicode/fritzctl/cmd/metrics"
+	"github.com/bpicode/fritzctl/logger"
+	"github.com/spf13/cobra"
+)
+
+var serveMetricsCmd = &cobra.Command{
+	Use:   "metrics",
+	Short: "Expose device and FRITZ!Box metrics to Prometheus",
+	Long: "Start an HTTP server exposing the smart home device measurements, the current internet traffic rates " +
+		"and the FRITZ!Box runtime on /metrics in the Prometheus text format. " +
+		"The data is obtained from the FRITZ!Box on every scrape, the login session is kept between scrapes.",
+	Example: `fritzctl serve metrics
+fritzctl serve metrics --listen=:9713
+fritzctl serve metrics --listen=127.0.0.1:9713`,
+	RunE: serveMetrics,
+}
+
+func init() {
+	serveMetricsCmd.Flags().String("listen", ":9713", "address to listen on")
+	serveCmd.AddCommand(serveMetricsCmd)
+}
+
+func serveMetrics(cmd *cobra.Command, _ []string) error {
+	addr, err := cmd.Flags().GetString("listen")
+	assertNoErr(err, "cannot parse listen address")
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", newMetricsCollector())
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><body><a href="/metrics">Metrics</a></body></html>`))
+	})
+	return listenAndServe(&http.Server{Addr: addr, Handler: mux})
+}
+
+// metricsCollector obtains the metrics from the FRITZ!Box on every request, reusing one session.
+type metricsCollector struct {
+	mu            sync.Mutex
+	session       *boxSession
+	scrapes       float64
+	scrapeSeconds float64
+}
+
+func newMetricsCollector() *metricsCollector {
+	return &metricsCollector{session: newBoxSession()}
+}
+
+// ServeHTTP collects the metrics and writes them in the Prometheus text format.
+func (c *metricsCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	start := time.Now()
+	samples := c.collect()
+	c.scrapes++
+	c.scrapeSeconds += time.Since(start).Seconds()
+	samples = append(samples,
+		metrics.Sample{Name: "scrapes_total", Help: "Number of scrapes.", Type: metrics.Counter, Value: c.scrapes},
+		metrics.Sample{Name: "scrape_duration_seconds_total", Help: "Time spent obtaining data from the FRITZ!Box.", Type: metrics.Counter, Value: c.scrapeSeconds},
+		metrics.Sample{Name: "login_failures_total", Help: "Number of failed logins.", Type: metrics.Counter, Value: float64(c.session.failedLogins())},
+	)
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := metrics.WritePrometheus(w, "fritzctl", samples); err != nil {
+		logger.Warn("Writing metrics failed:", err)
+	}
+}
+
+func (c *metricsCollector) collect() []metrics.Sample {
+	var samples, up []metrics.Sample
+	sources := []struct {
+		name string
+		get  func() ([]metrics.Sample, error)
+	}{
+		{name: "devices", get: c.devices},
+		{name: "traffic", get: c.traffic},
+		{name: "box", get: c.box},
+	}
+	for _, s := range sources {
+		var ss []metrics.Sample
+		err := c.session.do(func() error {
+			var err error
+			ss, err = s.get()
+			return err
+		})
+		value := 1.0
+		if err != nil {
+			logger.Warn("Collecting", s.name, "metrics failed:", err)
+			value = 0
+		}
+		samples = append(samples, ss...)
+		up = append(up, metrics.Sample{Name: "up", Help: "Whether the last scrape of the source succeeded (1) or not (0).", Type: metrics.Gauge, Labels: []metrics.Label{{Name: "source", Value: s.name}}, Value: value})
+	}
+	return append(up, samples...)
+}
+
+func (c *metricsCollector) devices() ([]metrics.Sample, error) {
+	l, err := c.session.homeAuto.List()
+	if err != nil {
+		return nil, err
+	}
+	return metrics.FromDevices(l.Devices), nil
+}
+
+func (c *metricsCollector) traffic() ([]metrics.Sample, error) {
+	data, err := c.session.internal.InternetStats()
+	if err != nil {
+		return nil, err
+	}
+	return metrics.FromTraffic(data), nil
+}
+
+func (c *metricsCollector) box() ([]metrics.Sample, error) {
+	data, err := c.session.internal.BoxInfo()
+	if err != nil {
+		return nil, err
+	}
+	return metrics.FromBoxData(data), nil
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"net"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bpicode/fritzctl/config"
+	"github.com/bpicode/fritzctl/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMetricsCollector scrapes the mock twice, reusing the session.
+func TestMetricsCollector(t *testing.T) {
+	oldPlaces := defaultConfigPlaces
+	defer func() { defaultConfigPlaces = oldPlaces }()
+	defaultConfigPlaces = append([]config.Place{config.InDir("../testdata/config", "config_localhost_http_test.json", config.JSON())}, defaultConfigPlaces...)
+	srv := mock.New().UnstartedServer()
+	var err error
+	srv.Listener, err = net.Listen("tcp", ":61666")
+	assert.NoError(t, err)
+	srv.Start()
+	defer srv.Close()
+
+	c := newMetricsCollector()
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		c.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+		assert.Equal(t, 200, rec.Code)
+		body := rec.Body.String()
+		assert.Contains(t, body, `fritzctl_up{source="devices"} 1`)
+		assert.Contains(t, body, `fritzctl_up{source="traffic"} 1`)
+		assert.Contains(t, body, `fritzctl_up{source="box"} 1`)
+		assert.Contains(t, body, `fritzctl_switch_state{name="SWITCH_1"`)
+		assert.Contains(t, body, "fritzctl_box_runtime_hours{")
+		assert.Contains(t, body, "fritzctl_login_failures_total 0")
+	}
+	assert.Equal(t, 2.0, c.scrapes)
+}
+
+// TestMetricsCollectorLoginFailure tests that failed logins are counted.
+func TestMetricsCollectorLoginFailure(t *testing.T) {
+	oldPlaces := defaultConfigPlaces
+	defer func() { defaultConfigPlaces = oldPlaces }()
+	defaultConfigPlaces = append([]config.Place{config.InDir("../testdata/config", "config_localhost_http_test.json", config.JSON())}, defaultConfigPlaces...)
+
+	c := newMetricsCollector()
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Contains(t, rec.Body.String(), `fritzctl_up{source="devices"} 0`)
+	assert.Contains(t, rec.Body.String(), "fritzctl_login_failures_total 3")
+}
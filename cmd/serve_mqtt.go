@@ -0,0 +1,389 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bpicode/fritzctl/fritz"
+	"github.com/bpicode/fritzctl/internal/mqtt"
+	"github.com/bpicode/fritzctl/logger"
+	"github.com/spf13/cobra"
+)
+
+var serveMqttCmd = &cobra.Command{
+	Use:   "mqtt",
+	Short: "Bridge the smart home devices to an MQTT broker",
+	Long: "Poll the FRITZ!Box periodically and publish the state of the smart home devices to an MQTT broker as " +
+		"retained messages on topics <prefix>/<ain>/<attribute>, e.g. fritzctl/087610000434/state or " +
+		"fritzctl/087610000434/temperature. Spaces are removed from the AIN. " +
+		"Switches are controlled by publishing ON, OFF or TOGGLE to <prefix>/<ain>/set, " +
+		"the goal temperature of thermostats is set by publishing a number to <prefix>/<ain>/goal/set. " +
+		"Home Assistant discovery configs are published for every device below the discovery prefix. " +
+		"Connections to the broker and the FRITZ!Box are reestablished if they are lost.",
+	Example: `fritzctl serve mqtt
+fritzctl serve mqtt --broker=tcp://broker.local:1883 --username=fritz --password=secret
+fritzctl serve mqtt --broker=tls://broker.local:8883 --interval=30s --topic-prefix=home/fritz`,
+	RunE: serveMqtt,
+}
+
+func init() {
+	serveMqttCmd.Flags().String("broker", "tcp://localhost:1883", "address of the MQTT broker")
+	serveMqttCmd.Flags().String("username", "", "username for the MQTT broker")
+	serveMqttCmd.Flags().String("password", "", "password for the MQTT broker")
+	serveMqttCmd.Flags().String("client-id", "fritzctl", "MQTT client identifier")
+	serveMqttCmd.Flags().String("topic-prefix", "fritzctl", "prefix of the state and command topics")
+	serveMqttCmd.Flags().String("discovery-prefix", "homeassistant", "prefix of the Home Assistant discovery topics, empty to disable discovery")
+	serveMqttCmd.Flags().Duration("interval", defaultWatchInterval, "time between two subsequent polls of the FRITZ!Box")
+	serveCmd.AddCommand(serveMqttCmd)
+}
+
+func serveMqtt(cmd *cobra.Command, _ []string) error {
+	flag := func(name string) string {
+		return cmd.Flag(name).Value.String()
+	}
+	interval, err := cmd.Flags().GetDuration("interval")
+	assertNoErr(err, "cannot parse interval")
+	assertTrue(interval > 0, fmt.Errorf("interval must be positive, got %s", interval))
+	assertTrue(flag("password") == "" || flag("username") != "", fmt.Errorf("a password for the MQTT broker requires a username"))
+	b := newMqttBridge(fritz.NewHomeAuto(optsFromPlaces(defaultConfigPlaces...)...), flag("topic-prefix"), flag("discovery-prefix"))
+	opts := mqtt.Options{
+		ClientID: flag("client-id"),
+		Username: flag("username"),
+		Password: flag("password"),
+		Will:     &mqtt.Message{Topic: b.statusTopic(), Payload: []byte("offline"), Retain: true},
+	}
+	ctx, cancel := interruptContext()
+	defer cancel()
+	b.serve(ctx, func() (*mqtt.Client, error) { return mqtt.Dial(flag("broker"), opts) }, interval)
+	return nil
+}
+
+// mqttBridge publishes the device states and executes the commands received from the broker.
+// codebeat:disable[TOO_MANY_IVARS]
+type mqttBridge struct {
+	homeAuto  fritz.HomeAuto
+	box       sync.Mutex
+	prefix    string
+	discovery string
+	backoff   time.Duration
+	mu        sync.Mutex
+	client    *mqtt.Client
+	names     map[string]string
+	published map[string]string
+}
+
+// codebeat:enable[TOO_MANY_IVARS]
+
+func newMqttBridge(h fritz.HomeAuto, prefix, discovery string) *mqttBridge {
+	return &mqttBridge{
+		homeAuto:  h,
+		prefix:    prefix,
+		discovery: discovery,
+		backoff:   time.Second,
+		names:     make(map[string]string),
+	}
+}
+
+// serve keeps connecting to the broker until ctx is done. Reconnection attempts are delayed exponentially, up to one
+// minute.
+func (b *mqttBridge) serve(ctx context.Context, dial func() (*mqtt.Client, error), interval time.Duration) {
+	backoff := b.backoff
+	for {
+		client, err := dial()
+		if err == nil {
+			logger.Info("Connected to MQTT broker")
+			backoff = b.backoff
+			err = b.run(ctx, client, interval)
+			if ctx.Err() != nil {
+				// The broker discards the will on a clean disconnect, so the bridge goes offline explicitly.
+				b.publish(b.statusTopic(), "offline")
+			}
+			client.Close()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		logger.Warn("MQTT connection failed:", err, "- retrying in", backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > time.Minute {
+			backoff = time.Minute
+		}
+	}
+}
+
+// run publishes the device states and handles commands until the connection is lost or ctx is done.
+func (b *mqttBridge) run(ctx context.Context, client *mqtt.Client, interval time.Duration) error {
+	b.mu.Lock()
+	b.client = client
+	b.published = make(map[string]string)
+	b.mu.Unlock()
+	b.publish(b.statusTopic(), "online")
+	if err := client.Subscribe(b.prefix+"/+/set", b.onCommand(b.switchCommand)); err != nil {
+		return err
+	}
+	if err := client.Subscribe(b.prefix+"/+/goal/set", b.onCommand(b.goalCommand)); err != nil {
+		return err
+	}
+	wctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	h := &lockedHomeAuto{HomeAuto: b.homeAuto, mu: &b.box}
+	events := fritz.NewWatcher(h, fritz.PollInterval(interval), fritz.OnList(b.publishList)).Watch(wctx)
+	for {
+		select {
+		case <-client.Done():
+			return client.Err()
+		case _, ok := <-events:
+			if !ok {
+				return nil
+			}
+		}
+	}
+}
+
+func (b *mqttBridge) statusTopic() string {
+	return b.prefix + "/status"
+}
+
+func (b *mqttBridge) topic(d fritz.Device, attribute string) string {
+	return b.prefix + "/" + mqttID(d) + "/" + attribute
+}
+
+func mqttID(d fritz.Device) string {
+	return strings.Replace(d.Identifier, " ", "", -1)
+}
+
+// publish sends a retained message, unless the same payload was already published on the current connection.
+func (b *mqttBridge) publish(topic, payload string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if p, ok := b.published[topic]; ok && p == payload {
+		return
+	}
+	if err := b.client.Publish(mqtt.Message{Topic: topic, Payload: []byte(payload), Retain: true}); err != nil {
+		logger.Warn("Publishing to", topic, "failed:", err)
+		return
+	}
+	b.published[topic] = payload
+}
+
+func (b *mqttBridge) publishList(l *fritz.Devicelist) {
+	for _, d := range l.Devices {
+		b.mu.Lock()
+		b.names[mqttID(d)] = d.Name
+		b.mu.Unlock()
+		for attribute, value := range mqttState(d) {
+			b.publish(b.topic(d, attribute), value)
+		}
+		if b.discovery == "" {
+			continue
+		}
+		for topic, config := range b.discoveryConfigs(d) {
+			bs, err := json.Marshal(config)
+			assertNoErr(err, "cannot encode discovery config")
+			b.publish(topic, string(bs))
+		}
+	}
+}
+
+func mqttState(d fritz.Device) map[string]string {
+	state := map[string]string{"available": map[int]string{0: "offline", 1: "online"}[d.Present]}
+	put := func(attribute, value string) {
+		if value != "" {
+			state[attribute] = value
+		}
+	}
+	if d.IsSwitch() {
+		put("state", onOff(d.Switch.State))
+	}
+	if d.CanMeasurePower() {
+		put("power", d.Powermeter.FmtPowerW())
+		put("energy", d.Powermeter.FmtEnergyWh())
+	}
+	if d.CanMeasureTemp() {
+		put("temperature", d.Temperature.FmtCelsius())
+	}
+	if d.IsThermostat() {
+		put("measured", numeric(d.Thermostat.FmtMeasuredTemperature()))
+		put("goal", numeric(d.Thermostat.FmtGoalTemperature()))
+		put("battery", d.Thermostat.BatteryChargeLevel)
+		put("battery_low", onOff(d.Thermostat.BatteryLow))
+		put("window", onOff(d.Thermostat.WindowOpen))
+	}
+	if d.HasAlertSensor() {
+		put("alert", onOff(d.AlertSensor.State))
+	}
+	return state
+}
+
+func onOff(s string) string {
+	return map[string]string{"0": "OFF", "1": "ON"}[s]
+}
+
+func numeric(s string) string {
+	if _, err := strconv.ParseFloat(s, 64); err != nil {
+		return ""
+	}
+	return s
+}
+
+// haConfig is a Home Assistant MQTT discovery config, see https://www.home-assistant.io/docs/mqtt/discovery/.
+// codebeat:disable[TOO_MANY_IVARS]
+type haConfig struct {
+	Name                    string   `json:"name"`
+	UniqueID                string   `json:"unique_id"`
+	AvailabilityTopic       string   `json:"availability_topic"`
+	StateTopic              string   `json:"state_topic,omitempty"`
+	CommandTopic            string   `json:"command_topic,omitempty"`
+	DeviceClass             string   `json:"device_class,omitempty"`
+	UnitOfMeasurement       string   `json:"unit_of_measurement,omitempty"`
+	CurrentTemperatureTopic string   `json:"current_temperature_topic,omitempty"`
+	TemperatureStateTopic   string   `json:"temperature_state_topic,omitempty"`
+	TemperatureCommandTopic string   `json:"temperature_command_topic,omitempty"`
+	MinTemp                 float64  `json:"min_temp,omitempty"`
+	MaxTemp                 float64  `json:"max_temp,omitempty"`
+	TempStep                float64  `json:"temp_step,omitempty"`
+	Modes                   []string `json:"modes,omitempty"`
+	Device                  haDevice `json:"device"`
+}
+
+// codebeat:enable[TOO_MANY_IVARS]
+
+type haDevice struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer,omitempty"`
+	Model        string   `json:"model,omitempty"`
+	SwVersion    string   `json:"sw_version,omitempty"`
+}
+
+// discoveryConfigs returns the configs of the Home Assistant entities of the device, by topic.
+func (b *mqttBridge) discoveryConfigs(d fritz.Device) map[string]haConfig {
+	configs := make(map[string]haConfig)
+	id := mqttID(d)
+	add := func(component, attribute, name string, c haConfig) {
+		c.Name = strings.TrimSpace(d.Name + " " + name)
+		c.UniqueID = "fritzctl_" + id + "_" + attribute
+		c.AvailabilityTopic = b.topic(d, "available")
+		c.Device = haDevice{Identifiers: []string{"fritzctl_" + id}, Name: d.Name, Manufacturer: d.Manufacturer, Model: d.Productname, SwVersion: d.Fwversion}
+		configs[b.discovery+"/"+component+"/fritzctl/"+id+"_"+attribute+"/config"] = c
+	}
+	sensor := func(attribute, name, class, unit string) {
+		add("sensor", attribute, name, haConfig{StateTopic: b.topic(d, attribute), DeviceClass: class, UnitOfMeasurement: unit})
+	}
+	binarySensor := func(attribute, name, class string) {
+		add("binary_sensor", attribute, name, haConfig{StateTopic: b.topic(d, attribute), DeviceClass: class})
+	}
+	if d.IsSwitch() {
+		add("switch", "state", "", haConfig{StateTopic: b.topic(d, "state"), CommandTopic: b.topic(d, "set")})
+	}
+	if d.CanMeasurePower() {
+		sensor("power", "power", "power", "W")
+		sensor("energy", "energy", "energy", "Wh")
+	}
+	if d.CanMeasureTemp() {
+		sensor("temperature", "temperature", "temperature", "°C")
+	}
+	if d.IsThermostat() {
+		add("climate", "thermostat", "", haConfig{
+			CurrentTemperatureTopic: b.topic(d, "measured"),
+			TemperatureStateTopic:   b.topic(d, "goal"),
+			TemperatureCommandTopic: b.topic(d, "goal/set"),
+			MinTemp:                 8,
+			MaxTemp:                 28,
+			TempStep:                0.5,
+			Modes:                   []string{"heat"},
+		})
+		sensor("battery", "battery", "battery", "%")
+		binarySensor("battery_low", "battery low", "battery")
+		binarySensor("window", "window", "window")
+	}
+	if d.HasAlertSensor() {
+		binarySensor("alert", "alert", "problem")
+	}
+	return configs
+}
+
+// onCommand adapts a command to a message handler. The command is executed in the background, so the connection is
+// not blocked while the FRITZ!Box is contacted. Commands are serialized with the polls of the device list.
+func (b *mqttBridge) onCommand(command func(name, payload string) error) func(mqtt.Message) {
+	return func(m mqtt.Message) {
+		id := strings.Split(strings.TrimPrefix(m.Topic, b.prefix+"/"), "/")[0]
+		b.mu.Lock()
+		name, ok := b.names[id]
+		b.mu.Unlock()
+		if !ok {
+			logger.Warn("Ignoring command for unknown device", id)
+			return
+		}
+		go func() {
+			err := command(name, strings.TrimSpace(string(m.Payload)))
+			if err != nil {
+				logger.Warn("Command", m.Topic, "failed:", err)
+			}
+		}()
+	}
+}
+
+func (b *mqttBridge) switchCommand(name, payload string) error {
+	switch strings.ToUpper(payload) {
+	case "ON":
+		return b.withLogin(func() error { return b.homeAuto.On(name) })
+	case "OFF":
+		return b.withLogin(func() error { return b.homeAuto.Off(name) })
+	case "TOGGLE":
+		return b.withLogin(func() error { return b.homeAuto.Toggle(name) })
+	}
+	return fmt.Errorf("unknown switch command '%s'", payload)
+}
+
+func (b *mqttBridge) goalCommand(name, payload string) error {
+	value, err := strconv.ParseFloat(payload, 64)
+	if err != nil {
+		return fmt.Errorf("cannot parse temperature '%s'", payload)
+	}
+	return b.withLogin(func() error { return b.homeAuto.Temp(value, name) })
+}
+
+// withLogin runs f and retries it once after a new login if the FRITZ!Box rejected the session, as it may have expired.
+// Other failures are not retried, f may have taken effect partially.
+func (b *mqttBridge) withLogin(f func() error) error {
+	b.box.Lock()
+	defer b.box.Unlock()
+	err := f()
+	if !fritz.IsSessionError(err) {
+		return err
+	}
+	if err := b.homeAuto.Login(); err != nil {
+		return err
+	}
+	return f()
+}
+
+// lockedHomeAuto serializes the calls a Watcher makes with the other users of the mutex.
+type lockedHomeAuto struct {
+	fritz.HomeAuto
+	mu *sync.Mutex
+}
+
+// Login logs in while holding the lock.
+func (h *lockedHomeAuto) Login() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.HomeAuto.Login()
+}
+
+// List lists the devices while holding the lock.
+func (h *lockedHomeAuto) List() (*fritz.Devicelist, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.HomeAuto.List()
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bpicode/fritzctl/fritz"
+	"github.com/bpicode/fritzctl/fritz/fritztest"
+	"github.com/bpicode/fritzctl/httpread"
+	"github.com/bpicode/fritzctl/internal/errors"
+	"github.com/bpicode/fritzctl/internal/mqtt"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMqttBridge runs the bridge against the in-process broker, including commands and a reconnect.
+func TestMqttBridge(t *testing.T) {
+	broker, err := mqtt.NewBroker("127.0.0.1:0")
+	assert.NoError(t, err)
+	defer broker.Close()
+	h := &fritztest.HomeAuto{Devices: []fritz.Device{
+		{Identifier: "12345 6789", Name: "SWITCH", Present: 1, Functionbitmask: "896", Switch: fritz.Switch{State: "1"}, Powermeter: fritz.Powermeter{Power: "5000"}},
+		{Identifier: "999", Name: "HKR", Present: 1, Functionbitmask: "320", Thermostat: fritz.Thermostat{Goal: "42", Measured: "40"}},
+	}}
+	b := newMqttBridge(h, "fritzctl", "homeassistant")
+	b.backoff = 10 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.serve(ctx, func() (*mqtt.Client, error) {
+			return mqtt.Dial(broker.Addr(), mqtt.Options{ClientID: "bridge", Will: &mqtt.Message{Topic: b.statusTopic(), Payload: []byte("offline"), Retain: true}})
+		}, 20*time.Millisecond)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	assertRetained(t, broker, "fritzctl/status", "online")
+	assertRetained(t, broker, "fritzctl/123456789/state", "ON")
+	assertRetained(t, broker, "fritzctl/123456789/power", "5")
+	assertRetained(t, broker, "fritzctl/999/goal", "21")
+	_, ok := broker.Retained("homeassistant/switch/fritzctl/123456789_state/config")
+	assert.True(t, ok)
+	_, ok = broker.Retained("homeassistant/climate/fritzctl/999_thermostat/config")
+	assert.True(t, ok)
+
+	c, err := mqtt.Dial(broker.Addr(), mqtt.Options{ClientID: "test"})
+	assert.NoError(t, err)
+	assert.NoError(t, c.Publish(mqtt.Message{Topic: "fritzctl/123456789/set", Payload: []byte("OFF")}))
+	assert.NoError(t, c.Publish(mqtt.Message{Topic: "fritzctl/999/goal/set", Payload: []byte("19.5")}))
+	assert.NoError(t, c.Publish(mqtt.Message{Topic: "fritzctl/123456789/state", Retain: true}))
+	c.Close()
+	eventually(t, func() bool { return len(h.Commands()) == 2 })
+	assert.ElementsMatch(t, []string{"off [SWITCH]", "temp 19.5 [HKR]"}, h.Commands())
+
+	broker.DropClients()
+	assertRetained(t, broker, "fritzctl/123456789/state", "ON")
+	assertRetained(t, broker, "fritzctl/status", "online")
+
+	cancel()
+	<-done
+	assertRetained(t, broker, "fritzctl/status", "offline")
+}
+
+// TestMqttWithLogin tests that commands are repeated only if the session was rejected.
+func TestMqttWithLogin(t *testing.T) {
+	b := newMqttBridge(&fritztest.HomeAuto{}, "fritzctl", "")
+	calls := 0
+	rejectOnce := func() error {
+		if calls++; calls == 1 {
+			return errors.Wrapf(&httpread.StatusError{Code: http.StatusForbidden, Status: "403 Forbidden"}, "unable to list devices")
+		}
+		return nil
+	}
+	assert.NoError(t, b.withLogin(rejectOnce))
+	assert.Equal(t, 2, calls)
+
+	calls = 0
+	assert.Error(t, b.withLogin(func() error {
+		calls++
+		return fmt.Errorf("not all operations could be completed")
+	}))
+	assert.Equal(t, 1, calls, "toggling must not be repeated blindly")
+}
+
+// TestMqttState tests the published attributes.
+func TestMqttState(t *testing.T) {
+	state := mqttState(fritz.Device{Present: 0, Functionbitmask: "320", Thermostat: fritz.Thermostat{Goal: "253", Measured: "40", BatteryLow: "1"}})
+	assert.Equal(t, map[string]string{"available": "offline", "measured": "20", "battery_low": "ON"}, state)
+}
+
+func assertRetained(t *testing.T, broker *mqtt.Broker, topic, payload string) {
+	eventually(t, func() bool {
+		m, ok := broker.Retained(topic)
+		return ok && string(m.Payload) == payload
+	})
+}
+
+func eventually(t *testing.T, condition func() bool) {
+	deadline := time.Now().Add(2 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bpicode/fritzctl/cmd/notify"
+	"github.com/bpicode/fritzctl/fritz"
+	"github.com/bpicode/fritzctl/logger"
+	"github.com/spf13/cobra"
+)
+
+var serveNotifyCmd = &cobra.Command{
+	Use:   "notify",
+	Short: "Send notifications on alerts, low batteries, errors and absent devices",
+	Long: "Poll the FRITZ!Box periodically and evaluate the rules of a YAML file. " +
+		"A rule raises a notification when its condition holds for a device: " +
+		"'alert' (the alert sensor reports an alert), 'battery-low' (the battery is running low), " +
+		"'error' (the thermostat reports an error code) or 'absent' (the device is not connected). " +
+		"A condition has to hold for the 'debounce' duration before it is notified. " +
+		"While it keeps holding, the notification is repeated every 'renotify' interval, if given. " +
+		"With 'resolved', a notification is also sent once the condition no longer holds. " +
+		"Notifications are delivered to sinks: webhooks receive a JSON document containing the device as " +
+		"in the JSON output of the list commands, shell commands receive the same document on stdin and a summary " +
+		"in the environment variables FRITZCTL_RULE, FRITZCTL_CONDITION, FRITZCTL_STATUS, FRITZCTL_MESSAGE, " +
+		"FRITZCTL_DEVICE and FRITZCTL_AIN, mails are sent via an SMTP relay.",
+	Example: `fritzctl serve notify --rules=notify.yml
+fritzctl serve notify --rules=notify.yml --interval=1m --dry-run
+
+notify.yml:
+  sinks:
+    - name: pager
+      type: webhook
+      url: https://example.com/hooks/fritz
+    - name: mail
+      type: smtp
+      relay: mail.example.com:587
+      from: fritzctl@example.com
+      to: [admin@example.com]
+      username: fritzctl
+      password: secret
+    - name: log
+      type: shell
+      command: cat >> /var/log/fritz-notifications.json
+  rules:
+    - name: burglary
+      condition: alert
+      devices: [SEC_1]
+      sinks: [pager, mail]
+    - name: batteries
+      condition: battery-low
+      renotify: 24h
+      sinks: [mail]
+    - name: offline
+      condition: absent
+      debounce: 10m
+      resolved: true
+      sinks: [log]`,
+	RunE: serveNotify,
+}
+
+func init() {
+	serveNotifyCmd.Flags().String("rules", "", "YAML file containing the sinks and rules")
+	serveNotifyCmd.Flags().Duration("interval", defaultWatchInterval, "time between two subsequent polls of the FRITZ!Box")
+	serveNotifyCmd.Flags().Bool("dry-run", false, "log the notifications instead of sending them")
+	serveCmd.AddCommand(serveNotifyCmd)
+}
+
+func serveNotify(cmd *cobra.Command, _ []string) error {
+	rulesFile := cmd.Flag("rules").Value.String()
+	assertTrue(rulesFile != "", fmt.Errorf("no rules file given, use --rules"))
+	interval, err := cmd.Flags().GetDuration("interval")
+	assertNoErr(err, "cannot parse interval")
+	assertTrue(interval > 0, fmt.Errorf("interval must be positive, got %s", interval))
+	dryRun, err := cmd.Flags().GetBool("dry-run")
+	assertNoErr(err, "cannot parse dry-run flag")
+
+	conf, err := notify.ParseFile(rulesFile)
+	assertNoErr(err, "cannot parse rules file")
+	n := &notificationHandler{notifier: notify.NewNotifier(conf.Rules), deliver: notificationDeliverer(conf, dryRun)}
+	ctx, cancel := interruptContext()
+	defer cancel()
+	logger.Info("Evaluating", len(conf.Rules), "notification rules")
+	w := fritz.NewWatcher(homeAutoClient(), fritz.PollInterval(interval), fritz.OnList(n.handle))
+	emitAll(w.Watch(ctx), func(fritz.Event) {})
+	return nil
+}
+
+func notificationDeliverer(conf *notify.Config, dryRun bool) func(notify.Notification) {
+	if dryRun {
+		return func(n notify.Notification) {
+			logger.Info("Dry run, not sending", n.Status, "notification:", n.Message)
+		}
+	}
+	return func(n notify.Notification) {
+		logger.Info("Sending", n.Status, "notification:", n.Message)
+		for _, name := range n.Sinks {
+			if err := conf.Sink(name).Send(n); err != nil {
+				logger.Warn("Sink", name, "failed:", err)
+			}
+		}
+	}
+}
+
+// notificationHandler evaluates the rules against every polled device list and delivers the notifications.
+type notificationHandler struct {
+	notifier *notify.Notifier
+	deliver  func(notify.Notification)
+}
+
+func (h *notificationHandler) handle(l *fritz.Devicelist) {
+	for _, n := range h.notifier.Evaluate(l, time.Now()) {
+		h.deliver(n)
+	}
+}
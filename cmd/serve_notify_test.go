@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bpicode/fritzctl/cmd/notify"
+	"github.com/bpicode/fritzctl/config"
+	"github.com/bpicode/fritzctl/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNotificationHandler evaluates rules against the mock and delivers to a local webhook.
+func TestNotificationHandler(t *testing.T) {
+	oldPlaces := defaultConfigPlaces
+	defer func() { defaultConfigPlaces = oldPlaces }()
+	defaultConfigPlaces = append([]config.Place{config.InDir("../testdata/config", "config_localhost_http_test.json", config.JSON())}, defaultConfigPlaces...)
+	srv := mock.New().UnstartedServer()
+	var err error
+	srv.Listener, err = net.Listen("tcp", ":61666")
+	assert.NoError(t, err)
+	srv.Start()
+	defer srv.Close()
+
+	var mu sync.Mutex
+	var received []notify.Notification
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n notify.Notification
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&n))
+		mu.Lock()
+		received = append(received, n)
+		mu.Unlock()
+	}))
+	defer hook.Close()
+
+	conf, err := notify.Parse(strings.NewReader(`
+sinks:
+  - name: hook
+    type: webhook
+    url: ` + hook.URL + `
+rules:
+  - name: alarm
+    condition: alert
+    sinks: [hook]
+  - name: batteries
+    condition: battery-low
+    sinks: [hook]
+`))
+	assert.NoError(t, err)
+	h := &notificationHandler{notifier: notify.NewNotifier(conf.Rules), deliver: notificationDeliverer(conf, false)}
+	l, err := homeAutoClient().List()
+	assert.NoError(t, err)
+	h.handle(l)
+	h.handle(l)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, received, 2)
+	assert.Equal(t, "SEC_1: alert", received[0].Message)
+	assert.Equal(t, "HKR_3: battery low", received[1].Message)
+	assert.Equal(t, "LOW", received[1].Device.State.BatteryState)
+}
+
+// TestNotificationDelivererDryRun tests that dry runs do not deliver.
+func TestNotificationDelivererDryRun(t *testing.T) {
+	conf := &notify.Config{Sinks: []notify.Sink{{Name: "unreachable", Type: "webhook", URL: "http://127.0.0.1:1"}}}
+	notificationDeliverer(conf, true)(notify.Notification{Sinks: []string{"unreachable"}})
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bpicode/fritzctl/internal/console"
+	"github.com/bpicode/fritzctl/logger"
+	"github.com/bpicode/fritzctl/scheduler"
+	"github.com/spf13/cobra"
+)
+
+var serveSchedulerCmd = &cobra.Command{
+	Use:   "scheduler",
+	Short: "Switch devices and set temperatures according to time-based rules",
+	Long: "Run a scheduler which executes the rules of a YAML file. " +
+		"Rules are triggered by a cron expression (minute, hour, day of month, month, day of week) or by sunrise " +
+		"or sunset with an optional offset, computed from the configured location. " +
+		"Cron expressions follow cron(8), except that ranges may wrap around, e.g. fri-mon. " +
+		"The time of the last run of every rule is persisted in a state file. " +
+		"After a restart, runs missed at most 'catchup' ago are executed. " +
+		"With --once, the rules are evaluated a single time and an overview is printed, e.g. for use in a cron job. " +
+		"With --dry-run, actions are only logged and the state file is not written.",
+	Example: `fritzctl serve scheduler --rules=rules.yml
+fritzctl serve scheduler --rules=rules.yml --state=/var/lib/fritzctl/scheduler.json
+fritzctl serve scheduler --rules=rules.yml --once --dry-run
+
+rules.yml:
+  location:
+    latitude: 52.52
+    longitude: 13.40
+  catchup: 1h
+  rules:
+    - name: heating-weekdays
+      cron: "30 6 * * mon-fri"
+      action: temperature
+      temperature: 21
+      devices: [HKR_1]
+    - name: lamp-evening
+      sun: sunset
+      offset: -30m
+      action: on
+      devices: [SWITCH_1]`,
+	RunE: serveScheduler,
+}
+
+func init() {
+	serveSchedulerCmd.Flags().String("rules", "", "YAML file containing the rules")
+	serveSchedulerCmd.Flags().String("state", "", "file recording the last runs, defaults to the rules file with suffix .state.json")
+	serveSchedulerCmd.Flags().Bool("once", false, "evaluate the rules once, print an overview and exit")
+	serveSchedulerCmd.Flags().Bool("dry-run", false, "log the actions instead of executing them, do not write the state file")
+	serveCmd.AddCommand(serveSchedulerCmd)
+}
+
+func serveScheduler(cmd *cobra.Command, _ []string) error {
+	rulesFile := cmd.Flag("rules").Value.String()
+	assertTrue(rulesFile != "", fmt.Errorf("no rules file given, use --rules"))
+	stateFile := cmd.Flag("state").Value.String()
+	if stateFile == "" {
+		stateFile = rulesFile + ".state.json"
+	}
+	once, err := cmd.Flags().GetBool("once")
+	assertNoErr(err, "cannot parse once flag")
+	dryRun, err := cmd.Flags().GetBool("dry-run")
+	assertNoErr(err, "cannot parse dry-run flag")
+
+	rules, err := scheduler.ParseFile(rulesFile)
+	assertNoErr(err, "cannot parse rules file")
+	state, err := scheduler.LoadState(stateFile)
+	assertNoErr(err, "cannot read state file")
+	s := scheduler.New(rules, state, ruleExecutor(dryRun))
+	save := func(st scheduler.State) error {
+		if dryRun {
+			return nil
+		}
+		return st.Save(stateFile)
+	}
+	if once {
+		now := time.Now()
+		if s.Evaluate(now) {
+			assertNoErr(save(s.State()), "cannot write state file")
+		}
+		printRules(rules, s, now)
+		return nil
+	}
+	ctx, cancel := interruptContext()
+	defer cancel()
+	logger.Info("Scheduling", len(rules.Rules), "rules")
+	s.Run(ctx, save)
+	return nil
+}
+
+func ruleExecutor(dryRun bool) func(scheduler.Rule) error {
+	if dryRun {
+		return func(r scheduler.Rule) error {
+			logger.Info("Dry run, skipping", r)
+			return nil
+		}
+	}
+	session := newBoxSession()
+	return func(r scheduler.Rule) error {
+		logger.Info("Running", r)
+		return session.do(func() error { return r.Apply(session.homeAuto) })
+	}
+}
+
+func printRules(rules *scheduler.Rules, s *scheduler.Scheduler, now time.Time) {
+	table := console.NewTable(console.Headers("NAME", "SCHEDULE", "ACTION", "DEVICES", "LAST RUN", "NEXT RUN"))
+	for _, r := range rules.Rules {
+		table.Append([]string{
+			r.Name,
+			r.When(),
+			r.Action,
+			strings.Join(r.Devices, ", "),
+			fmtTime(s.State()[r.Name]),
+			fmtTime(s.NextRun(r.Name, now)),
+		})
+	}
+	table.Print(os.Stdout)
+}
+
+func fmtTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bpicode/fritzctl/config"
+	"github.com/bpicode/fritzctl/mock"
+	"github.com/bpicode/fritzctl/scheduler"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestServeSchedulerOnce catches up a missed run against the mock and records it in the state file.
+func TestServeSchedulerOnce(t *testing.T) {
+	oldPlaces := defaultConfigPlaces
+	defer func() { defaultConfigPlaces = oldPlaces }()
+	defaultConfigPlaces = append([]config.Place{config.InDir("../testdata/config", "config_localhost_http_test.json", config.JSON())}, defaultConfigPlaces...)
+	srv := mock.New().UnstartedServer()
+	var err error
+	srv.Listener, err = net.Listen("tcp", ":61666")
+	assert.NoError(t, err)
+	srv.Start()
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "scheduler")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	stateFile := filepath.Join(dir, "state.json")
+	lastRun := time.Now().Add(-5 * time.Minute)
+	assert.NoError(t, scheduler.State{"every-minute": lastRun}.Save(stateFile))
+
+	cmd := serveSchedulerCmd
+	args := []string{"--rules=../testdata/scheduler_rules.yml", "--state=" + stateFile, "--once", "--dry-run=false"}
+	assert.NoError(t, cmd.ParseFlags(args))
+	assert.NoError(t, cmd.RunE(cmd, nil))
+
+	state, err := scheduler.LoadState(stateFile)
+	assert.NoError(t, err)
+	assert.True(t, state["every-minute"].After(lastRun))
+	assert.Contains(t, state, "evening")
+}
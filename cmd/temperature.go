@@ -6,16 +6,19 @@ import (
 	"strings"
 
 	"github.com/bpicode/fritzctl/fritz"
+	"github.com/bpicode/fritzctl/internal/units"
 	"github.com/bpicode/fritzctl/logger"
 	"github.com/spf13/cobra"
 )
 
 var temperatureCmd = &cobra.Command{
-	Use:   "temperature [value in °C, on, off, sav, comf] [device/group names]",
+	Use:   "temperature [value in °C/°F, on, off, sav, comf] [device/group names]",
 	Short: "Set the temperature of HKR devices/groups or turn them on/off",
-	Long: "Change the temperature of HKR devices/groups by supplying the desired value in °C. " +
+	Long: "Change the temperature of HKR devices/groups by supplying the desired value in °C, or in °F with --units=imperial. " +
+		"Values in °F are rounded to the steps of 0.5°C supported by the FRITZ!Box. " +
 		"When turning HKR devices on/off, replace the value by 'on'/'off' respectively." +
-		"To reset each devices to its comfort/saving temperature, replace the value by 'comf'/'sav'." +
+		"To reset each devices to its comfort/saving temperature, replace the value by 'comf'/'sav'. " +
+		"The comfort/saving temperatures themselves are changed by 'fritzctl thermostat comfort/saving'. " +
 		"To increase/decrease temperatures relative to the current goal, supply '+' or '-' followed by space.",
 	Example: `fritzctl temperature 21.0 HKR_1 HKR_2
 fritzctl temperature off HKR_1
@@ -24,6 +27,7 @@ fritzctl temperature comf HK1 HKR_2
 fritzctl temperature sav HK1 HKR_2
 fritzctl temperature + 1.5 HK1
 fritzctl temperature - 2 HK1
+fritzctl temperature --units=imperial 70 HKR_1
 `,
 	RunE: changeTemperature,
 }
@@ -70,15 +74,25 @@ func changeBy(val string, args ...string) {
 	assertMinLen(args, 2, "insufficient input: expected [+ or -] [amount] [devices]")
 	delta, err := strconv.ParseFloat(val+args[0], 64)
 	assertNoErr(err, "cannot parse temperature adjustment")
+	u := unitSystem()
 	changeByCallback(func(t fritz.Thermostat) string {
 		cur, err := strconv.ParseFloat(t.FmtGoalTemperature(), 64)
 		assertNoErr(err, "unable to parse the current temperature goal '%s'", t.FmtGoalTemperature())
-		return strconv.FormatFloat(cur+delta, 'f', -1, 64)
+		return strconv.FormatFloat(u.ToBox(u.FromCelsius(cur)+delta), 'f', -1, 64)
 	}, args[1:]...)
 }
 
 func changeTo(val string, devs ...string) {
-	changeByValue(nil, val, devs...)
+	changeByValue(nil, celsius(val, unitSystem()), devs...)
+}
+
+// celsius converts a temperature entered in the unit system to °C. The values 'on' and 'off' are passed through.
+func celsius(val string, u units.System) string {
+	t, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		return val
+	}
+	return strconv.FormatFloat(u.ToBox(t), 'f', -1, 64)
 }
 
 func changeByCallback(supplier func(t fritz.Thermostat) string, names ...string) {
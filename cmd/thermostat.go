@@ -0,0 +1,15 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var thermostatCmd = &cobra.Command{
+	Use:   "thermostat [subcommand]",
+	Short: "See subcommands",
+	Long:  "See subcommands. Run with --help to list the available commands.",
+}
+
+func init() {
+	RootCmd.AddCommand(thermostatCmd)
+}
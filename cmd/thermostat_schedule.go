@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/bpicode/fritzctl/cmd/printer"
+	"github.com/bpicode/fritzctl/fritz"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+var thermostatScheduleCmd = &cobra.Command{
+	Use:   "schedule [subcommand]",
+	Short: "Read and write the heating schedule of a thermostat",
+	Long: "Read and write the heating schedule of a thermostat: the weekly switch points between comfort and saving " +
+		"temperature, up to four holiday periods with saving temperature and the summer break, in which the " +
+		"thermostat is off. Run with --help to list the available commands.",
+}
+
+var thermostatScheduleGetCmd = &cobra.Command{
+	Use:   "get [device name]",
+	Short: "Print the heating schedule of a thermostat",
+	Long:  "Print the heating schedule of a thermostat in the YAML format accepted by 'fritzctl thermostat schedule set'.",
+	Example: `fritzctl thermostat schedule get HKR_1
+fritzctl --loglevel=error thermostat schedule get HKR_1 > schedule.yml
+fritzctl thermostat schedule get HKR_1 --output=json`,
+	RunE: getSchedule,
+}
+
+var thermostatScheduleSetCmd = &cobra.Command{
+	Use:   "set [device name] [schedule file]",
+	Short: "Replace the heating schedule of a thermostat",
+	Long: "Replace the heating schedule of a thermostat by the one in a YAML file, '-' reads from stdin. " +
+		"Days are mon, tue, wed, thu, fri, sat, sun, or daily, weekdays, weekend. " +
+		"Holidays are given by month, day and full hour, the summer break by month and day. " +
+		"Holidays and summer break are removed if they are missing in the file.",
+	Example: `fritzctl thermostat schedule set HKR_1 schedule.yml
+
+schedule.yml:
+  weekly:
+    - days: [weekdays]
+      at: "06:00"
+      mode: comfort
+    - days: [weekend]
+      at: "08:00"
+      mode: comfort
+    - days: [daily]
+      at: "22:00"
+      mode: saving
+  holidays:
+    - from: 12-24 08:00
+      to: 01-06 18:00
+  summer:
+    from: 06-01
+    to: 09-15`,
+	RunE: setSchedule,
+}
+
+func init() {
+	thermostatScheduleGetCmd.Flags().StringP("output", "o", "yaml", "specify output format, one of yaml, json")
+	thermostatScheduleCmd.AddCommand(thermostatScheduleGetCmd)
+	thermostatScheduleCmd.AddCommand(thermostatScheduleSetCmd)
+	thermostatCmd.AddCommand(thermostatScheduleCmd)
+}
+
+func getSchedule(cmd *cobra.Command, args []string) error {
+	assertMinLen(args, 1, "insufficient input: device name expected")
+	s, err := homeAutoClient().Schedule(args[0])
+	assertNoErr(err, "cannot obtain heating schedule")
+	switch output := cmd.Flag("output").Value.String(); output {
+	case "json":
+		printer.Print(s, os.Stdout)
+	case "yaml":
+		bs, err := yaml.Marshal(s)
+		assertNoErr(err, "cannot encode heating schedule")
+		os.Stdout.Write(bs)
+	default:
+		assertTrue(false, fmt.Errorf("unknown output format '%s', expected one of yaml, json", output))
+	}
+	return nil
+}
+
+func setSchedule(_ *cobra.Command, args []string) error {
+	assertMinLen(args, 2, "insufficient input: device name and schedule file expected")
+	s := parseSchedule(args[1])
+	assertNoErr(s.Validate(), "invalid heating schedule in '%s'", args[1])
+	err := homeAutoClient().SetSchedule(args[0], s)
+	assertNoErr(err, "cannot set heating schedule")
+	return nil
+}
+
+func parseSchedule(filename string) *fritz.HeatingSchedule {
+	var r io.Reader = os.Stdin
+	if filename != "-" {
+		file, err := os.Open(filename)
+		assertNoErr(err, "cannot open schedule file '%s'", filename)
+		defer file.Close()
+		r = file
+	}
+	bs, err := ioutil.ReadAll(r)
+	assertNoErr(err, "cannot read schedule file '%s'", filename)
+	var s fritz.HeatingSchedule
+	assertNoErr(yaml.UnmarshalStrict(bs, &s), "cannot parse schedule file '%s'", filename)
+	return &s
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bpicode/fritzctl/fritz"
+	"github.com/bpicode/fritzctl/internal/units"
+	"github.com/spf13/cobra"
+)
+
+var thermostatComfortCmd = &cobra.Command{
+	Use:   "comfort [value in °C/°F] [device names]",
+	Short: "Set the comfort temperature of thermostats",
+	Long: "Change the comfort temperature of thermostats, which applies in the comfort periods of the heating schedule. " +
+		"Accepted values are 8-28°C in steps of 0.5°C, values in °F (--units=imperial) are rounded accordingly.",
+	Example: "fritzctl thermostat comfort 21.5 HKR_1 HKR_2",
+	RunE:    configureThermostats(temperatureSetting(units.System.ToBox, func(s *fritz.ThermostatSettings, v float64) { s.Comfort = &v })),
+}
+
+var thermostatSavingCmd = &cobra.Command{
+	Use:   "saving [value in °C/°F] [device names]",
+	Short: "Set the saving temperature of thermostats",
+	Long: "Change the saving temperature of thermostats, which applies in the saving periods of the heating schedule. " +
+		"Accepted values are 8-28°C in steps of 0.5°C, values in °F (--units=imperial) are rounded accordingly.",
+	Example: "fritzctl thermostat saving 16 HKR_1 HKR_2",
+	RunE:    configureThermostats(temperatureSetting(units.System.ToBox, func(s *fritz.ThermostatSettings, v float64) { s.Saving = &v })),
+}
+
+var thermostatOffsetCmd = &cobra.Command{
+	Use:   "offset [value in °C/°F] [device names]",
+	Short: "Set the offset of the temperature sensor of thermostats",
+	Long: "Change the offset that corrects the temperature measured by thermostats. " +
+		"Accepted values are -10-10°C in steps of 0.5°C, values in °F (--units=imperial) are rounded accordingly. Separate negative values from the flags by '--'.",
+	Example: `fritzctl thermostat offset 1.5 HKR_1
+fritzctl thermostat offset -- -1 HKR_1 HKR_2`,
+	RunE: configureThermostats(temperatureSetting(units.System.DeltaToBox, func(s *fritz.ThermostatSettings, v float64) { s.Offset = &v })),
+}
+
+var thermostatLockCmd = &cobra.Command{
+	Use:     "lock [on, off] [device names]",
+	Short:   "Lock thermostats against changes via the FRITZ!Box",
+	Long:    "Lock (on) or unlock (off) thermostats against changes via the user interface and apps of the FRITZ!Box.",
+	Example: "fritzctl thermostat lock on HKR_1 HKR_2",
+	RunE:    configureThermostats(lockSetting(func(s *fritz.ThermostatSettings, v bool) { s.Lock = &v })),
+}
+
+var thermostatDeviceLockCmd = &cobra.Command{
+	Use:     "devicelock [on, off] [device names]",
+	Short:   "Lock the operating elements of thermostats",
+	Long:    "Lock (on) or unlock (off) the operating elements on the thermostats themselves.",
+	Example: "fritzctl thermostat devicelock on HKR_1",
+	RunE:    configureThermostats(lockSetting(func(s *fritz.ThermostatSettings, v bool) { s.DeviceLock = &v })),
+}
+
+func init() {
+	for _, c := range []*cobra.Command{thermostatComfortCmd, thermostatSavingCmd, thermostatOffsetCmd, thermostatLockCmd, thermostatDeviceLockCmd} {
+		thermostatCmd.AddCommand(c)
+	}
+}
+
+func configureThermostats(setting func(val string) (fritz.ThermostatSettings, error)) func(*cobra.Command, []string) error {
+	return func(_ *cobra.Command, args []string) error {
+		assertMinLen(args, 2, "insufficient input: at least two parameters expected (run with --help for more details)")
+		s, err := setting(args[0])
+		assertNoErr(err, "cannot parse value")
+		assertNoErr(s.Validate(), "invalid value")
+		err = homeAutoClient().Configure(s, args[1:]...)
+		assertNoErr(err, "error changing the settings of the thermostat(s)")
+		return nil
+	}
+}
+
+func temperatureSetting(toBox func(units.System, float64) float64, set func(s *fritz.ThermostatSettings, v float64)) func(val string) (fritz.ThermostatSettings, error) {
+	return func(val string) (fritz.ThermostatSettings, error) {
+		var s fritz.ThermostatSettings
+		v, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return s, err
+		}
+		set(&s, toBox(unitSystem(), v))
+		return s, nil
+	}
+}
+
+func lockSetting(set func(s *fritz.ThermostatSettings, v bool)) func(val string) (fritz.ThermostatSettings, error) {
+	return func(val string) (fritz.ThermostatSettings, error) {
+		var s fritz.ThermostatSettings
+		switch strings.ToLower(val) {
+		case "on":
+			set(&s, true)
+		case "off":
+			set(&s, false)
+		default:
+			return s, fmt.Errorf("expected on or off, got '%s'", val)
+		}
+		return s, nil
+	}
+}
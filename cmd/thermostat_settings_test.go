@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/bpicode/fritzctl/fritz"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestThermostatSettingsInvalidInput tests that invalid values are rejected before contacting the FRITZ!Box.
+func TestThermostatSettingsInvalidInput(t *testing.T) {
+	for _, tc := range []struct {
+		run  func(args []string) error
+		args []string
+	}{
+		{run: func(args []string) error { return thermostatComfortCmd.RunE(thermostatComfortCmd, args) }, args: []string{"21"}},
+		{run: func(args []string) error { return thermostatComfortCmd.RunE(thermostatComfortCmd, args) }, args: []string{"30", "HKR_1"}},
+		{run: func(args []string) error { return thermostatSavingCmd.RunE(thermostatSavingCmd, args) }, args: []string{"warm", "HKR_1"}},
+		{run: func(args []string) error { return thermostatOffsetCmd.RunE(thermostatOffsetCmd, args) }, args: []string{"0.3", "HKR_1"}},
+		{run: func(args []string) error { return thermostatLockCmd.RunE(thermostatLockCmd, args) }, args: []string{"yes", "HKR_1"}},
+	} {
+		assert.Panics(t, func() { tc.run(tc.args) }, "%v", tc.args)
+	}
+}
+
+// TestLockSetting tests the interpretation of on and off.
+func TestLockSetting(t *testing.T) {
+	s, err := lockSetting(func(s *fritz.ThermostatSettings, v bool) { s.Lock = &v })("On")
+	assert.NoError(t, err)
+	assert.True(t, *s.Lock)
+	s, err = lockSetting(func(s *fritz.ThermostatSettings, v bool) { s.DeviceLock = &v })("off")
+	assert.NoError(t, err)
+	assert.False(t, *s.DeviceLock)
+}
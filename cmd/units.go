@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/bpicode/fritzctl/internal/units"
+)
+
+const unitsEnv = "FRITZCTL_UNITS"
+
+func init() {
+	RootCmd.PersistentFlags().String("units", "", "unit system of temperatures, metric (°C) or imperial (°F), "+
+		"defaults to $"+unitsEnv+", then to the 'units' of the configuration file, then to metric")
+}
+
+// unitSystem determines the unit system of the temperatures displayed and entered. The --units flag takes precedence
+// over the environment variable, which takes precedence over the configuration file.
+func unitSystem() units.System {
+	name := RootCmd.PersistentFlags().Lookup("units").Value.String()
+	if name == "" {
+		name = os.Getenv(unitsEnv)
+	}
+	if name == "" {
+		if conf, err := cfg(defaultConfigPlaces...); err == nil && conf.Display != nil {
+			name = conf.Display.Units
+		}
+	}
+	s, err := units.Parse(name)
+	assertNoErr(err, "cannot determine the unit system")
+	return s
+}
+
+// fmtTemperature formats a temperature in °C, as given by the FRITZ!Box, in the unit system.
+func fmtTemperature(s units.System, f func() string) string {
+	return fmtUnit(func() string { return s.Convert(f()) }, s.Symbol())
+}
+
+// fmtTemperatureDelta formats a temperature difference in °C, e.g. an offset, in the unit system.
+func fmtTemperatureDelta(s units.System, f func() string) string {
+	return fmtUnit(func() string { return s.ConvertDelta(f()) }, s.Symbol())
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"net"
+	"os"
+	"testing"
+
+	"github.com/bpicode/fritzctl/config"
+	"github.com/bpicode/fritzctl/fritz"
+	"github.com/bpicode/fritzctl/internal/units"
+	"github.com/bpicode/fritzctl/mock"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestUnitSystemPrecedence tests that the flag takes precedence over the environment variable.
+func TestUnitSystemPrecedence(t *testing.T) {
+	flag := RootCmd.PersistentFlags().Lookup("units")
+	defer flag.Value.Set("")
+	defer os.Unsetenv(unitsEnv)
+
+	os.Setenv(unitsEnv, "imperial")
+	assert.Equal(t, units.Imperial, unitSystem())
+	assert.NoError(t, flag.Value.Set("metric"))
+	assert.Equal(t, units.Metric, unitSystem())
+	assert.NoError(t, flag.Value.Set("kelvin"))
+	assert.Panics(t, func() { unitSystem() })
+}
+
+// TestCelsius tests the conversion of entered temperatures.
+func TestCelsius(t *testing.T) {
+	assert.Equal(t, "21.5", celsius("21.3", units.Metric))
+	assert.Equal(t, "21", celsius("70", units.Imperial))
+	assert.Equal(t, "off", celsius("off", units.Imperial))
+}
+
+// TestFmtTemperature tests the formatting of temperatures in the unit system.
+func TestFmtTemperature(t *testing.T) {
+	th := fritz.Thermostat{Goal: "43", Measured: "40"}
+	assert.Equal(t, "21.5 °C", fmtTemperature(units.Metric, th.FmtGoalTemperature))
+	assert.Equal(t, "70.7 °F", fmtTemperature(units.Imperial, th.FmtGoalTemperature))
+	assert.Equal(t, "-0.9 °F", fmtTemperatureDelta(units.Imperial, func() string { return "-0.5" }))
+	assert.Equal(t, "OFF", fmtTemperature(units.Imperial, func() string { return "OFF" }))
+}
+
+// TestCommandsImperial runs the commands that display or accept temperatures in the imperial unit system.
+func TestCommandsImperial(t *testing.T) {
+	oldPlaces := defaultConfigPlaces
+	defer func() { defaultConfigPlaces = oldPlaces }()
+	defaultConfigPlaces = append([]config.Place{config.InDir("../testdata/config", "config_localhost_http_test.json", config.JSON())}, defaultConfigPlaces...)
+	defer os.Unsetenv(unitsEnv)
+	os.Setenv(unitsEnv, "imperial")
+	srv := mock.New().UnstartedServer()
+	var err error
+	srv.Listener, err = net.Listen("tcp", ":61666")
+	assert.NoError(t, err)
+	srv.Start()
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		cmd  *cobra.Command
+		args []string
+	}{
+		{cmd: listThermostatsCmd},
+		{cmd: listSwitchesCmd},
+		{cmd: listGroupsCmd},
+		{cmd: temperatureCmd, args: []string{"70", "HKR_1"}},
+		{cmd: temperatureCmd, args: []string{"+", "1", "HKR_3"}},
+		{cmd: thermostatComfortCmd, args: []string{"72", "HKR_1"}},
+		{cmd: thermostatOffsetCmd, args: []string{"-1", "HKR_1"}},
+	} {
+		assert.NoError(t, tc.cmd.RunE(tc.cmd, tc.args), "%s %v", tc.cmd.Name(), tc.args)
+	}
+}
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/bpicode/fritzctl/cmd/printer"
+	"github.com/bpicode/fritzctl/fritz"
+	"github.com/bpicode/fritzctl/internal/console"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+const defaultWatchInterval = 10 * time.Second
+
+var watchCmd = &cobra.Command{
+	Use:   "watch [device names]",
+	Short: "Watch smart home devices for changes",
+	Long: "Poll the FRITZ!Box periodically and report changes of the smart home devices, " +
+		"e.g. switches being toggled, alerts being raised, buttons being pressed or devices going absent. " +
+		"If no device names are given, all devices are watched. " +
+		"On a terminal, changes are printed in a readable form, otherwise one JSON object is emitted per line and change.",
+	Example: `fritzctl watch
+fritzctl watch --interval=30s SWITCH_1 BTN_1
+fritzctl watch --debounce=1m SEC_1
+fritzctl watch | jq .type`,
+	RunE: watch,
+}
+
+func init() {
+	watchCmd.Flags().Duration("interval", defaultWatchInterval, "time between two subsequent polls")
+	watchCmd.Flags().Duration("debounce", 0, "report only changes that persist for at least this duration")
+	RootCmd.AddCommand(watchCmd)
+}
+
+func watch(cmd *cobra.Command, names []string) error {
+	interval, err := cmd.Flags().GetDuration("interval")
+	assertNoErr(err, "cannot parse interval")
+	assertTrue(interval > 0, fmt.Errorf("interval must be positive, got %s", interval))
+	debounce, err := cmd.Flags().GetDuration("debounce")
+	assertNoErr(err, "cannot parse debounce duration")
+	ctx, cancel := interruptContext()
+	defer cancel()
+	w := fritz.NewWatcher(homeAutoClient(), fritz.PollInterval(interval), fritz.Debounce(debounce), fritz.DeviceFilter(devicesNamed(names)))
+	emitAll(w.Watch(ctx), eventEmitter(os.Stdout, isTerminal(os.Stdout)))
+	return nil
+}
+
+func addWatchFlag(cmd *cobra.Command) {
+	cmd.Flags().Duration("watch", 0, "poll periodically with an optional interval, redraw the table on a terminal or emit changes as JSON lines otherwise")
+	cmd.Flags().Lookup("watch").NoOptDefVal = defaultWatchInterval.String()
+}
+
+func watchInterval(cmd *cobra.Command) time.Duration {
+	interval, err := cmd.Flags().GetDuration("watch")
+	assertNoErr(err, "cannot parse watch interval")
+	return interval
+}
+
+// watchDevices is the --watch variant of the list commands. On a terminal, the table is redrawn in place after every
+// poll. Otherwise only the changes are written, one JSON object per line.
+func watchDevices(cmd *cobra.Command, interval time.Duration, accept func(fritz.Device) bool, defaultF func([]fritz.Device) interface{}) error {
+	ctx, cancel := interruptContext()
+	defer cancel()
+	opts := []fritz.WatchOption{fritz.PollInterval(interval), fritz.DeviceFilter(accept)}
+	emit := eventEmitter(os.Stdout, false)
+	if isTerminal(os.Stdout) {
+		opts = append(opts, fritz.OnList(tableRedrawer(os.Stdout, cmd, interval, accept, defaultF)))
+		emit = func(fritz.Event) {}
+	}
+	w := fritz.NewWatcher(homeAutoClient(), opts...)
+	emitAll(w.Watch(ctx), emit)
+	return nil
+}
+
+func tableRedrawer(w io.Writer, cmd *cobra.Command, interval time.Duration, accept func(fritz.Device) bool, defaultF func([]fritz.Device) interface{}) func(*fritz.Devicelist) {
+	return func(l *fritz.Devicelist) {
+		var ds []fritz.Device
+		for _, d := range l.Devices {
+			if accept(d) {
+				ds = append(ds, d)
+			}
+		}
+		fmt.Fprint(w, "\033[H\033[2J")
+		fmt.Fprintf(w, "Every %s, last update %s\n", interval, time.Now().Format("15:04:05"))
+		printer.Print(selectFmt(cmd, ds, defaultF), w)
+	}
+}
+
+func emitAll(events <-chan fritz.Event, emit func(fritz.Event)) {
+	for e := range events {
+		emit(e)
+	}
+}
+
+func eventEmitter(w io.Writer, human bool) func(fritz.Event) {
+	if human {
+		return func(e fritz.Event) {
+			fmt.Fprintf(w, "%s %s %s (%s ⟶ %s)\n", console.Cyan(e.Time.Format("15:04:05")), e.Name, e.Type, e.Before, e.After)
+		}
+	}
+	encoder := json.NewEncoder(w)
+	return func(e fritz.Event) {
+		encoder.Encode(e)
+	}
+}
+
+func devicesNamed(names []string) func(fritz.Device) bool {
+	wanted := make(map[string]bool, len(names))
+	for _, n := range names {
+		wanted[n] = true
+	}
+	return func(d fritz.Device) bool {
+		return len(wanted) == 0 || wanted[d.Name]
+	}
+}
+
+// interruptContext returns a context that is canceled when the process receives SIGINT or SIGTERM.
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		defer signal.Stop(sig)
+		select {
+		case <-sig:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+func isTerminal(f *os.File) bool {
+	return terminal.IsTerminal(int(f.Fd()))
+}
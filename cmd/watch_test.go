@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bpicode/fritzctl/fritz"
+	"github.com/bpicode/fritzctl/internal/console"
+	"github.com/bpicode/fritzctl/mock"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestTableRedrawer polls a FRITZ!Box whose switch is turned on between two polls and checks the redrawn tables.
+func TestTableRedrawer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "watch")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	original, err := ioutil.ReadFile("../mock/devicelist.xml")
+	assert.NoError(t, err)
+	devicelist := filepath.Join(dir, "devicelist.xml")
+	assert.NoError(t, ioutil.WriteFile(devicelist, original, 0644))
+	f := mock.New()
+	f.DeviceList = devicelist
+	srv := httptest.NewServer(f.UnstartedServer().Config.Handler)
+	defer srv.Close()
+	u, err := url.Parse(srv.URL)
+	assert.NoError(t, err)
+	h := fritz.NewHomeAuto(fritz.URL(u))
+	assert.NoError(t, h.Login())
+
+	buf := new(bytes.Buffer)
+	cmd := &cobra.Command{}
+	addOutputFlags(cmd)
+	redraw := tableRedrawer(buf, cmd, time.Millisecond, func(d fritz.Device) bool { return d.IsSwitch() }, switchTable)
+	polls := 0
+	w := fritz.NewWatcher(h, fritz.PollInterval(time.Millisecond), fritz.OnList(func(l *fritz.Devicelist) {
+		redraw(l)
+		if polls++; polls == 1 {
+			switchedOn := strings.Replace(string(original), "<state>0</state>", "<state>1</state>", 1)
+			assert.NoError(t, ioutil.WriteFile(devicelist, []byte(switchedOn), 0644))
+		}
+	}))
+	ctx, cancel := context.WithCancel(context.Background())
+	events := w.Watch(ctx)
+	e := <-events
+	cancel()
+	for range events {
+	}
+
+	assert.Equal(t, fritz.SwitchStateChanged, e.Type)
+	assert.Equal(t, "SWITCH_1", e.Name)
+	frames := strings.Split(buf.String(), "\033[H\033[2J")[1:]
+	assert.True(t, len(frames) >= 2)
+	assert.Equal(t, console.StringToCheckmark("0"), switchState(frames[0], "SWITCH_1"))
+	assert.Equal(t, console.StringToCheckmark("1"), switchState(frames[1], "SWITCH_1"))
+}
+
+// switchState extracts the STATE column of a device from a rendered switch table.
+func switchState(frame, name string) string {
+	for _, line := range strings.Split(frame, "\n") {
+		if cells := strings.Split(line, "|"); len(cells) > 4 && strings.TrimSpace(cells[1]) == name {
+			return strings.TrimSpace(cells[4])
+		}
+	}
+	return ""
+}
+
+// TestEventEmitter tests the output of the events.
+func TestEventEmitter(t *testing.T) {
+	e := fritz.Event{Type: fritz.SwitchStateChanged, Time: time.Unix(0, 0), Name: "SWITCH_1", AIN: "123", Before: "0", After: "1"}
+
+	buf := new(bytes.Buffer)
+	eventEmitter(buf, false)(e)
+	assert.Contains(t, buf.String(), `"type":"SwitchStateChanged"`)
+	assert.Equal(t, 1, bytes.Count(buf.Bytes(), []byte("\n")))
+
+	buf.Reset()
+	eventEmitter(buf, true)(e)
+	assert.Contains(t, buf.String(), "SWITCH_1 SwitchStateChanged")
+}
+
+// TestDevicesNamed tests the device selection by names.
+func TestDevicesNamed(t *testing.T) {
+	assert.True(t, devicesNamed(nil)(fritz.Device{Name: "a"}))
+	assert.True(t, devicesNamed([]string{"b", "c"})(fritz.Device{Name: "b"}))
+	assert.False(t, devicesNamed([]string{"b", "c"})(fritz.Device{Name: "a"}))
+}
@@ -15,6 +15,7 @@ type Config struct {
 	*Net
 	*Login
 	*Pki
+	*Display
 }
 
 // Net wraps the protocol://host:port data to contact the FRITZ!Box.
@@ -37,6 +38,11 @@ type Pki struct {
 	CertificateFile string `json:"certificateFile"  yaml:"certificate_file"` // Points to a certificate file (in PEM format) to verify the integrity of the FRITZ!Box.
 }
 
+// Display wraps the preferences of the presentation.
+type Display struct {
+	Units string `json:"units" yaml:"units"` // The unit system of temperatures, "metric" (°C, default) or "imperial" (°F).
+}
+
 // New creates a new Config by reading from a file given by the path.
 func New(path string) (*Config, error) {
 	logger.Debug("Reading config file", path)
@@ -49,11 +55,14 @@ func New(path string) (*Config, error) {
 	net := Net{}
 	pki := Pki{}
 	login := Login{}
+	display := Display{}
 	err = yaml.NewDecoder(file).Decode(&struct {
 		*Net
 		*Login
 		*Pki
-	}{&net, &login, &pki})
+		*Display
+	}{&net, &login, &pki, &display})
+	conf.Display = &display
 	conf.Pki = &pki
 	conf.Login = &login
 	conf.Net = &net
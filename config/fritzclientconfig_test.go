@@ -18,6 +18,7 @@ func TestReadFromFileParsesCorrectly(t *testing.T) {
 	assert.Equal(t, "xxxxx", config.Login.Password, "Password should be parsed correctly.")
 	assert.Equal(t, "/login_sid.lua", config.Login.LoginURL, "Login URL should be parsed correctly.")
 	assert.Equal(t, "", config.Login.Username, "Username should be parsed correctly.")
+	assert.Equal(t, "imperial", config.Display.Units, "Unit system should be parsed correctly.")
 }
 
 // TestConfigProducesValidLoginURL tests that the produced login URL is syntactically correct.
@@ -144,11 +144,14 @@ func (p *parser) decode(s source, r io.Reader) (*Config, error) {
 	net := Net{}
 	pki := Pki{}
 	login := Login{}
+	display := Display{}
 	err := s.Decode(r, &struct {
 		*Net
 		*Login
 		*Pki
-	}{&net, &login, &pki})
+		*Display
+	}{&net, &login, &pki, &display})
+	cfg.Display = &display
 	cfg.Pki = &pki
 	cfg.Login = &login
 	cfg.Net = &net
@@ -14,6 +14,8 @@ type ainBased interface {
 	switchOff(ain string) (string, error)
 	toggle(ain string) (string, error)
 	applyTemperature(value float64, ain string) (string, error)
+	basicDeviceStats(ain string) (*DeviceStats, error)
+	light(ain string, s LightSettings) (string, error)
 }
 
 // newAinBased creates a Fritz AHA API (working on AINs) from a given client.
@@ -35,6 +37,17 @@ func (a *ainBasedClient) listDevices() (*Devicelist, error) {
 	return &deviceList, errRead
 }
 
+// basicDeviceStats obtains the statistics of a device. The device is identified by its AIN.
+func (a *ainBasedClient) basicDeviceStats(ain string) (*DeviceStats, error) {
+	url := a.homeAutoSwitch().
+		query("ain", ain).
+		query("switchcmd", "getbasicdevicestats").
+		build()
+	var stats DeviceStats
+	errRead := httpread.XML(a.client.getf(url), &stats)
+	return &stats, errRead
+}
+
 // switchOn switches a device on. The device is identified by its AIN.
 func (a *ainBasedClient) switchOn(ain string) (string, error) {
 	return a.switchForAin(ain, "setswitchon")
@@ -68,6 +81,25 @@ func (a *ainBasedClient) applyTemperature(value float64, ain string) (string, er
 	return httpread.String(a.client.getf(url))
 }
 
+// light changes the settings of a light, one command after another. The device is identified by its AIN.
+func (a *ainBasedClient) light(ain string, s LightSettings) (string, error) {
+	cmds, err := s.commands()
+	if err != nil {
+		return "", err
+	}
+	var resp string
+	for _, cmd := range cmds {
+		b := a.homeAutoSwitch().query("ain", ain)
+		for k, vs := range cmd {
+			b = b.query(k, vs[0])
+		}
+		if resp, err = httpread.String(a.client.getf(b.build())); err != nil {
+			return resp, err
+		}
+	}
+	return resp, nil
+}
+
 func (a *ainBasedClient) switchForAin(ain, command string) (string, error) {
 	url := a.homeAutoSwitch().
 		query("ain", ain).
@@ -80,6 +112,12 @@ func (a *ainBasedClient) homeAutoSwitch() fritzURLBuilder {
 	return a.client.query().path(homeAutomationURI)
 }
 
+// ValidateTemperature checks a goal temperature in °C as accepted by HomeAuto.Temp: 8-28°C, 126.5 (off) or 127 (on).
+func ValidateTemperature(t float64) error {
+	_, err := temperatureParam(t)
+	return err
+}
+
 func temperatureParam(t float64) (int64, error) {
 	doubled := round(2 * t)
 	regular := doubled >= 16 && doubled <= 56
@@ -8,6 +8,7 @@ import (
 	"reflect"
 	"runtime"
 	"testing"
+	"time"
 
 	"github.com/bpicode/fritzctl/mock"
 	"github.com/stretchr/testify/assert"
@@ -30,6 +31,7 @@ func TestAinBased(t *testing.T) {
 	}{
 		{testListDevices},
 		{testListDevicesErrorServerDown},
+		{testBasicDeviceStats},
 		{testSwitchForAinErrorServerDown},
 		{testToggleErrorServerDown},
 	}
@@ -68,6 +70,19 @@ func testListDevices(t *testing.T, fritz *ainBasedClient, _ *httptest.Server) {
 
 }
 
+func testBasicDeviceStats(t *testing.T, fritz *ainBasedClient, _ *httptest.Server) {
+	stats, err := fritz.basicDeviceStats("12324 2131421")
+	assert.NoError(t, err)
+	assert.Len(t, stats.Energy, 2)
+	daily, ok := stats.EnergySeries(24 * time.Hour)
+	assert.True(t, ok)
+	assert.Equal(t, 31, daily.Count)
+	values := daily.Parse()
+	assert.Len(t, values, 31)
+	assert.Equal(t, 40.0, *values[0])
+	assert.Nil(t, values[30])
+}
+
 func testListDevicesErrorServerDown(t *testing.T, fritz *ainBasedClient, server *httptest.Server) {
 	server.Close()
 	_, err := fritz.listDevices()
@@ -2,6 +2,7 @@ package fritz
 
 import (
 	"fmt"
+	"net/url"
 	"strings"
 	"sync"
 
@@ -21,13 +22,37 @@ type HomeAuto interface {
 	Temp(value float64, names ...string) error
 }
 
-// NewHomeAuto a HomeAuto that communicates with the FRITZ!Box by means of the Home Automation HTTP Interface.
+// StatsReader obtains the statistics of devices.
+type StatsReader interface {
+	Stats(name string) (*DeviceStats, error)
+}
+
+// ThermostatConfigurer reads and changes the settings of thermostats besides the goal temperature.
+type ThermostatConfigurer interface {
+	Schedule(name string) (*HeatingSchedule, error)
+	SetSchedule(name string, s *HeatingSchedule) error
+	Configure(s ThermostatSettings, names ...string) error
+}
+
+// LightController changes the state, brightness and color of lights.
+type LightController interface {
+	Light(s LightSettings, names ...string) error
+}
+
+// Renamer changes the names of devices.
+type Renamer interface {
+	Rename(name, newName string) error
+}
+
+// NewHomeAuto a HomeAuto that communicates with the FRITZ!Box by means of the Home Automation HTTP Interface. The
+// returned client also implements StatsReader, ThermostatConfigurer, LightController and Renamer.
 func NewHomeAuto(options ...Option) HomeAuto {
 	client := defaultClient()
 	aha := newAinBased(client)
 	homeAuto := homeAuto{
 		client:  client,
 		aha:     aha,
+		hkr:     &hkrPage{client: client},
 		caching: false,
 	}
 	for _, option := range options {
@@ -43,6 +68,7 @@ type Option func(h *homeAuto)
 type homeAuto struct {
 	client        *Client
 	aha           ainBased
+	hkr           *hkrPage
 	caching       bool
 	cacheLock     sync.Mutex
 	cachedDevices *Devicelist
@@ -110,6 +136,139 @@ func (h *homeAuto) Temp(value float64, names ...string) error {
 	}, names...)
 }
 
+// Stats obtains the statistics of the given device, see DeviceStats. The device is identified by its name.
+func (h *homeAuto) Stats(name string) (*DeviceStats, error) {
+	d, err := h.device(name)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := h.aha.basicDeviceStats(d.Identifier)
+	return stats, errors.Wrapf(err, "unable to obtain statistics of '%s'", name)
+}
+
+// Schedule obtains the heating schedule of the given thermostat. The device is identified by its name.
+func (h *homeAuto) Schedule(name string) (*HeatingSchedule, error) {
+	d, err := h.device(name)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := h.hkr.read(d.ID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read the settings of '%s'", name)
+	}
+	s, err := parseHeatingSchedule(fields)
+	return s, errors.Wrapf(err, "unable to parse the heating schedule of '%s'", name)
+}
+
+// SetSchedule replaces the heating schedule of the given thermostat. The device is identified by its name.
+func (h *homeAuto) SetSchedule(name string, s *HeatingSchedule) error {
+	update, err := s.fields()
+	if err != nil {
+		return errors.Wrapf(err, "invalid heating schedule")
+	}
+	return h.updateSettings(name, func(fields url.Values) {
+		for k := range fields {
+			if isScheduleField(k) {
+				delete(fields, k)
+			}
+		}
+		for k, vs := range update {
+			fields[k] = vs
+		}
+	})
+}
+
+// Configure changes the comfort and saving temperature, the sensor offset and the locks of the given thermostats.
+// Devices are identified by their name.
+func (h *homeAuto) Configure(s ThermostatSettings, names ...string) error {
+	update, err := s.fields()
+	if err != nil {
+		return errors.Wrapf(err, "invalid thermostat settings")
+	}
+	results := make([]result, 0, len(names))
+	for _, name := range names {
+		err := h.updateSettings(name, func(fields url.Values) {
+			for k, vs := range update {
+				fields[k] = vs
+			}
+		})
+		if err != nil {
+			logger.Warn("Error while processing '" + name + "'; error was: " + err.Error())
+		}
+		results = append(results, result{err: err})
+	}
+	return genericResult(results)
+}
+
+// Light changes the state, brightness and color of the given lights. Devices are identified by their name.
+func (h *homeAuto) Light(s LightSettings, names ...string) error {
+	if err := s.Validate(); err != nil {
+		return errors.Wrapf(err, "invalid light settings")
+	}
+	return h.doConcurrently(func(ain string) func() (string, error) {
+		return func() (string, error) {
+			return h.aha.light(ain, s)
+		}
+	}, names...)
+}
+
+// Rename changes the name of the given device. The device is identified by its (current) name.
+func (h *homeAuto) Rename(name, newName string) error {
+	d, err := h.device(name)
+	if err != nil {
+		return err
+	}
+	page := editPageName
+	if d.IsThermostat() {
+		page = hkrPageName
+	}
+	fields, err := h.hkr.readPage(page, d.ID)
+	if err != nil {
+		return errors.Wrapf(err, "unable to read the settings of '%s'", name)
+	}
+	fields.Set("ule_device_name", newName)
+	if err := h.hkr.writePage(page, d.ID, fields); err != nil {
+		return errors.Wrapf(err, "unable to rename '%s'", name)
+	}
+	h.cacheLock.Lock()
+	h.cachedDevices = nil
+	h.cacheLock.Unlock()
+	logger.Success("Successfully renamed '" + name + "' to '" + newName + "'")
+	return nil
+}
+
+// updateSettings reads the settings page of a thermostat, modifies its fields and writes them back.
+func (h *homeAuto) updateSettings(name string, modify func(url.Values)) error {
+	d, err := h.device(name)
+	if err != nil {
+		return err
+	}
+	fields, err := h.hkr.read(d.ID)
+	if err != nil {
+		return errors.Wrapf(err, "unable to read the settings of '%s'", name)
+	}
+	modify(fields)
+	if err := h.hkr.write(d.ID, fields); err != nil {
+		return errors.Wrapf(err, "unable to write the settings of '%s'", name)
+	}
+	logger.Success("Successfully updated the settings of '" + name + "'")
+	return nil
+}
+
+// device looks up a device by its name.
+func (h *homeAuto) device(name string) (*Device, error) {
+	devList, err := h.List()
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to list devices")
+	}
+	for _, d := range devList.Devices {
+		if d.Name == name {
+			return &d, nil
+		}
+	}
+	return nil, fmt.Errorf("nothing found with name '%s'", name)
+}
+
 func (h *homeAuto) doConcurrently(workFactory func(string) func() (string, error), names ...string) error {
 	targets, err := buildBacklog(h, names, workFactory)
 	if err != nil {
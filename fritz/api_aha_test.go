@@ -50,7 +50,7 @@ func TestNoPanic(t *testing.T) {
 // TestFritzAPI test the FRITZ API.
 func TestFritzAPI(t *testing.T) {
 	testCases := []struct {
-		test func(t *testing.T, h HomeAuto)
+		test func(t *testing.T, h *homeAuto)
 	}{
 		{testOn},
 		{testOff},
@@ -62,6 +62,18 @@ func TestFritzAPI(t *testing.T) {
 		{testToggleMany},
 		{testToggleError},
 		{testToggleErrorDeviceNotFound},
+		{testStats},
+		{testStatsErrorDeviceNotFound},
+		{testSchedule},
+		{testSetSchedule},
+		{testSetScheduleInvalid},
+		{testScheduleErrorDeviceNotFound},
+		{testConfigure},
+		{testConfigureInvalid},
+		{testConfigureErrorDeviceNotFound},
+		{testLight},
+		{testLightInvalid},
+		{testRename},
 	}
 	for _, tc := range testCases {
 		t.Run(fmt.Sprintf("Test aha api %s", runtime.FuncForPC(reflect.ValueOf(tc.test).Pointer()).Name()), func(t *testing.T) {
@@ -73,7 +85,7 @@ func TestFritzAPI(t *testing.T) {
 	}
 }
 
-func login(mock *mock.Fritz, t *testing.T) HomeAuto {
+func login(mock *mock.Fritz, t *testing.T) *homeAuto {
 	u, err := url.Parse(mock.Server.URL)
 	assert.NoError(t, err)
 	client, err := NewClient("../mock/client_config_template.yml")
@@ -82,63 +94,136 @@ func login(mock *mock.Fritz, t *testing.T) HomeAuto {
 	client.Config.Net.Host = u.Host
 	err = client.Login()
 	assert.NoError(t, err)
-	return &homeAuto{client: client, aha: newAinBased(client)}
+	return &homeAuto{client: client, aha: newAinBased(client), hkr: &hkrPage{client: client}}
 }
 
-func testTemp(t *testing.T, fritz HomeAuto) {
+func testTemp(t *testing.T, fritz *homeAuto) {
 	err := fritz.Temp(12.5, "HKR_2")
 	assert.NoError(t, err)
 }
 
-func testTempErrorDeviceNotFound(t *testing.T, h HomeAuto) {
+func testTempErrorDeviceNotFound(t *testing.T, h *homeAuto) {
 	err := h.Temp(12.5, "DOES-NOT-EXIST")
 	assert.Error(t, err)
 }
 
-func testOn(t *testing.T, h HomeAuto) {
+func testOn(t *testing.T, h *homeAuto) {
 	err := h.On("SWITCH_1")
 	assert.NoError(t, err)
 }
 
-func testOnError(t *testing.T, h HomeAuto) {
+func testOnError(t *testing.T, h *homeAuto) {
 	err := h.On("DEVICE_THAT_DOES_NOT_EXIST")
 	assert.Error(t, err)
 }
 
-func testOff(t *testing.T, h HomeAuto) {
+func testOff(t *testing.T, h *homeAuto) {
 	err := h.Off("SWITCH_2")
 	assert.NoError(t, err)
 }
 
-func testOffError(t *testing.T, h HomeAuto) {
+func testOffError(t *testing.T, h *homeAuto) {
 	err := h.Off("DEVICE_THAT_DOES_NOT_EXIST")
 	assert.Error(t, err)
 }
 
-func testToggle(t *testing.T, h HomeAuto) {
+func testToggle(t *testing.T, h *homeAuto) {
 	err := h.Toggle("SWITCH_2")
 	assert.NoError(t, err)
 }
 
-func testToggleMany(t *testing.T, h HomeAuto) {
+func testToggleMany(t *testing.T, h *homeAuto) {
 	err := h.Toggle("SWITCH_1", "SWITCH_2", "SWITCH_3")
 	assert.NoError(t, err)
 }
 
-func testToggleError(t *testing.T, h HomeAuto) {
+func testToggleError(t *testing.T, h *homeAuto) {
 	err := h.Toggle("SWITCH_1", "SWITCH_2", "SWITCH_3", "SWITCH_4_FAILING")
 	assert.Error(t, err)
 }
 
-func testToggleErrorDeviceNotFound(t *testing.T, fritz HomeAuto) {
+func testToggleErrorDeviceNotFound(t *testing.T, fritz *homeAuto) {
 	err := fritz.Toggle("SWITCH_1", "UNKNOWN", "SWITCH_3")
 	assert.Error(t, err)
 }
 
+func testStats(t *testing.T, h *homeAuto) {
+	stats, err := h.Stats("SWITCH_1")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, stats.Energy)
+}
+
+func testStatsErrorDeviceNotFound(t *testing.T, h *homeAuto) {
+	_, err := h.Stats("UNKNOWN")
+	assert.Error(t, err)
+}
+
+func testSchedule(t *testing.T, h *homeAuto) {
+	s, err := h.Schedule("HKR_1")
+	assert.NoError(t, err)
+	assert.Len(t, s.Weekly, 3)
+	assert.Equal(t, SwitchPoint{Days: []string{"sat", "sun"}, At: "08:00", Mode: ComfortMode}, s.Weekly[1])
+	assert.Equal(t, []Holiday{{From: "12-24 08:00", To: "01-06 18:00"}}, s.Holidays)
+	assert.Equal(t, &SummerBreak{From: "06-01", To: "09-15"}, s.Summer)
+}
+
+func testSetSchedule(t *testing.T, h *homeAuto) {
+	err := h.SetSchedule("HKR_1", &HeatingSchedule{Weekly: []SwitchPoint{{Days: []string{"daily"}, At: "07:00", Mode: ComfortMode}}})
+	assert.NoError(t, err)
+}
+
+func testSetScheduleInvalid(t *testing.T, h *homeAuto) {
+	err := h.SetSchedule("HKR_1", &HeatingSchedule{Weekly: []SwitchPoint{{Days: []string{"daily"}, At: "7", Mode: ComfortMode}}})
+	assert.Error(t, err)
+}
+
+func testScheduleErrorDeviceNotFound(t *testing.T, h *homeAuto) {
+	_, err := h.Schedule("UNKNOWN")
+	assert.Error(t, err)
+	err = h.SetSchedule("UNKNOWN", &HeatingSchedule{})
+	assert.Error(t, err)
+}
+
+func testConfigure(t *testing.T, h *homeAuto) {
+	comfort, lock := 22.0, true
+	err := h.Configure(ThermostatSettings{Comfort: &comfort, Lock: &lock}, "HKR_1", "HKR_2")
+	assert.NoError(t, err)
+}
+
+func testConfigureInvalid(t *testing.T, h *homeAuto) {
+	offset := 11.0
+	err := h.Configure(ThermostatSettings{Offset: &offset}, "HKR_1")
+	assert.Error(t, err)
+}
+
+func testConfigureErrorDeviceNotFound(t *testing.T, h *homeAuto) {
+	saving := 16.0
+	err := h.Configure(ThermostatSettings{Saving: &saving}, "HKR_1", "UNKNOWN")
+	assert.Error(t, err)
+}
+
+func testLight(t *testing.T, h *homeAuto) {
+	on, level, hue, saturation := true, 80, 120, 200
+	err := h.Light(LightSettings{State: &on, Level: &level, Hue: &hue, Saturation: &saturation}, "BULB_1")
+	assert.NoError(t, err)
+}
+
+func testLightInvalid(t *testing.T, h *homeAuto) {
+	hue := 120
+	err := h.Light(LightSettings{Hue: &hue}, "BULB_1")
+	assert.Error(t, err)
+}
+
+func testRename(t *testing.T, h *homeAuto) {
+	assert.NoError(t, h.Rename("SWITCH_1", "Kitchen"))
+	assert.NoError(t, h.Rename("HKR_1", "Living room"))
+	assert.Error(t, h.Rename("UNKNOWN", "Kitchen"))
+}
+
 // TestWithServerShutDown test the FRITZ API error handling when the backend is unreachable spontaneously.
 func TestWithServerShutDown(t *testing.T) {
 	testCases := []struct {
-		test func(t *testing.T, h HomeAuto, s *httptest.Server)
+		test func(t *testing.T, h *homeAuto, s *httptest.Server)
 	}{
 		{testOffErrorServerDown},
 		{testToggleServerDown},
@@ -154,19 +239,19 @@ func TestWithServerShutDown(t *testing.T) {
 	}
 }
 
-func testOffErrorServerDown(t *testing.T, h HomeAuto, s *httptest.Server) {
+func testOffErrorServerDown(t *testing.T, h *homeAuto, s *httptest.Server) {
 	s.Close()
 	err := h.Off("SWITCH_1")
 	assert.Error(t, err)
 }
 
-func testToggleServerDown(t *testing.T, h HomeAuto, s *httptest.Server) {
+func testToggleServerDown(t *testing.T, h *homeAuto, s *httptest.Server) {
 	s.Close()
 	err := h.Toggle("SWITCH_1")
 	assert.Error(t, err)
 }
 
-func testTempServerDown(t *testing.T, h HomeAuto, s *httptest.Server) {
+func testTempServerDown(t *testing.T, h *homeAuto, s *httptest.Server) {
 	s.Close()
 	err := h.Temp(12.5, "HKR_1")
 	assert.Error(t, err)
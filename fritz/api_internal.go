@@ -1,6 +1,8 @@
 package fritz
 
 import (
+	"fmt"
+
 	"github.com/bpicode/fritzctl/httpread"
 	"github.com/bpicode/fritzctl/internal/errors"
 )
@@ -55,7 +57,13 @@ func (i *internal) InternetStats() (*TrafficMonitoringData, error) {
 		build()
 	var data []TrafficMonitoringData
 	err := httpread.JSON(i.client.getf(url), &data)
-	return &data[0], err
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("no traffic data in response")
+	}
+	return &data[0], nil
 }
 
 // BoxInfo queries metadata from the FRITZ!Box. Data is drawn from: https://fritz.box/cgi-bin/system_status.
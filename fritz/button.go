@@ -2,14 +2,47 @@ package fritz
 
 import (
 	"strconv"
+	"strings"
 	"time"
 )
 
-// Button collects data from devices that have a pressable button.
+// Button collects data from devices that have a pressable button. Devices with several buttons, or which tell short
+// and long presses apart, report one Button per unit.
 type Button struct {
+	Identifier           string `xml:"identifier,attr"`      // AIN of the button unit, e.g. "09995 0000001-1". Empty for devices with a single button.
+	ID                   string `xml:"id,attr"`              // Internal ID of the button unit. Empty for devices with a single button.
+	Name                 string `xml:"name"`                 // Name of the button unit, e.g. "FRITZ!DECT 400: kurz". Empty for devices with a single button.
 	LastPressedTimestamp string `xml:"lastpressedtimestamp"` // Timestamp (in epoch seconds) when the button was last pressed. "0" or "" if unknown.
 }
 
+// Press types reported by PressType.
+const (
+	ShortPress = "short"
+	LongPress  = "long"
+)
+
+// PressType returns ShortPress or LongPress if the name of the button unit indicates that it reports only short or
+// long presses. It returns "" if the unit does not tell them apart.
+func (b *Button) PressType() string {
+	name := strings.ToLower(b.Name)
+	switch {
+	case strings.HasSuffix(name, "kurz"), strings.HasSuffix(name, "short"):
+		return ShortPress
+	case strings.HasSuffix(name, "lang"), strings.HasSuffix(name, "long"):
+		return LongPress
+	}
+	return ""
+}
+
+// UnitName returns the part of the name which identifies the button unit within the device, e.g. "kurz" for
+// "FRITZ!DECT 400: kurz". It returns the whole name if it has no such suffix.
+func (b *Button) UnitName() string {
+	if i := strings.LastIndex(b.Name, ": "); i >= 0 {
+		return b.Name[i+2:]
+	}
+	return b.Name
+}
+
 // LastPressed returns the time when the button was last pressed. It returns nil on absence of this information or upon parsing errors.
 func (b *Button) LastPressed() *time.Time {
 	unix, err := strconv.ParseInt(b.LastPressedTimestamp, 10, 64)
@@ -38,3 +38,19 @@ func TestButton_FmtLastPressedCompact(t *testing.T) {
 	assert.NotEmpty(t, (&Button{LastPressedTimestamp: "0"}).FmtLastPressedCompact(oneDayAfterGenesis))
 	assert.NotEmpty(t, (&Button{LastPressedTimestamp: "1545160121"}).FmtLastPressedCompact(genesis))
 }
+
+// TestButton_PressType tests the detection of short and long press units.
+func TestButton_PressType(t *testing.T) {
+	assert.Equal(t, ShortPress, (&Button{Name: "FRITZ!DECT 400 #14: kurz"}).PressType())
+	assert.Equal(t, LongPress, (&Button{Name: "FRITZ!DECT 400 #14: lang"}).PressType())
+	assert.Equal(t, LongPress, (&Button{Name: "Hallway: Long"}).PressType())
+	assert.Empty(t, (&Button{Name: "FRITZ!DECT 440 #1: Oben rechts"}).PressType())
+	assert.Empty(t, (&Button{}).PressType())
+}
+
+// TestButton_UnitName tests the extraction of the unit name.
+func TestButton_UnitName(t *testing.T) {
+	assert.Equal(t, "kurz", (&Button{Name: "FRITZ!DECT 400 #14: kurz"}).UnitName())
+	assert.Equal(t, "Oben rechts", (&Button{Name: "FRITZ!DECT 440 #1: Oben rechts"}).UnitName())
+	assert.Equal(t, "plain", (&Button{Name: "plain"}).UnitName())
+}
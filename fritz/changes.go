@@ -0,0 +1,88 @@
+package fritz
+
+import "strconv"
+
+// Change is a modification of a watched property of a device, detected by comparing subsequent device lists.
+type Change struct {
+	Property string // The property that changed, see ChangeTracker.
+	Before   string // Formatted value before the change.
+	After    string // Formatted value after the change.
+	Device   Device // Device data of the list that revealed the change.
+}
+
+// ChangeTracker compares subsequent device lists and reports changes of the properties "present", "switch", "alert",
+// "button", "temperature", "goal", "battery" and "window". Devices are keyed by AIN and internal ID, as AINs alone are
+// not always unique.
+type ChangeTracker struct {
+	known   map[string]map[string]string
+	devices map[string]Device
+}
+
+// NewChangeTracker creates a ChangeTracker that has not seen any devices yet.
+func NewChangeTracker() *ChangeTracker {
+	return &ChangeTracker{
+		known:   make(map[string]map[string]string),
+		devices: make(map[string]Device),
+	}
+}
+
+// property extracts a watched value from a device.
+type property struct {
+	name  string
+	value func(d *Device) string
+}
+
+var properties = []property{
+	{name: "present", value: func(d *Device) string { return strconv.Itoa(d.Present) }},
+	{name: "switch", value: func(d *Device) string { return d.Switch.State }},
+	{name: "alert", value: func(d *Device) string { return d.AlertSensor.State }},
+	{name: "button", value: func(d *Device) string { return d.Button.LastPressedTimestamp }},
+	{name: "temperature", value: func(d *Device) string { return d.Temperature.FmtCelsius() }},
+	{name: "goal", value: func(d *Device) string { return d.Thermostat.FmtGoalTemperature() }},
+	{name: "battery", value: func(d *Device) string { return d.Thermostat.BatteryLow }},
+	{name: "window", value: func(d *Device) string { return d.Thermostat.WindowOpen }},
+}
+
+// Update compares the devices to the ones passed previously. Devices that are seen for the first time only establish
+// the baseline, devices that vanished from the list are reported as not present.
+func (t *ChangeTracker) Update(ds []Device) []Change {
+	var changes []Change
+	seen := make(map[string]bool, len(ds))
+	for _, d := range ds {
+		seen[deviceKey(d)] = true
+		t.devices[deviceKey(d)] = d
+		changes = append(changes, t.compare(d)...)
+	}
+	for key, d := range t.devices {
+		if !seen[key] {
+			d.Present = 0
+			changes = append(changes, t.compare(d)...)
+		}
+	}
+	return changes
+}
+
+func (t *ChangeTracker) compare(d Device) []Change {
+	known, ok := t.known[deviceKey(d)]
+	if !ok {
+		known = make(map[string]string, len(properties))
+		for _, p := range properties {
+			known[p.name] = p.value(&d)
+		}
+		t.known[deviceKey(d)] = known
+		return nil
+	}
+	var changes []Change
+	for _, p := range properties {
+		before, after := known[p.name], p.value(&d)
+		if before != after {
+			known[p.name] = after
+			changes = append(changes, Change{Property: p.name, Before: before, After: after, Device: d})
+		}
+	}
+	return changes
+}
+
+func deviceKey(d Device) string {
+	return d.Identifier + "#" + d.ID
+}
@@ -0,0 +1,73 @@
+package fritz
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestChangeTracker tests the detection of changes between two device lists.
+func TestChangeTracker(t *testing.T) {
+	tests := []struct {
+		name   string
+		before []Device
+		after  []Device
+		want   []string
+	}{
+		{name: "nothing", want: nil},
+		{name: "first appearance is no change", after: []Device{{Identifier: "1", Present: 1}}, want: nil},
+		{
+			name:   "switch toggled",
+			before: []Device{{Identifier: "1", Present: 1, Switch: Switch{State: "0"}}},
+			after:  []Device{{Identifier: "1", Present: 1, Switch: Switch{State: "1"}}},
+			want:   []string{"switch: 0 -> 1"},
+		},
+		{
+			name:   "alert raised",
+			before: []Device{{Identifier: "1", Present: 1, AlertSensor: AlertSensor{State: "0"}}},
+			after:  []Device{{Identifier: "1", Present: 1, AlertSensor: AlertSensor{State: "1"}}},
+			want:   []string{"alert: 0 -> 1"},
+		},
+		{
+			name:   "button pressed",
+			before: []Device{{Identifier: "1", Present: 1, Button: Button{LastPressedTimestamp: "1545160000"}}},
+			after:  []Device{{Identifier: "1", Present: 1, Button: Button{LastPressedTimestamp: "1545160121"}}},
+			want:   []string{"button: 1545160000 -> 1545160121"},
+		},
+		{
+			name:   "device went absent",
+			before: []Device{{Identifier: "1", Present: 1}},
+			after:  []Device{{Identifier: "1", Present: 0}},
+			want:   []string{"present: 1 -> 0"},
+		},
+		{
+			name:   "device vanished",
+			before: []Device{{Identifier: "1", Present: 1}},
+			want:   []string{"present: 1 -> 0"},
+		},
+		{
+			name:   "goal temperature",
+			before: []Device{{Identifier: "1", Present: 1, Thermostat: Thermostat{Goal: "40"}}},
+			after:  []Device{{Identifier: "1", Present: 1, Thermostat: Thermostat{Goal: "42"}}},
+			want:   []string{"goal: 20 -> 21"},
+		},
+		{
+			name:   "devices sharing an AIN are told apart by ID",
+			before: []Device{{Identifier: "1", ID: "16", Present: 1, Switch: Switch{State: "0"}}, {Identifier: "1", ID: "17", Present: 1, Switch: Switch{State: "1"}}},
+			after:  []Device{{Identifier: "1", ID: "16", Present: 1, Switch: Switch{State: "0"}}, {Identifier: "1", ID: "17", Present: 1, Switch: Switch{State: "1"}}},
+			want:   nil,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := NewChangeTracker()
+			assert.Empty(t, tr.Update(tc.before))
+			var changes []string
+			for _, c := range tr.Update(tc.after) {
+				assert.Equal(t, "1", c.Device.Identifier)
+				changes = append(changes, c.Property+": "+c.Before+" -> "+c.After)
+			}
+			assert.Equal(t, tc.want, changes)
+		})
+	}
+}
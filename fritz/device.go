@@ -1,5 +1,7 @@
 package fritz
 
+import "encoding/xml"
+
 // Capability enumerates the device capabilities.
 type Capability int
 
@@ -7,7 +9,7 @@ type Capability int
 const (
 	HANFUNCompatibility Capability = iota
 	_
-	_
+	Light
 	_
 	AlertTrigger
 	_
@@ -19,6 +21,10 @@ const (
 	Microphone
 	_
 	HANFUNUnit
+	_
+	OnOffControl
+	Dimmable
+	ColorLight
 )
 
 // Device models a smart home device. This corresponds to
@@ -38,16 +44,51 @@ type Device struct {
 	Temperature     Temperature `xml:"temperature"`          // Only filled with sensible data for devices with a temperature sensor.
 	Thermostat      Thermostat  `xml:"hkr"`                  // Thermostat data, only filled with sensible data for HKR devices.
 	AlertSensor     AlertSensor `xml:"alert"`                // Only filled with sensible data for devices with an alert sensor.
-	Button          Button      `xml:"button"`               // Button data, only filled with sensible data for button devices.
+	Button          Button      `xml:"button"`               // Button data, only filled with sensible data for button devices. For devices with several button units, this is the one pressed last.
+	Buttons         []Button    `xml:"-"`                    // All button units of the device, in the order reported by the FRITZ!Box.
+	OnOff           OnOff       `xml:"simpleonoff"`          // Only filled with sensible data for devices that can be turned on and off, like bulbs.
+	Level           Level       `xml:"levelcontrol"`         // Only filled with sensible data for dimmable devices.
+	Color           Color       `xml:"colorcontrol"`         // Only filled with sensible data for colored lights.
 }
 
 // codebeat:enable[TOO_MANY_IVARS]
 
+// UnmarshalXML decodes a device and collects all of its button units. Devices like the FRITZ!DECT 400 or 440 report
+// one button element per unit.
+func (d *Device) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+	type plain Device
+	var aux struct {
+		plain
+		Buttons []Button `xml:"button"`
+	}
+	if err := dec.DecodeElement(&aux, &start); err != nil {
+		return err
+	}
+	*d = Device(aux.plain)
+	d.Buttons = aux.Buttons
+	for i, b := range aux.Buttons {
+		if i == 0 || pressedLater(&b, &d.Button) {
+			d.Button = b
+		}
+	}
+	return nil
+}
+
+func pressedLater(b, than *Button) bool {
+	t, ref := b.LastPressed(), than.LastPressed()
+	return t != nil && (ref == nil || t.After(*ref))
+}
+
 // IsHANFUNCompatible returns true if the device speaks the "Home Area Network FUNctional protocol".
 func (d *Device) IsHANFUNCompatible() bool {
 	return d.Has(HANFUNCompatibility)
 }
 
+// IsLight returns true if the device is a light, e.g. a bulb.
+func (d *Device) IsLight() bool {
+	return d.Has(Light)
+}
+
 // HasAlertSensor returns true if the device has a sensor that may trigger alerts.
 func (d *Device) HasAlertSensor() bool {
 	return d.Has(AlertTrigger)
@@ -88,6 +129,11 @@ func (d *Device) HasHANFUNUnit() bool {
 	return d.Has(HANFUNUnit)
 }
 
+// HasButton returns true if the device has a pressable button, i.e. it reports when it was last pressed.
+func (d *Device) HasButton() bool {
+	return d.Button.LastPressedTimestamp != ""
+}
+
 // Has checks the passed capabilities and returns true iff the device supports all capabilities.
 func (d *Device) Has(cs ...Capability) bool {
 	for _, c := range cs {
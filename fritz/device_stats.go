@@ -0,0 +1,53 @@
+package fritz
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DeviceStats holds the statistics that the FRITZ!Box keeps for a smart home device, as returned by the
+// getbasicdevicestats command. Each measurement may be recorded in several series with different resolutions.
+type DeviceStats struct {
+	Temperature []StatsSeries `xml:"temperature>stats"` // Temperature in 0.1°C.
+	Voltage     []StatsSeries `xml:"voltage>stats"`     // Voltage in mV.
+	Power       []StatsSeries `xml:"power>stats"`       // Power in 0.01W.
+	Energy      []StatsSeries `xml:"energy>stats"`      // Energy in Wh.
+}
+
+// StatsSeries is a series of equidistant values, the most recent value comes first.
+type StatsSeries struct {
+	Count  int    `xml:"count,attr"` // Number of values.
+	Grid   int64  `xml:"grid,attr"`  // Distance between two values in seconds.
+	Values string `xml:",chardata"`  // Comma-separated values, "-" marks an unknown value.
+}
+
+// Interval returns the distance between two values of the series.
+func (s *StatsSeries) Interval() time.Duration {
+	return time.Duration(s.Grid) * time.Second
+}
+
+// Parse returns the values of the series, the most recent value comes first. Unknown values are returned as nil.
+func (s *StatsSeries) Parse() []*float64 {
+	tokens := strings.Split(strings.TrimSpace(s.Values), ",")
+	values := make([]*float64, 0, len(tokens))
+	for _, t := range tokens {
+		f, err := strconv.ParseFloat(strings.TrimSpace(t), 64)
+		if err != nil {
+			values = append(values, nil)
+			continue
+		}
+		values = append(values, &f)
+	}
+	return values
+}
+
+// EnergySeries returns the energy series with the given grid, if present.
+func (d *DeviceStats) EnergySeries(grid time.Duration) (StatsSeries, bool) {
+	for _, s := range d.Energy {
+		if s.Interval() == grid {
+			return s, true
+		}
+	}
+	return StatsSeries{}, false
+}
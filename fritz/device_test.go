@@ -1,6 +1,7 @@
 package fritz
 
 import (
+	"encoding/xml"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -38,3 +39,48 @@ func TestParsingFunctionBitMask(t *testing.T) {
 		})
 	}
 }
+
+// TestUnmarshalButtonUnits tests that all button units of a device are decoded.
+func TestUnmarshalButtonUnits(t *testing.T) {
+	data := `<devicelist version="1">
+<device identifier="13096 0007307" id="20" functionbitmask="1048864" fwversion="05.10" manufacturer="AVM" productname="FRITZ!DECT 400">
+	<present>1</present>
+	<name>DECT 400</name>
+	<button identifier="13096 0007307-1" id="5000">
+		<name>DECT 400: kurz</name>
+		<lastpressedtimestamp>1545160200</lastpressedtimestamp>
+	</button>
+	<button identifier="13096 0007307-9" id="5001">
+		<name>DECT 400: lang</name>
+		<lastpressedtimestamp>1545160100</lastpressedtimestamp>
+	</button>
+</device>
+<device identifier="214124 34625478542353" id="19" functionbitmask="8193">
+	<name>BTN_1</name>
+	<button><lastpressedtimestamp>1545160121</lastpressedtimestamp></button>
+</device>
+<device identifier="11630 0123456" id="17" functionbitmask="320">
+	<name>HKR</name>
+</device>
+</devicelist>`
+	var l Devicelist
+	assert.NoError(t, xml.Unmarshal([]byte(data), &l))
+	assert.Len(t, l.Devices, 3)
+
+	multi := l.Devices[0]
+	assert.Equal(t, "DECT 400", multi.Name)
+	assert.Equal(t, "13096 0007307", multi.Identifier)
+	assert.Len(t, multi.Buttons, 2)
+	assert.Equal(t, "13096 0007307-9", multi.Buttons[1].Identifier)
+	assert.Equal(t, "5001", multi.Buttons[1].ID)
+	assert.Equal(t, LongPress, multi.Buttons[1].PressType())
+	assert.Equal(t, "1545160200", multi.Button.LastPressedTimestamp, "the unit pressed last")
+
+	single := l.Devices[1]
+	assert.Len(t, single.Buttons, 1)
+	assert.Equal(t, "1545160121", single.Button.LastPressedTimestamp)
+	assert.True(t, single.HasButton())
+
+	assert.Empty(t, l.Devices[2].Buttons)
+	assert.False(t, l.Devices[2].HasButton())
+}
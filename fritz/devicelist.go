@@ -29,6 +29,13 @@ func (l *Devicelist) Thermostats() []Device {
 	})
 }
 
+// Lights returns the devices which satisfy IsLight.
+func (l *Devicelist) Lights() []Device {
+	return l.filter(func(d Device) bool {
+		return d.IsLight()
+	})
+}
+
 // AlertSensors returns the devices which satisfy HasAlertSensor.
 func (l *Devicelist) AlertSensors() []Device {
 	return l.filter(func(d Device) bool {
@@ -36,10 +43,10 @@ func (l *Devicelist) AlertSensors() []Device {
 	})
 }
 
-// Buttons returns the devices which have a pressable button.
+// Buttons returns the devices which satisfy HasButton.
 func (l *Devicelist) Buttons() []Device {
 	return l.filter(func(d Device) bool {
-		return d.Button.LastPressedTimestamp != ""
+		return d.HasButton()
 	})
 }
 
@@ -15,6 +15,7 @@ const (
 	inetStatURI       = "/internet/inetstat_monitor.lua"
 	phoneListURI      = "/fon_num/foncalls_list.lua"
 	systemStatusURI   = "/cgi-bin/system_status"
+	dataURI           = "/data.lua"
 )
 
 type fritzURLBuilder interface {
@@ -4,9 +4,11 @@ import (
 	"crypto/md5"
 	"crypto/tls"
 	"crypto/x509"
+	stderrors "errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 
 	"github.com/bpicode/fritzctl/config"
 	"github.com/bpicode/fritzctl/httpread"
@@ -71,6 +73,13 @@ func (client *Client) Login() error {
 	return nil
 }
 
+// IsSessionError reports whether err was caused by the FRITZ!Box rejecting the session, e.g. because it expired. The
+// rejected request had no effect, so it may be repeated after a new login.
+func IsSessionError(err error) bool {
+	var se *httpread.StatusError
+	return stderrors.As(err, &se) && se.Code == http.StatusForbidden
+}
+
 func (client *Client) obtainChallenge() (*SessionInfo, error) {
 	url := client.Config.GetLoginURL()
 	getRemote := func() (*http.Response, error) {
@@ -143,3 +152,12 @@ func (client *Client) getf(url string) func() (*http.Response, error) {
 		return client.HTTPClient.Get(url)
 	}
 }
+
+// postf posts the form, adding the session id.
+func (client *Client) postf(u string, form url.Values) func() (*http.Response, error) {
+	return func() (*http.Response, error) {
+		form.Set("sid", client.SessionInfo.SID)
+		logger.Debug("POST", u)
+		return client.HTTPClient.PostForm(u, form)
+	}
+}
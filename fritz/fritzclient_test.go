@@ -2,10 +2,13 @@ package fritz
 
 import (
 	"fmt"
+	"net/http"
 	"net/url"
 	"testing"
 
 	"github.com/bpicode/fritzctl/config"
+	"github.com/bpicode/fritzctl/httpread"
+	"github.com/bpicode/fritzctl/internal/errors"
 	"github.com/bpicode/fritzctl/mock"
 	"github.com/stretchr/testify/assert"
 )
@@ -106,6 +109,16 @@ func TestCertHandling(t *testing.T) {
 	assert.Nil(t, tlsConfig.RootCAs)
 }
 
+// TestIsSessionError tests the detection of rejected sessions through wrapped errors.
+func TestIsSessionError(t *testing.T) {
+	forbidden := &httpread.StatusError{Code: http.StatusForbidden, Status: "403 Forbidden"}
+	assert.True(t, IsSessionError(forbidden))
+	assert.True(t, IsSessionError(errors.Wrapf(forbidden, "unable to list devices")))
+	assert.False(t, IsSessionError(&httpread.StatusError{Code: http.StatusInternalServerError}))
+	assert.False(t, IsSessionError(fmt.Errorf("HTTP status code error (403)")))
+	assert.False(t, IsSessionError(nil))
+}
+
 // TestUtf8To16LE tests the UTF-8 to UTF-16 little endian conversion.
 func TestUtf8To16LE(t *testing.T) {
 	tcs := []struct {
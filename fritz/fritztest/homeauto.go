@@ -0,0 +1,117 @@
+// Package fritztest provides a fake of the home automation API for tests of its users.
+package fritztest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/bpicode/fritzctl/fritz"
+)
+
+// HomeAuto is a fake fritz.HomeAuto, which records the commands instead of sending them to a FRITZ!Box. It also
+// implements fritz.StatsReader, fritz.ThermostatConfigurer, fritz.LightController and fritz.Renamer. A command is
+// recorded as one entry per call, e.g. "on [a b]" or "temp 21.5 [c]". HomeAuto is safe for concurrent use.
+type HomeAuto struct {
+	Devices []fritz.Device      // Returned by List, unless Lists is given.
+	Lists   []*fritz.Devicelist // Returned by subsequent calls of List, which fails after the last one.
+	Fail    map[string]bool     // Commands that fail after being recorded.
+	Err     error               // If not nil, every call fails with it.
+
+	mu       sync.Mutex
+	listed   int
+	commands []string
+}
+
+// Login succeeds unless Err is set.
+func (h *HomeAuto) Login() error {
+	return h.Err
+}
+
+// List returns the configured devices.
+func (h *HomeAuto) List() (*fritz.Devicelist, error) {
+	if h.Err != nil {
+		return nil, h.Err
+	}
+	if h.Lists == nil {
+		return &fritz.Devicelist{Devices: h.Devices}, nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.listed >= len(h.Lists) {
+		return nil, errors.New("no more device lists")
+	}
+	l := h.Lists[h.listed]
+	h.listed++
+	return l, nil
+}
+
+// On records the command.
+func (h *HomeAuto) On(names ...string) error {
+	return h.record(fmt.Sprintf("on %v", names))
+}
+
+// Off records the command.
+func (h *HomeAuto) Off(names ...string) error {
+	return h.record(fmt.Sprintf("off %v", names))
+}
+
+// Toggle records the command.
+func (h *HomeAuto) Toggle(names ...string) error {
+	return h.record(fmt.Sprintf("toggle %v", names))
+}
+
+// Temp records the command.
+func (h *HomeAuto) Temp(value float64, names ...string) error {
+	return h.record(fmt.Sprintf("temp %v %v", value, names))
+}
+
+// Stats returns empty statistics.
+func (h *HomeAuto) Stats(name string) (*fritz.DeviceStats, error) {
+	return &fritz.DeviceStats{}, h.Err
+}
+
+// Schedule returns an empty heating schedule.
+func (h *HomeAuto) Schedule(name string) (*fritz.HeatingSchedule, error) {
+	return &fritz.HeatingSchedule{}, h.Err
+}
+
+// SetSchedule records the command.
+func (h *HomeAuto) SetSchedule(name string, s *fritz.HeatingSchedule) error {
+	return h.record("schedule " + name)
+}
+
+// Configure records the command.
+func (h *HomeAuto) Configure(s fritz.ThermostatSettings, names ...string) error {
+	return h.record(fmt.Sprintf("configure %s %v", s, names))
+}
+
+// Light records the command.
+func (h *HomeAuto) Light(s fritz.LightSettings, names ...string) error {
+	return h.record(fmt.Sprintf("light %s %v", s, names))
+}
+
+// Rename records the command.
+func (h *HomeAuto) Rename(name, newName string) error {
+	return h.record("rename " + name + " " + newName)
+}
+
+// Commands returns the recorded commands in the order they were received.
+func (h *HomeAuto) Commands() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.commands...)
+}
+
+func (h *HomeAuto) record(command string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.commands = append(h.commands, command)
+	if h.Err != nil {
+		return h.Err
+	}
+	if h.Fail[command] {
+		return fmt.Errorf("command '%s' failed", command)
+	}
+	return nil
+}
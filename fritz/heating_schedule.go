@@ -0,0 +1,281 @@
+package fritz
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Modes of a SwitchPoint.
+const (
+	ComfortMode = "comfort"
+	SavingMode  = "saving"
+)
+
+// MaxHolidays is the number of holiday periods a thermostat supports.
+const MaxHolidays = 4
+
+// scheduleDays are the days of the week in the order of the bits of the day mask used by the FRITZ!Box.
+var scheduleDays = []string{"mon", "tue", "wed", "thu", "fri", "sat", "sun"}
+
+// dayAliases are abbreviations for several days of the week.
+var dayAliases = map[string][]string{
+	"daily":    scheduleDays,
+	"weekdays": scheduleDays[:5],
+	"weekend":  scheduleDays[5:],
+}
+
+// HeatingSchedule is the timer of a thermostat: the weekly switch points between comfort and saving temperature, the
+// holiday periods, in which the saving temperature applies, and the summer break, in which the thermostat is off.
+type HeatingSchedule struct {
+	Weekly   []SwitchPoint `json:"weekly" yaml:"weekly"`                         // The weekly switch points.
+	Holidays []Holiday     `json:"holidays,omitempty" yaml:"holidays,omitempty"` // Up to MaxHolidays holiday periods.
+	Summer   *SummerBreak  `json:"summer,omitempty" yaml:"summer,omitempty"`     // The summer break, nil if disabled.
+}
+
+// SwitchPoint switches to the comfort or saving temperature at a time on some days of the week.
+type SwitchPoint struct {
+	Days []string `json:"days" yaml:"days,flow"` // Days of the week: mon, tue, ..., sun, or daily, weekdays, weekend.
+	At   string   `json:"at" yaml:"at"`          // Time of the day, e.g. "06:30".
+	Mode string   `json:"mode" yaml:"mode"`      // Either "comfort" or "saving".
+}
+
+// Holiday is a period in which the saving temperature applies. The FRITZ!Box supports full hours only.
+type Holiday struct {
+	From string `json:"from" yaml:"from"` // Start as month, day and hour, e.g. "12-24 08:00".
+	To   string `json:"to" yaml:"to"`     // End as month, day and hour, e.g. "01-06 18:00".
+}
+
+// SummerBreak is a period in which the thermostat is off.
+type SummerBreak struct {
+	From string `json:"from" yaml:"from"` // First day as month and day, e.g. "06-01".
+	To   string `json:"to" yaml:"to"`     // Last day as month and day, e.g. "09-15".
+}
+
+// Validate checks the schedule for invalid values.
+func (s *HeatingSchedule) Validate() error {
+	_, err := s.fields()
+	return err
+}
+
+// Equal returns true if the schedules switch in the same way.
+func (s *HeatingSchedule) Equal(o *HeatingSchedule) bool {
+	if s == nil || o == nil {
+		return s == o
+	}
+	a, errA := s.fields()
+	b, errB := o.fields()
+	return errA == nil && errB == nil && a.Encode() == b.Encode()
+}
+
+// String formats the schedule in one line.
+func (s *HeatingSchedule) String() string {
+	var parts []string
+	for _, p := range s.Weekly {
+		parts = append(parts, fmt.Sprintf("%s %s %s", strings.Join(p.Days, ","), p.At, p.Mode))
+	}
+	for _, h := range s.Holidays {
+		parts = append(parts, fmt.Sprintf("holiday %s - %s", h.From, h.To))
+	}
+	if s.Summer != nil {
+		parts = append(parts, fmt.Sprintf("summer %s - %s", s.Summer.From, s.Summer.To))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// fields encodes the schedule as the form fields of the settings page of the thermostat. The switch points become
+// "timer_item_<n>" with values like "0630;1;31" (time, 1 for comfort or 0 for saving, bit mask of days starting with
+// Monday), sorted by time. The holidays and the summer break are given by day, month and hour.
+func (s *HeatingSchedule) fields() (url.Values, error) {
+	f := make(url.Values)
+	items, err := s.timerItems()
+	if err != nil {
+		return nil, err
+	}
+	for i, item := range items {
+		f.Set(fmt.Sprintf("timer_item_%d", i), item)
+	}
+	if len(s.Holidays) > MaxHolidays {
+		return nil, fmt.Errorf("%d holidays exceed the maximum of %d", len(s.Holidays), MaxHolidays)
+	}
+	f.Set("HolidayEnabledCount", strconv.Itoa(len(s.Holidays)))
+	for i := 1; i <= MaxHolidays; i++ {
+		prefix := fmt.Sprintf("Holiday%d", i)
+		f.Set(prefix+"ID", strconv.Itoa(i))
+		if i > len(s.Holidays) {
+			f.Set(prefix+"Enabled", "0")
+			continue
+		}
+		if err := setHoliday(f, prefix, s.Holidays[i-1]); err != nil {
+			return nil, err
+		}
+	}
+	f.Set("SummerEnabled", "0")
+	if s.Summer != nil {
+		if err := setSummer(f, *s.Summer); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+func (s *HeatingSchedule) timerItems() ([]string, error) {
+	var items []string
+	for _, p := range s.Weekly {
+		at, err := time.Parse("15:04", p.At)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time '%s' of switch point, expected e.g. 06:30", p.At)
+		}
+		mask, err := dayMask(p.Days)
+		if err != nil {
+			return nil, err
+		}
+		var comfort int
+		switch p.Mode {
+		case ComfortMode:
+			comfort = 1
+		case SavingMode:
+		default:
+			return nil, fmt.Errorf("invalid mode '%s' of switch point, expected one of %s, %s", p.Mode, ComfortMode, SavingMode)
+		}
+		items = append(items, fmt.Sprintf("%s;%d;%d", at.Format("1504"), comfort, mask))
+	}
+	sort.Strings(items)
+	return items, nil
+}
+
+func dayMask(days []string) (int, error) {
+	if len(days) == 0 {
+		return 0, fmt.Errorf("switch point without days")
+	}
+	mask := 0
+	for _, d := range days {
+		names, ok := dayAliases[strings.ToLower(d)]
+		if !ok {
+			names = []string{strings.ToLower(d)}
+		}
+		for _, n := range names {
+			i := indexOf(scheduleDays, n)
+			if i < 0 {
+				return 0, fmt.Errorf("invalid day '%s', expected one of %s, daily, weekdays, weekend", d, strings.Join(scheduleDays, ", "))
+			}
+			mask |= 1 << uint(i)
+		}
+	}
+	return mask, nil
+}
+
+func indexOf(ss []string, s string) int {
+	for i, e := range ss {
+		if e == s {
+			return i
+		}
+	}
+	return -1
+}
+
+func setHoliday(f url.Values, prefix string, h Holiday) error {
+	from, err := time.Parse("01-02 15:04", h.From)
+	if err != nil || from.Minute() != 0 {
+		return fmt.Errorf("invalid start '%s' of holiday, expected a full hour like 12-24 08:00", h.From)
+	}
+	to, err := time.Parse("01-02 15:04", h.To)
+	if err != nil || to.Minute() != 0 {
+		return fmt.Errorf("invalid end '%s' of holiday, expected a full hour like 01-06 18:00", h.To)
+	}
+	f.Set(prefix+"Enabled", "1")
+	setDate(f, prefix+"Start", from)
+	f.Set(prefix+"StartHour", strconv.Itoa(from.Hour()))
+	setDate(f, prefix+"End", to)
+	f.Set(prefix+"EndHour", strconv.Itoa(to.Hour()))
+	return nil
+}
+
+func setSummer(f url.Values, s SummerBreak) error {
+	from, err := time.Parse("01-02", s.From)
+	if err != nil {
+		return fmt.Errorf("invalid start '%s' of summer break, expected e.g. 06-01", s.From)
+	}
+	to, err := time.Parse("01-02", s.To)
+	if err != nil {
+		return fmt.Errorf("invalid end '%s' of summer break, expected e.g. 09-15", s.To)
+	}
+	f.Set("SummerEnabled", "1")
+	setDate(f, "SummerStart", from)
+	setDate(f, "SummerEnd", to)
+	return nil
+}
+
+func setDate(f url.Values, prefix string, t time.Time) {
+	f.Set(prefix+"Day", strconv.Itoa(t.Day()))
+	f.Set(prefix+"Month", strconv.Itoa(int(t.Month())))
+}
+
+// isScheduleField returns true for the form fields that make up the schedule.
+func isScheduleField(name string) bool {
+	return strings.HasPrefix(name, "timer_item_") || strings.HasPrefix(name, "Holiday") || strings.HasPrefix(name, "Summer")
+}
+
+// parseHeatingSchedule decodes the schedule from the form fields of the settings page of the thermostat.
+func parseHeatingSchedule(f url.Values) (*HeatingSchedule, error) {
+	s := &HeatingSchedule{Weekly: []SwitchPoint{}}
+	for i := 0; f.Get(fmt.Sprintf("timer_item_%d", i)) != ""; i++ {
+		item := f.Get(fmt.Sprintf("timer_item_%d", i))
+		p, err := parseTimerItem(item)
+		if err != nil {
+			return nil, err
+		}
+		s.Weekly = append(s.Weekly, p)
+	}
+	for i := 1; i <= MaxHolidays; i++ {
+		prefix := fmt.Sprintf("Holiday%d", i)
+		if f.Get(prefix+"Enabled") != "1" {
+			continue
+		}
+		s.Holidays = append(s.Holidays, Holiday{
+			From: fmt.Sprintf("%s %s:00", getDate(f, prefix+"Start"), twoDigits(f.Get(prefix+"StartHour"))),
+			To:   fmt.Sprintf("%s %s:00", getDate(f, prefix+"End"), twoDigits(f.Get(prefix+"EndHour"))),
+		})
+	}
+	if f.Get("SummerEnabled") == "1" {
+		s.Summer = &SummerBreak{From: getDate(f, "SummerStart"), To: getDate(f, "SummerEnd")}
+	}
+	return s, s.Validate()
+}
+
+func parseTimerItem(item string) (SwitchPoint, error) {
+	var p SwitchPoint
+	parts := strings.Split(item, ";")
+	if len(parts) != 3 || len(parts[0]) != 4 {
+		return p, fmt.Errorf("invalid timer item '%s'", item)
+	}
+	mask, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return p, fmt.Errorf("invalid days of timer item '%s'", item)
+	}
+	for i, d := range scheduleDays {
+		if mask&(1<<uint(i)) != 0 {
+			p.Days = append(p.Days, d)
+		}
+	}
+	p.At = parts[0][:2] + ":" + parts[0][2:]
+	p.Mode = SavingMode
+	if parts[1] == "1" {
+		p.Mode = ComfortMode
+	}
+	return p, nil
+}
+
+func getDate(f url.Values, prefix string) string {
+	return twoDigits(f.Get(prefix+"Month")) + "-" + twoDigits(f.Get(prefix+"Day"))
+}
+
+func twoDigits(s string) string {
+	if len(s) == 1 {
+		return "0" + s
+	}
+	return s
+}
@@ -0,0 +1,73 @@
+package fritz
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestHeatingScheduleRoundTrip tests that a schedule survives encoding into and decoding from form fields.
+func TestHeatingScheduleRoundTrip(t *testing.T) {
+	s := &HeatingSchedule{
+		Weekly: []SwitchPoint{
+			{Days: []string{"weekdays"}, At: "06:00", Mode: ComfortMode},
+			{Days: []string{"daily"}, At: "22:00", Mode: SavingMode},
+			{Days: []string{"sat", "Sun"}, At: "08:30", Mode: ComfortMode},
+		},
+		Holidays: []Holiday{{From: "12-24 08:00", To: "01-06 18:00"}},
+		Summer:   &SummerBreak{From: "06-01", To: "09-15"},
+	}
+	f, err := s.fields()
+	assert.NoError(t, err)
+	assert.Equal(t, "0600;1;31", f.Get("timer_item_0"))
+	assert.Equal(t, "0830;1;96", f.Get("timer_item_1"))
+	assert.Equal(t, "2200;0;127", f.Get("timer_item_2"))
+	assert.Equal(t, "1", f.Get("HolidayEnabledCount"))
+	assert.Equal(t, "24", f.Get("Holiday1StartDay"))
+	assert.Equal(t, "12", f.Get("Holiday1StartMonth"))
+	assert.Equal(t, "8", f.Get("Holiday1StartHour"))
+	assert.Equal(t, "0", f.Get("Holiday2Enabled"))
+	assert.Equal(t, "1", f.Get("SummerEnabled"))
+	assert.Equal(t, "9", f.Get("SummerEndMonth"))
+
+	parsed, err := parseHeatingSchedule(f)
+	assert.NoError(t, err)
+	assert.Equal(t, []SwitchPoint{
+		{Days: []string{"mon", "tue", "wed", "thu", "fri"}, At: "06:00", Mode: ComfortMode},
+		{Days: []string{"sat", "sun"}, At: "08:30", Mode: ComfortMode},
+		{Days: []string{"mon", "tue", "wed", "thu", "fri", "sat", "sun"}, At: "22:00", Mode: SavingMode},
+	}, parsed.Weekly)
+	assert.Equal(t, s.Holidays, parsed.Holidays)
+	assert.Equal(t, s.Summer, parsed.Summer)
+	assert.True(t, s.Equal(parsed))
+	assert.False(t, s.Equal(&HeatingSchedule{}))
+	assert.False(t, s.Equal(nil))
+	assert.True(t, (*HeatingSchedule)(nil).Equal(nil))
+	assert.Contains(t, s.String(), "weekdays 06:00 comfort")
+}
+
+// TestHeatingScheduleInvalid tests the validation of schedules.
+func TestHeatingScheduleInvalid(t *testing.T) {
+	for _, s := range []HeatingSchedule{
+		{Weekly: []SwitchPoint{{Days: []string{"mon"}, At: "25:00", Mode: ComfortMode}}},
+		{Weekly: []SwitchPoint{{Days: []string{"someday"}, At: "06:00", Mode: ComfortMode}}},
+		{Weekly: []SwitchPoint{{At: "06:00", Mode: ComfortMode}}},
+		{Weekly: []SwitchPoint{{Days: []string{"mon"}, At: "06:00", Mode: "warm"}}},
+		{Holidays: []Holiday{{From: "12-24 08:30", To: "01-06 18:00"}}},
+		{Holidays: []Holiday{{From: "12-24 08:00", To: "tomorrow"}}},
+		{Holidays: make([]Holiday, MaxHolidays+1)},
+		{Summer: &SummerBreak{From: "June", To: "09-15"}},
+		{Summer: &SummerBreak{From: "06-01", To: "09-31"}},
+	} {
+		assert.Error(t, s.Validate(), "%+v", s)
+	}
+}
+
+// TestParseHeatingScheduleInvalid tests that malformed form fields are rejected.
+func TestParseHeatingScheduleInvalid(t *testing.T) {
+	for _, item := range []string{"0600;1", "600;1;31", "0600;1;x", "0600;1;0"} {
+		_, err := parseHeatingSchedule(url.Values{"timer_item_0": {item}})
+		assert.Error(t, err, item)
+	}
+}
@@ -0,0 +1,93 @@
+package fritz
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/bpicode/fritzctl/httpread"
+)
+
+// hkrPage reads and writes the settings page of a thermostat in the web interface of the FRITZ!Box, which is served
+// by the internal data.lua endpoint. The page is represented by its form fields, e.g. "Heiztemp" or "timer_item_0".
+// Writing posts all fields of the page, so the fields should be read, modified and written back. The settings pages
+// of other devices, see editPageName, are read and written the same way.
+type hkrPage struct {
+	client *Client
+}
+
+const (
+	hkrPageName  = "home_auto_hkr_edit"
+	editPageName = "home_auto_edit_view"
+)
+
+// read obtains the form fields of the settings page of the thermostat with the given internal ID.
+func (p *hkrPage) read(id string) (url.Values, error) {
+	return p.readPage(hkrPageName, id)
+}
+
+// write posts the form fields of the settings page of the thermostat with the given internal ID.
+func (p *hkrPage) write(id string, fields url.Values) error {
+	return p.writePage(hkrPageName, id, fields)
+}
+
+// readPage obtains the form fields of the given settings page of the device with the given internal ID.
+func (p *hkrPage) readPage(page, id string) (url.Values, error) {
+	form := url.Values{"xhr": {"1"}, "page": {page}, "device": {id}}
+	var resp struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := httpread.JSON(p.client.postf(p.url(), form), &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Data) == 0 {
+		return nil, fmt.Errorf("no settings found for device with ID '%s'", id)
+	}
+	fields := make(url.Values)
+	for k, v := range resp.Data {
+		if s, ok := formValue(v); ok {
+			fields.Set(k, s)
+		}
+	}
+	return fields, nil
+}
+
+// formValue formats a scalar JSON value as a form field. Objects, arrays and nulls are no form fields, they are
+// not reported.
+func formValue(v interface{}) (string, bool) {
+	switch x := v.(type) {
+	case string:
+		return x, true
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(x), true
+	default:
+		return "", false
+	}
+}
+
+// writePage posts the form fields of the given settings page of the device with the given internal ID.
+func (p *hkrPage) writePage(page, id string, fields url.Values) error {
+	form := make(url.Values)
+	for k, vs := range fields {
+		form[k] = vs
+	}
+	form.Set("xhr", "1")
+	form.Set("page", page)
+	form.Set("device", id)
+	form.Set("apply", "")
+	resp, err := httpread.String(p.client.postf(p.url(), form))
+	if err != nil {
+		return err
+	}
+	if strings.Contains(resp, `"apply":"error"`) {
+		return fmt.Errorf("the FRITZ!Box rejected the settings of the device with ID '%s'", id)
+	}
+	return nil
+}
+
+func (p *hkrPage) url() string {
+	return newURLBuilder(p.client.Config).path(dataURI).build()
+}
@@ -0,0 +1,43 @@
+package fritz
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bpicode/fritzctl/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestReadPageScalarsOnly tests that only scalar values of the settings page become form fields.
+func TestReadPageScalarsOnly(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hkr")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	settings := filepath.Join(dir, "hkr_settings.json")
+	assert.NoError(t, ioutil.WriteFile(settings, []byte(`{"data": {
+		"Heiztemp": 21.5,
+		"Serial": 123456789012345678901,
+		"ule_device_name": "HKR_1",
+		"locklocal": false,
+		"nested": {"a": 1},
+		"list": ["a", "b"],
+		"nothing": null
+	}}`), 0644))
+	m := mock.New()
+	m.HkrSettings = settings
+	m.Start()
+	defer m.Close()
+	h := login(m, t)
+
+	fields, err := h.hkr.read("12")
+	assert.NoError(t, err)
+	assert.Equal(t, "21.5", fields.Get("Heiztemp"))
+	assert.Equal(t, "123456789012345680000", fields.Get("Serial"))
+	assert.Equal(t, "HKR_1", fields.Get("ule_device_name"))
+	assert.Equal(t, "false", fields.Get("locklocal"))
+	assert.NotContains(t, fields, "nested")
+	assert.NotContains(t, fields, "list")
+	assert.NotContains(t, fields, "nothing")
+}
@@ -0,0 +1,115 @@
+package fritz
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// OnOff models the state of devices that can be turned on and off, like bulbs.
+type OnOff struct {
+	State string `xml:"state"` // 1/0 on/off (empty if not known or if there was an error).
+}
+
+// Level models the brightness of dimmable devices.
+type Level struct {
+	Level           string `xml:"level"`           // Brightness, 0-255 (empty if not known or if there was an error).
+	LevelPercentage string `xml:"levelpercentage"` // Brightness in percent, 0-100 (empty if not known or if there was an error).
+}
+
+// Color modes of colored lights.
+const (
+	HueSaturationMode    = "1" // The color is given by hue and saturation.
+	ColorTemperatureMode = "4" // The color is given by the color temperature.
+)
+
+// Color models the color of colored lights.
+type Color struct {
+	SupportedModes string `xml:"supported_modes,attr"` // Bit mask of the supported color modes.
+	CurrentMode    string `xml:"current_mode,attr"`    // The current color mode, see HueSaturationMode and ColorTemperatureMode.
+	Hue            string `xml:"hue"`                  // Hue in degrees, 0-359 (empty if not applicable).
+	Saturation     string `xml:"saturation"`           // Saturation, 0-255 (empty if not applicable).
+	Temperature    string `xml:"temperature"`          // Color temperature in kelvin (empty if not applicable).
+}
+
+// LightSettings are the adjustable settings of a light. Nil values are left untouched.
+type LightSettings struct {
+	State            *bool // On or off.
+	Level            *int  // Brightness in percent, 0-100.
+	Hue              *int  // Hue in degrees, 0-359, only together with Saturation.
+	Saturation       *int  // Saturation, 0-255, only together with Hue.
+	ColorTemperature *int  // Color temperature in kelvin, 2700-6500, not together with Hue and Saturation.
+}
+
+// Validate checks the settings for invalid values.
+func (s LightSettings) Validate() error {
+	_, err := s.commands()
+	return err
+}
+
+// String formats the settings that are set.
+func (s LightSettings) String() string {
+	var str string
+	add := func(name, value string) {
+		if str != "" {
+			str += ", "
+		}
+		str += name + "=" + value
+	}
+	if s.State != nil {
+		add("state", map[bool]string{true: "on", false: "off"}[*s.State])
+	}
+	for _, t := range []struct {
+		name  string
+		value *int
+		unit  string
+	}{{"level", s.Level, "%"}, {"hue", s.Hue, "°"}, {"saturation", s.Saturation, ""}, {"colortemperature", s.ColorTemperature, "K"}} {
+		if t.value != nil {
+			add(t.name, strconv.Itoa(*t.value)+t.unit)
+		}
+	}
+	return str
+}
+
+// commands encodes the settings as the query parameters of the commands of the AHA HTTP interface.
+func (s LightSettings) commands() ([]url.Values, error) {
+	var cmds []url.Values
+	if s.State != nil {
+		cmds = append(cmds, url.Values{"switchcmd": {"setsimpleonoff"}, "onoff": {boolField(*s.State)}})
+	}
+	if s.Level != nil {
+		if err := inRange("level", *s.Level, 0, 100); err != nil {
+			return nil, err
+		}
+		cmds = append(cmds, url.Values{"switchcmd": {"setlevelpercentage"}, "level": {strconv.Itoa(*s.Level)}})
+	}
+	if (s.Hue == nil) != (s.Saturation == nil) {
+		return nil, fmt.Errorf("hue and saturation have to be given together")
+	}
+	if s.Hue != nil && s.ColorTemperature != nil {
+		return nil, fmt.Errorf("either hue and saturation or color temperature can be given")
+	}
+	if s.Hue != nil {
+		if err := inRange("hue", *s.Hue, 0, 359); err != nil {
+			return nil, err
+		}
+		if err := inRange("saturation", *s.Saturation, 0, 255); err != nil {
+			return nil, err
+		}
+		cmds = append(cmds, url.Values{"switchcmd": {"setcolor"}, "hue": {strconv.Itoa(*s.Hue)}, "saturation": {strconv.Itoa(*s.Saturation)}, "duration": {"0"}})
+	}
+	if s.ColorTemperature != nil {
+		if err := inRange("color temperature", *s.ColorTemperature, 2700, 6500); err != nil {
+			return nil, err
+		}
+		cmds = append(cmds, url.Values{"switchcmd": {"setcolortemperature"}, "temperature": {strconv.Itoa(*s.ColorTemperature)}, "duration": {"0"}})
+	}
+	return cmds, nil
+}
+
+func inRange(name string, v, min, max int) error {
+	if v < min || v > max {
+		return fmt.Errorf("invalid %s: %d is not contained in the set of acceptable values: %d-%d", name, v, min, max)
+	}
+	return nil
+}
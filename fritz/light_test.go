@@ -0,0 +1,46 @@
+package fritz
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+// TestLightSettingsCommands tests the encoding of light settings as commands.
+func TestLightSettingsCommands(t *testing.T) {
+	s := LightSettings{State: boolPtr(true), Level: intPtr(50), Hue: intPtr(358), Saturation: intPtr(180)}
+	cmds, err := s.commands()
+	assert.NoError(t, err)
+	assert.Len(t, cmds, 3)
+	assert.Equal(t, "setsimpleonoff", cmds[0].Get("switchcmd"))
+	assert.Equal(t, "1", cmds[0].Get("onoff"))
+	assert.Equal(t, "50", cmds[1].Get("level"))
+	assert.Equal(t, "358", cmds[2].Get("hue"))
+	assert.Equal(t, "180", cmds[2].Get("saturation"))
+	assert.Equal(t, "state=on, level=50%, hue=358°, saturation=180", s.String())
+
+	cmds, err = LightSettings{ColorTemperature: intPtr(2700)}.commands()
+	assert.NoError(t, err)
+	assert.Equal(t, "setcolortemperature", cmds[0].Get("switchcmd"))
+	assert.Equal(t, "2700", cmds[0].Get("temperature"))
+	assert.Empty(t, LightSettings{}.String())
+}
+
+// TestLightSettingsInvalid tests that values outside the accepted ranges are rejected.
+func TestLightSettingsInvalid(t *testing.T) {
+	for _, s := range []LightSettings{
+		{Level: intPtr(101)},
+		{Hue: intPtr(120)},
+		{Saturation: intPtr(120)},
+		{Hue: intPtr(360), Saturation: intPtr(120)},
+		{Hue: intPtr(120), Saturation: intPtr(256)},
+		{Hue: intPtr(120), Saturation: intPtr(120), ColorTemperature: intPtr(2700)},
+		{ColorTemperature: intPtr(2000)},
+	} {
+		assert.Error(t, s.Validate(), s.String())
+	}
+}
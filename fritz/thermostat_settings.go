@@ -0,0 +1,107 @@
+package fritz
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+)
+
+// ThermostatSettings are the adjustable settings of a thermostat. Nil values are left untouched.
+type ThermostatSettings struct {
+	Comfort    *float64 // Comfort temperature in °C, 8-28°C in steps of 0.5°C.
+	Saving     *float64 // Saving temperature in °C, 8-28°C in steps of 0.5°C.
+	Offset     *float64 // Offset of the temperature sensor in °C, -10-10°C in steps of 0.5°C.
+	Lock       *bool    // Lock the thermostat against changes via the user interface and apps of the FRITZ!Box.
+	DeviceLock *bool    // Lock the operating elements on the thermostat itself.
+}
+
+// Validate checks the settings for invalid values.
+func (s ThermostatSettings) Validate() error {
+	_, err := s.fields()
+	return err
+}
+
+// String formats the settings that are set.
+func (s ThermostatSettings) String() string {
+	var str string
+	add := func(name, value string) {
+		if str != "" {
+			str += ", "
+		}
+		str += name + "=" + value
+	}
+	for _, t := range []struct {
+		name  string
+		value *float64
+	}{{"comfort", s.Comfort}, {"saving", s.Saving}, {"offset", s.Offset}} {
+		if t.value != nil {
+			add(t.name, strconv.FormatFloat(*t.value, 'f', -1, 64)+"°C")
+		}
+	}
+	if s.Lock != nil {
+		add("lock", strconv.FormatBool(*s.Lock))
+	}
+	if s.DeviceLock != nil {
+		add("devicelock", strconv.FormatBool(*s.DeviceLock))
+	}
+	return str
+}
+
+// fields encodes the settings as the form fields of the settings page of the thermostat.
+func (s ThermostatSettings) fields() (url.Values, error) {
+	f := make(url.Values)
+	for _, t := range []struct {
+		field string
+		name  string
+		value *float64
+	}{{"Heiztemp", "comfort", s.Comfort}, {"Absenktemp", "saving", s.Saving}} {
+		if t.value == nil {
+			continue
+		}
+		if err := settingTemperature(*t.value); err != nil {
+			return nil, fmt.Errorf("invalid %s temperature: %v", t.name, err)
+		}
+		f.Set(t.field, strconv.FormatFloat(*t.value, 'f', -1, 64))
+	}
+	if s.Offset != nil {
+		if err := offsetTemperature(*s.Offset); err != nil {
+			return nil, err
+		}
+		f.Set("Offset", strconv.FormatFloat(*s.Offset, 'f', -1, 64))
+	}
+	if s.Lock != nil {
+		f.Set("lockuiapp", boolField(*s.Lock))
+	}
+	if s.DeviceLock != nil {
+		f.Set("locklocal", boolField(*s.DeviceLock))
+	}
+	return f, nil
+}
+
+// settingTemperature checks a comfort or saving temperature. They follow the rules of temperatureParam, but
+// cannot be on or off.
+func settingTemperature(t float64) error {
+	param, err := temperatureParam(t)
+	if err != nil {
+		return err
+	}
+	if float64(param) != 2*t || param > 56 {
+		return fmt.Errorf("%v°C is not contained in the set of acceptable values: 8-28°C in steps of 0.5°C", t)
+	}
+	return nil
+}
+
+func offsetTemperature(o float64) error {
+	if o < -10 || o > 10 || math.Mod(2*o, 1) != 0 {
+		return fmt.Errorf("invalid offset: %v°C is not contained in the set of acceptable values: -10-10°C in steps of 0.5°C", o)
+	}
+	return nil
+}
+
+func boolField(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
@@ -0,0 +1,49 @@
+package fritz
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+// TestThermostatSettingsFields tests the encoding of thermostat settings.
+func TestThermostatSettingsFields(t *testing.T) {
+	s := ThermostatSettings{Comfort: floatPtr(21.5), Saving: floatPtr(16), Offset: floatPtr(-1.5), Lock: boolPtr(true), DeviceLock: boolPtr(false)}
+	f, err := s.fields()
+	assert.NoError(t, err)
+	assert.Equal(t, "21.5", f.Get("Heiztemp"))
+	assert.Equal(t, "16", f.Get("Absenktemp"))
+	assert.Equal(t, "-1.5", f.Get("Offset"))
+	assert.Equal(t, "1", f.Get("lockuiapp"))
+	assert.Equal(t, "0", f.Get("locklocal"))
+	assert.Equal(t, "comfort=21.5°C, saving=16°C, offset=-1.5°C, lock=true, devicelock=false", s.String())
+
+	f, err = ThermostatSettings{Saving: floatPtr(8)}.fields()
+	assert.NoError(t, err)
+	assert.Len(t, f, 1)
+	assert.Empty(t, ThermostatSettings{}.String())
+}
+
+// TestThermostatSettingsInvalid tests that values outside the accepted ranges are rejected.
+func TestThermostatSettingsInvalid(t *testing.T) {
+	for _, s := range []ThermostatSettings{
+		{Comfort: floatPtr(7.5)},
+		{Comfort: floatPtr(28.5)},
+		{Comfort: floatPtr(21.3)},
+		{Comfort: floatPtr(126.5)},
+		{Saving: floatPtr(127)},
+		{Offset: floatPtr(10.5)},
+		{Offset: floatPtr(-0.2)},
+	} {
+		assert.Error(t, s.Validate(), s.String())
+	}
+	assert.NoError(t, ThermostatSettings{Offset: floatPtr(-10)}.Validate())
+}
@@ -0,0 +1,226 @@
+package fritz
+
+import (
+	"context"
+	"time"
+
+	"github.com/bpicode/fritzctl/internal/errors"
+	"github.com/bpicode/fritzctl/logger"
+)
+
+// EventType classifies the changes reported by a Watcher.
+type EventType string
+
+// Known event types.
+const (
+	PresenceChanged        EventType = "PresenceChanged"        // The device connected or disconnected. Devices vanishing from the list count as disconnected.
+	SwitchStateChanged     EventType = "SwitchStateChanged"     // The switch was turned on or off.
+	AlertRaised            EventType = "AlertRaised"            // The alert sensor started to report an alert.
+	AlertCleared           EventType = "AlertCleared"           // The alert sensor stopped to report an alert.
+	ButtonPressed          EventType = "ButtonPressed"          // The button was pressed, i.e. it reported a new timestamp.
+	TemperatureChanged     EventType = "TemperatureChanged"     // The temperature measured at the device changed.
+	GoalTemperatureChanged EventType = "GoalTemperatureChanged" // The desired temperature of a thermostat changed.
+	BatteryLow             EventType = "BatteryLow"             // The battery started running low on capacity.
+	WindowOpened           EventType = "WindowOpened"           // The thermostat detected an open window.
+	WindowClosed           EventType = "WindowClosed"           // The thermostat no longer detects an open window.
+)
+
+// Event is a change of a device detected by a Watcher.
+type Event struct {
+	Type   EventType `json:"type"`             // Kind of change.
+	Time   time.Time `json:"time"`             // When the change was detected.
+	Name   string    `json:"name"`             // Name of the device.
+	AIN    string    `json:"ain"`              // Identifier of the device.
+	Before string    `json:"before,omitempty"` // Formatted value before the change.
+	After  string    `json:"after,omitempty"`  // Formatted value after the change.
+	Device Device    `json:"-"`                // Device data of the poll that led to the event.
+}
+
+// Watcher polls the device list of a HomeAuto client periodically and emits an Event for every detected change.
+// codebeat:disable[TOO_MANY_IVARS]
+type Watcher struct {
+	homeAuto HomeAuto
+	interval time.Duration
+	debounce time.Duration
+	filter   func(Device) bool
+	onList   func(*Devicelist)
+	onError  func(error)
+}
+
+// codebeat:enable[TOO_MANY_IVARS]
+
+// NewWatcher creates a Watcher polling the given HomeAuto client, which is expected to be logged in already.
+// By default it polls every 10 seconds, does not debounce and watches all devices.
+func NewWatcher(h HomeAuto, options ...WatchOption) *Watcher {
+	w := Watcher{
+		homeAuto: h,
+		interval: 10 * time.Second,
+		filter:   func(Device) bool { return true },
+		onList:   func(*Devicelist) {},
+		onError: func(err error) {
+			logger.Warn("Polling devices failed:", err)
+		},
+	}
+	for _, option := range options {
+		option(&w)
+	}
+	return &w
+}
+
+// Watch starts polling in the background and returns the channel on which the events are sent. The first poll only
+// establishes the baseline. The channel is closed after ctx is done. Callers should drain the channel, polling is
+// suspended while an event cannot be delivered.
+func (w *Watcher) Watch(ctx context.Context) <-chan Event {
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		t := NewChangeTracker()
+		b := &debouncer{duration: w.debounce}
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		for {
+			for _, e := range w.poll(t, b, time.Now()) {
+				select {
+				case events <- e:
+				case <-ctx.Done():
+					return
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return events
+}
+
+func (w *Watcher) poll(t *ChangeTracker, b *debouncer, now time.Time) []Event {
+	l, err := w.list()
+	if err != nil {
+		w.onError(err)
+		return nil
+	}
+	w.onList(l)
+	var ds []Device
+	for _, d := range l.Devices {
+		if w.filter(d) {
+			ds = append(ds, d)
+		}
+	}
+	return classify(b.update(t.Update(ds), now), now)
+}
+
+// list obtains the device list. If that fails, e.g. because the session expired or the FRITZ!Box rebooted, one new
+// login is attempted.
+func (w *Watcher) list() (*Devicelist, error) {
+	l, err := w.homeAuto.List()
+	if err == nil {
+		return l, nil
+	}
+	logger.Debug("Listing devices failed, logging in again:", err)
+	if err := w.homeAuto.Login(); err != nil {
+		return nil, errors.Wrapf(err, "login failed")
+	}
+	return w.homeAuto.List()
+}
+
+// eventTypes classifies the changes of the properties watched by the ChangeTracker. An empty type means the change is
+// not reported.
+var eventTypes = map[string]func(after string) EventType{
+	"present":     always(PresenceChanged),
+	"switch":      always(SwitchStateChanged),
+	"alert":       oneOrZero(AlertRaised, AlertCleared),
+	"button":      buttonPress,
+	"temperature": always(TemperatureChanged),
+	"goal":        always(GoalTemperatureChanged),
+	"battery":     oneOrZero(BatteryLow, ""),
+	"window":      oneOrZero(WindowOpened, WindowClosed),
+}
+
+func always(t EventType) func(string) EventType {
+	return func(string) EventType {
+		return t
+	}
+}
+
+func oneOrZero(one, zero EventType) func(string) EventType {
+	return func(after string) EventType {
+		switch after {
+		case "1":
+			return one
+		case "0":
+			return zero
+		}
+		return ""
+	}
+}
+
+func buttonPress(after string) EventType {
+	if after == "" || after == "0" {
+		return ""
+	}
+	return ButtonPressed
+}
+
+// classify turns the changes into events, dropping the ones that are not reported.
+func classify(cs []Change, now time.Time) []Event {
+	var es []Event
+	for _, c := range cs {
+		if typ := eventTypes[c.Property](c.After); typ != "" {
+			es = append(es, Event{Type: typ, Time: now, Name: c.Device.Name, AIN: c.Device.Identifier, Before: c.Before, After: c.After, Device: c.Device})
+		}
+	}
+	return es
+}
+
+// debouncer holds back changes until they were observed for at least the debounce duration. Changes that are reverted
+// before are dropped.
+type debouncer struct {
+	duration time.Duration
+	pending  []pendingChange
+}
+
+type pendingChange struct {
+	key   string
+	since time.Time
+	Change
+}
+
+// update merges the changes into the pending ones and returns the changes that are stable for long enough.
+func (b *debouncer) update(cs []Change, now time.Time) []Change {
+	if b.duration <= 0 {
+		return cs
+	}
+	for _, c := range cs {
+		b.merge(c, now)
+	}
+	var stable []Change
+	pending := b.pending[:0]
+	for _, p := range b.pending {
+		if now.Sub(p.since) >= b.duration {
+			stable = append(stable, p.Change)
+		} else {
+			pending = append(pending, p)
+		}
+	}
+	b.pending = pending
+	return stable
+}
+
+func (b *debouncer) merge(c Change, now time.Time) {
+	key := deviceKey(c.Device) + "/" + c.Property
+	for i, p := range b.pending {
+		if p.key != key {
+			continue
+		}
+		if p.Before == c.After {
+			b.pending = append(b.pending[:i], b.pending[i+1:]...)
+			return
+		}
+		b.pending[i].After, b.pending[i].Device, b.pending[i].since = c.After, c.Device, now
+		return
+	}
+	b.pending = append(b.pending, pendingChange{key: key, since: now, Change: c})
+}
@@ -0,0 +1,33 @@
+package fritz_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bpicode/fritzctl/fritz"
+	"github.com/bpicode/fritzctl/fritz/fritztest"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWatcherWatch tests event delivery and filtering.
+func TestWatcherWatch(t *testing.T) {
+	h := &fritztest.HomeAuto{Lists: []*fritz.Devicelist{
+		{Devices: []fritz.Device{{Identifier: "1", Name: "a", Switch: fritz.Switch{State: "0"}}, {Identifier: "2", Name: "b", Switch: fritz.Switch{State: "0"}}}},
+		{Devices: []fritz.Device{{Identifier: "1", Name: "a", Switch: fritz.Switch{State: "1"}}, {Identifier: "2", Name: "b", Switch: fritz.Switch{State: "1"}}}},
+	}}
+	w := fritz.NewWatcher(h,
+		fritz.PollInterval(time.Millisecond),
+		fritz.DeviceFilter(func(d fritz.Device) bool { return d.Name == "b" }),
+		fritz.OnPollError(func(error) {}))
+	ctx, cancel := context.WithCancel(context.Background())
+	events := w.Watch(ctx)
+	e := <-events
+	cancel()
+	for range events {
+	}
+	assert.Equal(t, fritz.SwitchStateChanged, e.Type)
+	assert.Equal(t, "b", e.Name)
+	assert.Equal(t, "0", e.Before)
+	assert.Equal(t, "1", e.After)
+}
@@ -0,0 +1,45 @@
+package fritz
+
+import "time"
+
+// WatchOption applies fine-grained configuration to a Watcher.
+type WatchOption func(w *Watcher)
+
+// PollInterval sets the time between two subsequent polls of the device list. Non-positive values are ignored.
+func PollInterval(d time.Duration) WatchOption {
+	return func(w *Watcher) {
+		if d > 0 {
+			w.interval = d
+		}
+	}
+}
+
+// Debounce suppresses changes that do not persist for at least the given duration. A value flapping back before that
+// is not reported at all.
+func Debounce(d time.Duration) WatchOption {
+	return func(w *Watcher) {
+		w.debounce = d
+	}
+}
+
+// DeviceFilter restricts the watched devices to those satisfying the given predicate.
+func DeviceFilter(f func(Device) bool) WatchOption {
+	return func(w *Watcher) {
+		w.filter = f
+	}
+}
+
+// OnList registers a callback which is invoked with every successfully polled device list, before the changes are
+// evaluated.
+func OnList(f func(*Devicelist)) WatchOption {
+	return func(w *Watcher) {
+		w.onList = f
+	}
+}
+
+// OnPollError registers a callback which is invoked whenever a poll fails. The default logs a warning.
+func OnPollError(f func(error)) WatchOption {
+	return func(w *Watcher) {
+		w.onError = f
+	}
+}
@@ -0,0 +1,115 @@
+package fritz
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bpicode/fritzctl/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWatcherEvents tests the classification of changes between two polls.
+func TestWatcherEvents(t *testing.T) {
+	now := time.Unix(1545160121, 0)
+	tests := []struct {
+		name   string
+		before []Device
+		after  []Device
+		want   []EventType
+	}{
+		{name: "nothing", want: nil},
+		{
+			name:   "switch toggled",
+			before: []Device{{Identifier: "1", Present: 1, Switch: Switch{State: "0"}}},
+			after:  []Device{{Identifier: "1", Present: 1, Switch: Switch{State: "1"}}},
+			want:   []EventType{SwitchStateChanged},
+		},
+		{
+			name:   "alert raised",
+			before: []Device{{Identifier: "1", Present: 1, AlertSensor: AlertSensor{State: "0"}}},
+			after:  []Device{{Identifier: "1", Present: 1, AlertSensor: AlertSensor{State: "1"}}},
+			want:   []EventType{AlertRaised},
+		},
+		{
+			name:   "alert cleared",
+			before: []Device{{Identifier: "1", Present: 1, AlertSensor: AlertSensor{State: "1"}}},
+			after:  []Device{{Identifier: "1", Present: 1, AlertSensor: AlertSensor{State: "0"}}},
+			want:   []EventType{AlertCleared},
+		},
+		{
+			name:   "button pressed",
+			before: []Device{{Identifier: "1", Present: 1, Button: Button{LastPressedTimestamp: "1545160000"}}},
+			after:  []Device{{Identifier: "1", Present: 1, Button: Button{LastPressedTimestamp: "1545160121"}}},
+			want:   []EventType{ButtonPressed},
+		},
+		{
+			name:   "device vanished",
+			before: []Device{{Identifier: "1", Present: 1}},
+			want:   []EventType{PresenceChanged},
+		},
+		{
+			name:   "thermostat",
+			before: []Device{{Identifier: "1", Present: 1, Temperature: Temperature{Celsius: "200"}, Thermostat: Thermostat{Goal: "40", BatteryLow: "0", WindowOpen: "0"}}},
+			after:  []Device{{Identifier: "1", Present: 1, Temperature: Temperature{Celsius: "190"}, Thermostat: Thermostat{Goal: "42", BatteryLow: "1", WindowOpen: "1"}}},
+			want:   []EventType{TemperatureChanged, GoalTemperatureChanged, BatteryLow, WindowOpened},
+		},
+		{
+			name:   "battery recovery is not reported",
+			before: []Device{{Identifier: "1", Present: 1, Thermostat: Thermostat{BatteryLow: "1"}}},
+			after:  []Device{{Identifier: "1", Present: 1, Thermostat: Thermostat{BatteryLow: "0"}}},
+			want:   nil,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := NewChangeTracker()
+			tr.Update(tc.before)
+			var types []EventType
+			for _, e := range classify(tr.Update(tc.after), now) {
+				assert.Equal(t, now, e.Time)
+				assert.Equal(t, "1", e.AIN)
+				types = append(types, e.Type)
+			}
+			assert.Equal(t, tc.want, types)
+		})
+	}
+}
+
+// TestDebouncer tests that short-lived changes are suppressed.
+func TestDebouncer(t *testing.T) {
+	t0 := time.Unix(1545160121, 0)
+	on := []Device{{Identifier: "1", Present: 1, Switch: Switch{State: "1"}}}
+	off := []Device{{Identifier: "1", Present: 1, Switch: Switch{State: "0"}}}
+	tr := NewChangeTracker()
+	b := &debouncer{duration: time.Minute}
+	update := func(ds []Device, after time.Duration) []Change {
+		return b.update(tr.Update(ds), t0.Add(after))
+	}
+	update(off, 0)
+
+	assert.Empty(t, update(on, 10*time.Second))
+	assert.Empty(t, update(off, 20*time.Second))
+	assert.Empty(t, update(on, 30*time.Second))
+	assert.Empty(t, update(on, 80*time.Second))
+	changes := update(on, 90*time.Second)
+	assert.Len(t, changes, 1)
+	assert.Equal(t, "switch", changes[0].Property)
+	assert.Equal(t, "0", changes[0].Before)
+	assert.Equal(t, "1", changes[0].After)
+	assert.Empty(t, update(on, 100*time.Second))
+}
+
+// TestWatcherAgainstMock tests that the watcher uses the HomeAuto API.
+func TestWatcherAgainstMock(t *testing.T) {
+	m := mock.New().Start()
+	defer m.Close()
+	h := login(m, t)
+	var lists int
+	w := NewWatcher(h, OnList(func(*Devicelist) { lists++ }))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for range w.Watch(ctx) {
+	}
+	assert.Equal(t, 1, lists)
+}
@@ -4,12 +4,15 @@ require (
 	github.com/cpuguy83/go-md2man/v2 v2.0.0
 	github.com/fatih/color v1.10.0
 	github.com/julienschmidt/httprouter v1.3.0
+	github.com/mattn/go-sqlite3 v1.14.6
+	github.com/pelletier/go-toml v1.9.5
 	github.com/spf13/cobra v0.0.6-0.20191202130430-b04b5bfc50cb
 	github.com/spf13/pflag v1.0.5
 	github.com/stretchr/testify v1.6.1
 	golang.org/x/crypto v0.0.0-20191206172530-e9b2fee46413
 	golang.org/x/tools v0.0.0-20191213221258-04c2e8eff935
 	gopkg.in/yaml.v2 v2.4.0
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c
 )
 
 go 1.13
@@ -94,6 +94,17 @@ func guessStatusCode(body string) (int, string) {
 	return 0, ""
 }
 
+// StatusError is returned if the remote replied with an HTTP status code indicating an error.
+type StatusError struct {
+	Code   int    // The status code, e.g. 403.
+	Status string // The status line, e.g. "403 Forbidden".
+}
+
+// Error makes *StatusError an error type.
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("HTTP status code error (%d): remote replied with '%s'", e.Code, e.Status)
+}
+
 type decoder interface {
 	Decode(v interface{}) error
 }
@@ -123,7 +134,7 @@ func readDecode(f func() (*http.Response, error), df decoderFactory, v interface
 	}
 	defer response.Body.Close()
 	if response.StatusCode >= 400 {
-		return fmt.Errorf("HTTP status code error (%d): remote replied with '%s'", response.StatusCode, response.Status)
+		return &StatusError{Code: response.StatusCode, Status: response.Status}
 	}
 	return decode(response.Body, df, v)
 }
@@ -32,7 +32,8 @@ func TestError400(t *testing.T) {
 	_, err := String(func() (*http.Response, error) {
 		return resp, nil
 	})
-	assert.Error(t, err)
+	assert.EqualError(t, err, "HTTP status code error (400): remote replied with 'Bad Request'")
+	assert.Equal(t, 400, err.(*StatusError).Code)
 }
 
 // TestSuccess follows the regular workflow.
@@ -32,6 +32,12 @@ func (wc *withCause) Cause() error {
 	return wc.cause
 }
 
+// Unwrap returns the wrapped error, which makes the causal chain accessible to errors.Is and errors.As of the standard
+// library.
+func (wc *withCause) Unwrap() error {
+	return wc.cause
+}
+
 // Msg returns the "bare" error message. It differs from Error in that the causal chain is omitted.
 func (wc *withCause) Msg() string {
 	return wc.msg
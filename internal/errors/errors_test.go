@@ -18,4 +18,5 @@ func TestErrors(t *testing.T) {
 	assert.True(t, ok)
 	assert.Equal(t, wc.Cause().Error(), "inner")
 	assert.Equal(t, wc.Msg(), "outer")
+	assert.Equal(t, wc.Cause(), wc.Unwrap())
 }
@@ -0,0 +1,207 @@
+package mqtt
+
+import (
+	"bufio"
+	"net"
+	"sync"
+)
+
+// Broker is an in-process MQTT broker supporting quality of service 0, retained messages and wills. It is intended as
+// a stand-in for tests, not for production use.
+type Broker struct {
+	listener net.Listener
+	mu       sync.Mutex
+	sessions map[*session]bool
+	retained map[string]Message
+	wg       sync.WaitGroup
+}
+
+type session struct {
+	conn    net.Conn
+	writeMu sync.Mutex
+	filters []string
+	will    *Message
+}
+
+// NewBroker starts a broker listening on the given address, e.g. "127.0.0.1:0".
+func NewBroker(address string) (*Broker, error) {
+	l, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	b := &Broker{listener: l, sessions: make(map[*session]bool), retained: make(map[string]Message)}
+	b.wg.Add(1)
+	go b.accept()
+	return b, nil
+}
+
+// Addr returns the address the broker listens on.
+func (b *Broker) Addr() string {
+	return b.listener.Addr().String()
+}
+
+// Retained returns the retained message of the topic, if any.
+func (b *Broker) Retained(topic string) (Message, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	m, ok := b.retained[topic]
+	return m, ok
+}
+
+// DropClients closes the connections to all clients, as if the network failed.
+func (b *Broker) DropClients() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for s := range b.sessions {
+		s.conn.Close()
+	}
+}
+
+// Close stops the broker and closes all client connections.
+func (b *Broker) Close() {
+	b.listener.Close()
+	b.DropClients()
+	b.wg.Wait()
+}
+
+func (b *Broker) accept() {
+	defer b.wg.Done()
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			return
+		}
+		b.wg.Add(1)
+		go b.serve(conn)
+	}
+}
+
+func (b *Broker) serve(conn net.Conn) {
+	defer b.wg.Done()
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	p, err := readPacket(r)
+	if err != nil || p.typ != typeConnect {
+		return
+	}
+	s := &session{conn: conn, will: decodeWill(p)}
+	s.write(typeConnack, 0, []byte{0, 0})
+	b.mu.Lock()
+	b.sessions[s] = true
+	b.mu.Unlock()
+	defer func() {
+		b.mu.Lock()
+		delete(b.sessions, s)
+		b.mu.Unlock()
+		if s.will != nil {
+			b.publish(*s.will)
+		}
+	}()
+	for {
+		p, err := readPacket(r)
+		if err != nil {
+			return
+		}
+		switch p.typ {
+		case typePublish:
+			pub, err := decodePublish(p)
+			if err != nil {
+				return
+			}
+			b.publish(pub.Message)
+		case typeSubscribe:
+			b.subscribe(s, p)
+		case typePingreq:
+			s.write(typePingresp, 0, nil)
+		case typeDisconnect:
+			s.will = nil
+			return
+		}
+	}
+}
+
+func decodeWill(p packet) *Message {
+	d := decoder{b: p.body}
+	d.string()
+	d.byte()
+	flags := d.byte()
+	d.uint16()
+	d.string()
+	if flags&0x04 == 0 {
+		return nil
+	}
+	topic := d.string()
+	n := int(d.uint16())
+	if d.err != nil || len(d.b) < n {
+		return nil
+	}
+	return &Message{Topic: topic, Payload: d.b[:n], Retain: flags&0x20 != 0}
+}
+
+func (b *Broker) subscribe(s *session, p packet) {
+	d := decoder{b: p.body}
+	id := d.uint16()
+	var filters []string
+	for d.err == nil && len(d.b) > 0 {
+		filters = append(filters, d.string())
+		d.byte()
+	}
+	codes := make([]byte, len(filters))
+	var granted []string
+	for i, f := range filters {
+		if !ValidFilter(f) {
+			codes[i] = 0x80
+			continue
+		}
+		granted = append(granted, f)
+	}
+	s.write(typeSuback, 0, append(appendUint16(nil, id), codes...))
+	b.mu.Lock()
+	s.filters = append(s.filters, granted...)
+	var retained []Message
+	for _, m := range b.retained {
+		for _, f := range granted {
+			if Match(f, m.Topic) {
+				retained = append(retained, m)
+				break
+			}
+		}
+	}
+	b.mu.Unlock()
+	for _, m := range retained {
+		flags, body := encodePublish(m)
+		s.write(typePublish, flags, body)
+	}
+}
+
+func (b *Broker) publish(m Message) {
+	b.mu.Lock()
+	if m.Retain {
+		if len(m.Payload) == 0 {
+			delete(b.retained, m.Topic)
+		} else {
+			b.retained[m.Topic] = m
+		}
+	}
+	var receivers []*session
+	for s := range b.sessions {
+		for _, f := range s.filters {
+			if Match(f, m.Topic) {
+				receivers = append(receivers, s)
+				break
+			}
+		}
+	}
+	b.mu.Unlock()
+	m.Retain = false
+	flags, body := encodePublish(m)
+	for _, s := range receivers {
+		s.write(typePublish, flags, body)
+	}
+}
+
+func (s *session) write(typ, flags byte, body []byte) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	writePacket(s.conn, typ, flags, body)
+}
@@ -0,0 +1,337 @@
+// Package mqtt is a minimal MQTT 3.1.1 client, restricted to quality of service 0, together with an in-process
+// broker for tests.
+package mqtt
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message is an application message.
+type Message struct {
+	Topic   string
+	Payload []byte
+	Retain  bool
+}
+
+// Options configure the connection to the broker.
+type Options struct {
+	ClientID  string        // Identifies the client at the broker.
+	Username  string        // Username for authentication, may be empty.
+	Password  string        // Password for authentication, may be empty. Requires a username.
+	KeepAlive time.Duration // Interval of pings, defaults to 30 seconds.
+	Will      *Message      // Message published by the broker when the connection is lost, may be nil.
+	TLSConfig *tls.Config   // Used for tls:// and ssl:// broker addresses, may be nil.
+}
+
+// Client is a connection to an MQTT broker. It is safe for concurrent use.
+// codebeat:disable[TOO_MANY_IVARS]
+type Client struct {
+	conn      net.Conn
+	keepAlive time.Duration
+	writeMu   sync.Mutex
+	mu        sync.Mutex
+	subs      []subscription
+	nextID    uint16
+	acks      map[uint16]chan []byte
+	done      chan struct{}
+	closeOnce sync.Once
+	err       error
+}
+
+// codebeat:enable[TOO_MANY_IVARS]
+
+type subscription struct {
+	id      uint16
+	filter  string
+	handler func(Message)
+}
+
+// Dial connects to the broker at the given address, e.g. "localhost:1883", "tcp://broker:1883" or
+// "tls://broker:8883". Without port, the default ports 1883 and 8883 are used.
+func Dial(address string, opts Options) (*Client, error) {
+	if opts.Password != "" && opts.Username == "" {
+		return nil, fmt.Errorf("a password cannot be sent without a username")
+	}
+	if opts.KeepAlive <= 0 {
+		opts.KeepAlive = 30 * time.Second
+	}
+	conn, err := dial(address, opts.TLSConfig)
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{
+		conn:      conn,
+		keepAlive: opts.KeepAlive,
+		acks:      make(map[uint16]chan []byte),
+		done:      make(chan struct{}),
+	}
+	r := bufio.NewReader(conn)
+	if err := c.connect(r, opts); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	go c.read(r)
+	go c.ping()
+	return c, nil
+}
+
+func dial(address string, tlsConfig *tls.Config) (net.Conn, error) {
+	scheme := "tcp"
+	if i := strings.Index(address, "://"); i >= 0 {
+		scheme, address = address[:i], address[i+3:]
+	}
+	useTLS := false
+	switch scheme {
+	case "tcp", "mqtt":
+	case "tls", "ssl", "mqtts":
+		useTLS = true
+	default:
+		return nil, fmt.Errorf("unsupported scheme '%s'", scheme)
+	}
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		port := "1883"
+		if useTLS {
+			port = "8883"
+		}
+		address = net.JoinHostPort(address, port)
+	}
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	if useTLS {
+		return tls.DialWithDialer(dialer, "tcp", address, tlsConfig)
+	}
+	return dialer.Dial("tcp", address)
+}
+
+func (c *Client) connect(r *bufio.Reader, opts Options) error {
+	var flags byte = 0x02 // Clean session.
+	body := appendString(nil, "MQTT")
+	body = append(body, 4)
+	payload := appendString(nil, opts.ClientID)
+	if opts.Will != nil {
+		flags |= 0x04
+		if opts.Will.Retain {
+			flags |= 0x20
+		}
+		payload = appendString(payload, opts.Will.Topic)
+		payload = appendUint16(payload, uint16(len(opts.Will.Payload)))
+		payload = append(payload, opts.Will.Payload...)
+	}
+	if opts.Username != "" {
+		flags |= 0x80
+		payload = appendString(payload, opts.Username)
+	}
+	if opts.Password != "" {
+		flags |= 0x40
+		payload = appendString(payload, opts.Password)
+	}
+	body = append(body, flags)
+	body = appendUint16(body, uint16(opts.KeepAlive/time.Second))
+	body = append(body, payload...)
+	c.conn.SetDeadline(time.Now().Add(10 * time.Second))
+	defer c.conn.SetDeadline(time.Time{})
+	if err := writePacket(c.conn, typeConnect, 0, body); err != nil {
+		return err
+	}
+	p, err := readPacket(r)
+	if err != nil {
+		return err
+	}
+	if p.typ != typeConnack || len(p.body) != 2 {
+		return fmt.Errorf("unexpected packet of type %d instead of CONNACK", p.typ)
+	}
+	if code := p.body[1]; code != 0 {
+		return fmt.Errorf("connection refused by broker: %s", connackReason(code))
+	}
+	return nil
+}
+
+func connackReason(code byte) string {
+	reasons := map[byte]string{
+		1: "unacceptable protocol version",
+		2: "identifier rejected",
+		3: "server unavailable",
+		4: "bad user name or password",
+		5: "not authorized",
+	}
+	if r, ok := reasons[code]; ok {
+		return r
+	}
+	return fmt.Sprintf("return code %d", code)
+}
+
+// Publish sends a message to the broker.
+func (c *Client) Publish(m Message) error {
+	flags, body := encodePublish(m)
+	return c.write(typePublish, flags, body)
+}
+
+// Subscribe registers the handler for messages matching the filter and waits for the broker to acknowledge the
+// subscription. Handlers are called sequentially from the reading goroutine and should return quickly. The handler is
+// removed again if the subscription fails.
+func (c *Client) Subscribe(filter string, handler func(Message)) error {
+	c.mu.Lock()
+	c.nextID++
+	id := c.nextID
+	c.subs = append(c.subs, subscription{id: id, filter: filter, handler: handler})
+	ack := make(chan []byte, 1)
+	c.acks[id] = ack
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.acks, id)
+		c.mu.Unlock()
+	}()
+	err := c.subscribe(id, filter, ack)
+	if err != nil {
+		c.unsubscribe(id)
+	}
+	return err
+}
+
+func (c *Client) subscribe(id uint16, filter string, ack <-chan []byte) error {
+	body := appendUint16(nil, id)
+	body = appendString(body, filter)
+	body = append(body, 0)
+	if err := c.write(typeSubscribe, 0x02, body); err != nil {
+		return err
+	}
+	select {
+	case codes := <-ack:
+		if len(codes) != 1 || codes[0] == 0x80 {
+			return fmt.Errorf("subscription to '%s' rejected", filter)
+		}
+		return nil
+	case <-c.done:
+		return c.Err()
+	case <-time.After(10 * time.Second):
+		return fmt.Errorf("subscription to '%s' not acknowledged", filter)
+	}
+}
+
+// unsubscribe removes the handler of the subscription with the given packet identifier.
+func (c *Client) unsubscribe(id uint16) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, s := range c.subs {
+		if s.id == id {
+			c.subs = append(c.subs[:i], c.subs[i+1:]...)
+			return
+		}
+	}
+}
+
+// Done is closed when the connection is lost or closed.
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns the reason why the connection ended, or nil if it is still alive.
+func (c *Client) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Close disconnects from the broker. The will message is discarded.
+func (c *Client) Close() error {
+	c.write(typeDisconnect, 0, nil)
+	c.shutdown(fmt.Errorf("connection closed"))
+	return nil
+}
+
+func (c *Client) write(typ, flags byte, body []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(c.keepAlive))
+	err := writePacket(c.conn, typ, flags, body)
+	if err != nil {
+		c.shutdown(err)
+	}
+	return err
+}
+
+func (c *Client) shutdown(err error) {
+	c.closeOnce.Do(func() {
+		c.mu.Lock()
+		c.err = err
+		c.mu.Unlock()
+		c.conn.Close()
+		close(c.done)
+	})
+}
+
+func (c *Client) read(r *bufio.Reader) {
+	for {
+		c.conn.SetReadDeadline(time.Now().Add(c.keepAlive * 3 / 2))
+		p, err := readPacket(r)
+		if err != nil {
+			c.shutdown(err)
+			return
+		}
+		if err := c.handle(p); err != nil {
+			c.shutdown(err)
+			return
+		}
+	}
+}
+
+func (c *Client) handle(p packet) error {
+	switch p.typ {
+	case typePublish:
+		pub, err := decodePublish(p)
+		if err != nil {
+			return err
+		}
+		if pub.qos == 1 {
+			c.write(typePuback, 0, appendUint16(nil, pub.packetID))
+		}
+		c.dispatch(pub.Message)
+	case typeSuback:
+		d := decoder{b: p.body}
+		id := d.uint16()
+		codes := d.rest()
+		c.mu.Lock()
+		ack, ok := c.acks[id]
+		c.mu.Unlock()
+		if ok {
+			ack <- codes
+		}
+	case typePingresp:
+	default:
+		return fmt.Errorf("unexpected packet of type %d", p.typ)
+	}
+	return nil
+}
+
+func (c *Client) dispatch(m Message) {
+	c.mu.Lock()
+	var handlers []func(Message)
+	for _, s := range c.subs {
+		if Match(s.filter, m.Topic) {
+			handlers = append(handlers, s.handler)
+		}
+	}
+	c.mu.Unlock()
+	for _, h := range handlers {
+		h(m)
+	}
+}
+
+func (c *Client) ping() {
+	ticker := time.NewTicker(c.keepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			c.write(typePingreq, 0, nil)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package mqtt
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPacketRoundTrip tests the encoding of the remaining length.
+func TestPacketRoundTrip(t *testing.T) {
+	for _, n := range []int{0, 127, 128, 16383, 16384, 200000} {
+		buf := new(bytes.Buffer)
+		assert.NoError(t, writePacket(buf, typePublish, 0x01, make([]byte, n)))
+		p, err := readPacket(bufio.NewReader(buf))
+		assert.NoError(t, err)
+		assert.Equal(t, typePublish, p.typ)
+		assert.Equal(t, byte(0x01), p.flags)
+		assert.Len(t, p.body, n)
+	}
+}
+
+// TestPublishSubscribe tests message delivery and retained messages through the broker.
+func TestPublishSubscribe(t *testing.T) {
+	b, err := NewBroker("127.0.0.1:0")
+	assert.NoError(t, err)
+	defer b.Close()
+
+	pub, err := Dial(b.Addr(), Options{ClientID: "pub"})
+	assert.NoError(t, err)
+	defer pub.Close()
+	assert.NoError(t, pub.Publish(Message{Topic: "a/retained", Payload: []byte("r"), Retain: true}))
+
+	sub, err := Dial("tcp://"+b.Addr(), Options{ClientID: "sub", Username: "u", Password: "p"})
+	assert.NoError(t, err)
+	defer sub.Close()
+	received := make(chan Message, 10)
+	assert.NoError(t, sub.Subscribe("a/+", func(m Message) { received <- m }))
+	assert.Equal(t, "r", string(receive(t, received).Payload))
+
+	assert.NoError(t, pub.Publish(Message{Topic: "a/b", Payload: []byte("hello")}))
+	assert.NoError(t, pub.Publish(Message{Topic: "x/y", Payload: []byte("ignored")}))
+	assert.NoError(t, pub.Publish(Message{Topic: "a/c", Payload: []byte("world")}))
+	assert.Equal(t, "hello", string(receive(t, received).Payload))
+	m := receive(t, received)
+	assert.Equal(t, "a/c", m.Topic)
+	assert.Equal(t, "world", string(m.Payload))
+}
+
+// TestWill tests that the will is published when a client drops out.
+func TestWill(t *testing.T) {
+	b, err := NewBroker("127.0.0.1:0")
+	assert.NoError(t, err)
+	defer b.Close()
+
+	c, err := Dial(b.Addr(), Options{ClientID: "c", Will: &Message{Topic: "status", Payload: []byte("offline"), Retain: true}})
+	assert.NoError(t, err)
+	assert.NoError(t, c.Publish(Message{Topic: "status", Payload: []byte("online"), Retain: true}))
+	time.Sleep(50 * time.Millisecond)
+	b.DropClients()
+	select {
+	case <-c.Done():
+	case <-time.After(time.Second):
+		t.Fatal("client did not notice the dropped connection")
+	}
+	assert.Error(t, c.Err())
+	time.Sleep(50 * time.Millisecond)
+	m, ok := b.Retained("status")
+	assert.True(t, ok)
+	assert.Equal(t, "offline", string(m.Payload))
+}
+
+// TestDialInvalid tests connection errors.
+func TestDialInvalid(t *testing.T) {
+	_, err := Dial("ftp://localhost", Options{})
+	assert.Error(t, err)
+	_, err = Dial("127.0.0.1:1", Options{})
+	assert.Error(t, err)
+	_, err = Dial("127.0.0.1:1", Options{Password: "p"})
+	assert.EqualError(t, err, "a password cannot be sent without a username")
+}
+
+// TestSubscribeRejected tests that the handler of a rejected subscription is removed.
+func TestSubscribeRejected(t *testing.T) {
+	b, err := NewBroker("127.0.0.1:0")
+	assert.NoError(t, err)
+	defer b.Close()
+
+	c, err := Dial(b.Addr(), Options{ClientID: "c"})
+	assert.NoError(t, err)
+	defer c.Close()
+	assert.NoError(t, c.Subscribe("a/+", func(Message) {}))
+	assert.EqualError(t, c.Subscribe("a/#/b", func(Message) {}), "subscription to 'a/#/b' rejected")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	assert.Len(t, c.subs, 1)
+	assert.Equal(t, "a/+", c.subs[0].filter)
+}
+
+func receive(t *testing.T, ms <-chan Message) Message {
+	select {
+	case m := <-ms:
+		return m
+	case <-time.After(time.Second):
+		t.Fatal("no message received")
+	}
+	return Message{}
+}
@@ -0,0 +1,167 @@
+package mqtt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Control packet types of MQTT 3.1.1.
+const (
+	typeConnect     byte = 1
+	typeConnack     byte = 2
+	typePublish     byte = 3
+	typePuback      byte = 4
+	typeSubscribe   byte = 8
+	typeSuback      byte = 9
+	typePingreq     byte = 12
+	typePingresp    byte = 13
+	typeDisconnect  byte = 14
+	maxRemainingLen      = 268435455
+)
+
+type packet struct {
+	typ   byte
+	flags byte
+	body  []byte
+}
+
+func readPacket(r *bufio.Reader) (packet, error) {
+	first, err := r.ReadByte()
+	if err != nil {
+		return packet{}, err
+	}
+	length, err := readRemainingLength(r)
+	if err != nil {
+		return packet{}, err
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return packet{}, err
+	}
+	return packet{typ: first >> 4, flags: first & 0x0f, body: body}, nil
+}
+
+func readRemainingLength(r *bufio.Reader) (int, error) {
+	length, multiplier := 0, 1
+	for i := 0; i < 4; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		length += int(b&0x7f) * multiplier
+		if b&0x80 == 0 {
+			return length, nil
+		}
+		multiplier *= 128
+	}
+	return 0, fmt.Errorf("malformed remaining length")
+}
+
+func writePacket(w io.Writer, typ, flags byte, body []byte) error {
+	if len(body) > maxRemainingLen {
+		return fmt.Errorf("packet too large: %d bytes", len(body))
+	}
+	buf := make([]byte, 0, len(body)+5)
+	buf = append(buf, typ<<4|flags)
+	length := len(body)
+	for {
+		b := byte(length % 128)
+		length /= 128
+		if length > 0 {
+			b |= 0x80
+		}
+		buf = append(buf, b)
+		if length == 0 {
+			break
+		}
+	}
+	buf = append(buf, body...)
+	_, err := w.Write(buf)
+	return err
+}
+
+func appendString(b []byte, s string) []byte {
+	b = appendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	return append(b, byte(v>>8), byte(v))
+}
+
+// decoder reads the fields of a packet body. The first error sticks, subsequent reads return zero values.
+type decoder struct {
+	b   []byte
+	err error
+}
+
+func (d *decoder) uint16() uint16 {
+	if d.err != nil || len(d.b) < 2 {
+		d.fail()
+		return 0
+	}
+	v := binary.BigEndian.Uint16(d.b)
+	d.b = d.b[2:]
+	return v
+}
+
+func (d *decoder) byte() byte {
+	if d.err != nil || len(d.b) < 1 {
+		d.fail()
+		return 0
+	}
+	v := d.b[0]
+	d.b = d.b[1:]
+	return v
+}
+
+func (d *decoder) string() string {
+	n := int(d.uint16())
+	if d.err != nil || len(d.b) < n {
+		d.fail()
+		return ""
+	}
+	s := string(d.b[:n])
+	d.b = d.b[n:]
+	return s
+}
+
+func (d *decoder) rest() []byte {
+	r := d.b
+	d.b = nil
+	return r
+}
+
+func (d *decoder) fail() {
+	if d.err == nil {
+		d.err = fmt.Errorf("malformed packet")
+	}
+}
+
+type publishPacket struct {
+	Message
+	qos      byte
+	packetID uint16
+}
+
+func decodePublish(p packet) (publishPacket, error) {
+	d := decoder{b: p.body}
+	pub := publishPacket{qos: (p.flags >> 1) & 0x03}
+	pub.Retain = p.flags&0x01 != 0
+	pub.Topic = d.string()
+	if pub.qos > 0 {
+		pub.packetID = d.uint16()
+	}
+	pub.Payload = d.rest()
+	return pub, d.err
+}
+
+func encodePublish(m Message) (flags byte, body []byte) {
+	if m.Retain {
+		flags |= 0x01
+	}
+	body = appendString(nil, m.Topic)
+	return flags, append(body, m.Payload...)
+}
@@ -0,0 +1,40 @@
+package mqtt
+
+import "strings"
+
+// Match reports whether the topic matches the filter. Filters may contain the single-level wildcard "+" and end with
+// the multi-level wildcard "#".
+func Match(filter, topic string) bool {
+	fs := strings.Split(filter, "/")
+	ts := strings.Split(topic, "/")
+	for i, f := range fs {
+		if f == "#" {
+			return true
+		}
+		if i >= len(ts) {
+			return false
+		}
+		if f != "+" && f != ts[i] {
+			return false
+		}
+	}
+	return len(fs) == len(ts)
+}
+
+// ValidFilter reports whether the filter is well-formed: it is not empty, the wildcards occupy entire levels and "#" is
+// the last level.
+func ValidFilter(filter string) bool {
+	if filter == "" {
+		return false
+	}
+	levels := strings.Split(filter, "/")
+	for i, l := range levels {
+		if l == "#" && i == len(levels)-1 || l == "+" {
+			continue
+		}
+		if strings.ContainsAny(l, "#+") {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,41 @@
+package mqtt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMatch tests topic filters with wildcards.
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		filter string
+		topic  string
+		want   bool
+	}{
+		{filter: "a/b", topic: "a/b", want: true},
+		{filter: "a/b", topic: "a/c", want: false},
+		{filter: "a/+", topic: "a/b", want: true},
+		{filter: "a/+", topic: "a/b/c", want: false},
+		{filter: "a/+/c", topic: "a/b/c", want: true},
+		{filter: "a/#", topic: "a/b/c", want: true},
+		{filter: "a/#", topic: "a", want: true},
+		{filter: "#", topic: "a/b", want: true},
+		{filter: "a/b/c", topic: "a/b", want: false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.filter+" "+tc.topic, func(t *testing.T) {
+			assert.Equal(t, tc.want, Match(tc.filter, tc.topic))
+		})
+	}
+}
+
+// TestValidFilter tests the placement of wildcards in filters.
+func TestValidFilter(t *testing.T) {
+	for _, f := range []string{"a", "a/b", "+", "a/+/c", "#", "a/#", "+/+/#"} {
+		assert.True(t, ValidFilter(f), f)
+	}
+	for _, f := range []string{"", "a/#/b", "a#", "a/b+", "#/a"} {
+		assert.False(t, ValidFilter(f), f)
+	}
+}
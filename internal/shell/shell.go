@@ -0,0 +1,20 @@
+// Package shell runs user-supplied command lines through the shell of the platform.
+package shell
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// Command prepares the command line to be run with "sh -c", or "cmd /C" on Windows. The given variables, formatted as
+// "KEY=value", are added to the environment of the current process.
+func Command(ctx context.Context, line string, env ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "sh", "-c", line)
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", line)
+	}
+	cmd.Env = append(os.Environ(), env...)
+	return cmd
+}
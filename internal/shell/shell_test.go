@@ -0,0 +1,19 @@
+package shell
+
+import (
+	"context"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCommand tests that the command line is interpreted by the shell and sees the additional environment.
+func TestCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires sh")
+	}
+	out, err := Command(context.Background(), `echo "$GREETING" | tr a-z A-Z`, "GREETING=hello").Output()
+	assert.NoError(t, err)
+	assert.Equal(t, "HELLO\n", string(out))
+}
@@ -0,0 +1,42 @@
+package statefile
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSaveAndLoad tests the round trip through a file.
+func TestSaveAndLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "statefile")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "state.json")
+
+	v := map[string]int{"untouched": 1}
+	assert.NoError(t, Load(filename, &v))
+	assert.Equal(t, map[string]int{"untouched": 1}, v)
+
+	assert.NoError(t, Save(filename, map[string]int{"a": 2}))
+	var loaded map[string]int
+	assert.NoError(t, Load(filename, &loaded))
+	assert.Equal(t, map[string]int{"a": 2}, loaded)
+
+	files, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1, "no temporary files are left behind")
+}
+
+// TestLoadBrokenFile tests that corrupt files are reported.
+func TestLoadBrokenFile(t *testing.T) {
+	file, err := ioutil.TempFile("", "statefile")
+	assert.NoError(t, err)
+	defer os.Remove(file.Name())
+	file.WriteString("{")
+	file.Close()
+	var v map[string]int
+	assert.Error(t, Load(file.Name(), &v))
+}
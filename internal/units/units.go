@@ -0,0 +1,123 @@
+// Package units converts temperatures between the unit system of the user and °C, the unit used by the FRITZ!Box.
+package units
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/bpicode/fritzctl/logger"
+)
+
+// System is a unit system for temperatures.
+type System string
+
+// Supported unit systems.
+const (
+	Metric   System = "metric"   // Temperatures in °C.
+	Imperial System = "imperial" // Temperatures in °F.
+)
+
+// Resolution is the smallest temperature step, in °C, the FRITZ!Box can set.
+const Resolution = 0.5
+
+// Parse looks up the unit system by name. The empty string yields the Metric system.
+func Parse(s string) (System, error) {
+	switch System(strings.ToLower(strings.TrimSpace(s))) {
+	case "", Metric:
+		return Metric, nil
+	case Imperial:
+		return Imperial, nil
+	}
+	return Metric, fmt.Errorf("unknown unit system '%s', expected one of %s, %s", s, Metric, Imperial)
+}
+
+// Symbol returns the symbol of temperatures, e.g. "°C".
+func (s System) Symbol() string {
+	if s == Imperial {
+		return "°F"
+	}
+	return "°C"
+}
+
+// FromCelsius converts a temperature in °C to the unit system.
+func (s System) FromCelsius(c float64) float64 {
+	if s == Imperial {
+		return c*9/5 + 32
+	}
+	return c
+}
+
+// ToCelsius converts a temperature given in the unit system to °C.
+func (s System) ToCelsius(v float64) float64 {
+	if s == Imperial {
+		return (v - 32) * 5 / 9
+	}
+	return v
+}
+
+// DeltaFromCelsius converts a temperature difference, e.g. an offset, in °C to the unit system.
+func (s System) DeltaFromCelsius(c float64) float64 {
+	if s == Imperial {
+		return c * 9 / 5
+	}
+	return c
+}
+
+// DeltaToCelsius converts a temperature difference given in the unit system to °C.
+func (s System) DeltaToCelsius(v float64) float64 {
+	if s == Imperial {
+		return v * 5 / 9
+	}
+	return v
+}
+
+// ToBox converts a temperature given in the unit system to °C, rounded to the Resolution of the FRITZ!Box. A warning
+// is logged if the rounding changes the temperature, in either unit system.
+func (s System) ToBox(v float64) float64 {
+	return s.round(v, s.ToCelsius(v))
+}
+
+// DeltaToBox converts a temperature difference given in the unit system to °C, rounded like in ToBox.
+func (s System) DeltaToBox(v float64) float64 {
+	return s.round(v, s.DeltaToCelsius(v))
+}
+
+func (s System) round(v, c float64) float64 {
+	r := math.Round(c/Resolution) * Resolution
+	if math.Abs(r-c) <= 1e-9 {
+		return r
+	}
+	if s == Metric {
+		logger.Warn(fmt.Sprintf("%s°C is rounded to %s°C, the FRITZ!Box supports steps of %s°C only",
+			format(v), format(r), format(Resolution)))
+	} else {
+		logger.Warn(fmt.Sprintf("%s%s is %s°C, rounded to %s°C, the FRITZ!Box supports steps of %s°C only",
+			format(v), s.Symbol(), strconv.FormatFloat(c, 'f', 2, 64), format(r), format(Resolution)))
+	}
+	return r
+}
+
+// Convert converts a temperature in °C, formatted like by the FRITZ!Box, to the unit system. Values that are no
+// numbers, e.g. "ON", "OFF" or "", are returned as they are.
+func (s System) Convert(celsius string) string {
+	return s.convert(celsius, s.FromCelsius)
+}
+
+// ConvertDelta converts a temperature difference in °C like Convert.
+func (s System) ConvertDelta(celsius string) string {
+	return s.convert(celsius, s.DeltaFromCelsius)
+}
+
+func (s System) convert(celsius string, conv func(float64) float64) string {
+	c, err := strconv.ParseFloat(celsius, 64)
+	if err != nil || s == Metric {
+		return celsius
+	}
+	return strconv.FormatFloat(conv(c), 'f', 1, 64)
+}
+
+func format(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
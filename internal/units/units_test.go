@@ -0,0 +1,50 @@
+package units
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParse tests the lookup of unit systems by name.
+func TestParse(t *testing.T) {
+	for name, expected := range map[string]System{"": Metric, "metric": Metric, " Imperial ": Imperial} {
+		s, err := Parse(name)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, s)
+	}
+	_, err := Parse("kelvin")
+	assert.Error(t, err)
+}
+
+// TestConversions tests the conversion of temperatures and temperature differences.
+func TestConversions(t *testing.T) {
+	assert.Equal(t, "°C", Metric.Symbol())
+	assert.Equal(t, "°F", Imperial.Symbol())
+	assert.Equal(t, 21.5, Metric.FromCelsius(21.5))
+	assert.InDelta(t, 70.7, Imperial.FromCelsius(21.5), 1e-9)
+	assert.InDelta(t, 20.0, Imperial.ToCelsius(68), 1e-9)
+	assert.InDelta(t, 1.8, Imperial.DeltaFromCelsius(1), 1e-9)
+	assert.InDelta(t, 1.0, Imperial.DeltaToCelsius(1.8), 1e-9)
+}
+
+// TestToBox tests the rounding to the resolution of the FRITZ!Box.
+func TestToBox(t *testing.T) {
+	assert.Equal(t, 21.5, Metric.ToBox(21.3))
+	assert.Equal(t, 21.0, Metric.ToBox(21))
+	assert.Equal(t, -0.5, Metric.DeltaToBox(-0.4))
+	assert.Equal(t, 20.0, Imperial.ToBox(68))
+	assert.Equal(t, 22.0, Imperial.ToBox(72))
+	assert.Equal(t, 22.5, Imperial.ToBox(72.5))
+	assert.Equal(t, -1.0, Imperial.DeltaToBox(-2))
+}
+
+// TestConvert tests the conversion of temperatures formatted like by the FRITZ!Box.
+func TestConvert(t *testing.T) {
+	assert.Equal(t, "21.5", Metric.Convert("21.5"))
+	assert.Equal(t, "70.7", Imperial.Convert("21.5"))
+	assert.Equal(t, "-0.9", Imperial.ConvertDelta("-0.5"))
+	for _, special := range []string{"ON", "OFF", "?", ""} {
+		assert.Equal(t, special, Imperial.Convert(special))
+	}
+}
@@ -1,8 +1,107 @@
 package manifest
 
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
 // Applier defines the interface to apply a plan to the AHA system.
 type Applier interface {
 	// Apply performs the changes necessary to transition from src to target configuration. If the target plan
 	// could be realized, it returns an error. If the plan was applied successfully, it returns null.
 	Apply(src, target *Plan) error
 }
+
+// Option configures an Applier.
+type Option func(*options)
+
+type options struct {
+	strict      bool
+	updateNames bool
+	atomic      bool
+	expected    []Change
+	expect      bool
+}
+
+// Strict makes the Applier treat the target as the full desired state, see StrictPlanner.
+func Strict() Option {
+	return func(o *options) {
+		o.strict = true
+	}
+}
+
+// UpdateNames makes the Applier rename devices that are addressed by their AIN in the manifest to the name given there.
+// Otherwise, differing names are only reported.
+func UpdateNames() Option {
+	return func(o *options) {
+		o.updateNames = true
+	}
+}
+
+// Atomic makes the Applier revert the successful changes, in reverse order, if any change fails. The values before the
+// changes are taken from the source state.
+func Atomic() Option {
+	return func(o *options) {
+		o.atomic = true
+	}
+}
+
+// Expect makes the Applier refuse to apply a plan whose changes differ from the expected ones, e.g. because the state
+// of the devices has drifted since the changes were planned, see Changeset.
+func Expect(changes []Change) Option {
+	return func(o *options) {
+		o.expected = changes
+		o.expect = true
+	}
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+func (o options) planner() Planner {
+	p := targetBasedPlanner{updateNames: o.updateNames}
+	if o.strict {
+		return &strictPlanner{targetBasedPlanner: p}
+	}
+	return &p
+}
+
+// plan creates the actions and checks them against the expected changes.
+func (o options) plan(src, target *Plan) ([]Action, error) {
+	actions, err := o.planner().Plan(src, target)
+	if err != nil {
+		return nil, err
+	}
+	if o.expect {
+		if err := checkDrift(o.expected, Describe(actions)); err != nil {
+			return nil, err
+		}
+	}
+	return actions, nil
+}
+
+func checkDrift(expected, actual []Change) error {
+	if len(expected) == 0 && len(actual) == 0 || reflect.DeepEqual(expected, actual) {
+		return nil
+	}
+	var lines []string
+	for _, c := range actual {
+		lines = append(lines, "\t"+c.String())
+	}
+	return fmt.Errorf("the state of the devices has drifted since planning, the changes would now be:\n%s", strings.Join(lines, "\n"))
+}
+
+// Describe returns the changes of the actions.
+func Describe(actions []Action) []Change {
+	changes := make([]Change, 0, len(actions))
+	for _, a := range actions {
+		changes = append(changes, a.Change())
+	}
+	return changes
+}
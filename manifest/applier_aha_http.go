@@ -3,14 +3,18 @@ package manifest
 import (
 	"errors"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/bpicode/fritzctl/internal/console"
 )
 
 type ahaAPIApplier struct {
-	fritz aha
+	fritz   aha
+	options options
 }
 
 type aha interface {
@@ -19,27 +23,124 @@ type aha interface {
 	Temp(value float64, names ...string) error
 }
 
-// NewApplier is an Applier that performs changes to the AHA system via the HTTP API.
-func NewApplier(f aha) Applier {
-	return &ahaAPIApplier{fritz: f}
+// NewApplier is an Applier that performs changes to the AHA system via the HTTP API. Changing the settings of
+// thermostats, lights or names requires f to implement fritz.ThermostatConfigurer, fritz.LightController or
+// fritz.Renamer, respectively, as the client created by fritz.NewHomeAuto does.
+func NewApplier(f aha, opts ...Option) Applier {
+	return &ahaAPIApplier{fritz: f, options: newOptions(opts)}
 }
 
-// Apply does only log the proposed changes.
+// Apply performs the changes stage by stage, pausing for the stage delay of the target between the stages. Within a
+// stage the devices are changed concurrently, the changes of one device one after another. If a change fails, the
+// later stages are skipped. If the Applier is atomic, the successful changes are then reverted in reverse order.
 func (a *ahaAPIApplier) Apply(src, target *Plan) error {
-	planner := TargetBasedPlanner(reconfigureSwitch, reconfigureThermostat)
-	actions, err := planner.Plan(src, target)
+	actions, err := a.options.plan(src, target)
 	if err != nil {
 		return err
 	}
-	fanOutChan, wg := a.fanOut(actions)
+	var done journal
+	stages := byStage(actions)
+	for i, stage := range stages {
+		if i > 0 {
+			time.Sleep(target.StageDelay)
+		}
+		if len(stages) > 1 {
+			fmt.Printf("Stage %d:\n", stage[0].Change().Stage)
+		}
+		if err = a.applyStage(stage, &done); err != nil {
+			err = skipped(err, stages[i+1:])
+			break
+		}
+	}
+	if err != nil && a.options.atomic {
+		return a.rollback(done.actions, err)
+	}
+	return err
+}
+
+func (a *ahaAPIApplier) applyStage(actions []Action, done *journal) error {
+	fanOutChan, wg := a.fanOut(byDevice(actions), done)
 	fanInChan := a.fanIn(fanOutChan)
 	wg.Wait()
 	close(fanOutChan)
-	err = <-fanInChan
+	err := <-fanInChan
 	close(fanInChan)
 	return err
 }
 
+// skipped adds the stages that were not applied to the error of the failed stage.
+func skipped(cause error, stages [][]Action) error {
+	if len(stages) == 0 {
+		return cause
+	}
+	var numbers []string
+	for _, s := range stages {
+		numbers = append(numbers, strconv.Itoa(s[0].Change().Stage))
+	}
+	return fmt.Errorf("%v\nthe following stages were skipped: %s", cause, strings.Join(numbers, ", "))
+}
+
+// journal records the actions performed successfully, in the order of their completion.
+type journal struct {
+	mu      sync.Mutex
+	actions []Action
+}
+
+func (j *journal) record(ac Action) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.actions = append(j.actions, ac)
+}
+
+// rollback reverts the actions in reverse order and adds the outcome to the error of the failed operations.
+func (a *ahaAPIApplier) rollback(done []Action, cause error) error {
+	fmt.Println("Rolling back the successful changes:")
+	var errMessages []string
+	for i := len(done) - 1; i >= 0; i-- {
+		c := done[i].Change()
+		c.Before, c.After = c.After, c.Before
+		err := done[i].Revert(a.fritz)
+		report(c, err)
+		errMessages = appendToErrorMessages(errMessages, err)
+	}
+	if len(errMessages) > 0 {
+		return fmt.Errorf("%v\nthe rollback of %d of %d changes failed:\n%s", cause, len(errMessages), len(done), strings.Join(errMessages, "\n"))
+	}
+	return fmt.Errorf("%v\nall %d successful changes were rolled back", cause, len(done))
+}
+
+// byStage groups the actions by stage in ascending order, keeping the order within a stage.
+func byStage(actions []Action) [][]Action {
+	sorted := make([]Action, len(actions))
+	copy(sorted, actions)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Change().Stage < sorted[j].Change().Stage })
+	var stages [][]Action
+	for i, ac := range sorted {
+		if i == 0 || ac.Change().Stage != sorted[i-1].Change().Stage {
+			stages = append(stages, nil)
+		}
+		stages[len(stages)-1] = append(stages[len(stages)-1], ac)
+	}
+	return stages
+}
+
+// byDevice groups the actions by device, keeping their order.
+func byDevice(actions []Action) [][]Action {
+	var groups [][]Action
+	index := make(map[string]int)
+	for _, ac := range actions {
+		device := ac.Change().Device
+		i, ok := index[device]
+		if !ok {
+			i = len(groups)
+			index[device] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], ac)
+	}
+	return groups
+}
+
 func (a *ahaAPIApplier) fanIn(fanOutChan chan error) chan error {
 	fanInChan := make(chan error)
 	go func() {
@@ -63,63 +164,35 @@ func appendToErrorMessages(errMsgs []string, err error) []string {
 	return errMsgs
 }
 
-func (a *ahaAPIApplier) fanOut(actions []Action) (chan error, *sync.WaitGroup) {
+func (a *ahaAPIApplier) fanOut(groups [][]Action, done *journal) (chan error, *sync.WaitGroup) {
 	var wg sync.WaitGroup
 	fanOutChan := make(chan error)
-	for _, action := range actions {
+	for _, group := range groups {
 		wg.Add(1)
-		go func(ac Action) {
+		go func(acs []Action) {
 			defer wg.Done()
-			fanOutChan <- ac.Perform(a.fritz)
-		}(action)
+			for _, ac := range acs {
+				err := a.perform(ac)
+				if err == nil {
+					done.record(ac)
+				}
+				fanOutChan <- err
+			}
+		}(group)
 	}
 	return fanOutChan, &wg
 }
 
-type reconfigureSwitchAction struct {
-	before Switch
-	after  Switch
-}
-
-func reconfigureSwitch(before, after Switch) Action {
-	return &reconfigureSwitchAction{before: before, after: after}
-}
-
-// Perform applies the target state to a switch by turning it on/off.
-func (a *reconfigureSwitchAction) Perform(f aha) (err error) {
-	if a.before.State != a.after.State {
-		if a.after.State {
-			err = f.On(a.before.Name)
-		} else {
-			err = f.Off(a.before.Name)
-		}
-		if err == nil {
-			fmt.Printf("\t[%s]\t'%s'\t%s\t⟶\t%s\n", console.Green("OK"), a.before.Name, console.Btoc(a.before.State), console.Btoc(a.after.State))
-		} else {
-			fmt.Printf("\t[%s]\t'%s'\t%s\t⟶\t%s\t%s\n", console.Red("FAIL"), a.before.Name, console.Btoc(a.before.State), console.Btoc(a.after.State), err.Error())
-		}
-	}
+func (a *ahaAPIApplier) perform(ac Action) error {
+	err := ac.Perform(a.fritz)
+	report(ac.Change(), err)
 	return err
 }
 
-type reconfigureThermostatAction struct {
-	before Thermostat
-	after  Thermostat
-}
-
-func reconfigureThermostat(before, after Thermostat) Action {
-	return &reconfigureThermostatAction{before: before, after: after}
-}
-
-// Perform applies the target state to a switch by turning it on/off.
-func (a *reconfigureThermostatAction) Perform(f aha) (err error) {
-	if a.before.Temperature != a.after.Temperature {
-		err = f.Temp(a.after.Temperature, a.before.Name)
-		if err == nil {
-			fmt.Printf("\t[%s]\t'%s'\t%.1f°C\t⟶\t%.1f°C\n", console.Green("OK"), a.before.Name, a.before.Temperature, a.after.Temperature)
-		} else {
-			fmt.Printf("\t[%s]\t'%s'\t%.1f°C\t⟶\t%.1f°C\t%s\n", console.Red("FAIL"), a.before.Name, a.before.Temperature, a.after.Temperature, err.Error())
-		}
+func report(c Change, err error) {
+	if err == nil {
+		fmt.Printf("\t[%s]\t%s\n", console.Green("OK"), c)
+	} else {
+		fmt.Printf("\t[%s]\t%s\t%s\n", console.Red("FAIL"), c, err.Error())
 	}
-	return err
 }
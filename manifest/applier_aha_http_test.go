@@ -3,46 +3,32 @@ package manifest
 import (
 	"errors"
 	"testing"
+	"time"
+
+	"github.com/bpicode/fritzctl/fritz"
+	"github.com/bpicode/fritzctl/fritz/fritztest"
 
 	"github.com/stretchr/testify/assert"
 )
 
-type fritzAlwaysSuccess struct {
-}
-
-// On always succeeds.
-func (f *fritzAlwaysSuccess) On(names ...string) error {
-	return nil
-}
-
-// Off always succeeds.
-func (f *fritzAlwaysSuccess) Off(names ...string) error {
-	return nil
-}
-
-// Temp always succeeds.
-func (f *fritzAlwaysSuccess) Temp(value float64, names ...string) error {
-	return nil
-}
-
 // TestApplyViaAha tests the http interface applier.
 func TestApplyViaAha(t *testing.T) {
-	applier := NewApplier(&fritzAlwaysSuccess{})
+	applier := NewApplier(&fritztest.HomeAuto{})
 	err := applier.Apply(
 		&Plan{
 			Switches:    []Switch{{Name: "s", State: true}},
-			Thermostats: []Thermostat{{Name: "t", Temperature: 17.5}},
+			Thermostats: []Thermostat{{Name: "t", Temperature: floatPtr(17.5)}},
 		},
 		&Plan{
 			Switches:    []Switch{{Name: "s", State: false}},
-			Thermostats: []Thermostat{{Name: "t", Temperature: 20.5}},
+			Thermostats: []Thermostat{{Name: "t", Temperature: floatPtr(20.5)}},
 		})
 	assert.NoError(t, err)
 }
 
 // TestApplyViaAhaLargeSystem tests the http interface applier.
 func TestApplyViaAhaLargeSystem(t *testing.T) {
-	applier := NewApplier(&fritzAlwaysSuccess{})
+	applier := NewApplier(&fritztest.HomeAuto{})
 	err := applier.Apply(
 		&Plan{
 			Switches: []Switch{
@@ -61,15 +47,15 @@ func TestApplyViaAhaLargeSystem(t *testing.T) {
 				{Name: "s13", State: false},
 			},
 			Thermostats: []Thermostat{
-				{Name: "t1", Temperature: 17.5},
-				{Name: "t2", Temperature: 18.5},
-				{Name: "t3", Temperature: 19.5},
-				{Name: "t4", Temperature: 21.5},
-				{Name: "t5", Temperature: 22.5},
-				{Name: "t6", Temperature: 23.0},
-				{Name: "t7", Temperature: 34.0},
-				{Name: "t8", Temperature: 26.0},
-				{Name: "t9", Temperature: 27.5},
+				{Name: "t1", Temperature: floatPtr(17.5)},
+				{Name: "t2", Temperature: floatPtr(18.5)},
+				{Name: "t3", Temperature: floatPtr(19.5)},
+				{Name: "t4", Temperature: floatPtr(21.5)},
+				{Name: "t5", Temperature: floatPtr(22.5)},
+				{Name: "t6", Temperature: floatPtr(23.0)},
+				{Name: "t7", Temperature: floatPtr(34.0)},
+				{Name: "t8", Temperature: floatPtr(26.0)},
+				{Name: "t9", Temperature: floatPtr(27.5)},
 			},
 		},
 		&Plan{
@@ -89,86 +75,102 @@ func TestApplyViaAhaLargeSystem(t *testing.T) {
 				{Name: "s13", State: true},
 			},
 			Thermostats: []Thermostat{
-				{Name: "t1", Temperature: 27.5},
-				{Name: "t2", Temperature: 19.5},
-				{Name: "t3", Temperature: 17.5},
-				{Name: "t4", Temperature: 25.5},
-				{Name: "t5", Temperature: 21.5},
-				{Name: "t6", Temperature: 24.0},
-				{Name: "t7", Temperature: 24.0},
-				{Name: "t8", Temperature: 16.0},
-				{Name: "t9", Temperature: 17.5},
+				{Name: "t1", Temperature: floatPtr(27.5)},
+				{Name: "t2", Temperature: floatPtr(19.5)},
+				{Name: "t3", Temperature: floatPtr(17.5)},
+				{Name: "t4", Temperature: floatPtr(25.5)},
+				{Name: "t5", Temperature: floatPtr(21.5)},
+				{Name: "t6", Temperature: floatPtr(24.0)},
+				{Name: "t7", Temperature: floatPtr(24.0)},
+				{Name: "t8", Temperature: floatPtr(16.0)},
+				{Name: "t9", Temperature: floatPtr(17.5)},
 			},
 		})
 	assert.NoError(t, err)
 }
 
-type fritzAlwaysError struct {
+// TestApplyViaAhaSettings tests that only changed settings are applied.
+func TestApplyViaAhaSettings(t *testing.T) {
+	comfort, saving, lock := 21.0, 16.0, true
+	src := &Plan{Thermostats: []Thermostat{{Name: "t", Temperature: floatPtr(20.5), Comfort: &comfort, Saving: &saving}}}
+	applier := NewApplier(&fritztest.HomeAuto{Err: errors.New("that didn't work")})
+	assert.NoError(t, applier.Apply(src, &Plan{Thermostats: []Thermostat{{Name: "t", Temperature: floatPtr(20.5), Comfort: &comfort}}}))
+	assert.Error(t, applier.Apply(src, &Plan{Thermostats: []Thermostat{{Name: "t", Temperature: floatPtr(20.5), Lock: &lock}}}))
+	assert.NoError(t, NewApplier(&fritztest.HomeAuto{}).Apply(src, &Plan{Thermostats: []Thermostat{{Name: "t", Temperature: floatPtr(20.5), Saving: &comfort, Lock: &lock}}}))
 }
 
-// On always returns an error.
-func (f *fritzAlwaysError) On(names ...string) error {
-	return errors.New("that didn't work")
+// TestApplyViaAhaSchedule tests that only changed schedules are applied.
+func TestApplyViaAhaSchedule(t *testing.T) {
+	nights := &fritz.HeatingSchedule{Weekly: []fritz.SwitchPoint{{Days: []string{"daily"}, At: "22:00", Mode: fritz.SavingMode}}}
+	same := &fritz.HeatingSchedule{Weekly: []fritz.SwitchPoint{{Days: []string{"mon", "tue", "wed", "thu", "fri", "sat", "sun"}, At: "22:00", Mode: fritz.SavingMode}}}
+	mornings := &fritz.HeatingSchedule{Weekly: []fritz.SwitchPoint{{Days: []string{"daily"}, At: "06:00", Mode: fritz.ComfortMode}}}
+	applier := NewApplier(&fritztest.HomeAuto{Err: errors.New("that didn't work")})
+	src := &Plan{Thermostats: []Thermostat{{Name: "t", Temperature: floatPtr(20.5), Schedule: nights}}}
+	assert.NoError(t, applier.Apply(src, &Plan{Thermostats: []Thermostat{{Name: "t", Temperature: floatPtr(20.5)}}}))
+	assert.NoError(t, applier.Apply(src, &Plan{Thermostats: []Thermostat{{Name: "t", Temperature: floatPtr(20.5), Schedule: same}}}))
+	assert.Error(t, applier.Apply(src, &Plan{Thermostats: []Thermostat{{Name: "t", Temperature: floatPtr(20.5), Schedule: mornings}}}))
+	assert.NoError(t, NewApplier(&fritztest.HomeAuto{}).Apply(src, &Plan{Thermostats: []Thermostat{{Name: "t", Temperature: floatPtr(20.5), Schedule: mornings}}}))
 }
 
-// Off always returns an error.
-func (f *fritzAlwaysError) Off(names ...string) error {
-	return errors.New("that didn't work")
-}
-
-// Temp always returns an error.
-func (f *fritzAlwaysError) Temp(value float64, names ...string) error {
-	return errors.New("that didn't work")
+// TestApplyViaAhaUnsupported tests that changes beyond fritz.HomeAuto fail if the client does not support them.
+func TestApplyViaAhaUnsupported(t *testing.T) {
+	comfort := 21.0
+	src := &Plan{Thermostats: []Thermostat{{Name: "t", Temperature: floatPtr(20.5)}}}
+	applier := NewApplier(struct{ fritz.HomeAuto }{&fritztest.HomeAuto{}})
+	assert.NoError(t, applier.Apply(src, &Plan{Thermostats: []Thermostat{{Name: "t", Temperature: floatPtr(21)}}}))
+	err := applier.Apply(src, &Plan{Thermostats: []Thermostat{{Name: "t", Temperature: floatPtr(20.5), Comfort: &comfort}}})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "does not support configuring thermostats")
 }
 
 // TestApplyViaAhaErrorByThermostat tests the http interface applier.
 func TestApplyViaAhaErrorByThermostat(t *testing.T) {
-	applier := NewApplier(&fritzAlwaysError{})
+	applier := NewApplier(&fritztest.HomeAuto{Err: errors.New("that didn't work")})
 	err := applier.Apply(
 		&Plan{
 			Switches:    []Switch{{Name: "s", State: true}},
-			Thermostats: []Thermostat{{Name: "t", Temperature: 17.5}},
+			Thermostats: []Thermostat{{Name: "t", Temperature: floatPtr(17.5)}},
 		},
 		&Plan{
 			Switches:    []Switch{{Name: "s", State: true}},
-			Thermostats: []Thermostat{{Name: "t", Temperature: 20.5}},
+			Thermostats: []Thermostat{{Name: "t", Temperature: floatPtr(20.5)}},
 		})
 	assert.Error(t, err)
 }
 
 // TestApplyViaAhaErrorBySwitch tests the http interface applier.
 func TestApplyViaAhaErrorBySwitch(t *testing.T) {
-	applier := NewApplier(&fritzAlwaysError{})
+	applier := NewApplier(&fritztest.HomeAuto{Err: errors.New("that didn't work")})
 	err := applier.Apply(
 		&Plan{
 			Switches:    []Switch{{Name: "s", State: false}},
-			Thermostats: []Thermostat{{Name: "t", Temperature: 20.5}},
+			Thermostats: []Thermostat{{Name: "t", Temperature: floatPtr(20.5)}},
 		},
 		&Plan{
 			Switches:    []Switch{{Name: "s", State: true}},
-			Thermostats: []Thermostat{{Name: "t", Temperature: 20.5}},
+			Thermostats: []Thermostat{{Name: "t", Temperature: floatPtr(20.5)}},
 		})
 	assert.Error(t, err)
 }
 
 // TestApplyViaAhaErrorBySwitch tests the http interface applier.
 func TestApplyViaAhaErrorByMalformedPlan(t *testing.T) {
-	applier := NewApplier(&fritzAlwaysError{})
+	applier := NewApplier(&fritztest.HomeAuto{Err: errors.New("that didn't work")})
 	err := applier.Apply(
 		&Plan{
 			Switches:    []Switch{{Name: "s", State: false}},
-			Thermostats: []Thermostat{{Name: "t", Temperature: 20.5}},
+			Thermostats: []Thermostat{{Name: "t", Temperature: floatPtr(20.5)}},
 		},
 		&Plan{
 			Switches:    []Switch{{Name: "CCCCC", State: true}},
-			Thermostats: []Thermostat{{Name: "YYYYYY", Temperature: 20.5}},
+			Thermostats: []Thermostat{{Name: "YYYYYY", Temperature: floatPtr(20.5)}},
 		})
 	assert.Error(t, err)
 }
 
 // TestApplyViaAhaLargeSystemWithErrors tests the http interface applier.
 func TestApplyViaAhaLargeSystemWithErrors(t *testing.T) {
-	applier := NewApplier(&fritzAlwaysError{})
+	applier := NewApplier(&fritztest.HomeAuto{Err: errors.New("that didn't work")})
 	err := applier.Apply(
 		&Plan{
 			Switches: []Switch{
@@ -187,15 +189,15 @@ func TestApplyViaAhaLargeSystemWithErrors(t *testing.T) {
 				{Name: "s13", State: false},
 			},
 			Thermostats: []Thermostat{
-				{Name: "t1", Temperature: 17.5},
-				{Name: "t2", Temperature: 18.5},
-				{Name: "t3", Temperature: 19.5},
-				{Name: "t4", Temperature: 21.5},
-				{Name: "t5", Temperature: 22.5},
-				{Name: "t6", Temperature: 23.0},
-				{Name: "t7", Temperature: 34.0},
-				{Name: "t8", Temperature: 26.0},
-				{Name: "t9", Temperature: 27.5},
+				{Name: "t1", Temperature: floatPtr(17.5)},
+				{Name: "t2", Temperature: floatPtr(18.5)},
+				{Name: "t3", Temperature: floatPtr(19.5)},
+				{Name: "t4", Temperature: floatPtr(21.5)},
+				{Name: "t5", Temperature: floatPtr(22.5)},
+				{Name: "t6", Temperature: floatPtr(23.0)},
+				{Name: "t7", Temperature: floatPtr(34.0)},
+				{Name: "t8", Temperature: floatPtr(26.0)},
+				{Name: "t9", Temperature: floatPtr(27.5)},
 			},
 		},
 		&Plan{
@@ -215,16 +217,43 @@ func TestApplyViaAhaLargeSystemWithErrors(t *testing.T) {
 				{Name: "s13", State: true},
 			},
 			Thermostats: []Thermostat{
-				{Name: "t1", Temperature: 27.5},
-				{Name: "t2", Temperature: 19.5},
-				{Name: "t3", Temperature: 17.5},
-				{Name: "t4", Temperature: 25.5},
-				{Name: "t5", Temperature: 21.5},
-				{Name: "t6", Temperature: 24.0},
-				{Name: "t7", Temperature: 24.0},
-				{Name: "t8", Temperature: 16.0},
-				{Name: "t9", Temperature: 17.5},
+				{Name: "t1", Temperature: floatPtr(27.5)},
+				{Name: "t2", Temperature: floatPtr(19.5)},
+				{Name: "t3", Temperature: floatPtr(17.5)},
+				{Name: "t4", Temperature: floatPtr(25.5)},
+				{Name: "t5", Temperature: floatPtr(21.5)},
+				{Name: "t6", Temperature: floatPtr(24.0)},
+				{Name: "t7", Temperature: floatPtr(24.0)},
+				{Name: "t8", Temperature: floatPtr(16.0)},
+				{Name: "t9", Temperature: floatPtr(17.5)},
 			},
 		})
 	assert.Error(t, err)
 }
+
+// TestApplyStaged tests that the stages are applied in ascending order and that later stages are skipped on failure.
+func TestApplyStaged(t *testing.T) {
+	src := &Plan{
+		Switches:    []Switch{{Name: "a", State: false}, {Name: "b", State: false}, {Name: "c", State: false}},
+		Thermostats: []Thermostat{{Name: "t", Temperature: floatPtr(20)}},
+	}
+	target := &Plan{
+		Switches: []Switch{
+			{Name: "a", State: true, Ordering: Ordering{Stage: 2}},
+			{Name: "b", State: true, Ordering: Ordering{DependsOn: []string{"t"}}},
+			{Name: "c", State: true, Ordering: Ordering{Stage: 2}},
+		},
+		Thermostats: []Thermostat{{Name: "t", Temperature: floatPtr(21)}},
+		StageDelay:  time.Millisecond,
+	}
+	f := &fritztest.HomeAuto{}
+	assert.NoError(t, NewApplier(f).Apply(src, target))
+	assert.Equal(t, []string{"temp 21 [t]", "on [b]"}, f.Commands()[:2])
+	assert.ElementsMatch(t, []string{"on [a]", "on [c]"}, f.Commands()[2:])
+
+	f = &fritztest.HomeAuto{Fail: map[string]bool{"on [b]": true}}
+	err := NewApplier(f).Apply(src, target)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "the following stages were skipped: 2")
+	assert.Equal(t, []string{"temp 21 [t]", "on [b]"}, f.Commands())
+}
@@ -0,0 +1,67 @@
+package manifest
+
+import (
+	"testing"
+
+	"github.com/bpicode/fritzctl/fritz/fritztest"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestApplyAtomic tests that successful changes are reverted if any change fails.
+func TestApplyAtomic(t *testing.T) {
+	src := &Plan{
+		Switches:    []Switch{{Name: "a", State: false}, {Name: "b", State: false}},
+		Thermostats: []Thermostat{{Name: "t", Temperature: floatPtr(20)}},
+	}
+	target := &Plan{
+		Switches:    []Switch{{Name: "a", State: true}, {Name: "b", State: true}},
+		Thermostats: []Thermostat{{Name: "t", Temperature: floatPtr(21)}},
+	}
+	f := &fritztest.HomeAuto{Fail: map[string]bool{"on [b]": true}}
+	err := NewApplier(f, Atomic()).Apply(src, target)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "all 2 successful changes were rolled back")
+	assert.Len(t, f.Commands(), 5)
+	assert.ElementsMatch(t, []string{"off [a]", "temp 20 [t]"}, f.Commands()[3:], "the rollback comes after all changes")
+
+	f = &fritztest.HomeAuto{Fail: map[string]bool{"on [b]": true, "off [a]": true}}
+	err = NewApplier(f, Atomic()).Apply(src, target)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "the rollback of 1 of 2 changes failed")
+
+	f = &fritztest.HomeAuto{Fail: map[string]bool{"on [b]": true}}
+	assert.Error(t, NewApplier(f).Apply(src, target))
+	assert.Len(t, f.Commands(), 3, "no rollback unless atomic")
+}
+
+// TestApplyAtomicOrder tests that the changes of a device are reverted in reverse order.
+func TestApplyAtomicOrder(t *testing.T) {
+	on, off, level := true, false, 20
+	src := &Plan{
+		Switches: []Switch{{Name: "a", AIN: "1", State: false}, {Name: "b", State: false}},
+		Groups:   []Group{{Name: "g"}},
+		Bulbs:    []Bulb{{Name: "l", State: &on, Level: &level}},
+	}
+	target := &Plan{
+		Switches: []Switch{{Name: "x", AIN: "1", State: true}, {Name: "b", State: true}},
+		Groups:   []Group{{Name: "g", State: &off}},
+		Bulbs:    []Bulb{{Name: "l", State: &off}},
+	}
+	f := &fritztest.HomeAuto{Fail: map[string]bool{"on [b]": true}}
+	err := NewApplier(f, Atomic(), UpdateNames()).Apply(src, target)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "the rollback of 1 of 4 changes failed", "the state of the group before is unknown")
+	assert.Contains(t, err.Error(), "the state of 'g' before the change is unknown")
+	assert.Contains(t, f.Commands(), "light state=on [l]")
+	assert.Contains(t, f.Commands(), "rename x a")
+	var renamed, switched int
+	for i, c := range f.Commands() {
+		switch c {
+		case "rename x a":
+			renamed = i
+		case "off [a]":
+			switched = i
+		}
+	}
+	assert.True(t, renamed < switched, "%v", f.Commands())
+}
@@ -2,62 +2,43 @@ package manifest
 
 import (
 	"fmt"
-
-	"github.com/bpicode/fritzctl/internal/console"
+	"sort"
+	"time"
 )
 
 type dryRunner struct {
-}
-
-type justLogSwitchAction struct {
-	before Switch
-	after  Switch
-}
-
-type justLogThermostatAction struct {
-	before Thermostat
-	after  Thermostat
+	options options
 }
 
 // DryRunner is an Applier that only plans changes to the AHA system.
-func DryRunner() Applier {
-	return &dryRunner{}
+func DryRunner(opts ...Option) Applier {
+	return &dryRunner{options: newOptions(opts)}
 }
 
 // Apply does only log the proposed changes.
 func (d *dryRunner) Apply(src, target *Plan) error {
-	planner := TargetBasedPlanner(justLogSwitchState, justLogThermostat)
-	actions, err := planner.Plan(src, target)
+	actions, err := d.options.plan(src, target)
 	if err != nil {
 		return err
 	}
-	fmt.Println("\n\nThe following actions would be applied by the manifest:")
-	for _, action := range actions {
-		action.Perform(nil)
-	}
+	printChanges(Describe(actions), target.StageDelay)
 	return nil
 }
 
-func justLogThermostat(before, after Thermostat) Action {
-	return &justLogThermostatAction{before: before, after: after}
-}
-
-// Perform only logs changes.
-func (a *justLogThermostatAction) Perform(f aha) error {
-	if a.before.Temperature != a.after.Temperature {
-		fmt.Printf("\t'%s'\t%.1f°C\t⟶\t%.1f°C\n", a.before.Name, a.before.Temperature, a.after.Temperature)
-	}
-	return nil
-}
-
-func justLogSwitchState(before, after Switch) Action {
-	return &justLogSwitchAction{before: before, after: after}
-}
-
-// Perform only logs changes.
-func (a *justLogSwitchAction) Perform(f aha) error {
-	if a.before.State != a.after.State {
-		fmt.Printf("\t'%s'\t%s\t⟶\t%s\n", a.before.Name, console.Btoc(a.before.State), console.Btoc(a.after.State))
+// printChanges lists the changes by stage, in the order in which they are applied.
+func printChanges(changes []Change, stageDelay time.Duration) {
+	fmt.Println("\n\nThe following actions would be applied by the manifest:")
+	sorted := make([]Change, len(changes))
+	copy(sorted, changes)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Stage < sorted[j].Stage })
+	staged := len(sorted) > 0 && sorted[0].Stage != sorted[len(sorted)-1].Stage
+	for i, c := range sorted {
+		if staged && (i == 0 || c.Stage != sorted[i-1].Stage) {
+			fmt.Printf("Stage %d:\n", c.Stage)
+			if i > 0 && stageDelay > 0 {
+				fmt.Printf("\t(after a delay of %s)\n", stageDelay)
+			}
+		}
+		fmt.Printf("\t%s\n", c)
 	}
-	return nil
 }
@@ -2,6 +2,9 @@ package manifest
 
 import (
 	"testing"
+	"time"
+
+	"github.com/bpicode/fritzctl/fritz"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -33,11 +36,11 @@ func TestDryRunSwitchToggleAndTemperatureChange(t *testing.T) {
 	err := applier.Apply(
 		&Plan{
 			Switches:    []Switch{{Name: "s", State: true}},
-			Thermostats: []Thermostat{{Name: "t", Temperature: 17.5}},
+			Thermostats: []Thermostat{{Name: "t", Temperature: floatPtr(17.5)}},
 		},
 		&Plan{
 			Switches:    []Switch{{Name: "s", State: false}},
-			Thermostats: []Thermostat{{Name: "t", Temperature: 20.5}},
+			Thermostats: []Thermostat{{Name: "t", Temperature: floatPtr(20.5)}},
 		})
 	assert.NoError(t, err)
 }
@@ -45,6 +48,28 @@ func TestDryRunSwitchToggleAndTemperatureChange(t *testing.T) {
 // TestDryRunThermostatNameNotFound tests the dry-runner for a HKR that does not exist.
 func TestDryRunThermostatNameNotFound(t *testing.T) {
 	applier := DryRunner()
-	err := applier.Apply(&Plan{Thermostats: []Thermostat{{Name: "AAA", Temperature: 24.5}}}, &Plan{Thermostats: []Thermostat{{Name: "YYY", Temperature: 20.5}}})
+	err := applier.Apply(&Plan{Thermostats: []Thermostat{{Name: "AAA", Temperature: floatPtr(24.5)}}}, &Plan{Thermostats: []Thermostat{{Name: "YYY", Temperature: floatPtr(20.5)}}})
+	assert.Error(t, err)
+}
+
+// TestDryRunSchedule tests the dry-runner for a changed heating schedule.
+func TestDryRunSchedule(t *testing.T) {
+	applier := DryRunner()
+	schedule := &fritz.HeatingSchedule{Weekly: []fritz.SwitchPoint{{Days: []string{"daily"}, At: "22:00", Mode: fritz.SavingMode}}}
+	err := applier.Apply(&Plan{Thermostats: []Thermostat{{Name: "t", Temperature: floatPtr(20.5)}}}, &Plan{Thermostats: []Thermostat{{Name: "t", Temperature: floatPtr(20.5), Schedule: schedule}}})
+	assert.NoError(t, err)
+}
+
+// TestDryRunStages tests the dry-runner for a staged manifest.
+func TestDryRunStages(t *testing.T) {
+	applier := DryRunner()
+	err := applier.Apply(
+		&Plan{Switches: []Switch{{Name: "a", State: false}, {Name: "b", State: false}}},
+		&Plan{Switches: []Switch{{Name: "a", State: true}, {Name: "b", State: true, Ordering: Ordering{DependsOn: []string{"a"}}}}, StageDelay: time.Minute})
+	assert.NoError(t, err)
+
+	err = applier.Apply(
+		&Plan{Switches: []Switch{{Name: "a", State: false}}},
+		&Plan{Switches: []Switch{{Name: "a", State: true, Ordering: Ordering{DependsOn: []string{"a"}}}}})
 	assert.Error(t, err)
 }
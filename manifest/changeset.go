@@ -0,0 +1,118 @@
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/bpicode/fritzctl/fritz"
+	"gopkg.in/yaml.v2"
+)
+
+// Changeset is a machine-readable plan: the changes that transition the devices to the target state. It can be saved
+// and applied later by NewApplier with the options of Changeset.Options to the target returned by Changeset.Resolve,
+// which refuses to apply it if the state of the devices has drifted in the meantime.
+type Changeset struct {
+	Strict      bool      `json:"strict" yaml:"strict"`                               // The target is the full desired state, see Strict.
+	UpdateNames bool      `json:"updatenames,omitempty" yaml:"updatenames,omitempty"` // Devices addressed by AIN are renamed, see UpdateNames.
+	Target      *Plan     `json:"target" yaml:"target"`                               // The target state, including its rules.
+	Outcomes    []Outcome `json:"outcomes,omitempty" yaml:"outcomes,omitempty"`       // The outcomes of the rules of the target when planned.
+	Changes     []Change  `json:"changes" yaml:"changes"`                             // The changes, in the order in which they are applied.
+}
+
+// NewChangeset plans the transition from src to target, whose rules are resolved to the given outcomes, see
+// Plan.Resolve. There is one outcome per rule.
+func NewChangeset(src, target *Plan, outcomes []Outcome, opts ...Option) (*Changeset, error) {
+	resolved, err := target.resolved(outcomes)
+	if err != nil {
+		return nil, err
+	}
+	o := newOptions(opts)
+	actions, err := o.plan(src, resolved)
+	if err != nil {
+		return nil, err
+	}
+	return &Changeset{Strict: o.strict, UpdateNames: o.updateNames, Target: target, Outcomes: outcomes, Changes: Describe(actions)}, nil
+}
+
+// Resolve evaluates the rules of the target against the live devices again, see Plan.Resolve. It returns the resolved
+// target, or an error if the outcome of any rule differs from the one when the changeset was planned.
+func (c *Changeset) Resolve(l *fritz.Devicelist) (*Plan, error) {
+	resolved, outcomes, err := c.Target.Resolve(l)
+	if err != nil {
+		return nil, err
+	}
+	if len(outcomes) != len(c.Outcomes) {
+		return nil, fmt.Errorf("the target has %d rules, but %d were planned", len(outcomes), len(c.Outcomes))
+	}
+	var changed []string
+	for i, o := range outcomes {
+		if o.Held != c.Outcomes[i].Held {
+			changed = append(changed, fmt.Sprintf("%s %s now", o.Rule, holds(o)))
+		}
+	}
+	if len(changed) > 0 {
+		return nil, fmt.Errorf("the outcomes of rules changed since planning: %s", strings.Join(changed, ", "))
+	}
+	return resolved, nil
+}
+
+func holds(o Outcome) string {
+	if o.Held {
+		return "holds"
+	}
+	return "does not hold"
+}
+
+// Options returns the options to apply the changeset to its Target.
+func (c *Changeset) Options() []Option {
+	opts := []Option{Expect(c.Changes)}
+	if c.Strict {
+		opts = append(opts, Strict())
+	}
+	if c.UpdateNames {
+		opts = append(opts, UpdateNames())
+	}
+	return opts
+}
+
+// Print lists the changes like the Applier of DryRunner does.
+func (c *Changeset) Print() {
+	printChanges(c.Changes, c.Target.StageDelay)
+}
+
+// Write encodes the changeset as JSON, or as YAML if the file name ends in .yml or .yaml.
+func (c *Changeset) Write(w io.Writer, filename string) error {
+	var bs []byte
+	var err error
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yml", ".yaml":
+		bs, err = yaml.Marshal(c)
+	default:
+		bs, err = json.MarshalIndent(c, "", "  ")
+	}
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(bs)
+	return err
+}
+
+// ParseChangeset decodes a changeset written by Changeset.Write, either as JSON or as YAML.
+func ParseChangeset(r io.Reader) (*Changeset, error) {
+	bs, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var c Changeset
+	if err := yaml.Unmarshal(bs, &c); err != nil {
+		return nil, err
+	}
+	if c.Target == nil {
+		c.Target = &Plan{}
+	}
+	return &c, nil
+}
@@ -0,0 +1,80 @@
+package manifest
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/bpicode/fritzctl/fritz"
+	"github.com/bpicode/fritzctl/fritz/fritztest"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestChangesetRoundTrip tests writing and parsing changesets in both formats.
+func TestChangesetRoundTrip(t *testing.T) {
+	comfort := 21.5
+	src := &Plan{Switches: []Switch{{Name: "s", State: true}}, Thermostats: []Thermostat{{Name: "t", Temperature: floatPtr(17.5)}}}
+	target := &Plan{Unmanaged: OffUnmanaged, Switches: []Switch{}, Thermostats: []Thermostat{{Name: "t", Temperature: floatPtr(20.5), Comfort: &comfort}}}
+	cs, err := NewChangeset(src, target, nil, Strict())
+	assert.NoError(t, err)
+	assert.Equal(t, []Change{
+		{Device: "t", Attribute: TemperatureAttribute, Before: "17.5", After: "20.5", Reason: ManifestReason},
+		{Device: "t", Attribute: SettingsAttribute, Before: "", After: "comfort=21.5°C", Reason: ManifestReason},
+		{Device: "s", Attribute: StateAttribute, Before: "on", After: "off", Reason: UnmanagedReason},
+	}, cs.Changes)
+
+	for _, filename := range []string{"plan.json", "plan.yml"} {
+		var buf bytes.Buffer
+		assert.NoError(t, cs.Write(&buf, filename))
+		parsed, err := ParseChangeset(&buf)
+		assert.NoError(t, err)
+		assert.Equal(t, cs, parsed, filename)
+		assert.NoError(t, NewApplier(&fritztest.HomeAuto{}, parsed.Options()...).Apply(src, parsed.Target))
+	}
+}
+
+// TestChangesetDrift tests that changesets are refused if the state has drifted.
+func TestChangesetDrift(t *testing.T) {
+	target := &Plan{Switches: []Switch{{Name: "s", State: false}}}
+	cs, err := NewChangeset(&Plan{Switches: []Switch{{Name: "s", State: true}}}, target, nil)
+	assert.NoError(t, err)
+	drifted := &Plan{Switches: []Switch{{Name: "s", State: false}}}
+	assert.Error(t, NewApplier(&fritztest.HomeAuto{}, cs.Options()...).Apply(drifted, target))
+	assert.Error(t, DryRunner(cs.Options()...).Apply(drifted, target))
+	_, err = NewChangeset(drifted, &Plan{Switches: []Switch{{Name: "x"}}}, nil)
+	assert.Error(t, err)
+}
+
+// TestChangesetRules tests that changesets keep the rules of the target and are refused if their outcomes change.
+func TestChangesetRules(t *testing.T) {
+	on := true
+	target := &Plan{
+		Thermostats: []Thermostat{{Name: "HKR_Living", Temperature: floatPtr(21)}},
+		Rules: []Rule{{
+			Name: "window open",
+			When: []Condition{{Device: "Window", Alert: &on}},
+			Then: Plan{Thermostats: []Thermostat{{Name: "HKR_Living", Temperature: floatPtr(126.5)}}},
+		}},
+	}
+	src := &Plan{Thermostats: []Thermostat{{Name: "HKR_Living", Temperature: floatPtr(21)}}}
+	_, outcomes, err := target.Resolve(rulesDevicelist)
+	assert.NoError(t, err)
+	cs, err := NewChangeset(src, target, outcomes)
+	assert.NoError(t, err)
+	assert.Equal(t, []Change{{Device: "HKR_Living", Attribute: TemperatureAttribute, Before: "21", After: "126.5", Reason: ManifestReason}}, cs.Changes)
+
+	var buf bytes.Buffer
+	assert.NoError(t, cs.Write(&buf, "plan.json"))
+	parsed, err := ParseChangeset(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, target.Rules, parsed.Target.Rules)
+	resolved, err := parsed.Resolve(rulesDevicelist)
+	assert.NoError(t, err)
+	assert.NoError(t, NewApplier(&fritztest.HomeAuto{}, parsed.Options()...).Apply(src, resolved))
+
+	closed := &fritz.Devicelist{Devices: []fritz.Device{{Name: "Window", Identifier: "111", Functionbitmask: "16", Present: 1, AlertSensor: fritz.AlertSensor{State: "0"}}}}
+	_, err = parsed.Resolve(closed)
+	assert.EqualError(t, err, "the outcomes of rules changed since planning: rule 'window open' does not hold now")
+
+	_, err = NewChangeset(src, target, nil)
+	assert.Error(t, err)
+}
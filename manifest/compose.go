@@ -0,0 +1,374 @@
+package manifest
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+	yaml3 "gopkg.in/yaml.v3"
+)
+
+// document is the content of a manifest file: a Plan and the directives to compose it from other files.
+type document struct {
+	Include []string          `yaml:"include,omitempty"` // Manifest files merged before this one, relative to this file.
+	Vars    map[string]string `yaml:"vars,omitempty"`    // Variables, referenced as ${name}.
+	Plan    `yaml:",inline"`
+}
+
+// source is the raw content of a manifest file.
+type source struct {
+	name  string      // The file name, empty if the manifest is not read from a file.
+	dir   string      // Directory to which includes are relative.
+	data  []byte      // The content as YAML.
+	lines map[int]int // The lines of the file by the lines of the content, if it was converted from another format.
+}
+
+// line returns the line of the file for a line of the content, or 0 if there is none.
+func (src source) line(n int) int {
+	if src.lines == nil {
+		return n
+	}
+	return src.lines[n]
+}
+
+// composer composes a Plan from manifest files. The files are merged in the order of their precedence, lowest first:
+// the included files before the including one, the overlays after the base manifest, in the order given. The same
+// precedence applies to the variables, which are looked up in the environment if they are not defined in any file.
+type composer struct {
+	sources  []source
+	vars     map[string]string
+	visiting map[string]bool
+}
+
+func newComposer() *composer {
+	return &composer{vars: make(map[string]string), visiting: make(map[string]bool)}
+}
+
+// add reads the includes and variables of the manifest and adds it, after its includes, to the sources. Manifests in
+// other formats than YAML are converted first.
+func (c *composer) add(src source) error {
+	if f := DetectFormat(src.name, src.data); f != YAML {
+		data, lines, err := toYAML(f, src.data)
+		if err != nil {
+			return locate(src.name, err)
+		}
+		src.data, src.lines = data, lines
+	}
+	var d struct {
+		Include []string          `yaml:"include"`
+		Vars    map[string]string `yaml:"vars"`
+	}
+	if err := yaml.Unmarshal(src.data, &d); err != nil {
+		return locate(src.name, src.relocate(err))
+	}
+	key, _ := filepath.Abs(src.name)
+	if src.name != "" && c.visiting[key] {
+		return fmt.Errorf("%s: include cycle", src.name)
+	}
+	c.visiting[key] = true
+	defer delete(c.visiting, key)
+	for _, inc := range d.Include {
+		if err := c.addFile(src.name, filepath.Join(src.dir, inc)); err != nil {
+			return err
+		}
+	}
+	for k, v := range d.Vars {
+		expanded, undefined := expand(v, os.LookupEnv)
+		if undefined != "" {
+			return fmt.Errorf("%s: variable '%s' refers to the undefined environment variable '%s'", describeSource(src.name), k, undefined)
+		}
+		c.vars[k] = expanded
+	}
+	c.sources = append(c.sources, src)
+	return nil
+}
+
+func (c *composer) addFile(includedBy, filename string) error {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil && includedBy != "" {
+		return fmt.Errorf("%s: cannot include '%s': %v", includedBy, filename, err)
+	}
+	if err != nil {
+		return err
+	}
+	return c.add(source{name: filename, dir: filepath.Dir(filename), data: data})
+}
+
+func (c *composer) lookup(name string) (string, bool) {
+	if v, ok := c.vars[name]; ok {
+		return v, true
+	}
+	return os.LookupEnv(name)
+}
+
+// plan decodes, checks and merges the sources. If any of them is invalid, the problems of all sources are returned.
+func (c *composer) plan() (*Plan, error) {
+	var plan Plan
+	var problems Problems
+	for _, src := range c.sources {
+		d, err := c.decode(src)
+		if err != nil {
+			problems = append(problems, err)
+			continue
+		}
+		for _, p := range d.Plan.check() {
+			problems = append(problems, fmt.Errorf("%s: %v", describeSource(src.name), p))
+		}
+		plan.merge(&d.Plan)
+	}
+	if _, err := plan.stages(); err != nil && len(problems) == 0 {
+		problems = append(problems, err)
+	}
+	return &plan, problems.orNil()
+}
+
+// decode substitutes the variables in the source and decodes it strictly, unknown fields are an error.
+func (c *composer) decode(src source) (*document, error) {
+	data, err := substitute(src, c.lookup)
+	if err != nil {
+		return nil, err
+	}
+	var d document
+	if err := yaml.UnmarshalStrict(data, &d); err != nil {
+		return nil, locate(src.name, src.relocate(err))
+	}
+	if err := d.Plan.toCelsius(); err != nil {
+		return nil, fmt.Errorf("%s: %v", describeSource(src.name), err)
+	}
+	return &d, nil
+}
+
+var variable = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// substitute replaces the references ${name} to variables in the values of the source by the values of the variables,
+// comments are left untouched. A reference in a plain value is replaced as if the value of the variable was written
+// there, so that e.g. numbers and booleans can be given by variables. If the result would not be read as the same
+// value, e.g. as it contains " #" or ": ", it is quoted instead, just like the results of quoted values. Sources that
+// cannot be parsed before the substitution, e.g. with references in flow collections, are substituted textually.
+func substitute(src source, lookup func(string) (string, bool)) ([]byte, error) {
+	var root yaml3.Node
+	if err := yaml3.Unmarshal(src.data, &root); err != nil {
+		return substituteText(src, lookup)
+	}
+	lines := strings.SplitAfter(string(src.data), "\n")
+	refs := references(&root)
+	for i := len(refs) - 1; i >= 0; i-- {
+		n := refs[i]
+		value, undefined := expand(n.Value, lookup)
+		if undefined != "" {
+			return nil, substitutionError(src, n.Line, fmt.Sprintf("undefined variable '%s'", undefined))
+		}
+		l := lines[n.Line-1]
+		start := len(string([]rune(l)[:n.Column-1]))
+		end := scalarEnd(n, l, start)
+		if end < 0 {
+			return nil, substitutionError(src, n.Line, "variables are only supported in plain or quoted values on a single line")
+		}
+		replacement := strconv.Quote(value)
+		if n.Style == 0 && isPlain(value) {
+			replacement = value
+		}
+		lines[n.Line-1] = l[:start] + replacement + l[end:]
+	}
+	return []byte(strings.Join(lines, "")), nil
+}
+
+// references returns the scalars containing references to variables, in the order of the document.
+func references(n *yaml3.Node) []*yaml3.Node {
+	var refs []*yaml3.Node
+	if n.Kind == yaml3.ScalarNode && variable.MatchString(n.Value) {
+		refs = append(refs, n)
+	}
+	for _, c := range n.Content {
+		refs = append(refs, references(c)...)
+	}
+	return refs
+}
+
+// scalarEnd returns the offset in the line after the scalar starting at start, or -1 if it does not end on the line.
+func scalarEnd(n *yaml3.Node, line string, start int) int {
+	switch n.Style {
+	case 0:
+		if strings.HasPrefix(line[start:], n.Value) && !strings.Contains(n.Value, "\n") {
+			return start + len(n.Value)
+		}
+	case yaml3.DoubleQuotedStyle:
+		for i := start + 1; i < len(line); i++ {
+			switch line[i] {
+			case '\\':
+				i++
+			case '"':
+				return i + 1
+			}
+		}
+	case yaml3.SingleQuotedStyle:
+		for i := start + 1; i < len(line); i++ {
+			if line[i] == '\'' && i+1 < len(line) && line[i+1] == '\'' {
+				i++
+			} else if line[i] == '\'' {
+				return i + 1
+			}
+		}
+	}
+	return -1
+}
+
+// isPlain reports whether the value is read as the same string if written as a plain scalar.
+func isPlain(value string) bool {
+	var n yaml3.Node
+	if err := yaml3.Unmarshal([]byte(value), &n); err != nil || len(n.Content) != 1 {
+		return false
+	}
+	s := n.Content[0]
+	return s.Kind == yaml3.ScalarNode && s.Style == 0 && s.Value == value
+}
+
+// substituteText replaces the references ${name} to variables in the text of the source.
+func substituteText(src source, lookup func(string) (string, bool)) ([]byte, error) {
+	lines := strings.SplitAfter(string(src.data), "\n")
+	for i, l := range lines {
+		expanded, undefined := expand(l, lookup)
+		if undefined != "" {
+			return nil, substitutionError(src, i+1, fmt.Sprintf("undefined variable '%s'", undefined))
+		}
+		lines[i] = expanded
+	}
+	return []byte(strings.Join(lines, "")), nil
+}
+
+func substitutionError(src source, line int, msg string) error {
+	if l := src.line(line); l > 0 {
+		return fmt.Errorf("%s: %s", position(src.name, l), msg)
+	}
+	return fmt.Errorf("%s: %s", describeSource(src.name), msg)
+}
+
+// expand replaces the references ${name} to variables by their values. If a variable is undefined, its name is
+// returned.
+func expand(s string, lookup func(string) (string, bool)) (string, string) {
+	var undefined string
+	result := variable.ReplaceAllStringFunc(s, func(ref string) string {
+		name := ref[2 : len(ref)-1]
+		v, ok := lookup(name)
+		if !ok && undefined == "" {
+			undefined = name
+		}
+		return v
+	})
+	return result, undefined
+}
+
+var yamlLine = regexp.MustCompile(`line (\d+):`)
+
+// relocate replaces the lines of the content in a YAML error by the lines of the file. References to lines without a
+// corresponding line in the file are removed.
+func (src source) relocate(err error) error {
+	if src.lines == nil {
+		return err
+	}
+	return errors.New(yamlLine.ReplaceAllStringFunc(err.Error(), func(ref string) string {
+		n, _ := strconv.Atoi(yamlLine.FindStringSubmatch(ref)[1])
+		if l := src.line(n); l > 0 {
+			return fmt.Sprintf("line %d:", l)
+		}
+		return ""
+	}))
+}
+
+// locate prefixes the lines of the YAML error with the name of the file.
+func locate(name string, err error) error {
+	msg := strings.TrimPrefix(err.Error(), "yaml: ")
+	msg = strings.TrimPrefix(msg, "unmarshal errors:\n")
+	lines := strings.Split(msg, "\n")
+	for i, l := range lines {
+		l = strings.TrimSpace(l)
+		if m := yamlLine.FindStringSubmatchIndex(l); m != nil {
+			lines[i] = position(name, 0) + l[m[2]:m[3]] + ":" + l[m[1]:]
+			continue
+		}
+		lines[i] = describeSource(name) + ": " + l
+	}
+	return errors.New(strings.Join(lines, "\n"))
+}
+
+// position formats a position in a file like "file.yml:3", or the prefix "file.yml:" if the line is 0.
+func position(name string, line int) string {
+	prefix := name + ":"
+	if name == "" {
+		prefix = "line "
+	}
+	if line == 0 {
+		return prefix
+	}
+	return fmt.Sprintf("%s%d", prefix, line)
+}
+
+func describeSource(name string) string {
+	if name == "" {
+		return "manifest"
+	}
+	return name
+}
+
+// merge adds the devices and rules of other to the plan. An entry of other replaces the entry for the same device, the
+// policy for unmanaged devices and the stage delay are replaced if given.
+func (plan *Plan) merge(other *Plan) {
+	if other.Unmanaged != "" {
+		plan.Unmanaged = other.Unmanaged
+	}
+	if other.StageDelay != 0 {
+		plan.StageDelay = other.StageDelay
+	}
+	plan.Rules = append(plan.Rules, other.Rules...)
+	for _, s := range other.Switches {
+		if i := indexOf(len(plan.Switches), func(i int) bool { return sameDevice(plan.Switches[i].AIN, plan.Switches[i].Name, s.AIN, s.Name) }); i >= 0 {
+			plan.Switches[i] = s
+		} else {
+			plan.Switches = append(plan.Switches, s)
+		}
+	}
+	for _, t := range other.Thermostats {
+		if i := indexOf(len(plan.Thermostats), func(i int) bool { return sameDevice(plan.Thermostats[i].AIN, plan.Thermostats[i].Name, t.AIN, t.Name) }); i >= 0 {
+			plan.Thermostats[i] = t
+		} else {
+			plan.Thermostats = append(plan.Thermostats, t)
+		}
+	}
+	for _, g := range other.Groups {
+		if i := indexOf(len(plan.Groups), func(i int) bool { return plan.Groups[i].Name == g.Name }); i >= 0 {
+			plan.Groups[i] = g
+		} else {
+			plan.Groups = append(plan.Groups, g)
+		}
+	}
+	for _, b := range other.Bulbs {
+		if i := indexOf(len(plan.Bulbs), func(i int) bool { return sameDevice(plan.Bulbs[i].AIN, plan.Bulbs[i].Name, b.AIN, b.Name) }); i >= 0 {
+			plan.Bulbs[i] = b
+		} else {
+			plan.Bulbs = append(plan.Bulbs, b)
+		}
+	}
+}
+
+// sameDevice reports whether two manifest entries refer to the same device: by AIN if both have one, by name otherwise.
+func sameDevice(ain1, name1, ain2, name2 string) bool {
+	if ain1 != "" && ain2 != "" {
+		return addresses(ain1, "", ain2, "")
+	}
+	return name1 == name2
+}
+
+func indexOf(n int, predicate func(int) bool) int {
+	for i := 0; i < n; i++ {
+		if predicate(i) {
+			return i
+		}
+	}
+	return -1
+}
@@ -15,20 +15,128 @@ func ConvertDevicelist(l *fritz.Devicelist) *Plan {
 	for _, t := range l.Thermostats() {
 		p.Thermostats = append(p.Thermostats, convertThermostat(&t))
 	}
+	for _, g := range l.DeviceGroups() {
+		c := convertGroup(&g.Group)
+		for _, d := range g.Devices {
+			c.Members = append(c.Members, d.Identifier)
+		}
+		p.Groups = append(p.Groups, c)
+	}
+	for _, b := range l.Lights() {
+		p.Bulbs = append(p.Bulbs, convertBulb(&b))
+	}
 	return &p
 }
 
 func convertSwitch(d *fritz.Device) Switch {
 	var s Switch
 	s.Name = d.Name
+	s.AIN = d.Identifier
 	s.State, _ = strconv.ParseBool(d.Switch.State)
+	s.Lock = lockState(d.Switch.Lock)
+	s.DeviceLock = lockState(d.Switch.DeviceLock)
 	return s
 }
 
+func convertGroup(g *fritz.Group) Group {
+	var c Group
+	c.Name = g.Name
+	if g.MadeFromSwitches() {
+		c.State = lockState(g.Switch.State)
+	}
+	if g.MadeFromThermostats() {
+		if goalTimesTwo, err := strconv.ParseFloat(g.Thermostat.Goal, 64); err == nil && goalTimesTwo != 255 {
+			goal := goalTimesTwo / 2
+			c.Temperature = &goal
+		}
+	}
+	return c
+}
+
+func convertBulb(d *fritz.Device) Bulb {
+	var b Bulb
+	b.Name = d.Name
+	b.AIN = d.Identifier
+	b.State = lockState(d.OnOff.State)
+	b.Level = intValue(d.Level.LevelPercentage)
+	switch d.Color.CurrentMode {
+	case fritz.HueSaturationMode:
+		b.Hue = intValue(d.Color.Hue)
+		b.Saturation = intValue(d.Color.Saturation)
+	case fritz.ColorTemperatureMode:
+		b.ColorTemperature = intValue(d.Color.Temperature)
+	}
+	return b
+}
+
+func intValue(s string) *int {
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return nil
+	}
+	return &i
+}
+
 func convertThermostat(d *fritz.Device) Thermostat {
 	var t Thermostat
 	t.Name = d.Name
-	goalTimesTwo, _ := strconv.ParseFloat(d.Thermostat.Goal, 64)
-	t.Temperature = goalTimesTwo * 0.5
+	t.AIN = d.Identifier
+	if goalTimesTwo, err := strconv.ParseFloat(d.Thermostat.Goal, 64); err == nil {
+		goal := goalTimesTwo * 0.5
+		t.Temperature = &goal
+	}
+	t.Comfort = settingTemperature(d.Thermostat.Comfort)
+	t.Saving = settingTemperature(d.Thermostat.Saving)
+	if offset, err := strconv.ParseFloat(d.Temperature.Offset, 64); err == nil {
+		offset /= 10
+		t.Offset = &offset
+	}
+	t.Lock = lockState(d.Thermostat.Lock)
+	t.DeviceLock = lockState(d.Thermostat.DeviceLock)
 	return t
 }
+
+// settingTemperature converts a comfort or saving temperature, given in units of 0.5°C, to °C. Unknown and special
+// values yield nil.
+func settingTemperature(s string) *float64 {
+	timesTwo, err := strconv.ParseFloat(s, 64)
+	if err != nil || timesTwo < 16 || timesTwo > 56 {
+		return nil
+	}
+	t := timesTwo / 2
+	return &t
+}
+
+func lockState(s string) *bool {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return nil
+	}
+	return &b
+}
+
+type scheduleReader interface {
+	Schedule(name string) (*fritz.HeatingSchedule, error)
+}
+
+// LoadSchedules completes the src plan by the heating schedules of the thermostats for which the target plan specifies
+// a schedule. Thermostats are matched by AIN if given, by name otherwise. Reading schedules takes a request per
+// thermostat, so the others are skipped.
+func LoadSchedules(r scheduleReader, src, target *Plan) error {
+	for _, t := range target.Thermostats {
+		if t.Schedule == nil {
+			continue
+		}
+		for i, c := range src.Thermostats {
+			if !addresses(t.AIN, t.Name, c.AIN, c.Name) {
+				continue
+			}
+			s, err := r.Schedule(c.Name)
+			if err != nil {
+				return err
+			}
+			src.Thermostats[i].Schedule = s
+		}
+	}
+	return nil
+}
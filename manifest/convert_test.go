@@ -2,6 +2,7 @@ package manifest
 
 import (
 	"encoding/xml"
+	"errors"
 	"os"
 	"testing"
 
@@ -25,4 +26,97 @@ func TestConvertDevicelist(t *testing.T) {
 	temperature, ok := plan.temperatureOf("HKR_1")
 	assert.True(t, ok)
 	assert.InDelta(t, 126.5, temperature, 0.01)
+
+	th, ok := plan.thermostatNamed("HKR_1")
+	assert.True(t, ok)
+	assert.NotNil(t, th.Comfort)
+	assert.NotNil(t, th.Saving)
+	assert.NotNil(t, th.Lock)
+	assert.NotNil(t, th.DeviceLock)
+	assert.NotEmpty(t, th.AIN)
+
+	plan.WithoutAINs()
+	th, _ = plan.thermostatNamed("HKR_1")
+	assert.Empty(t, th.AIN)
+}
+
+// TestConvertThermostatSettings tests the conversion of the settings of a thermostat.
+func TestConvertThermostatSettings(t *testing.T) {
+	th := convertThermostat(&fritz.Device{Name: "t",
+		Thermostat:  fritz.Thermostat{Goal: "42", Comfort: "43", Saving: "253", Lock: "1", DeviceLock: ""},
+		Temperature: fritz.Temperature{Offset: "-15"}})
+	assert.Equal(t, 21.0, *th.Temperature)
+	assert.Equal(t, 21.5, *th.Comfort)
+	assert.Nil(t, th.Saving)
+	assert.Equal(t, -1.5, *th.Offset)
+	assert.True(t, *th.Lock)
+	assert.Nil(t, th.DeviceLock)
+}
+
+// TestConvertGroupsAndBulbs tests the conversion of device groups, lights and locks of switches.
+func TestConvertGroupsAndBulbs(t *testing.T) {
+	l := &fritz.Devicelist{
+		Devices: []fritz.Device{
+			{Name: "s", ID: "16", Identifier: "08761 0000434", Functionbitmask: "512", Switch: fritz.Switch{State: "1", Lock: "0", DeviceLock: "1"}},
+			{Name: "b", Functionbitmask: "237572", OnOff: fritz.OnOff{State: "1"}, Level: fritz.Level{LevelPercentage: "50"},
+				Color: fritz.Color{CurrentMode: fritz.HueSaturationMode, Hue: "358", Saturation: "180"}},
+			{Name: "w", Functionbitmask: "237572", OnOff: fritz.OnOff{State: "0"},
+				Color: fritz.Color{CurrentMode: fritz.ColorTemperatureMode, Temperature: "2700"}},
+		},
+		Groups: []fritz.Group{
+			{Name: "g1", Functionbitmask: "512", Switch: fritz.Switch{State: "1"}, GroupInfo: fritz.GroupInfo{Members: "16"}},
+			{Name: "g2", Functionbitmask: "320", Thermostat: fritz.Thermostat{Goal: "41"}},
+		},
+	}
+	plan := ConvertDevicelist(l)
+	assert.Len(t, plan.Switches, 1)
+	assert.False(t, *plan.Switches[0].Lock)
+	assert.True(t, *plan.Switches[0].DeviceLock)
+
+	assert.Len(t, plan.Groups, 2)
+	assert.True(t, *plan.Groups[0].State)
+	assert.Nil(t, plan.Groups[0].Temperature)
+	assert.Equal(t, []string{"08761 0000434"}, plan.Groups[0].Members)
+	assert.Nil(t, plan.Groups[1].State)
+	assert.Equal(t, 20.5, *plan.Groups[1].Temperature)
+
+	assert.Len(t, plan.Bulbs, 2)
+	b, w := plan.Bulbs[0], plan.Bulbs[1]
+	assert.True(t, *b.State)
+	assert.Equal(t, 50, *b.Level)
+	assert.Equal(t, 358, *b.Hue)
+	assert.Equal(t, 180, *b.Saturation)
+	assert.Nil(t, b.ColorTemperature)
+	assert.False(t, *w.State)
+	assert.Nil(t, w.Level)
+	assert.Nil(t, w.Hue)
+	assert.Equal(t, 2700, *w.ColorTemperature)
+}
+
+type fixedSchedules map[string]*fritz.HeatingSchedule
+
+// Schedule returns the configured schedule or an error.
+func (f fixedSchedules) Schedule(name string) (*fritz.HeatingSchedule, error) {
+	if s, ok := f[name]; ok {
+		return s, nil
+	}
+	return nil, errors.New("no schedule")
+}
+
+// TestLoadSchedules tests that only the schedules referenced by the target are loaded.
+func TestLoadSchedules(t *testing.T) {
+	schedule := &fritz.HeatingSchedule{Weekly: []fritz.SwitchPoint{{Days: []string{"daily"}, At: "22:00", Mode: fritz.SavingMode}}}
+	src := &Plan{Thermostats: []Thermostat{{Name: "a"}, {Name: "b"}}}
+	target := &Plan{Thermostats: []Thermostat{{Name: "a", Schedule: schedule}, {Name: "b"}}}
+	assert.NoError(t, LoadSchedules(fixedSchedules{"a": schedule}, src, target))
+	assert.Equal(t, schedule, src.Thermostats[0].Schedule)
+	assert.Nil(t, src.Thermostats[1].Schedule)
+
+	assert.Error(t, LoadSchedules(fixedSchedules{}, src, target))
+
+	src = &Plan{Thermostats: []Thermostat{{Name: "a", AIN: "1"}, {Name: "b", AIN: "2"}}}
+	target = &Plan{Thermostats: []Thermostat{{Name: "a", AIN: "2", Schedule: schedule}}}
+	assert.NoError(t, LoadSchedules(fixedSchedules{"b": schedule}, src, target))
+	assert.Nil(t, src.Thermostats[0].Schedule)
+	assert.Equal(t, schedule, src.Thermostats[1].Schedule, "renamed devices are matched by AIN")
 }
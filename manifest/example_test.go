@@ -2,6 +2,7 @@ package manifest_test
 
 import (
 	"net/url"
+	"time"
 
 	"github.com/bpicode/fritzctl/fritz"
 	"github.com/bpicode/fritzctl/manifest"
@@ -21,3 +22,24 @@ func ExampleNewApplier() {
 	})
 	// output:
 }
+
+// A Changeset lists its changes by stage.
+func ExampleChangeset_Print() {
+	cs, _ := manifest.NewChangeset(&manifest.Plan{
+		Switches: []manifest.Switch{{Name: "Switch1", State: false}, {Name: "Switch2", State: false}},
+	}, &manifest.Plan{
+		Switches: []manifest.Switch{
+			{Name: "Switch1", State: true},
+			{Name: "Switch2", State: true, Ordering: manifest.Ordering{DependsOn: []string{"Switch1"}}},
+		},
+		StageDelay: time.Minute,
+	}, nil)
+	cs.Print()
+	// output:
+	// The following actions would be applied by the manifest:
+	// Stage 0:
+	// 	'Switch1'	state	off	⟶	on
+	// Stage 1:
+	// 	(after a delay of 1m0s)
+	// 	'Switch2'	state	off	⟶	on
+}
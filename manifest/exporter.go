@@ -2,8 +2,6 @@ package manifest
 
 import (
 	"io"
-
-	"gopkg.in/yaml.v2"
 )
 
 // Exporter allows exporting of a Plan.
@@ -18,9 +16,14 @@ func ExporterTo(w io.Writer) *Exporter {
 	return &Exporter{writer: w}
 }
 
-// Export performs the export.
+// Export performs the export as YAML.
 func (e *Exporter) Export(plan *Plan) error {
-	return e.export(plan, yaml.Marshal)
+	return e.ExportAs(plan, YAML)
+}
+
+// ExportAs performs the export in the given format.
+func (e *Exporter) ExportAs(plan *Plan, f Format) error {
+	return e.export(plan, f.marshal)
 }
 
 func (e *Exporter) export(p *Plan, m marshaller) error {
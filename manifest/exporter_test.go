@@ -14,7 +14,7 @@ func TestExportToStdout(t *testing.T) {
 	assert.NotNil(t, exporter)
 	plan := Plan{
 		Switches:    []Switch{{Name: "s1", State: true}, {Name: "s2", State: false}},
-		Thermostats: []Thermostat{{Name: "t1", Temperature: 20.0}, {Name: "t2", Temperature: 22.0}},
+		Thermostats: []Thermostat{{Name: "t1", Temperature: floatPtr(20.0)}, {Name: "t2", Temperature: floatPtr(22.0)}},
 	}
 	err := exporter.Export(&plan)
 	assert.NoError(t, err)
@@ -0,0 +1,80 @@
+package manifest
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/bpicode/fritzctl/fritz"
+)
+
+// The kinds of manifest entries, named like the lists of a Plan.
+const (
+	SwitchesKind    = "switches"
+	ThermostatsKind = "thermostats"
+	GroupsKind      = "groups"
+	BulbsKind       = "bulbs"
+)
+
+// Filter selects the devices converted to a Plan, see Filter.Convert.
+type Filter struct {
+	Kinds       []string // The kinds of entries, see SwitchesKind etc., all if empty.
+	Names       string   // Glob pattern of the names, see path.Match, all if empty.
+	PresentOnly bool     // Only devices connected to the FRITZ!Box.
+}
+
+// Convert converts the devices of a fritz.Devicelist selected by the filter to a Plan.
+func (f Filter) Convert(l *fritz.Devicelist) (*Plan, error) {
+	if _, err := path.Match(f.Names, ""); err != nil {
+		return nil, fmt.Errorf("invalid name pattern '%s': %v", f.Names, err)
+	}
+	kinds := make(map[string]bool)
+	for _, k := range f.Kinds {
+		switch k = strings.ToLower(strings.TrimSpace(k)); k {
+		case SwitchesKind, ThermostatsKind, GroupsKind, BulbsKind:
+			kinds[k] = true
+		default:
+			return nil, fmt.Errorf("unknown kind '%s', expected one of %s, %s, %s, %s", k, SwitchesKind, ThermostatsKind, GroupsKind, BulbsKind)
+		}
+	}
+	var selected fritz.Devicelist
+	for _, d := range l.Devices {
+		if f.selects(d.Name, d.Present) {
+			selected.Devices = append(selected.Devices, d)
+		}
+	}
+	for _, g := range l.Groups {
+		if f.selects(g.Name, g.Present) {
+			selected.Groups = append(selected.Groups, g)
+		}
+	}
+	p := ConvertDevicelist(&selected)
+	if len(kinds) > 0 {
+		p.only(kinds)
+	}
+	return p, nil
+}
+
+func (f Filter) selects(name string, present int) bool {
+	if f.PresentOnly && present != 1 {
+		return false
+	}
+	matches, _ := path.Match(f.Names, name)
+	return f.Names == "" || matches
+}
+
+// only drops the entries whose kind is not selected.
+func (plan *Plan) only(kinds map[string]bool) {
+	if !kinds[SwitchesKind] {
+		plan.Switches = nil
+	}
+	if !kinds[ThermostatsKind] {
+		plan.Thermostats = nil
+	}
+	if !kinds[GroupsKind] {
+		plan.Groups = nil
+	}
+	if !kinds[BulbsKind] {
+		plan.Bulbs = nil
+	}
+}
@@ -0,0 +1,45 @@
+package manifest
+
+import (
+	"testing"
+
+	"github.com/bpicode/fritzctl/fritz"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFilterConvert tests the selection of devices by kind, name and presence.
+func TestFilterConvert(t *testing.T) {
+	l := &fritz.Devicelist{
+		Devices: []fritz.Device{
+			{Name: "Kitchen switch", Functionbitmask: "2944", Present: 1},
+			{Name: "Kitchen heating", Functionbitmask: "320", Present: 1},
+			{Name: "Kitchen plug", Functionbitmask: "2944", Present: 0},
+			{Name: "Bath heating", Functionbitmask: "320", Present: 1},
+		},
+		Groups: []fritz.Group{{Name: "Kitchen", Functionbitmask: "6784", Present: 1}},
+	}
+
+	p, err := Filter{}.Convert(l)
+	assert.NoError(t, err)
+	assert.Len(t, p.Switches, 2)
+	assert.Len(t, p.Thermostats, 2)
+	assert.Len(t, p.Groups, 1)
+
+	p, err = Filter{Names: "Kitchen*", PresentOnly: true}.Convert(l)
+	assert.NoError(t, err)
+	assert.Len(t, p.Switches, 1)
+	assert.Equal(t, "Kitchen switch", p.Switches[0].Name)
+	assert.Len(t, p.Thermostats, 1)
+	assert.Len(t, p.Groups, 1)
+
+	p, err = Filter{Kinds: []string{"thermostats", " Groups"}}.Convert(l)
+	assert.NoError(t, err)
+	assert.Empty(t, p.Switches)
+	assert.Len(t, p.Thermostats, 2)
+	assert.Len(t, p.Groups, 1)
+
+	_, err = Filter{Kinds: []string{"plugs"}}.Convert(l)
+	assert.Error(t, err)
+	_, err = Filter{Names: "[a"}.Convert(l)
+	assert.Error(t, err)
+}
@@ -0,0 +1,206 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+	yaml3 "gopkg.in/yaml.v3"
+)
+
+// Format is an encoding of manifests.
+type Format string
+
+// The supported formats of manifests. They are interchangeable: JSON and TOML manifests have the same structure as
+// YAML manifests, including includes and variables.
+const (
+	YAML Format = "yaml"
+	JSON Format = "json"
+	TOML Format = "toml"
+)
+
+// ParseFormat returns the Format with the given name.
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(strings.ToLower(name)); f {
+	case YAML, JSON, TOML:
+		return f, nil
+	case "yml":
+		return YAML, nil
+	default:
+		return "", fmt.Errorf("unknown format '%s', expected one of %s, %s, %s", name, YAML, JSON, TOML)
+	}
+}
+
+var tomlStatement = regexp.MustCompile(`^(\[\[?[^\]]+\]\]?|[A-Za-z0-9_."'-]+\s*=)`)
+
+// DetectFormat determines the format of a manifest by the extension of the file name, or by its content if the
+// extension is none of .yml, .yaml, .json and .toml.
+func DetectFormat(filename string, data []byte) Format {
+	if f, err := ParseFormat(strings.TrimPrefix(filepath.Ext(filename), ".")); err == nil {
+		return f
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "{") {
+			return JSON
+		}
+		if tomlStatement.MatchString(line) {
+			return TOML
+		}
+		break
+	}
+	return YAML
+}
+
+// toYAML converts a manifest in the given format to YAML. It returns the lines of the manifest by the lines of the
+// YAML, as far as they correspond.
+func toYAML(f Format, data []byte) ([]byte, map[int]int, error) {
+	var doc *yaml3.Node
+	switch f {
+	case JSON:
+		// JSON is YAML already. Re-encoding it in block style unquotes the references to variables, e.g.
+		// "${temperature}", so that they can stand for numbers.
+		var root yaml3.Node
+		if err := yaml3.Unmarshal(data, &root); err != nil {
+			return nil, nil, err
+		}
+		if len(root.Content) == 0 {
+			return nil, nil, nil
+		}
+		doc = root.Content[0]
+		unquoteReferences(doc)
+	case TOML:
+		var err error
+		if doc, err = decodeTOML(data); err != nil {
+			return nil, nil, err
+		}
+	default:
+		return data, nil, nil
+	}
+	converted, err := yaml3.Marshal(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	var root yaml3.Node
+	if err := yaml3.Unmarshal(converted, &root); err != nil {
+		return nil, nil, err
+	}
+	lines := make(map[int]int)
+	correspond(doc, root.Content[0], lines)
+	return converted, lines, nil
+}
+
+// unquoteReferences writes the collections of a decoded JSON document in block style, and the strings with references
+// to variables as plain scalars.
+func unquoteReferences(n *yaml3.Node) {
+	if n.Kind != yaml3.ScalarNode || variable.MatchString(n.Value) {
+		n.Style = 0
+	}
+	for _, c := range n.Content {
+		unquoteReferences(c)
+	}
+}
+
+// stringNode returns a string scalar, which is quoted unless it contains references to variables.
+func stringNode(s string, line int) *yaml3.Node {
+	n := &yaml3.Node{Kind: yaml3.ScalarNode, Tag: "!!str", Value: s, Line: line, Style: yaml3.DoubleQuotedStyle}
+	if variable.MatchString(s) {
+		n.Style = 0
+	}
+	return n
+}
+
+// scalarNode returns a scalar of another type than string, in the notation of the YAML decoder.
+func scalarNode(v interface{}, line int) *yaml3.Node {
+	bs, _ := yaml.Marshal(v)
+	return &yaml3.Node{Kind: yaml3.ScalarNode, Value: strings.TrimSpace(string(bs)), Line: line}
+}
+
+// correspond records the lines of the scalars of a document by the lines of the same scalars in its encoding. The
+// collections start at the line of their first scalar.
+func correspond(doc, encoded *yaml3.Node, lines map[int]int) {
+	if _, ok := lines[encoded.Line]; !ok && doc.Kind == yaml3.ScalarNode && doc.Line > 0 {
+		lines[encoded.Line] = doc.Line
+	}
+	for i := range doc.Content {
+		if i < len(encoded.Content) {
+			correspond(doc.Content[i], encoded.Content[i], lines)
+		}
+	}
+}
+
+// marshal encodes a value in the format. The JSON and TOML encodings are derived from the YAML encoding, so that all
+// formats have the same structure and field order.
+func (f Format) marshal(in interface{}) ([]byte, error) {
+	bs, err := yaml.Marshal(in)
+	if err != nil || f == YAML {
+		return bs, err
+	}
+	var doc yaml.MapSlice
+	if err := yaml.Unmarshal(bs, &doc); err != nil {
+		return nil, err
+	}
+	if f == TOML {
+		return encodeTOML(doc)
+	}
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, doc); err != nil {
+		return nil, err
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, buf.Bytes(), "", "  "); err != nil {
+		return nil, err
+	}
+	indented.WriteByte('\n')
+	return indented.Bytes(), nil
+}
+
+// writeJSON writes a generic YAML document as JSON, keeping the order of the keys.
+func writeJSON(buf *bytes.Buffer, v interface{}) error {
+	switch x := v.(type) {
+	case yaml.MapSlice:
+		buf.WriteByte('{')
+		for i, item := range x {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(quote(fmt.Sprint(item.Key)))
+			buf.WriteByte(':')
+			if err := writeJSON(buf, item.Value); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, e := range x {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSON(buf, e); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	default:
+		bs, err := json.Marshal(x)
+		if err != nil {
+			return err
+		}
+		buf.Write(bs)
+	}
+	return nil
+}
+
+// quote formats a JSON string.
+func quote(s string) string {
+	bs, _ := json.Marshal(s)
+	return string(bs)
+}
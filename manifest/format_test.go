@@ -0,0 +1,64 @@
+package manifest
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/bpicode/fritzctl/fritz"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseFormat tests the names of the formats.
+func TestParseFormat(t *testing.T) {
+	for name, expected := range map[string]Format{"yaml": YAML, "yml": YAML, "JSON": JSON, "toml": TOML} {
+		f, err := ParseFormat(name)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, f)
+	}
+	_, err := ParseFormat("xml")
+	assert.Error(t, err)
+}
+
+// TestDetectFormat tests the detection of the format by file name and content.
+func TestDetectFormat(t *testing.T) {
+	assert.Equal(t, JSON, DetectFormat("a.json", []byte("switches: []")))
+	assert.Equal(t, TOML, DetectFormat("a.TOML", nil))
+	assert.Equal(t, YAML, DetectFormat("a.yml", []byte("{}")))
+	assert.Equal(t, JSON, DetectFormat("", []byte("\n  {\"switches\": []}")))
+	assert.Equal(t, TOML, DetectFormat("a.txt", []byte("# comment\n[[switches]]\nname = \"a\"")))
+	assert.Equal(t, TOML, DetectFormat("", []byte("units = \"metric\"")))
+	assert.Equal(t, YAML, DetectFormat("", []byte("---\nswitches:\n  - name: a")))
+	assert.Equal(t, YAML, DetectFormat("", nil))
+}
+
+// TestFormatRoundTrip tests that plans exported in any format are parsed to the same plan.
+func TestFormatRoundTrip(t *testing.T) {
+	comfort, level, hue, sat := 21.5, 80, 120, 200
+	on, off := true, false
+	plan := &Plan{
+		Unmanaged:  WarnUnmanaged,
+		StageDelay: 30 * time.Second,
+		Switches:   []Switch{{Name: "Kitchen \"main\"", AIN: "12345 6789", State: true, Lock: &off}},
+		Thermostats: []Thermostat{{
+			Name:        "Bath",
+			Temperature: floatPtr(20),
+			Comfort:     &comfort,
+			Ordering:    Ordering{Stage: 1, DependsOn: []string{"Kitchen \"main\""}},
+			Schedule: &fritz.HeatingSchedule{
+				Weekly: []fritz.SwitchPoint{{Days: []string{"weekdays"}, At: "06:30", Mode: fritz.ComfortMode}, {Days: []string{"daily"}, At: "22:00", Mode: fritz.SavingMode}},
+				Summer: &fritz.SummerBreak{From: "06-01", To: "09-15"},
+			},
+		}},
+		Groups: []Group{{Name: "G1", State: &on}},
+		Bulbs:  []Bulb{{Name: "Bulb", Level: &level, Hue: &hue, Saturation: &sat}},
+	}
+	for _, f := range []Format{YAML, JSON, TOML} {
+		var buf bytes.Buffer
+		assert.NoError(t, ExporterTo(&buf).ExportAs(plan, f))
+		assert.Equal(t, f, DetectFormat("", buf.Bytes()), buf.String())
+		parsed, err := Parse(&buf)
+		assert.NoError(t, err, string(f))
+		assert.Equal(t, plan, parsed, string(f))
+	}
+}
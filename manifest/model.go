@@ -1,23 +1,90 @@
 package manifest
 
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bpicode/fritzctl/fritz"
+)
+
+// Policies for devices that are not part of a plan applied strictly, see Plan.Unmanaged.
+const (
+	FailUnmanaged = "fail" // Refuse to apply the plan.
+	WarnUnmanaged = "warn" // Warn about the devices, but leave them untouched.
+	OffUnmanaged  = "off"  // Turn the devices off.
+)
+
 // Plan represents the data model of an absolute state of the fritz smart home.
 type Plan struct {
-	Switches    []Switch     // The power switches.
-	Thermostats []Thermostat // The HKR devices.
+	Units       string        `json:"units,omitempty" yaml:"units,omitempty"`             // Unit system of the temperatures, "metric" (°C, default) or "imperial" (°F).
+	Unmanaged   string        `json:"unmanaged,omitempty" yaml:"unmanaged,omitempty"`     // Policy for devices missing in the plan when applied strictly, "fail" (default), "warn" or "off".
+	Switches    []Switch      `json:"switches" yaml:"switches"`                           // The power switches.
+	Thermostats []Thermostat  `json:"thermostats" yaml:"thermostats"`                     // The HKR devices.
+	Groups      []Group       `json:"groups,omitempty" yaml:"groups,omitempty"`           // The device groups, addressed by name.
+	Bulbs       []Bulb        `json:"bulbs,omitempty" yaml:"bulbs,omitempty"`             // The lights.
+	StageDelay  time.Duration `json:"stage_delay,omitempty" yaml:"stage_delay,omitempty"` // Pause before each stage but the first, see Ordering.
+	Rules       []Rule        `json:"rules,omitempty" yaml:"rules,omitempty"`             // Entries applied only if conditions on the live state hold, see Resolve.
+}
+
+// Ordering declares when the changes of an entry are applied. The entries are applied stage by stage, the entries of
+// one stage concurrently. An entry is applied in its stage, but at least one stage after the entries it depends on.
+type Ordering struct {
+	Stage     int      `json:"stage,omitempty" yaml:"stage,omitempty"`           // The stage, 0 by default.
+	DependsOn []string `json:"depends_on,omitempty" yaml:"depends_on,omitempty"` // Names of the entries applied before.
 }
 
 // Switch represents the state of a switch.
 type Switch struct {
-	Name  string // Name of the switch.
-	State bool   // On (true) or off (false).
+	Name       string `json:"name" yaml:"name"`                                 // Name of the switch.
+	AIN        string `json:"ain,omitempty" yaml:"ain,omitempty"`               // Identifier of the switch, preferred over the name if given.
+	State      bool   `json:"state" yaml:"state"`                               // On (true) or off (false).
+	Lock       *bool  `json:"lock,omitempty" yaml:"lock,omitempty"`             // Locked against changes via the FRITZ!Box, not changeable via the AHA interface.
+	DeviceLock *bool  `json:"devicelock,omitempty" yaml:"devicelock,omitempty"` // Operating elements locked, not changeable via the AHA interface.
+	Ordering   `yaml:",inline"`
 }
 
 // Thermostat represents the state of a HKR device.
+// codebeat:disable[TOO_MANY_IVARS]
 type Thermostat struct {
-	Name        string  // Name of the device.
-	Temperature float64 // The temperature in °C.
+	Name        string                 `json:"name" yaml:"name"`                                   // Name of the device.
+	AIN         string                 `json:"ain,omitempty" yaml:"ain,omitempty"`                 // Identifier of the device, preferred over the name if given.
+	Temperature *float64               `json:"temperature,omitempty" yaml:"temperature,omitempty"` // The goal temperature in °C, left untouched if nil.
+	Comfort     *float64               `json:"comfort,omitempty" yaml:"comfort,omitempty"`         // The comfort temperature in °C, left untouched if nil.
+	Saving      *float64               `json:"saving,omitempty" yaml:"saving,omitempty"`           // The saving temperature in °C, left untouched if nil.
+	Offset      *float64               `json:"offset,omitempty" yaml:"offset,omitempty"`           // The offset of the temperature sensor in °C, left untouched if nil.
+	Lock        *bool                  `json:"lock,omitempty" yaml:"lock,omitempty"`               // Locked against changes via the FRITZ!Box, left untouched if nil.
+	DeviceLock  *bool                  `json:"devicelock,omitempty" yaml:"devicelock,omitempty"`   // Operating elements locked, left untouched if nil.
+	Schedule    *fritz.HeatingSchedule `json:"schedule,omitempty" yaml:"schedule,omitempty"`       // The heating schedule, left untouched if nil.
+	Ordering    `yaml:",inline"`
+}
+
+// codebeat:enable[TOO_MANY_IVARS]
+
+// Group represents the state of a device group, addressed by the name of the group.
+type Group struct {
+	Name        string   `json:"name" yaml:"name"`                                   // Name of the group.
+	State       *bool    `json:"state,omitempty" yaml:"state,omitempty"`             // On (true) or off (false) for groups of switches, left untouched if nil.
+	Temperature *float64 `json:"temperature,omitempty" yaml:"temperature,omitempty"` // The temperature in °C for groups of thermostats, left untouched if nil.
+	Members     []string `json:"-" yaml:"-"`                                         // AINs of the member devices, only known for states read from the FRITZ!Box.
+	Ordering    `yaml:",inline"`
+}
+
+// Bulb represents the state of a light.
+// codebeat:disable[TOO_MANY_IVARS]
+type Bulb struct {
+	Name             string `json:"name" yaml:"name"`                                             // Name of the light.
+	AIN              string `json:"ain,omitempty" yaml:"ain,omitempty"`                           // Identifier of the light, preferred over the name if given.
+	State            *bool  `json:"state,omitempty" yaml:"state,omitempty"`                       // On (true) or off (false), left untouched if nil.
+	Level            *int   `json:"level,omitempty" yaml:"level,omitempty"`                       // Brightness in percent, left untouched if nil.
+	Hue              *int   `json:"hue,omitempty" yaml:"hue,omitempty"`                           // Hue in degrees, only together with saturation, left untouched if nil.
+	Saturation       *int   `json:"saturation,omitempty" yaml:"saturation,omitempty"`             // Saturation, 0-255, only together with hue, left untouched if nil.
+	ColorTemperature *int   `json:"colortemperature,omitempty" yaml:"colortemperature,omitempty"` // Color temperature in kelvin, left untouched if nil.
+	Ordering         `yaml:",inline"`
 }
 
+// codebeat:enable[TOO_MANY_IVARS]
+
 func (plan *Plan) switchNamed(name string) (sw Switch, ok bool) {
 	for _, s := range plan.Switches {
 		if name == s.Name {
@@ -48,8 +115,245 @@ func (plan *Plan) thermostatNamed(name string) (th Thermostat, ok bool) {
 }
 
 func (plan *Plan) temperatureOf(name string) (float64, bool) {
-	if th, ok := plan.thermostatNamed(name); ok {
-		return th.Temperature, true
+	if th, ok := plan.thermostatNamed(name); ok && th.Temperature != nil {
+		return *th.Temperature, true
 	}
 	return 0, false
 }
+
+// addresses reports whether the manifest entry with the given AIN and name refers to the device with the given AIN
+// and name. The AIN takes precedence, spaces in it are ignored.
+func addresses(ain, name, deviceAIN, deviceName string) bool {
+	if ain != "" {
+		return strings.Replace(ain, " ", "", -1) == strings.Replace(deviceAIN, " ", "", -1)
+	}
+	return name == deviceName
+}
+
+// switchFor looks up the switch the given manifest entry refers to.
+func (plan *Plan) switchFor(s Switch) (Switch, bool) {
+	for _, c := range plan.Switches {
+		if addresses(s.AIN, s.Name, c.AIN, c.Name) {
+			return c, true
+		}
+	}
+	return Switch{}, false
+}
+
+// thermostatFor looks up the thermostat the given manifest entry refers to.
+func (plan *Plan) thermostatFor(t Thermostat) (Thermostat, bool) {
+	for _, c := range plan.Thermostats {
+		if addresses(t.AIN, t.Name, c.AIN, c.Name) {
+			return c, true
+		}
+	}
+	return Thermostat{}, false
+}
+
+// bulbFor looks up the bulb the given manifest entry refers to.
+func (plan *Plan) bulbFor(b Bulb) (Bulb, bool) {
+	for _, c := range plan.Bulbs {
+		if addresses(b.AIN, b.Name, c.AIN, c.Name) {
+			return c, true
+		}
+	}
+	return Bulb{}, false
+}
+
+// manages reports whether any of the entries of the plan refers to the given device.
+func (plan *Plan) manages(ain, name string) bool {
+	for _, s := range plan.Switches {
+		if addresses(s.AIN, s.Name, ain, name) {
+			return true
+		}
+	}
+	for _, t := range plan.Thermostats {
+		if addresses(t.AIN, t.Name, ain, name) {
+			return true
+		}
+	}
+	for _, b := range plan.Bulbs {
+		if addresses(b.AIN, b.Name, ain, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (plan *Plan) groupNamed(name string) (Group, bool) {
+	for _, g := range plan.Groups {
+		if name == g.Name {
+			return g, true
+		}
+	}
+	return Group{}, false
+}
+
+// settingsChange returns the settings of the thermostat that are given in after and differ from before.
+func settingsChange(before, after Thermostat) (fritz.ThermostatSettings, bool) {
+	var s fritz.ThermostatSettings
+	changed := false
+	floats := []struct {
+		before, after *float64
+		target        **float64
+	}{{before.Comfort, after.Comfort, &s.Comfort}, {before.Saving, after.Saving, &s.Saving}, {before.Offset, after.Offset, &s.Offset}}
+	for _, f := range floats {
+		if f.after != nil && (f.before == nil || *f.before != *f.after) {
+			*f.target = f.after
+			changed = true
+		}
+	}
+	bools := []struct {
+		before, after *bool
+		target        **bool
+	}{{before.Lock, after.Lock, &s.Lock}, {before.DeviceLock, after.DeviceLock, &s.DeviceLock}}
+	for _, b := range bools {
+		if b.after != nil && (b.before == nil || *b.before != *b.after) {
+			*b.target = b.after
+			changed = true
+		}
+	}
+	return s, changed
+}
+
+// settingsBefore returns the settings of before that are set in s.
+func settingsBefore(before Thermostat, s fritz.ThermostatSettings) fritz.ThermostatSettings {
+	var b fritz.ThermostatSettings
+	if s.Comfort != nil {
+		b.Comfort = before.Comfort
+	}
+	if s.Saving != nil {
+		b.Saving = before.Saving
+	}
+	if s.Offset != nil {
+		b.Offset = before.Offset
+	}
+	if s.Lock != nil {
+		b.Lock = before.Lock
+	}
+	if s.DeviceLock != nil {
+		b.DeviceLock = before.DeviceLock
+	}
+	return b
+}
+
+// lightChange returns the settings of the bulb that are given in after and differ from before. Hue and saturation are
+// changed together.
+func lightChange(before, after Bulb) (fritz.LightSettings, bool) {
+	var s fritz.LightSettings
+	changed := false
+	if after.State != nil && (before.State == nil || *before.State != *after.State) {
+		s.State = after.State
+		changed = true
+	}
+	ints := []struct {
+		before, after *int
+		target        **int
+	}{{before.Level, after.Level, &s.Level}, {before.ColorTemperature, after.ColorTemperature, &s.ColorTemperature}}
+	for _, i := range ints {
+		if intChanged(i.before, i.after) {
+			*i.target = i.after
+			changed = true
+		}
+	}
+	if intChanged(before.Hue, after.Hue) || intChanged(before.Saturation, after.Saturation) {
+		s.Hue, s.Saturation = after.Hue, after.Saturation
+		changed = true
+	}
+	return s, changed
+}
+
+func intChanged(before, after *int) bool {
+	return after != nil && (before == nil || *before != *after)
+}
+
+// lightBefore returns the settings of before that are set in s. If s changes the color, the color of before is
+// returned in either color mode.
+func lightBefore(before Bulb, s fritz.LightSettings) fritz.LightSettings {
+	var b fritz.LightSettings
+	if s.State != nil {
+		b.State = before.State
+	}
+	if s.Level != nil {
+		b.Level = before.Level
+	}
+	if s.Hue != nil || s.Saturation != nil || s.ColorTemperature != nil {
+		b.Hue, b.Saturation, b.ColorTemperature = before.Hue, before.Saturation, before.ColorTemperature
+	}
+	return b
+}
+
+// WithoutAINs removes the AINs of all devices, which are then addressed by name.
+func (plan *Plan) WithoutAINs() {
+	for i := range plan.Switches {
+		plan.Switches[i].AIN = ""
+	}
+	for i := range plan.Thermostats {
+		plan.Thermostats[i].AIN = ""
+	}
+	for i := range plan.Bulbs {
+		plan.Bulbs[i].AIN = ""
+	}
+}
+
+// stages resolves the stage of every entry by its name, see Ordering.
+func (plan *Plan) stages() (map[string]int, error) {
+	var names []string
+	declared := make(map[string]Ordering)
+	add := func(name string, o Ordering) {
+		names = append(names, name)
+		declared[name] = o
+	}
+	for _, s := range plan.Switches {
+		add(s.Name, s.Ordering)
+	}
+	for _, t := range plan.Thermostats {
+		add(t.Name, t.Ordering)
+	}
+	for _, g := range plan.Groups {
+		add(g.Name, g.Ordering)
+	}
+	for _, b := range plan.Bulbs {
+		add(b.Name, b.Ordering)
+	}
+	r := stageResolver{declared: declared, resolved: make(map[string]int)}
+	for _, n := range names {
+		if _, err := r.resolve(n, nil); err != nil {
+			return nil, err
+		}
+	}
+	return r.resolved, nil
+}
+
+type stageResolver struct {
+	declared map[string]Ordering
+	resolved map[string]int
+}
+
+func (r *stageResolver) resolve(name string, path []string) (int, error) {
+	if s, ok := r.resolved[name]; ok {
+		return s, nil
+	}
+	path = append(path, name)
+	for _, p := range path[:len(path)-1] {
+		if p == name {
+			return 0, fmt.Errorf("dependency cycle: %s", strings.Join(path, " -> "))
+		}
+	}
+	o := r.declared[name]
+	stage := o.Stage
+	for _, d := range o.DependsOn {
+		if _, ok := r.declared[d]; !ok {
+			return 0, fmt.Errorf("'%s' depends on '%s', which is not in the manifest", name, d)
+		}
+		s, err := r.resolve(d, path)
+		if err != nil {
+			return 0, err
+		}
+		if s+1 > stage {
+			stage = s + 1
+		}
+	}
+	r.resolved[name] = stage
+	return stage, nil
+}
@@ -3,6 +3,8 @@ package manifest
 import (
 	"testing"
 
+	"github.com/bpicode/fritzctl/fritz"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -32,3 +34,46 @@ func TestSwitchStateOfOf(t *testing.T) {
 	_, ok = plan.switchStateOf("DoesNotExist")
 	assert.Equal(t, false, ok)
 }
+
+// TestSettingsChange tests that only given and differing settings are changed.
+func TestSettingsChange(t *testing.T) {
+	c21, c22, yes, no := 21.0, 22.0, true, false
+	before := Thermostat{Comfort: &c21, Saving: &c21, Lock: &no}
+
+	_, ok := settingsChange(before, Thermostat{})
+	assert.False(t, ok)
+	_, ok = settingsChange(before, Thermostat{Comfort: &c21, Lock: &no})
+	assert.False(t, ok)
+
+	s, ok := settingsChange(before, Thermostat{Comfort: &c22, Saving: &c21, Offset: &c21, Lock: &yes, DeviceLock: &yes})
+	assert.True(t, ok)
+	assert.Equal(t, fritz.ThermostatSettings{Comfort: &c22, Offset: &c21, Lock: &yes, DeviceLock: &yes}, s)
+}
+
+// TestStages tests the resolution of stages and dependencies.
+func TestStages(t *testing.T) {
+	plan := &Plan{
+		Switches: []Switch{
+			{Name: "pump", State: true, Ordering: Ordering{DependsOn: []string{"heating"}}},
+			{Name: "light", State: true},
+			{Name: "fan", State: true, Ordering: Ordering{Stage: 5}},
+		},
+		Thermostats: []Thermostat{{Name: "heating", Temperature: floatPtr(21), Ordering: Ordering{Stage: 2}}},
+		Groups:      []Group{{Name: "all", Ordering: Ordering{Stage: 1, DependsOn: []string{"pump", "light"}}}},
+	}
+	stages, err := plan.stages()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"pump": 3, "light": 0, "fan": 5, "heating": 2, "all": 4}, stages)
+}
+
+// TestStagesErrors tests that dependency cycles and unknown dependencies are detected.
+func TestStagesErrors(t *testing.T) {
+	_, err := (&Plan{Switches: []Switch{
+		{Name: "a", Ordering: Ordering{DependsOn: []string{"b"}}},
+		{Name: "b", Ordering: Ordering{DependsOn: []string{"a"}}},
+	}}).stages()
+	assert.EqualError(t, err, "dependency cycle: a -> b -> a")
+
+	_, err = (&Plan{Bulbs: []Bulb{{Name: "a", Ordering: Ordering{DependsOn: []string{"x"}}}}}).stages()
+	assert.EqualError(t, err, "'a' depends on 'x', which is not in the manifest")
+}
@@ -3,28 +3,89 @@ package manifest
 import (
 	"io"
 	"io/ioutil"
-	"os"
 
-	"gopkg.in/yaml.v2"
+	"github.com/bpicode/fritzctl/internal/units"
 )
 
-// ParseFile opens a file and marshals the contents as a Plan, returning a pointer it.
-func ParseFile(filename string) (*Plan, error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, err
+// ParseFile reads a manifest file and the given overlays and marshals the contents as a Plan, returning a pointer it.
+// The entries of an overlay replace the ones for the same device, overlays given later take precedence. Files
+// included by the manifest or an overlay are resolved relative to it. Errors report the file and line.
+func ParseFile(filename string, overlays ...string) (*Plan, error) {
+	c := newComposer()
+	for _, f := range append([]string{filename}, overlays...) {
+		if err := c.addFile("", f); err != nil {
+			return nil, err
+		}
 	}
-	defer file.Close()
-	return Parse(file)
+	return c.plan()
 }
 
-// Parse takes an io.Reader and marshals the contents as a Plan, returning a pointer it.
+// Parse takes an io.Reader and marshals the contents as a Plan, returning a pointer it. Files included by the manifest
+// are resolved relative to the working directory. Variables ${name} are replaced by the values given in 'vars' or by
+// environment variables, and temperatures given in °F are converted to °C.
 func Parse(r io.Reader) (*Plan, error) {
-	bytes, err := ioutil.ReadAll(r)
+	data, err := ioutil.ReadAll(r)
 	if err != nil {
 		return nil, err
 	}
-	var state Plan
-	err = yaml.Unmarshal(bytes, &state)
-	return &state, err
+	c := newComposer()
+	if err := c.add(source{dir: ".", data: data}); err != nil {
+		return &Plan{}, err
+	}
+	return c.plan()
+}
+
+// toCelsius converts the temperatures of a plan to °C if they are given in another unit system and rounds them to the
+// resolution of the FRITZ!Box, with a warning if that changes them. The special temperatures 126.5 (off) and 127 (on)
+// are kept.
+func (plan *Plan) toCelsius() error {
+	u, err := units.Parse(plan.Units)
+	if err != nil {
+		return err
+	}
+	for i := range plan.Thermostats {
+		t := &plan.Thermostats[i]
+		if t.Temperature != nil && *t.Temperature != 126.5 && *t.Temperature != 127 {
+			t.Temperature = convertPtr(t.Temperature, u.ToBox)
+		}
+		t.Comfort = convertPtr(t.Comfort, u.ToBox)
+		t.Saving = convertPtr(t.Saving, u.ToBox)
+		t.Offset = convertPtr(t.Offset, u.DeltaToBox)
+	}
+	for i := range plan.Groups {
+		g := &plan.Groups[i]
+		if g.Temperature != nil && *g.Temperature != 126.5 && *g.Temperature != 127 {
+			g.Temperature = convertPtr(g.Temperature, u.ToBox)
+		}
+	}
+	for i := range plan.Rules {
+		if err := plan.Rules[i].toCelsius(u, plan.Units); err != nil {
+			return err
+		}
+	}
+	plan.Units = ""
+	return nil
+}
+
+// toCelsius converts the temperatures of the conditions exactly and the ones of the entries like the ones of a plan.
+// The entries are given in the unit system of the manifest, unless they declare their own.
+func (r *Rule) toCelsius(u units.System, system string) error {
+	for _, c := range r.When {
+		if c.Temperature != nil {
+			c.Temperature.Above = convertPtr(c.Temperature.Above, u.ToCelsius)
+			c.Temperature.Below = convertPtr(c.Temperature.Below, u.ToCelsius)
+		}
+	}
+	if r.Then.Units == "" {
+		r.Then.Units = system
+	}
+	return r.Then.toCelsius()
+}
+
+func convertPtr(v *float64, conv func(float64) float64) *float64 {
+	if v == nil {
+		return nil
+	}
+	c := conv(*v)
+	return &c
 }
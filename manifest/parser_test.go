@@ -2,7 +2,10 @@ package manifest
 
 import (
 	"errors"
+	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -21,7 +24,7 @@ func TestParseAllOff(t *testing.T) {
 
 	assert.Len(t, plan.Thermostats, 1)
 	assert.Equal(t, "ThermoOne", plan.Thermostats[0].Name)
-	assert.Equal(t, float64(15), plan.Thermostats[0].Temperature)
+	assert.Equal(t, float64(15), *plan.Thermostats[0].Temperature)
 }
 
 // TestParseAllOn test the correct parsing of an example plan file.
@@ -58,3 +61,180 @@ func TestParseNotReadable(t *testing.T) {
 	_, err := Parse(&errReader{})
 	assert.Error(t, err)
 }
+
+// TestParseThermostatSettings test the parsing of thermostat settings and a heating schedule.
+func TestParseThermostatSettings(t *testing.T) {
+	plan, err := ParseFile("../testdata/thermostat_manifest.yml")
+	assert.NoError(t, err)
+	assert.Len(t, plan.Thermostats, 1)
+	th := plan.Thermostats[0]
+	assert.Equal(t, 21.5, *th.Comfort)
+	assert.Equal(t, 16.0, *th.Saving)
+	assert.Equal(t, -0.5, *th.Offset)
+	assert.True(t, *th.Lock)
+	assert.False(t, *th.DeviceLock)
+	s := th.Schedule
+	assert.NotNil(t, s)
+	assert.Len(t, s.Weekly, 3)
+	assert.Equal(t, "06:30", s.Weekly[0].At)
+	assert.Equal(t, []string{"weekdays"}, s.Weekly[0].Days)
+	assert.Empty(t, s.Holidays)
+	assert.Equal(t, "09-15", s.Summer.To)
+	assert.NoError(t, s.Validate())
+}
+
+// TestParseImperial tests the conversion of temperatures given in °F.
+func TestParseImperial(t *testing.T) {
+	plan, err := ParseFile("../testdata/imperial_manifest.yml")
+	assert.NoError(t, err)
+	assert.Empty(t, plan.Units)
+	assert.Len(t, plan.Thermostats, 2)
+	hkr := plan.Thermostats[0]
+	assert.Equal(t, 21.0, *hkr.Temperature)
+	assert.Equal(t, 22.0, *hkr.Comfort)
+	assert.Equal(t, 16.0, *hkr.Saving)
+	assert.Equal(t, -0.5, *hkr.Offset)
+	assert.Equal(t, 126.5, *plan.Thermostats[1].Temperature, "special values are kept")
+}
+
+// TestParseMetricRounding tests that metric temperatures are rounded to the resolution of the FRITZ!Box.
+func TestParseMetricRounding(t *testing.T) {
+	plan, err := Parse(strings.NewReader("thermostats: [{name: HKR_1, temperature: 21.3, comfort: 20.8, offset: -0.4}]"))
+	assert.NoError(t, err)
+	assert.Equal(t, 21.5, *plan.Thermostats[0].Temperature)
+	assert.Equal(t, 21.0, *plan.Thermostats[0].Comfort)
+	assert.Equal(t, -0.5, *plan.Thermostats[0].Offset)
+}
+
+// TestParseUnknownUnits tests that unknown unit systems are rejected.
+func TestParseUnknownUnits(t *testing.T) {
+	_, err := Parse(strings.NewReader("units: kelvin"))
+	assert.Error(t, err)
+}
+
+// TestParseCompose tests includes, variables and overlays.
+func TestParseCompose(t *testing.T) {
+	os.Setenv("FRITZCTL_TEST_SWITCH_2", "true")
+	defer os.Unsetenv("FRITZCTL_TEST_SWITCH_2")
+
+	plan, err := ParseFile("../testdata/compose/base.yml")
+	assert.NoError(t, err)
+	assert.Equal(t, []Switch{{Name: "SWITCH_1", State: true}, {Name: "SWITCH_2", State: true}}, plan.Switches, "entries of the including file take precedence")
+	assert.Equal(t, []Thermostat{{Name: "HKR_1", Temperature: floatPtr(21)}}, plan.Thermostats)
+
+	plan, err = ParseFile("../testdata/compose/base.yml", "../testdata/compose/winter.yml")
+	assert.NoError(t, err)
+	assert.Equal(t, []Thermostat{{Name: "HKR_1", Temperature: floatPtr(23)}, {Name: "HKR_2", Temperature: floatPtr(23)}}, plan.Thermostats, "variables of overlays take precedence")
+
+	plan, err = Parse(strings.NewReader("include: [../testdata/compose/shared.yml]\nvars: {heating: '19.5'}"))
+	assert.NoError(t, err)
+	assert.Equal(t, 19.5, *plan.Thermostats[0].Temperature, "includes are relative to the working directory")
+}
+
+// TestParseVariableValues tests that the values of variables are substituted as values, whatever their content.
+func TestParseVariableValues(t *testing.T) {
+	plan, err := Parse(strings.NewReader(`vars:
+  hash: "Lamp # 1"
+  colon: "Lamp: kitchen"
+  quotes: He said "it's on"
+  on: "on"
+  one: "1"
+  heating: "20.5"
+switches:
+  # ${undefined} is not substituted in comments
+  - name: ${hash} # neither is ${undefined}
+    state: ${on}
+  - name: ${colon}
+  - name: "${quotes}"
+  - name: '${quotes}, too'
+  - name: Plug ${hash}
+  - name: ${one}
+thermostats:
+  - name: ${on}
+    temperature: ${heating}
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, []Switch{
+		{Name: "Lamp # 1", State: true},
+		{Name: "Lamp: kitchen"},
+		{Name: `He said "it's on"`},
+		{Name: `He said "it's on", too`},
+		{Name: "Plug Lamp # 1"},
+		{Name: "1"},
+	}, plan.Switches)
+	assert.Equal(t, []Thermostat{{Name: "on", Temperature: floatPtr(20.5)}}, plan.Thermostats)
+
+	_, err = Parse(strings.NewReader("vars: {text: warm}\nthermostats:\n  - name: HKR_1\n    temperature: \"${text}\""))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "line 4")
+
+	_, err = Parse(strings.NewReader("vars: {name: Lamp}\nswitches:\n  - name: |\n      ${name}\n"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "line 3: variables are only supported")
+}
+
+// TestParseComposeErrors tests that errors report the file and line.
+func TestParseComposeErrors(t *testing.T) {
+	for file, msg := range map[string]string{
+		"../testdata/compose/undefined.yml": "../testdata/compose/undefined.yml:5: undefined variable 'undefined'",
+		"../testdata/compose/invalid.yml":   "../testdata/compose/invalid.yml:5: cannot unmarshal",
+		"../testdata/compose/cycle.yml":     "include cycle",
+		"../testdata/compose/base.yml":      "../testdata/compose/base.yml:11: undefined variable 'FRITZCTL_TEST_SWITCH_2'",
+	} {
+		_, err := ParseFile(file)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), msg)
+	}
+	_, err := Parse(strings.NewReader("include: [does_not_exist.yml]"))
+	assert.Error(t, err)
+	_, err = Parse(strings.NewReader("switches:\n  - name: [\n"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "line ")
+}
+
+// TestParseStages tests parsing a staged manifest and rejecting dependency cycles.
+func TestParseStages(t *testing.T) {
+	plan, err := ParseFile("../testdata/staged_manifest.yml")
+	assert.NoError(t, err)
+	assert.Equal(t, 10*time.Millisecond, plan.StageDelay)
+	assert.Equal(t, []string{"HKR_1"}, plan.Switches[0].DependsOn)
+	assert.Equal(t, 2, plan.Switches[1].Stage)
+
+	_, err = ParseFile("../testdata/invalid/cycle_manifest.yml")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "dependency cycle: SWITCH_1 -> SWITCH_2 -> SWITCH_1")
+}
+
+// TestParseFormats tests parsing manifests in TOML and JSON, with includes and variables across formats, and that
+// errors refer to their lines.
+func TestParseFormats(t *testing.T) {
+	plan, err := ParseFile("../testdata/formats/manifest.toml")
+	assert.NoError(t, err)
+	assert.Equal(t, []Switch{{Name: "SWITCH_1", State: true}}, plan.Switches)
+	assert.Equal(t, []Thermostat{{Name: "HKR_1", Temperature: floatPtr(21.5)}}, plan.Thermostats)
+
+	f, err := os.Open("../testdata/formats/switches.json")
+	assert.NoError(t, err)
+	defer f.Close()
+	plan, err = Parse(f)
+	assert.NoError(t, err)
+	assert.Len(t, plan.Switches, 1)
+
+	_, err = ParseFile("../testdata/formats/typo.toml")
+	assert.EqualError(t, err, "invalid manifest:\n../testdata/formats/typo.toml:3: field stat not found in type manifest.Switch")
+	_, err = Parse(strings.NewReader("[[switches]]\nname = \"a\"\nstate = maybe"))
+	assert.EqualError(t, err, "line 3: no value can start with m")
+	_, err = Parse(strings.NewReader("{\n  \"switches\": [\n    {\"name\": \"on\", \"state\": \"yes\"}\n  ]\n}"))
+	assert.EqualError(t, err, "invalid manifest:\nline 3: cannot unmarshal !!str `yes` into bool")
+}
+
+// TestParseRules tests parsing rules, whose temperatures are converted like the ones of the manifest.
+func TestParseRules(t *testing.T) {
+	plan, err := ParseFile("../testdata/rules_manifest.yml")
+	assert.NoError(t, err)
+	assert.Len(t, plan.Rules, 3)
+	assert.Equal(t, 126.5, *plan.Rules[0].Then.Thermostats[0].Temperature)
+	assert.Empty(t, plan.Rules[0].Then.Units)
+	assert.Equal(t, 25.0, *plan.Rules[1].When[1].Temperature.Below)
+	assert.Equal(t, 5.0, *plan.Rules[1].When[0].Power.Above)
+}
@@ -2,6 +2,11 @@ package manifest
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bpicode/fritzctl/fritz"
+	"github.com/bpicode/fritzctl/logger"
 )
 
 // Planner represents an execution planner, returning actions to transition from a src to a target state.
@@ -11,24 +16,152 @@ type Planner interface {
 
 // Action is one operation on the home automation system.
 type Action interface {
+	Change() Change
 	Perform(a aha) error
+	Revert(a aha) error
+}
+
+// Attributes of devices that are changed by Actions.
+const (
+	StateAttribute       = "state"       // The state of a switch or a group of switches, "on" or "off".
+	TemperatureAttribute = "temperature" // The goal temperature of a thermostat or a group of thermostats in °C.
+	SettingsAttribute    = "settings"    // The comfort and saving temperature, offset and locks of a thermostat.
+	ScheduleAttribute    = "schedule"    // The heating schedule of a thermostat.
+	LightAttribute       = "light"       // The state, brightness and color of a bulb.
+	NameAttribute        = "name"        // The name of a device addressed by its AIN.
+)
+
+// Reasons for Changes.
+const (
+	ManifestReason  = "manifest"  // The device is declared in the manifest.
+	UnmanagedReason = "unmanaged" // The device is missing in the manifest, which turns unmanaged devices off.
+)
+
+// Change describes what an Action does, values are formatted like in the String methods of the attribute's type.
+type Change struct {
+	Device    string `json:"device" yaml:"device"`       // Name of the device.
+	Attribute string `json:"attribute" yaml:"attribute"` // The attribute that is changed, e.g. "temperature".
+	Before    string `json:"before" yaml:"before"`       // The value before the change.
+	After     string `json:"after" yaml:"after"`         // The value after the change.
+	Reason    string `json:"reason" yaml:"reason"`       // Why the device is changed, "manifest" or "unmanaged".
+	Stage     int    `json:"stage" yaml:"stage"`         // The stage in which the change is applied, see Ordering.
+}
+
+// String formats the change in one line.
+func (c Change) String() string {
+	unit := ""
+	if c.Attribute == TemperatureAttribute {
+		unit = "°C"
+	}
+	return fmt.Sprintf("'%s'\t%s\t%s%s\t⟶\t%s%s", c.Device, c.Attribute, c.Before, unit, c.After, unit)
+}
+
+type action struct {
+	change  Change
+	perform func(f aha) error
+	revert  func(f aha) error
+}
+
+// Change describes the action.
+func (a *action) Change() Change {
+	return a.change
+}
+
+// Perform executes the action.
+func (a *action) Perform(f aha) error {
+	return a.perform(f)
+}
+
+// Revert undoes the action, restoring the state before it was performed.
+func (a *action) Revert(f aha) error {
+	return a.revert(f)
+}
+
+// switchTo turns a device on or off.
+func switchTo(state bool, name string) func(f aha) error {
+	return func(f aha) error {
+		if state {
+			return f.On(name)
+		}
+		return f.Off(name)
+	}
+}
+
+// rename changes the name of a device, which requires f to be a fritz.Renamer.
+func rename(name, newName string) func(f aha) error {
+	return func(f aha) error {
+		r, ok := f.(fritz.Renamer)
+		if !ok {
+			return unsupported(f, "renaming devices")
+		}
+		return r.Rename(name, newName)
+	}
+}
+
+// configure changes the settings of a thermostat, which requires f to be a fritz.ThermostatConfigurer.
+func configure(s fritz.ThermostatSettings, name string) func(f aha) error {
+	return func(f aha) error {
+		c, ok := f.(fritz.ThermostatConfigurer)
+		if !ok {
+			return unsupported(f, "configuring thermostats")
+		}
+		return c.Configure(s, name)
+	}
+}
+
+// setSchedule changes the heating schedule of a thermostat, which requires f to be a fritz.ThermostatConfigurer.
+func setSchedule(s *fritz.HeatingSchedule, name string) func(f aha) error {
+	return func(f aha) error {
+		c, ok := f.(fritz.ThermostatConfigurer)
+		if !ok {
+			return unsupported(f, "changing heating schedules")
+		}
+		return c.SetSchedule(name, s)
+	}
+}
+
+// light changes a bulb, which requires f to be a fritz.LightController.
+func light(s fritz.LightSettings, name string) func(f aha) error {
+	return func(f aha) error {
+		l, ok := f.(fritz.LightController)
+		if !ok {
+			return unsupported(f, "controlling lights")
+		}
+		return l.Light(s, name)
+	}
+}
+
+func unsupported(f aha, what string) error {
+	return fmt.Errorf("%T does not support %s", f, what)
+}
+
+// irreversible is the revert function of actions for which the state before is unknown.
+func irreversible(c Change) func(f aha) error {
+	return func(aha) error {
+		return fmt.Errorf("the %s of '%s' before the change is unknown", c.Attribute, c.Device)
+	}
+}
+
+// staged assigns the actions to a stage.
+func staged(actions []Action, stage int) []Action {
+	for _, a := range actions {
+		if ac, ok := a.(*action); ok {
+			ac.change.Stage = stage
+		}
+	}
+	return actions
 }
 
 // TargetBasedPlanner creates a Planner that only focuses on target state. Devices in the source state that are not
 // referenced in the target will be left untouched.
-func TargetBasedPlanner(scf switchCommandFactory, tcf thermostatCommandFactory) Planner {
-	return &targetBasedPlanner{switchCommandFactory: scf, thermostatCommandFactory: tcf}
+func TargetBasedPlanner() Planner {
+	return &targetBasedPlanner{}
 }
 
 type targetBasedPlanner struct {
-	switchCommandFactory     switchCommandFactory
-	thermostatCommandFactory thermostatCommandFactory
+	updateNames bool
 }
 
-type switchCommandFactory func(before, after Switch) Action
-
-type thermostatCommandFactory func(before, after Thermostat) Action
-
 // Plan creates an execution plan (a slice of Actions) which shall be applied in oder to reach the target state.
 func (d *targetBasedPlanner) Plan(src, target *Plan) ([]Action, error) {
 	var actions []Action
@@ -43,31 +176,356 @@ func (d *targetBasedPlanner) Plan(src, target *Plan) ([]Action, error) {
 		return []Action{}, err
 	}
 	actions = append(actions, thermostatActions...)
+
+	groupActions, err := d.PlanGroups(src, target)
+	if err != nil {
+		return []Action{}, err
+	}
+	actions = append(actions, groupActions...)
+
+	bulbActions, err := d.PlanBulbs(src, target)
+	if err != nil {
+		return []Action{}, err
+	}
+	actions = append(actions, bulbActions...)
 	return actions, nil
 }
 
 // PlanSwitches creates a partial execution plan (a slice of Actions) which shall be applied to the switches.
 func (d *targetBasedPlanner) PlanSwitches(src, target *Plan) ([]Action, error) {
-	var switchActions []Action
+	stages, err := target.stages()
+	if err != nil {
+		return []Action{}, err
+	}
+	var actions []Action
 	for _, t := range target.Switches {
-		before, ok := src.switchNamed(t.Name)
+		before, ok := src.switchFor(t)
 		if !ok {
-			return []Action{}, fmt.Errorf("unable to find device (switch): '%s'", t.Name)
+			return []Action{}, fmt.Errorf("unable to find device (switch): %s", describe(t.AIN, t.Name))
 		}
-		switchActions = append(switchActions, d.switchCommandFactory(before, t))
+		warnLockChange(before, t)
+		actions = append(actions, staged(switchActions(before, t, ManifestReason), stages[t.Name])...)
+		actions = append(actions, staged(d.renameActions(t.AIN, before.Name, t.Name), stages[t.Name])...)
 	}
-	return switchActions, nil
+	return actions, nil
 }
 
 // PlanThermostats creates a partial execution plan (a slice of Actions) which shall be applied to the thermostats.
 func (d *targetBasedPlanner) PlanThermostats(src, target *Plan) ([]Action, error) {
-	var switchActions []Action
+	stages, err := target.stages()
+	if err != nil {
+		return []Action{}, err
+	}
+	var actions []Action
 	for _, t := range target.Thermostats {
-		before, ok := src.thermostatNamed(t.Name)
+		before, ok := src.thermostatFor(t)
+		if !ok {
+			return []Action{}, fmt.Errorf("unable to find device (thermostat): %s", describe(t.AIN, t.Name))
+		}
+		actions = append(actions, staged(thermostatActions(before, t, ManifestReason), stages[t.Name])...)
+		actions = append(actions, staged(d.renameActions(t.AIN, before.Name, t.Name), stages[t.Name])...)
+	}
+	return actions, nil
+}
+
+// PlanGroups creates a partial execution plan (a slice of Actions) which shall be applied to the device groups.
+func (d *targetBasedPlanner) PlanGroups(src, target *Plan) ([]Action, error) {
+	stages, err := target.stages()
+	if err != nil {
+		return []Action{}, err
+	}
+	var actions []Action
+	for _, t := range target.Groups {
+		before, ok := src.groupNamed(t.Name)
+		if !ok {
+			return []Action{}, fmt.Errorf("unable to find device (group): '%s'", t.Name)
+		}
+		actions = append(actions, staged(groupActions(before, t, ManifestReason), stages[t.Name])...)
+	}
+	return actions, nil
+}
+
+// PlanBulbs creates a partial execution plan (a slice of Actions) which shall be applied to the lights.
+func (d *targetBasedPlanner) PlanBulbs(src, target *Plan) ([]Action, error) {
+	stages, err := target.stages()
+	if err != nil {
+		return []Action{}, err
+	}
+	var actions []Action
+	for _, t := range target.Bulbs {
+		before, ok := src.bulbFor(t)
 		if !ok {
-			return []Action{}, fmt.Errorf("unable to find device (thermostat): '%s'", t.Name)
+			return []Action{}, fmt.Errorf("unable to find device (bulb): %s", describe(t.AIN, t.Name))
+		}
+		a, err := bulbActions(before, t, ManifestReason)
+		if err != nil {
+			return []Action{}, err
+		}
+		actions = append(actions, staged(a, stages[t.Name])...)
+		actions = append(actions, staged(d.renameActions(t.AIN, before.Name, t.Name), stages[t.Name])...)
+	}
+	return actions, nil
+}
+
+// renameActions handles devices addressed by their AIN whose name differs from the one in the manifest, i.e. that have
+// been renamed on the FRITZ!Box. The drift is reported, and only if names are updated the device is renamed. Renaming
+// comes last, the other actions address the device by its current name.
+func (d *targetBasedPlanner) renameActions(ain, before, after string) []Action {
+	if ain == "" || before == after {
+		return nil
+	}
+	if !d.updateNames {
+		logger.Warn(fmt.Sprintf("Device with AIN '%s' has been renamed: it is named '%s', but '%s' in the manifest", ain, before, after))
+		return nil
+	}
+	return []Action{&action{
+		change:  Change{Device: before, Attribute: NameAttribute, Before: before, After: after, Reason: ManifestReason},
+		perform: rename(before, after),
+		revert:  rename(after, before),
+	}}
+}
+
+func describe(ain, name string) string {
+	if ain == "" {
+		return fmt.Sprintf("'%s'", name)
+	}
+	return fmt.Sprintf("'%s' (AIN '%s')", name, ain)
+}
+
+// warnLockChange warns about locks of switches that differ, the AHA interface has no command to change them.
+func warnLockChange(before, after Switch) {
+	for _, l := range []struct {
+		name          string
+		before, after *bool
+	}{{"lock", before.Lock, after.Lock}, {"devicelock", before.DeviceLock, after.DeviceLock}} {
+		if l.after != nil && (l.before == nil || *l.before != *l.after) {
+			logger.Warn(fmt.Sprintf("Cannot change %s of switch '%s' via the AHA interface, leaving it untouched", l.name, after.Name))
+		}
+	}
+}
+
+func switchActions(before, after Switch, reason string) []Action {
+	if before.State == after.State {
+		return nil
+	}
+	name := before.Name
+	return []Action{&action{
+		change:  Change{Device: name, Attribute: StateAttribute, Before: onOff(before.State), After: onOff(after.State), Reason: reason},
+		perform: switchTo(after.State, name),
+		revert:  switchTo(before.State, name),
+	}}
+}
+
+func onOff(state bool) string {
+	if state {
+		return "on"
+	}
+	return "off"
+}
+
+func thermostatActions(before, after Thermostat, reason string) []Action {
+	var actions []Action
+	name := before.Name
+	actions = append(actions, temperatureActions(name, before.Temperature, after.Temperature, reason)...)
+	if s, ok := settingsChange(before, after); ok {
+		actions = append(actions, &action{
+			change:  Change{Device: name, Attribute: SettingsAttribute, Before: settingsBefore(before, s).String(), After: s.String(), Reason: reason},
+			perform: configure(s, name),
+			revert:  configure(settingsBefore(before, s), name),
+		})
+	}
+	if scheduleChanged(before, after) {
+		c := Change{Device: name, Attribute: ScheduleAttribute, Before: fmtSchedule(before.Schedule), After: fmtSchedule(after.Schedule), Reason: reason}
+		revert := irreversible(c)
+		if before.Schedule != nil {
+			revert = setSchedule(before.Schedule, name)
+		}
+		actions = append(actions, &action{
+			change:  c,
+			perform: setSchedule(after.Schedule, name),
+			revert:  revert,
+		})
+	}
+	return actions
+}
+
+func groupActions(before, after Group, reason string) []Action {
+	var actions []Action
+	name := before.Name
+	if after.State != nil && (before.State == nil || *before.State != *after.State) {
+		c := Change{Device: name, Attribute: StateAttribute, Before: fmtState(before.State), After: onOff(*after.State), Reason: reason}
+		revert := irreversible(c)
+		if before.State != nil {
+			revert = switchTo(*before.State, name)
+		}
+		actions = append(actions, &action{change: c, perform: switchTo(*after.State, name), revert: revert})
+	}
+	return append(actions, temperatureActions(name, before.Temperature, after.Temperature, reason)...)
+}
+
+// temperatureActions sets the goal temperature of a thermostat or group, unless the target leaves it untouched.
+func temperatureActions(name string, before, after *float64, reason string) []Action {
+	if after == nil || before != nil && *before == *after {
+		return nil
+	}
+	temperature := *after
+	c := Change{Device: name, Attribute: TemperatureAttribute, Before: fmtTemperaturePtr(before), After: fmtTemperature(temperature), Reason: reason}
+	revert := irreversible(c)
+	if before != nil {
+		previous := *before
+		revert = func(f aha) error { return f.Temp(previous, name) }
+	}
+	return []Action{&action{change: c, perform: func(f aha) error { return f.Temp(temperature, name) }, revert: revert}}
+}
+
+func bulbActions(before, after Bulb, reason string) ([]Action, error) {
+	s, ok := lightChange(before, after)
+	if !ok {
+		return nil, nil
+	}
+	if err := s.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid settings of bulb '%s': %v", after.Name, err)
+	}
+	name := before.Name
+	previous := lightBefore(before, s)
+	return []Action{&action{
+		change:  Change{Device: name, Attribute: LightAttribute, Before: previous.String(), After: s.String(), Reason: reason},
+		perform: light(s, name),
+		revert:  light(previous, name),
+	}}, nil
+}
+
+func fmtState(state *bool) string {
+	if state == nil {
+		return "?"
+	}
+	return onOff(*state)
+}
+
+func fmtTemperaturePtr(t *float64) string {
+	if t == nil {
+		return "?"
+	}
+	return fmtTemperature(*t)
+}
+
+func fmtTemperature(t float64) string {
+	return strconv.FormatFloat(t, 'f', -1, 64)
+}
+
+func fmtSchedule(s *fritz.HeatingSchedule) string {
+	if s == nil {
+		return ""
+	}
+	return s.String()
+}
+
+func scheduleChanged(before, after Thermostat) bool {
+	return after.Schedule != nil && !after.Schedule.Equal(before.Schedule)
+}
+
+// StrictPlanner creates a Planner that treats the target as the full desired state. Devices in the source state that
+// are not referenced in the target are handled according to the policy given by the target, see Plan.Unmanaged.
+func StrictPlanner() Planner {
+	return &strictPlanner{}
+}
+
+type strictPlanner struct {
+	targetBasedPlanner
+}
+
+// Plan creates an execution plan like the TargetBasedPlanner and adds the actions for the unmanaged devices.
+func (d *strictPlanner) Plan(src, target *Plan) ([]Action, error) {
+	switches, thermostats, bulbs := unmanaged(src, target)
+	policy := target.Unmanaged
+	if policy == "" {
+		policy = FailUnmanaged
+	}
+	var actions []Action
+	switch policy {
+	case FailUnmanaged:
+		if len(switches)+len(thermostats)+len(bulbs) > 0 {
+			return []Action{}, fmt.Errorf("devices not managed by the manifest: %s", strings.Join(names(switches, thermostats, bulbs), ", "))
+		}
+	case WarnUnmanaged:
+		for _, n := range names(switches, thermostats, bulbs) {
+			logger.Warn(fmt.Sprintf("Device '%s' is not managed by the manifest", n))
+		}
+	case OffUnmanaged:
+		for _, s := range switches {
+			actions = append(actions, switchActions(s, Switch{Name: s.Name, State: false}, UnmanagedReason)...)
+		}
+		for _, t := range thermostats {
+			off, temperature := t, 126.5
+			off.Temperature = &temperature
+			actions = append(actions, thermostatActions(t, off, UnmanagedReason)...)
 		}
-		switchActions = append(switchActions, d.thermostatCommandFactory(before, t))
+		off := false
+		for _, b := range bulbs {
+			a, _ := bulbActions(b, Bulb{Name: b.Name, State: &off}, UnmanagedReason)
+			actions = append(actions, a...)
+		}
+	default:
+		return []Action{}, fmt.Errorf("unknown policy '%s' for unmanaged devices, expected one of %s, %s, %s", policy, FailUnmanaged, WarnUnmanaged, OffUnmanaged)
+	}
+	managed, err := d.targetBasedPlanner.Plan(src, target)
+	if err != nil {
+		return []Action{}, err
+	}
+	return append(managed, actions...), nil
+}
+
+// unmanaged returns the devices of the source state that are neither referenced in the target nor members of a group
+// referenced in the target.
+func unmanaged(src, target *Plan) ([]Switch, []Thermostat, []Bulb) {
+	members := groupMembers(src, target)
+	managed := func(ain, name string) bool {
+		return members[ain] || target.manages(ain, name)
+	}
+	var switches []Switch
+	for _, s := range src.Switches {
+		if !managed(s.AIN, s.Name) {
+			switches = append(switches, s)
+		}
+	}
+	var thermostats []Thermostat
+	for _, t := range src.Thermostats {
+		if !managed(t.AIN, t.Name) {
+			thermostats = append(thermostats, t)
+		}
+	}
+	var bulbs []Bulb
+	for _, b := range src.Bulbs {
+		if !managed(b.AIN, b.Name) {
+			bulbs = append(bulbs, b)
+		}
+	}
+	return switches, thermostats, bulbs
+}
+
+// groupMembers collects the AINs of the members of the source groups that are referenced in the target.
+func groupMembers(src, target *Plan) map[string]bool {
+	members := make(map[string]bool)
+	for _, g := range target.Groups {
+		if s, ok := src.groupNamed(g.Name); ok {
+			for _, ain := range s.Members {
+				members[ain] = true
+			}
+		}
+	}
+	return members
+}
+
+func names(switches []Switch, thermostats []Thermostat, bulbs []Bulb) []string {
+	var ns []string
+	for _, s := range switches {
+		ns = append(ns, s.Name)
+	}
+	for _, t := range thermostats {
+		ns = append(ns, t.Name)
+	}
+	for _, b := range bulbs {
+		ns = append(ns, b.Name)
 	}
-	return switchActions, nil
+	return ns
 }
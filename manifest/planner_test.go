@@ -0,0 +1,155 @@
+package manifest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bpicode/fritzctl/fritz/fritztest"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestStrictPlanner tests the policies for devices that are not part of the target.
+func TestStrictPlanner(t *testing.T) {
+	src := &Plan{
+		Switches:    []Switch{{Name: "s1", State: true}, {Name: "s2", State: true}},
+		Thermostats: []Thermostat{{Name: "t1", Temperature: floatPtr(20)}, {Name: "t2", Temperature: floatPtr(126.5)}},
+	}
+	planner := StrictPlanner()
+	for policy, expected := range map[string]int{WarnUnmanaged: 1, OffUnmanaged: 3} {
+		actions, err := planner.Plan(src, &Plan{Unmanaged: policy, Switches: []Switch{{Name: "s1", State: false}}})
+		assert.NoError(t, err)
+		assert.Len(t, actions, expected, policy)
+	}
+	actions, err := planner.Plan(src, &Plan{Unmanaged: OffUnmanaged})
+	assert.NoError(t, err)
+	assert.Equal(t, []Change{
+		{Device: "s1", Attribute: StateAttribute, Before: "on", After: "off", Reason: UnmanagedReason},
+		{Device: "s2", Attribute: StateAttribute, Before: "on", After: "off", Reason: UnmanagedReason},
+		{Device: "t1", Attribute: TemperatureAttribute, Before: "20", After: "126.5", Reason: UnmanagedReason},
+	}, Describe(actions), "devices already off are left untouched")
+
+	for _, policy := range []string{"", FailUnmanaged, "unknown"} {
+		_, err := planner.Plan(src, &Plan{Unmanaged: policy})
+		assert.Error(t, err, policy)
+	}
+	_, err = planner.Plan(src, &Plan{Switches: src.Switches, Thermostats: src.Thermostats})
+	assert.NoError(t, err, "fails only if devices are unmanaged")
+}
+
+// TestStrictPlannerGroups tests that the members of the groups of the target are managed.
+func TestStrictPlannerGroups(t *testing.T) {
+	on, off := true, false
+	src := &Plan{
+		Switches:    []Switch{{Name: "s1", AIN: "1", State: false}, {Name: "s2", AIN: "2", State: true}, {Name: "s3", AIN: "3", State: true}},
+		Thermostats: []Thermostat{{Name: "t1", AIN: "4", Temperature: floatPtr(20)}},
+		Groups:      []Group{{Name: "g", State: &off, Members: []string{"1", "2"}}, {Name: "h", Members: []string{"3", "4"}}},
+	}
+	actions, err := StrictPlanner().Plan(src, &Plan{Unmanaged: OffUnmanaged, Groups: []Group{{Name: "g", State: &on}}})
+	assert.NoError(t, err)
+	assert.Equal(t, []Change{
+		{Device: "g", Attribute: StateAttribute, Before: "off", After: "on", Reason: ManifestReason},
+		{Device: "s3", Attribute: StateAttribute, Before: "on", After: "off", Reason: UnmanagedReason},
+		{Device: "t1", Attribute: TemperatureAttribute, Before: "20", After: "126.5", Reason: UnmanagedReason},
+	}, Describe(actions), "members of the group are not switched off")
+}
+
+// TestStrictAppliers tests that both appliers can plan strictly.
+func TestStrictAppliers(t *testing.T) {
+	src := &Plan{Switches: []Switch{{Name: "s1", State: true}}}
+	assert.Error(t, DryRunner(Strict()).Apply(src, &Plan{}))
+	assert.Error(t, NewApplier(&fritztest.HomeAuto{}, Strict()).Apply(src, &Plan{}))
+	assert.NoError(t, DryRunner(Strict()).Apply(src, &Plan{Unmanaged: OffUnmanaged}))
+	assert.NoError(t, NewApplier(&fritztest.HomeAuto{}, Strict()).Apply(src, &Plan{Unmanaged: OffUnmanaged}))
+	assert.NoError(t, NewApplier(&fritztest.HomeAuto{}).Apply(src, &Plan{}))
+}
+
+// TestPlanGroupsAndBulbs tests that only the given attributes of groups and bulbs are changed.
+func TestPlanGroupsAndBulbs(t *testing.T) {
+	on, off, temperature, level, hue, saturation, warm := true, false, 20.0, 50, 358, 180, 2700
+	src := &Plan{
+		Groups: []Group{{Name: "g1", State: &on}, {Name: "g2", Temperature: &temperature}},
+		Bulbs:  []Bulb{{Name: "b", State: &on, Level: &level, Hue: &hue, Saturation: &saturation}},
+	}
+	warmer := 21.0
+	actions, err := TargetBasedPlanner().Plan(src, &Plan{
+		Groups: []Group{{Name: "g1", State: &off}, {Name: "g2", Temperature: &warmer}},
+		Bulbs:  []Bulb{{Name: "b", State: &on, Level: &level, ColorTemperature: &warm}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []Change{
+		{Device: "g1", Attribute: StateAttribute, Before: "on", After: "off", Reason: ManifestReason},
+		{Device: "g2", Attribute: TemperatureAttribute, Before: "20", After: "21", Reason: ManifestReason},
+		{Device: "b", Attribute: LightAttribute, Before: "hue=358°, saturation=180", After: "colortemperature=2700K", Reason: ManifestReason},
+	}, Describe(actions))
+	assert.NoError(t, NewApplier(&fritztest.HomeAuto{}).Apply(src, &Plan{Bulbs: []Bulb{{Name: "b", State: &off}}}))
+
+	actions, err = TargetBasedPlanner().Plan(src, &Plan{Groups: []Group{{Name: "g1"}}, Bulbs: []Bulb{{Name: "b"}}})
+	assert.NoError(t, err)
+	assert.Empty(t, actions, "unset attributes are left untouched")
+
+	for _, target := range []*Plan{
+		{Groups: []Group{{Name: "unknown"}}},
+		{Bulbs: []Bulb{{Name: "unknown"}}},
+		{Bulbs: []Bulb{{Name: "b", Hue: &level}}},
+		{Bulbs: []Bulb{{Name: "b", Level: &warm}}},
+	} {
+		_, err := TargetBasedPlanner().Plan(src, target)
+		assert.Error(t, err)
+	}
+}
+
+// TestPlanSwitchLocks tests that locks of switches are left untouched.
+func TestPlanSwitchLocks(t *testing.T) {
+	locked, unlocked := true, false
+	src := &Plan{Switches: []Switch{{Name: "s", State: true, Lock: &unlocked}}}
+	actions, err := TargetBasedPlanner().Plan(src, &Plan{Switches: []Switch{{Name: "s", State: true, Lock: &locked, DeviceLock: &locked}}})
+	assert.NoError(t, err)
+	assert.Empty(t, actions)
+}
+
+// TestPlanByAIN tests that devices are addressed by AIN and that renamed devices are detected.
+func TestPlanByAIN(t *testing.T) {
+	src := &Plan{
+		Switches:    []Switch{{Name: "Kitchen", AIN: "12345 6789", State: false}},
+		Thermostats: []Thermostat{{Name: "Living room", AIN: "98765 4321", Temperature: floatPtr(20)}},
+	}
+	target := &Plan{
+		Switches:    []Switch{{Name: "Plug", AIN: "123456789", State: true}},
+		Thermostats: []Thermostat{{Name: "Living room", Temperature: floatPtr(21)}},
+	}
+	actions, err := TargetBasedPlanner().Plan(src, target)
+	assert.NoError(t, err)
+	assert.Equal(t, []Change{
+		{Device: "Kitchen", Attribute: StateAttribute, Before: "off", After: "on", Reason: ManifestReason},
+		{Device: "Living room", Attribute: TemperatureAttribute, Before: "20", After: "21", Reason: ManifestReason},
+	}, Describe(actions), "renamed devices are only reported")
+
+	cs, err := NewChangeset(src, target, nil, UpdateNames())
+	assert.NoError(t, err)
+	assert.True(t, cs.UpdateNames)
+	assert.Equal(t, []Change{
+		{Device: "Kitchen", Attribute: StateAttribute, Before: "off", After: "on", Reason: ManifestReason},
+		{Device: "Kitchen", Attribute: NameAttribute, Before: "Kitchen", After: "Plug", Reason: ManifestReason},
+		{Device: "Living room", Attribute: TemperatureAttribute, Before: "20", After: "21", Reason: ManifestReason},
+	}, cs.Changes, "renaming comes after the other changes of the device")
+	assert.NoError(t, NewApplier(&fritztest.HomeAuto{}, cs.Options()...).Apply(src, target))
+
+	_, err = TargetBasedPlanner().Plan(src, &Plan{Switches: []Switch{{Name: "Kitchen", AIN: "0000"}}})
+	assert.Error(t, err, "the AIN takes precedence over the name")
+
+	_, err = StrictPlanner().Plan(src, &Plan{Switches: target.Switches, Thermostats: []Thermostat{{Name: "Heater", AIN: "987654321"}}})
+	assert.NoError(t, err, "devices addressed by AIN are managed")
+}
+
+// TestPlanThermostatWithoutTemperature tests that thermostats without a temperature keep their goal temperature.
+func TestPlanThermostatWithoutTemperature(t *testing.T) {
+	comfort := 22.0
+	src := &Plan{Thermostats: []Thermostat{{Name: "t", Temperature: floatPtr(20.5)}}}
+	actions, err := TargetBasedPlanner().Plan(src, &Plan{Thermostats: []Thermostat{{Name: "t", Comfort: &comfort}}})
+	assert.NoError(t, err)
+	assert.Equal(t, []Change{{Device: "t", Attribute: SettingsAttribute, Before: "", After: "comfort=22°C", Reason: ManifestReason}}, Describe(actions))
+
+	plan, err := Parse(strings.NewReader("thermostats: [{name: t, comfort: 22}]"))
+	assert.NoError(t, err)
+	assert.Nil(t, plan.Thermostats[0].Temperature)
+}
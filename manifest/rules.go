@@ -0,0 +1,217 @@
+package manifest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bpicode/fritzctl/fritz"
+)
+
+// Rule declares entries that apply only if its conditions hold for the live state of the devices, e.g. turning off a
+// thermostat while the alert sensor of a window reports an alert. The entries of the rules that hold replace the
+// entries of the manifest for the same devices, later rules take precedence.
+type Rule struct {
+	Name string      `json:"name,omitempty" yaml:"name,omitempty"` // Name of the rule, used when reporting its outcome.
+	When []Condition `json:"when" yaml:"when"`                     // The conditions, all of them have to hold.
+	Then Plan        `json:"then" yaml:"then"`                     // The entries applied if the conditions hold.
+}
+
+// Condition is a predicate on the live state of a device. All of the given predicates have to hold.
+type Condition struct {
+	Device      string `json:"device" yaml:"device"`                               // Name of the device.
+	AIN         string `json:"ain,omitempty" yaml:"ain,omitempty"`                 // Identifier of the device, preferred over the name if given.
+	State       *bool  `json:"state,omitempty" yaml:"state,omitempty"`             // The switch or light is on (true) or off (false).
+	Present     *bool  `json:"present,omitempty" yaml:"present,omitempty"`         // The device is connected to the FRITZ!Box.
+	Alert       *bool  `json:"alert,omitempty" yaml:"alert,omitempty"`             // The alert sensor reports an alert.
+	Temperature *Range `json:"temperature,omitempty" yaml:"temperature,omitempty"` // The measured temperature in °C.
+	Power       *Range `json:"power,omitempty" yaml:"power,omitempty"`             // The power consumption in W.
+}
+
+// Range bounds a measured value. The bounds are exclusive, a nil bound is not checked.
+type Range struct {
+	Above *float64 `json:"above,omitempty" yaml:"above,omitempty"` // The value has to be greater.
+	Below *float64 `json:"below,omitempty" yaml:"below,omitempty"` // The value has to be less.
+}
+
+// Outcome is the result of the evaluation of a rule.
+type Outcome struct {
+	Rule    string   `json:"rule" yaml:"rule"`                           // The name of the rule, or its position if it has no name.
+	Held    bool     `json:"held" yaml:"held"`                           // All conditions held.
+	Details []string `json:"details,omitempty" yaml:"details,omitempty"` // The evaluation of each predicate.
+}
+
+// String formats the outcome on a single line.
+func (o Outcome) String() string {
+	return fmt.Sprintf("%s: %s", o.Rule, strings.Join(o.Details, "; "))
+}
+
+// Resolve evaluates the rules of the plan against the live devices. It returns a plan without rules, in which the
+// entries of the rules that hold replace the entries for the same devices, and the outcomes of the rules.
+func (plan *Plan) Resolve(l *fritz.Devicelist) (*Plan, []Outcome, error) {
+	outcomes := make([]Outcome, 0, len(plan.Rules))
+	for i, r := range plan.Rules {
+		o := Outcome{Rule: r.describe(i), Held: true}
+		for _, c := range r.When {
+			held, details, err := c.evaluate(l)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s: %v", o.Rule, err)
+			}
+			o.Held = o.Held && held
+			o.Details = append(o.Details, details...)
+		}
+		outcomes = append(outcomes, o)
+	}
+	resolved, err := plan.resolved(outcomes)
+	return resolved, outcomes, err
+}
+
+// resolved returns the plan without rules, in which the entries of the rules that held replace the entries for the same
+// devices. There is one outcome per rule.
+func (plan *Plan) resolved(outcomes []Outcome) (*Plan, error) {
+	if len(outcomes) != len(plan.Rules) {
+		return nil, fmt.Errorf("%d outcomes for %d rules", len(outcomes), len(plan.Rules))
+	}
+	resolved := *plan
+	resolved.Rules = nil
+	resolved.Switches = append([]Switch(nil), plan.Switches...)
+	resolved.Thermostats = append([]Thermostat(nil), plan.Thermostats...)
+	resolved.Groups = append([]Group(nil), plan.Groups...)
+	resolved.Bulbs = append([]Bulb(nil), plan.Bulbs...)
+	for i, r := range plan.Rules {
+		if outcomes[i].Held {
+			resolved.merge(&r.Then)
+		}
+	}
+	return &resolved, nil
+}
+
+func (r Rule) describe(i int) string {
+	if r.Name != "" {
+		return fmt.Sprintf("rule '%s'", r.Name)
+	}
+	return fmt.Sprintf("rule %d", i+1)
+}
+
+// predicate is the evaluation of a single predicate: whether it held and a description of it.
+type predicate struct {
+	held   bool
+	detail string
+}
+
+// evaluate checks the predicates of the condition against the device it refers to. It is an error if there is no such
+// device or if it cannot report the values of the predicates.
+func (c Condition) evaluate(l *fritz.Devicelist) (bool, []string, error) {
+	d, ok := deviceFor(l, c.AIN, c.Device)
+	if !ok {
+		return false, nil, fmt.Errorf("%s: no such device", describe(c.AIN, c.Device))
+	}
+	ps, err := c.predicates(d)
+	if err != nil {
+		return false, nil, err
+	}
+	held := true
+	details := make([]string, 0, len(ps))
+	for _, p := range ps {
+		held = held && p.held
+		details = append(details, p.detail)
+	}
+	return held, details, nil
+}
+
+func (c Condition) predicates(d *fritz.Device) ([]predicate, error) {
+	var ps []predicate
+	if c.Present != nil {
+		ps = append(ps, is(d, "present", d.Present == 1, *c.Present))
+	}
+	if c.State != nil {
+		p, err := stateOf(d, *c.State)
+		if err != nil {
+			return nil, err
+		}
+		ps = append(ps, p)
+	}
+	if c.Alert != nil {
+		if !d.HasAlertSensor() {
+			return nil, fmt.Errorf("'%s' has no alert sensor", d.Name)
+		}
+		ps = append(ps, is(d, "alert", d.AlertSensor.State == "1", *c.Alert))
+	}
+	ms, err := c.measurements(d)
+	return append(ps, ms...), err
+}
+
+func (c Condition) measurements(d *fritz.Device) ([]predicate, error) {
+	var ps []predicate
+	if c.Temperature != nil {
+		if !d.CanMeasureTemp() {
+			return nil, fmt.Errorf("'%s' cannot measure the temperature", d.Name)
+		}
+		ps = append(ps, c.Temperature.contains(d, "temperature", d.Temperature.Celsius, 10, "°C"))
+	}
+	if c.Power != nil {
+		if !d.CanMeasurePower() {
+			return nil, fmt.Errorf("'%s' cannot measure the power", d.Name)
+		}
+		ps = append(ps, c.Power.contains(d, "power", d.Powermeter.Power, 1000, " W"))
+	}
+	return ps, nil
+}
+
+func deviceFor(l *fritz.Devicelist, ain, name string) (*fritz.Device, bool) {
+	for i := range l.Devices {
+		if addresses(ain, name, l.Devices[i].Identifier, l.Devices[i].Name) {
+			return &l.Devices[i], true
+		}
+	}
+	return nil, false
+}
+
+func stateOf(d *fritz.Device, expected bool) (predicate, error) {
+	var state string
+	switch {
+	case d.IsSwitch():
+		state = d.Switch.State
+	case d.IsLight():
+		state = d.OnOff.State
+	default:
+		return predicate{}, fmt.Errorf("'%s' is neither a switch nor a light", d.Name)
+	}
+	on, err := strconv.ParseBool(state)
+	if err != nil {
+		return predicate{detail: fmt.Sprintf("'%s' state is unknown, expected %t", d.Name, expected)}, nil
+	}
+	return is(d, "state", on, expected), nil
+}
+
+func is(d *fritz.Device, property string, actual, expected bool) predicate {
+	return predicate{
+		held:   actual == expected,
+		detail: fmt.Sprintf("'%s' %s is %t, expected %t", d.Name, property, actual, expected),
+	}
+}
+
+// contains checks the value reported by the FRITZ!Box, which is divided by the given factor to convert it to the unit
+// of the range. An unknown value is not contained.
+func (r Range) contains(d *fritz.Device, property, value string, factor float64, unit string) predicate {
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return predicate{detail: fmt.Sprintf("'%s' %s is unknown, expected %s", d.Name, property, r.format(unit))}
+	}
+	v /= factor
+	return predicate{
+		held:   (r.Above == nil || v > *r.Above) && (r.Below == nil || v < *r.Below),
+		detail: fmt.Sprintf("'%s' %s is %s%s, expected %s", d.Name, property, strconv.FormatFloat(v, 'f', -1, 64), unit, r.format(unit)),
+	}
+}
+
+func (r Range) format(unit string) string {
+	var bounds []string
+	if r.Above != nil {
+		bounds = append(bounds, "above "+strconv.FormatFloat(*r.Above, 'f', -1, 64)+unit)
+	}
+	if r.Below != nil {
+		bounds = append(bounds, "below "+strconv.FormatFloat(*r.Below, 'f', -1, 64)+unit)
+	}
+	return strings.Join(bounds, " and ")
+}
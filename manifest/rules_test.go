@@ -0,0 +1,89 @@
+package manifest
+
+import (
+	"testing"
+
+	"github.com/bpicode/fritzctl/fritz"
+	"github.com/stretchr/testify/assert"
+)
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+// rulesDevicelist has a window sensor with an active alert, a heater consuming 1800 W and a thermostat.
+var rulesDevicelist = &fritz.Devicelist{Devices: []fritz.Device{
+	{Name: "Window", Identifier: "111", Functionbitmask: "16", Present: 1, AlertSensor: fritz.AlertSensor{State: "1"}},
+	{Name: "Heater", Identifier: "222", Functionbitmask: "2944", Present: 1, Switch: fritz.Switch{State: "1"}, Powermeter: fritz.Powermeter{Power: "1800000"}, Temperature: fritz.Temperature{Celsius: "215"}},
+	{Name: "Dryer", Identifier: "333", Functionbitmask: "2944", Present: 0},
+	{Name: "HKR_Living", Identifier: "444", Functionbitmask: "320", Present: 1},
+}}
+
+// TestResolve tests that the entries of the rules whose conditions hold replace the entries of the manifest.
+func TestResolve(t *testing.T) {
+	on, off := true, false
+	plan := &Plan{
+		Switches:    []Switch{{Name: "Dryer", State: true}},
+		Thermostats: []Thermostat{{Name: "HKR_Living", Temperature: floatPtr(21)}},
+		Rules: []Rule{
+			{
+				Name: "window open",
+				When: []Condition{{Device: "Window", Alert: &on}},
+				Then: Plan{Thermostats: []Thermostat{{Name: "HKR_Living", Temperature: floatPtr(126.5)}}},
+			},
+			{
+				When: []Condition{{AIN: "2 2 2", Power: &Range{Above: floatPtr(1500)}, State: &on}, {Device: "Dryer", Present: &on}},
+				Then: Plan{Switches: []Switch{{Name: "Dryer", State: false}}},
+			},
+			{
+				Name: "warm",
+				When: []Condition{{Device: "Heater", Temperature: &Range{Above: floatPtr(21), Below: floatPtr(22)}}},
+				Then: Plan{Bulbs: []Bulb{{Name: "Lamp", State: &off}}},
+			},
+		},
+	}
+	resolved, outcomes, err := plan.Resolve(rulesDevicelist)
+	assert.NoError(t, err)
+	assert.Empty(t, resolved.Rules)
+	assert.Equal(t, []Thermostat{{Name: "HKR_Living", Temperature: floatPtr(126.5)}}, resolved.Thermostats)
+	assert.Equal(t, []Switch{{Name: "Dryer", State: true}}, resolved.Switches)
+	assert.Equal(t, []Bulb{{Name: "Lamp", State: &off}}, resolved.Bulbs)
+	assert.Equal(t, 21.0, *plan.Thermostats[0].Temperature, "the plan itself is left untouched")
+
+	assert.Equal(t, []Outcome{
+		{Rule: "rule 'window open'", Held: true, Details: []string{"'Window' alert is true, expected true"}},
+		{Rule: "rule 2", Held: false, Details: []string{
+			"'Heater' state is true, expected true",
+			"'Heater' power is 1800 W, expected above 1500 W",
+			"'Dryer' present is false, expected true",
+		}},
+		{Rule: "rule 'warm'", Held: true, Details: []string{"'Heater' temperature is 21.5°C, expected above 21°C and below 22°C"}},
+	}, outcomes)
+	assert.Equal(t, "rule 'window open': 'Window' alert is true, expected true", outcomes[0].String())
+}
+
+// TestResolveErrors tests that conditions on missing devices or values the devices cannot report are errors.
+func TestResolveErrors(t *testing.T) {
+	on := true
+	for c, msg := range map[*Condition]string{
+		{Device: "Door", Alert: &on}:                                 "rule 1: 'Door': no such device",
+		{Device: "Heater", Alert: &on}:                               "rule 1: 'Heater' has no alert sensor",
+		{Device: "Window", State: &on}:                               "rule 1: 'Window' is neither a switch nor a light",
+		{Device: "Window", Temperature: &Range{Above: floatPtr(20)}}: "rule 1: 'Window' cannot measure the temperature",
+		{Device: "HKR_Living", Power: &Range{Below: floatPtr(1000)}}: "rule 1: 'HKR_Living' cannot measure the power",
+	} {
+		_, _, err := (&Plan{Rules: []Rule{{When: []Condition{*c}}}}).Resolve(rulesDevicelist)
+		assert.EqualError(t, err, msg)
+	}
+}
+
+// TestResolveUnknownValues tests that conditions on values the devices do not report do not hold.
+func TestResolveUnknownValues(t *testing.T) {
+	on := true
+	_, outcomes, err := (&Plan{Rules: []Rule{{When: []Condition{
+		{Device: "Dryer", State: &on, Power: &Range{Above: floatPtr(0)}},
+	}}}}).Resolve(rulesDevicelist)
+	assert.NoError(t, err)
+	assert.False(t, outcomes[0].Held)
+	assert.Equal(t, []string{"'Dryer' state is unknown, expected true", "'Dryer' power is unknown, expected above 0 W"}, outcomes[0].Details)
+}
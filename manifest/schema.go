@@ -0,0 +1,179 @@
+package manifest
+
+// Schema is the JSON Schema of manifest files, see https://json-schema.org/draft-07/schema. Editors with YAML support
+// can use it for completion and validation. Numbers may also be given as variables ${name}. The ranges of
+// temperatures depend on the unit system, they are checked when the manifest is parsed.
+const Schema = `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "$id": "https://github.com/bpicode/fritzctl/manifest.schema.json",
+  "title": "fritzctl manifest",
+  "description": "Desired state of the smart home devices of a FRITZ!Box, applied by 'fritzctl manifest apply'.",
+  "type": "object",
+  "additionalProperties": false,
+  "properties": {
+    "include": {"description": "Manifest files merged before this one, relative to this file.", "type": "array", "items": {"type": "string"}},
+    "vars": {"description": "Variables, referenced as ${name}.", "type": "object", "additionalProperties": {"type": "string"}},
+    "units": {"description": "Unit system of the temperatures.", "enum": ["metric", "imperial"]},
+    "unmanaged": {"description": "Policy for devices missing in the manifest when applied strictly.", "enum": ["fail", "warn", "off"]},
+    "switches": {"type": "array", "items": {"$ref": "#/definitions/switch"}},
+    "thermostats": {"type": "array", "items": {"$ref": "#/definitions/thermostat"}},
+    "groups": {"type": "array", "items": {"$ref": "#/definitions/group"}},
+    "bulbs": {"type": "array", "items": {"$ref": "#/definitions/bulb"}},
+    "stage_delay": {"description": "Pause before each stage but the first, e.g. 30s or 2m.", "type": "string", "pattern": "^([0-9]+(\\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$"},
+    "rules": {"type": "array", "items": {"$ref": "#/definitions/rule"}}
+  },
+  "definitions": {
+    "variable": {"type": "string", "pattern": "^\\$\\{[A-Za-z_][A-Za-z0-9_]*\\}$"},
+    "number": {"anyOf": [{"type": "number"}, {"$ref": "#/definitions/variable"}]},
+    "integer": {"anyOf": [{"type": "integer"}, {"$ref": "#/definitions/variable"}]},
+    "boolean": {"anyOf": [{"type": "boolean"}, {"$ref": "#/definitions/variable"}]},
+    "name": {"description": "Name of the device.", "type": "string", "minLength": 1},
+    "ain": {"description": "Identifier of the device, preferred over the name if given.", "type": "string"},
+    "stage": {"description": "Stage in which the entry is applied, lower stages first.", "type": "integer", "minimum": 0},
+    "depends_on": {"description": "Names of the entries applied in an earlier stage.", "type": "array", "items": {"type": "string"}},
+    "switch": {
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["name", "state"],
+      "properties": {
+        "name": {"$ref": "#/definitions/name"},
+        "ain": {"$ref": "#/definitions/ain"},
+        "state": {"description": "On (true) or off (false).", "$ref": "#/definitions/boolean"},
+        "lock": {"description": "Locked against changes via the FRITZ!Box, cannot be changed.", "$ref": "#/definitions/boolean"},
+        "devicelock": {"description": "Operating elements locked, cannot be changed.", "$ref": "#/definitions/boolean"},
+        "stage": {"$ref": "#/definitions/stage"},
+        "depends_on": {"$ref": "#/definitions/depends_on"}
+      }
+    },
+    "thermostat": {
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["name", "temperature"],
+      "properties": {
+        "name": {"$ref": "#/definitions/name"},
+        "ain": {"$ref": "#/definitions/ain"},
+        "temperature": {"description": "Goal temperature: 8-28°C in steps of 0.5°C, 126.5 (off) or 127 (on).", "$ref": "#/definitions/number"},
+        "comfort": {"description": "Comfort temperature, 8-28°C in steps of 0.5°C.", "$ref": "#/definitions/number"},
+        "saving": {"description": "Saving temperature, 8-28°C in steps of 0.5°C.", "$ref": "#/definitions/number"},
+        "offset": {"description": "Offset of the temperature sensor, -10-10°C in steps of 0.5°C.", "$ref": "#/definitions/number"},
+        "lock": {"description": "Locked against changes via the FRITZ!Box.", "$ref": "#/definitions/boolean"},
+        "devicelock": {"description": "Operating elements locked.", "$ref": "#/definitions/boolean"},
+        "schedule": {"$ref": "#/definitions/schedule"},
+        "stage": {"$ref": "#/definitions/stage"},
+        "depends_on": {"$ref": "#/definitions/depends_on"}
+      }
+    },
+    "schedule": {
+      "description": "Heating schedule.",
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["weekly"],
+      "properties": {
+        "weekly": {
+          "type": "array",
+          "items": {
+            "type": "object",
+            "additionalProperties": false,
+            "required": ["days", "at", "mode"],
+            "properties": {
+              "days": {"type": "array", "items": {"enum": ["mon", "tue", "wed", "thu", "fri", "sat", "sun", "daily", "weekdays", "weekend"]}},
+              "at": {"type": "string", "pattern": "^[0-2][0-9]:[0-5][0-9]$"},
+              "mode": {"enum": ["comfort", "saving"]}
+            }
+          }
+        },
+        "holidays": {
+          "type": "array",
+          "maxItems": 4,
+          "items": {
+            "type": "object",
+            "additionalProperties": false,
+            "required": ["from", "to"],
+            "properties": {"from": {"type": "string"}, "to": {"type": "string"}}
+          }
+        },
+        "summer": {
+          "type": "object",
+          "additionalProperties": false,
+          "required": ["from", "to"],
+          "properties": {"from": {"type": "string"}, "to": {"type": "string"}}
+        }
+      }
+    },
+    "group": {
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["name"],
+      "properties": {
+        "name": {"description": "Name of the group.", "type": "string", "minLength": 1},
+        "state": {"description": "On (true) or off (false), for groups of switches.", "$ref": "#/definitions/boolean"},
+        "temperature": {"description": "Goal temperature, for groups of thermostats.", "$ref": "#/definitions/number"},
+        "stage": {"$ref": "#/definitions/stage"},
+        "depends_on": {"$ref": "#/definitions/depends_on"}
+      }
+    },
+    "rule": {
+      "description": "Entries applied only if all conditions hold for the live state of the devices.",
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["when", "then"],
+      "properties": {
+        "name": {"type": "string"},
+        "when": {"type": "array", "minItems": 1, "items": {"$ref": "#/definitions/condition"}},
+        "then": {
+          "type": "object",
+          "additionalProperties": false,
+          "properties": {
+            "units": {"enum": ["metric", "imperial"]},
+            "switches": {"type": "array", "items": {"$ref": "#/definitions/switch"}},
+            "thermostats": {"type": "array", "items": {"$ref": "#/definitions/thermostat"}},
+            "groups": {"type": "array", "items": {"$ref": "#/definitions/group"}},
+            "bulbs": {"type": "array", "items": {"$ref": "#/definitions/bulb"}}
+          }
+        }
+      }
+    },
+    "condition": {
+      "description": "Predicates on the live state of a device, all of them have to hold.",
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["device"],
+      "minProperties": 2,
+      "properties": {
+        "device": {"$ref": "#/definitions/name"},
+        "ain": {"$ref": "#/definitions/ain"},
+        "state": {"description": "The switch or light is on (true) or off (false).", "$ref": "#/definitions/boolean"},
+        "present": {"description": "The device is connected to the FRITZ!Box.", "$ref": "#/definitions/boolean"},
+        "alert": {"description": "The alert sensor reports an alert.", "$ref": "#/definitions/boolean"},
+        "temperature": {"description": "The measured temperature.", "$ref": "#/definitions/range"},
+        "power": {"description": "The power consumption in W.", "$ref": "#/definitions/range"}
+      }
+    },
+    "range": {
+      "type": "object",
+      "additionalProperties": false,
+      "minProperties": 1,
+      "properties": {
+        "above": {"description": "Exclusive lower bound.", "$ref": "#/definitions/number"},
+        "below": {"description": "Exclusive upper bound.", "$ref": "#/definitions/number"}
+      }
+    },
+    "bulb": {
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["name"],
+      "properties": {
+        "name": {"$ref": "#/definitions/name"},
+        "ain": {"$ref": "#/definitions/ain"},
+        "state": {"description": "On (true) or off (false).", "$ref": "#/definitions/boolean"},
+        "level": {"description": "Brightness in percent, 0-100.", "$ref": "#/definitions/integer"},
+        "hue": {"description": "Hue in degrees, 0-359, only together with saturation.", "$ref": "#/definitions/integer"},
+        "saturation": {"description": "Saturation, 0-255, only together with hue.", "$ref": "#/definitions/integer"},
+        "colortemperature": {"description": "Color temperature in kelvin, 2700-6500.", "$ref": "#/definitions/integer"},
+        "stage": {"$ref": "#/definitions/stage"},
+        "depends_on": {"$ref": "#/definitions/depends_on"}
+      }
+    }
+  }
+}
+`
@@ -0,0 +1,178 @@
+package manifest
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/pelletier/go-toml"
+	"gopkg.in/yaml.v2"
+	yaml3 "gopkg.in/yaml.v3"
+)
+
+// The manifest is encoded in TOML by way of its YAML form: the YAML is decoded into a generic, ordered document,
+// which is written as TOML, and vice versa.
+
+// encodeTOML writes a generic YAML document as TOML, keeping the order of the keys.
+func encodeTOML(doc yaml.MapSlice) ([]byte, error) {
+	line := 1
+	t, err := tomlTree(doc, &line)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Order(toml.OrderPreserve).Encode(t); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// tomlTree converts a table of a generic YAML document. The order of the keys is kept by numbering their positions,
+// which the encoder sorts by. Tables and arrays of tables are numbered after the other values, which must precede them.
+func tomlTree(doc yaml.MapSlice, line *int) (*toml.Tree, error) {
+	t, err := toml.TreeFromMap(map[string]interface{}{})
+	if err != nil {
+		return nil, err
+	}
+	t.SetPositionPath(nil, toml.Position{Line: *line, Col: 1})
+	for _, tables := range []bool{false, true} {
+		for _, item := range doc {
+			if isTable(item.Value) != tables {
+				continue
+			}
+			*line++
+			pos := toml.Position{Line: *line, Col: 1}
+			key := []string{fmt.Sprint(item.Key)}
+			v, err := tomlValue(item.Value, line)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", key[0], err)
+			}
+			if v == nil {
+				continue
+			}
+			t.SetPath(key, v)
+			t.SetPositionPath(key, pos)
+		}
+	}
+	return t, nil
+}
+
+// tomlValue converts a value of a generic YAML document. Sequences of mappings become arrays of tables.
+func tomlValue(v interface{}, line *int) (interface{}, error) {
+	switch x := v.(type) {
+	case yaml.MapSlice:
+		return tomlTree(x, line)
+	case []interface{}:
+		if isTable(x) {
+			trees := make([]*toml.Tree, len(x))
+			for i, e := range x {
+				t, err := tomlTree(e.(yaml.MapSlice), line)
+				if err != nil {
+					return nil, err
+				}
+				trees[i] = t
+			}
+			return trees, nil
+		}
+		values := make([]interface{}, len(x))
+		for i, e := range x {
+			v, err := tomlValue(e, line)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = v
+		}
+		return values, nil
+	case int:
+		return int64(x), nil
+	case string, bool, float64, nil:
+		return x, nil
+	}
+	return nil, fmt.Errorf("unsupported value %v", v)
+}
+
+// isTable reports whether the value is written as a table or an array of tables.
+func isTable(v interface{}) bool {
+	switch x := v.(type) {
+	case yaml.MapSlice:
+		return true
+	case []interface{}:
+		for _, e := range x {
+			if _, ok := e.(yaml.MapSlice); !ok {
+				return false
+			}
+		}
+		return len(x) > 0
+	}
+	return false
+}
+
+var tomlPosition = regexp.MustCompile(`^\((\d+), \d+\): `)
+
+// decodeTOML converts TOML to a YAML document, whose nodes have the lines of the corresponding TOML. The TOML decoder
+// does not record the positions within inline tables, they have the line of the enclosing table.
+func decodeTOML(data []byte) (*yaml3.Node, error) {
+	t, err := toml.LoadBytes(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s", tomlPosition.ReplaceAllString(err.Error(), "line $1: "))
+	}
+	return tomlNode(t, 0), nil
+}
+
+// tomlNode converts a value of a TOML tree, the line is used for values without their own position.
+func tomlNode(v interface{}, line int) *yaml3.Node {
+	switch x := v.(type) {
+	case *toml.Tree:
+		if p := x.Position(); !x.Inline() && !p.Invalid() {
+			line = p.Line
+		}
+		n := &yaml3.Node{Kind: yaml3.MappingNode, Tag: "!!map", Line: line}
+		for _, k := range sortedKeys(x) {
+			l := line
+			if p := keyPosition(x, k); !x.Inline() && !p.Invalid() {
+				l = p.Line
+			}
+			n.Content = append(n.Content,
+				&yaml3.Node{Kind: yaml3.ScalarNode, Tag: "!!str", Value: k, Line: l},
+				tomlNode(x.GetPath([]string{k}), l))
+		}
+		return n
+	case []*toml.Tree:
+		n := &yaml3.Node{Kind: yaml3.SequenceNode, Tag: "!!seq", Line: line}
+		for _, t := range x {
+			n.Content = append(n.Content, tomlNode(t, line))
+		}
+		return n
+	case []interface{}:
+		n := &yaml3.Node{Kind: yaml3.SequenceNode, Tag: "!!seq", Line: line}
+		for _, e := range x {
+			n.Content = append(n.Content, tomlNode(e, line))
+		}
+		return n
+	case string:
+		return stringNode(x, line)
+	}
+	return scalarNode(v, line)
+}
+
+// sortedKeys returns the keys of the table in the order of the document, followed by the keys without position.
+func sortedKeys(t *toml.Tree) []string {
+	keys := t.Keys()
+	sort.SliceStable(keys, func(i, j int) bool {
+		pi, pj := keyPosition(t, keys[i]), keyPosition(t, keys[j])
+		if pi.Invalid() || pj.Invalid() {
+			return !pi.Invalid() && pj.Invalid()
+		}
+		return pi.Line < pj.Line || pi.Line == pj.Line && pi.Col < pj.Col
+	})
+	return keys
+}
+
+// keyPosition returns the position of the key, which is the first table for an array of tables.
+func keyPosition(t *toml.Tree, key string) toml.Position {
+	if trees, ok := t.GetPath([]string{key}).([]*toml.Tree); ok && len(trees) > 0 {
+		return trees[0].Position()
+	}
+	return t.GetPositionPath([]string{key})
+}
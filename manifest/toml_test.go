@@ -0,0 +1,72 @@
+package manifest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+// TestDecodeTOML tests converting TOML to YAML, keeping the lines of the TOML.
+func TestDecodeTOML(t *testing.T) {
+	data, lines, err := toYAML(TOML, []byte(`# a comment
+units = 'metric' # trailing comment
+"quoted key" = "a # b \"c\""
+state = "on"
+
+[[thermostats]]
+name = "HKR_1"
+temperature = "${heating}"
+summer = { from = "06-01", to = "09-15" }
+
+[thermostats.schedule]
+weekly = [
+  { days = ["mon", "tue"], at = "06:30", mode = "comfort" },
+]
+
+[[thermostats]]
+name = "HKR_2"
+lock = false
+offset = -1.0e0
+`))
+	assert.NoError(t, err)
+	var doc map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal(data, &doc))
+	assert.Equal(t, map[string]interface{}{
+		"units":      "metric",
+		"quoted key": `a # b "c"`,
+		"state":      "on",
+		"thermostats": []interface{}{
+			map[interface{}]interface{}{
+				"name":        "HKR_1",
+				"temperature": "${heating}",
+				"summer":      map[interface{}]interface{}{"from": "06-01", "to": "09-15"},
+				"schedule": map[interface{}]interface{}{"weekly": []interface{}{
+					map[interface{}]interface{}{"days": []interface{}{"mon", "tue"}, "at": "06:30", "mode": "comfort"},
+				}},
+			},
+			map[interface{}]interface{}{"name": "HKR_2", "lock": false, "offset": -1},
+		},
+	}, doc)
+	assert.Equal(t, 2, lines[1], "units")
+	assert.Equal(t, 8, lines[6], "temperature of HKR_1")
+	assert.Equal(t, 11, lines[8], "weekly schedule of HKR_1")
+	assert.Equal(t, 6, lines[14], "inline table, which has the line of the enclosing table")
+	assert.Equal(t, 19, lines[19], "offset of HKR_2")
+}
+
+// TestDecodeTOMLErrors tests that invalid TOML is reported with its line.
+func TestDecodeTOMLErrors(t *testing.T) {
+	for doc, line := range map[string]string{
+		"a = 1\na = 2":  "line 2: ",
+		"\n\nswitches":  "line 3: ",
+		"a = \"b":       "line 1: ",
+		"a = 1\n[a]":    "line 2: ",
+		"a = [1 2]":     "line 1: ",
+		"a = 1\nb = {c": "line 2: ",
+	} {
+		_, _, err := toYAML(TOML, []byte(doc))
+		assert.Error(t, err, doc)
+		assert.Regexp(t, "^"+line, err.Error(), doc)
+	}
+}
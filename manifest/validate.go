@@ -0,0 +1,226 @@
+package manifest
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bpicode/fritzctl/fritz"
+)
+
+// Problems are the findings of the validation of a manifest.
+type Problems []error
+
+// Error lists the problems, one per line.
+func (p Problems) Error() string {
+	msgs := make([]string, 0, len(p))
+	for _, err := range p {
+		msgs = append(msgs, err.Error())
+	}
+	return "invalid manifest:\n" + strings.Join(msgs, "\n")
+}
+
+// orNil returns the problems as an error, nil if there are none.
+func (p Problems) orNil() error {
+	if len(p) == 0 {
+		return nil
+	}
+	return p
+}
+
+// validator collects the problems of a plan, checking each entry on its own and for duplicates.
+type validator struct {
+	problems Problems
+	names    map[string]string
+	ains     map[string]string
+}
+
+// check validates a plan decoded from a single file: duplicate names or AINs, temperatures and settings that the
+// FRITZ!Box does not accept, negative stages and unknown policies for unmanaged devices.
+func (plan *Plan) check() Problems {
+	v := &validator{names: make(map[string]string), ains: make(map[string]string)}
+	switch plan.Unmanaged {
+	case "", FailUnmanaged, WarnUnmanaged, OffUnmanaged:
+	default:
+		v.add("", "", fmt.Errorf("unknown policy '%s' for unmanaged devices, expected one of %s, %s, %s", plan.Unmanaged, FailUnmanaged, WarnUnmanaged, OffUnmanaged))
+	}
+	for _, s := range plan.Switches {
+		v.device("switch", s.Name, s.AIN)
+		v.ordering("switch", s.Name, s.Ordering)
+	}
+	for _, t := range plan.Thermostats {
+		v.device("thermostat", t.Name, t.AIN)
+		v.ordering("thermostat", t.Name, t.Ordering)
+		if t.Temperature != nil {
+			v.add("thermostat", t.Name, fritz.ValidateTemperature(*t.Temperature))
+		}
+		v.add("thermostat", t.Name, fritz.ThermostatSettings{Comfort: t.Comfort, Saving: t.Saving, Offset: t.Offset}.Validate())
+		if t.Schedule != nil {
+			v.add("thermostat", t.Name, t.Schedule.Validate())
+		}
+	}
+	for _, g := range plan.Groups {
+		v.device("group", g.Name, "")
+		v.ordering("group", g.Name, g.Ordering)
+		if g.Temperature != nil {
+			v.add("group", g.Name, fritz.ValidateTemperature(*g.Temperature))
+		}
+	}
+	for _, b := range plan.Bulbs {
+		v.device("bulb", b.Name, b.AIN)
+		v.ordering("bulb", b.Name, b.Ordering)
+		v.add("bulb", b.Name, fritz.LightSettings{State: b.State, Level: b.Level, Hue: b.Hue, Saturation: b.Saturation, ColorTemperature: b.ColorTemperature}.Validate())
+	}
+	for i, r := range plan.Rules {
+		v.rule(r.describe(i), r)
+	}
+	return v.problems
+}
+
+// rule checks the conditions and entries of a rule. The entries are checked on their own, they may refer to the same
+// devices as the entries of the manifest.
+func (v *validator) rule(name string, r Rule) {
+	if len(r.When) == 0 {
+		v.add("", "", fmt.Errorf("%s: no conditions", name))
+	}
+	for i, c := range r.When {
+		if err := c.check(); err != nil {
+			v.add("", "", fmt.Errorf("%s: condition %d: %v", name, i+1, err))
+		}
+	}
+	if len(r.Then.Rules) > 0 {
+		v.add("", "", fmt.Errorf("%s: rules cannot be nested", name))
+	}
+	if r.Then.Unmanaged != "" || r.Then.StageDelay != 0 {
+		v.add("", "", fmt.Errorf("%s: unmanaged and stage_delay apply to the whole manifest", name))
+	}
+	then := r.Then
+	then.Rules = nil
+	for _, p := range then.check() {
+		v.add("", "", fmt.Errorf("%s: %v", name, p))
+	}
+}
+
+func (v *validator) device(kind, name, ain string) {
+	if name == "" {
+		v.add(kind, name, fmt.Errorf("name missing"))
+	}
+	if other, ok := v.names[name]; ok && name != "" {
+		v.add(kind, name, fmt.Errorf("duplicate name, also declared as %s", other))
+	}
+	v.names[name] = kind
+	key := strings.Replace(ain, " ", "", -1)
+	if other, ok := v.ains[key]; ok && key != "" {
+		v.add(kind, name, fmt.Errorf("duplicate AIN '%s', also used by '%s'", ain, other))
+	}
+	v.ains[key] = name
+}
+
+func (v *validator) ordering(kind, name string, o Ordering) {
+	if o.Stage < 0 {
+		v.add(kind, name, fmt.Errorf("invalid stage %d, stages start at 0", o.Stage))
+	}
+	for _, d := range o.DependsOn {
+		if d == name {
+			v.add(kind, name, fmt.Errorf("depends on itself"))
+		}
+	}
+}
+
+func (v *validator) add(kind, name string, err error) {
+	if err == nil {
+		return
+	}
+	if kind != "" {
+		err = fmt.Errorf("%s '%s': %v", kind, name, err)
+	}
+	v.problems = append(v.problems, err)
+}
+
+// check validates a condition on its own: it has to name a device and at least one predicate.
+func (c Condition) check() error {
+	if c.Device == "" && c.AIN == "" {
+		return fmt.Errorf("device missing")
+	}
+	if c.State == nil && c.Present == nil && c.Alert == nil && c.Temperature == nil && c.Power == nil {
+		return fmt.Errorf("no predicate, expected one of state, present, alert, temperature, power")
+	}
+	for _, r := range []*Range{c.Temperature, c.Power} {
+		if err := r.check(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Range) check() error {
+	if r == nil {
+		return nil
+	}
+	if r.Above == nil && r.Below == nil {
+		return fmt.Errorf("range without bounds, expected above or below")
+	}
+	if r.Above != nil && r.Below != nil && *r.Above >= *r.Below {
+		return fmt.Errorf("empty range, nothing is %s", r.format(""))
+	}
+	return nil
+}
+
+// Validate checks the target plan against the live state of the devices: every entry has to refer to an existing
+// device of the declared kind, e.g. a switch entry must not point at a thermostat. This applies to the entries of the
+// rules as well. It returns Problems, or nil if there are none.
+func Validate(target, live *Plan) error {
+	var problems Problems
+	for _, s := range target.Switches {
+		if _, ok := live.switchFor(s); !ok {
+			problems = append(problems, missing(live, "switch", s.Name, s.AIN))
+		}
+	}
+	for _, t := range target.Thermostats {
+		if _, ok := live.thermostatFor(t); !ok {
+			problems = append(problems, missing(live, "thermostat", t.Name, t.AIN))
+		}
+	}
+	for _, g := range target.Groups {
+		if _, ok := live.groupNamed(g.Name); !ok {
+			problems = append(problems, missing(live, "group", g.Name, ""))
+		}
+	}
+	for _, b := range target.Bulbs {
+		if _, ok := live.bulbFor(b); !ok {
+			problems = append(problems, missing(live, "bulb", b.Name, b.AIN))
+		}
+	}
+	for i, r := range target.Rules {
+		if err := Validate(&r.Then, live); err != nil {
+			for _, p := range err.(Problems) {
+				problems = append(problems, fmt.Errorf("%s: %v", r.describe(i), p))
+			}
+		}
+	}
+	return problems.orNil()
+}
+
+// missing describes an entry that refers to no device of its kind, naming the kind of device it refers to instead.
+func missing(live *Plan, kind, name, ain string) error {
+	if actual := live.kindOf(name, ain); actual != "" {
+		return fmt.Errorf("%s %s: the device is a %s", kind, describe(ain, name), actual)
+	}
+	return fmt.Errorf("%s %s: no such device", kind, describe(ain, name))
+}
+
+// kindOf returns the kind of the device the entry with the given name and AIN refers to, or "" if there is none.
+func (plan *Plan) kindOf(name, ain string) string {
+	if _, ok := plan.switchFor(Switch{Name: name, AIN: ain}); ok {
+		return "switch"
+	}
+	if _, ok := plan.thermostatFor(Thermostat{Name: name, AIN: ain}); ok {
+		return "thermostat"
+	}
+	if _, ok := plan.bulbFor(Bulb{Name: name, AIN: ain}); ok {
+		return "bulb"
+	}
+	if _, ok := plan.groupNamed(name); ok && ain == "" {
+		return "group"
+	}
+	return ""
+}
@@ -0,0 +1,100 @@
+package manifest
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseStrict tests that unknown fields and values of the wrong type are rejected.
+func TestParseStrict(t *testing.T) {
+	_, err := ParseFile("../testdata/invalid/typo_manifest.yml")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "../testdata/invalid/typo_manifest.yml:5: field temprature not found")
+}
+
+// TestParseSemanticChecks tests that duplicates and values the FRITZ!Box does not accept are rejected.
+func TestParseSemanticChecks(t *testing.T) {
+	_, err := ParseFile("../testdata/invalid/semantic_manifest.yml")
+	assert.Error(t, err)
+	problems, ok := err.(Problems)
+	assert.True(t, ok)
+	assert.Len(t, problems, 3)
+	assert.Contains(t, err.Error(), "switch 'SWITCH_1': duplicate name")
+	assert.Contains(t, err.Error(), "thermostat 'HKR_1': invalid temperature value: 30.0°C")
+	assert.Contains(t, err.Error(), "thermostat 'HKR_1': invalid comfort temperature: invalid temperature value: 35.0°C")
+}
+
+// TestCheck tests the checks of single plans.
+func TestCheck(t *testing.T) {
+	level, hue, temperature := 50, 120, 35.0
+	for _, p := range []*Plan{
+		{Unmanaged: "ignore"},
+		{Switches: []Switch{{Name: ""}}},
+		{Switches: []Switch{{Name: "a", AIN: "123 45"}}, Thermostats: []Thermostat{{Name: "b", AIN: "12345", Temperature: floatPtr(20)}}},
+		{Switches: []Switch{{Name: "a"}}, Groups: []Group{{Name: "a"}}},
+		{Groups: []Group{{Name: "g", Temperature: &temperature}}},
+		{Bulbs: []Bulb{{Name: "b", Level: &level, Hue: &hue}}},
+	} {
+		assert.Len(t, p.check(), 1, "%+v", p)
+	}
+	assert.Empty(t, (&Plan{Unmanaged: OffUnmanaged, Thermostats: []Thermostat{{Name: "t", Temperature: floatPtr(126.5)}}}).check())
+}
+
+// TestValidate tests the checks against the live state.
+func TestValidate(t *testing.T) {
+	live := &Plan{
+		Switches:    []Switch{{Name: "s", AIN: "1"}},
+		Thermostats: []Thermostat{{Name: "t", AIN: "2"}},
+		Groups:      []Group{{Name: "g"}},
+		Bulbs:       []Bulb{{Name: "b", AIN: "3"}},
+	}
+	assert.NoError(t, Validate(&Plan{Switches: []Switch{{Name: "x", AIN: "1"}}, Thermostats: []Thermostat{{Name: "t"}}, Groups: []Group{{Name: "g"}}, Bulbs: []Bulb{{Name: "b"}}}, live))
+	err := Validate(&Plan{
+		Switches:    []Switch{{Name: "t"}},
+		Thermostats: []Thermostat{{Name: "x", AIN: "3"}},
+		Groups:      []Group{{Name: "s"}},
+		Bulbs:       []Bulb{{Name: "unknown"}},
+	}, live)
+	assert.Error(t, err)
+	assert.Len(t, err.(Problems), 4)
+	assert.Contains(t, err.Error(), "switch 't': the device is a thermostat")
+	assert.Contains(t, err.Error(), "thermostat 'x' (AIN '3'): the device is a bulb")
+	assert.Contains(t, err.Error(), "group 's': the device is a switch")
+	assert.Contains(t, err.Error(), "bulb 'unknown': no such device")
+}
+
+// TestSchema tests that the schema is valid JSON.
+func TestSchema(t *testing.T) {
+	var s map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(Schema), &s))
+	assert.Contains(t, s["properties"], "thermostats")
+}
+
+// TestCheckRules tests the checks of the rules of a plan.
+func TestCheckRules(t *testing.T) {
+	on, temperature := true, 35.0
+	for _, r := range []Rule{
+		{Then: Plan{Switches: []Switch{{Name: "a"}}}},
+		{When: []Condition{{Alert: &on}}},
+		{When: []Condition{{Device: "a"}}},
+		{When: []Condition{{Device: "a", Power: &Range{}}}},
+		{When: []Condition{{Device: "a", Temperature: &Range{Above: floatPtr(22), Below: floatPtr(18)}}}},
+		{When: []Condition{{Device: "a", State: &on}}, Then: Plan{Rules: []Rule{{}}}},
+		{When: []Condition{{Device: "a", State: &on}}, Then: Plan{Unmanaged: OffUnmanaged}},
+		{When: []Condition{{Device: "a", State: &on}}, Then: Plan{Groups: []Group{{Name: "g", Temperature: &temperature}}}},
+	} {
+		assert.Len(t, (&Plan{Rules: []Rule{r}}).check(), 1, "%+v", r)
+	}
+	p := &Plan{
+		Switches: []Switch{{Name: "a"}},
+		Rules:    []Rule{{Name: "r", When: []Condition{{Device: "b", Present: &on}}, Then: Plan{Switches: []Switch{{Name: "a", State: true}}}}},
+	}
+	assert.Empty(t, p.check())
+	err := Validate(p, &Plan{Switches: []Switch{{Name: "a"}}, Thermostats: []Thermostat{{Name: "t"}}})
+	assert.NoError(t, err)
+	p.Rules[0].Then.Switches[0].Name = "t"
+	err = Validate(p, &Plan{Switches: []Switch{{Name: "a"}}, Thermostats: []Thermostat{{Name: "t"}}})
+	assert.EqualError(t, err, "invalid manifest:\nrule 'r': switch 't': the device is a thermostat")
+}
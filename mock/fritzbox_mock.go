@@ -16,11 +16,13 @@ type Fritz struct {
 	LoginChallengeResponse string
 	LoginResponse          string
 	DeviceList             string
+	DeviceStats            string
 	Logs                   string
 	LanDevices             string
 	InetStats              string
 	PhoneCalls             string
 	SystemStatus           string
+	HkrSettings            string
 	Server                 *httptest.Server
 }
 
@@ -32,11 +34,13 @@ func New() *Fritz {
 		LoginChallengeResponse: "../mock/login_challenge.xml",
 		LoginResponse:          "../mock/login_response_success.xml",
 		DeviceList:             "../mock/devicelist.xml",
+		DeviceStats:            "../mock/devicestats.xml",
 		Logs:                   "../mock/logs.json",
 		LanDevices:             "../mock/landevices.json",
 		InetStats:              "../mock/traffic.json",
 		PhoneCalls:             "../mock/calls.csv",
 		SystemStatus:           "../mock/system_status.html",
+		HkrSettings:            "../mock/hkr_settings.json",
 	}
 }
 
@@ -67,6 +71,7 @@ func (f *Fritz) fritzRoutes() *httprouter.Router {
 	router.GET("/internet/inetstat_monitor.lua", f.inetStatHandler)
 	router.GET("/fon_num/foncalls_list.lua", f.phoneCallsHandler)
 	router.GET("/cgi-bin/system_status", f.systemStatusHandler)
+	router.POST("/data.lua", f.dataHandler)
 	return router
 }
 
@@ -85,13 +90,15 @@ func (f *Fritz) homeAutoHandler(w http.ResponseWriter, r *http.Request, ps httpr
 	switch r.URL.Query().Get("switchcmd") {
 	case "getdevicelistinfos":
 		f.writeFromFs(w, f.DeviceList)
+	case "getbasicdevicestats":
+		f.writeFromFs(w, f.DeviceStats)
 	case "setswitchon":
 		w.Write([]byte("1"))
 	case "setswitchoff":
 		w.Write([]byte("0"))
 	case "setswitchtoggle":
 		w.Write([]byte("1"))
-	case "sethkrtsoll":
+	case "sethkrtsoll", "setsimpleonoff", "setlevelpercentage", "setcolor", "setcolortemperature":
 		w.Write([]byte("OK"))
 	}
 }
@@ -136,3 +143,20 @@ func (f *Fritz) phoneCallsHandler(w http.ResponseWriter, r *http.Request, ps htt
 func (f *Fritz) systemStatusHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	f.writeFromFs(w, f.SystemStatus)
 }
+
+func (f *Fritz) dataHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	page := r.FormValue("page")
+	if (page != "home_auto_hkr_edit" && page != "home_auto_edit_view") || strings.Contains(strings.ToLower(r.FormValue("device")), "fail") {
+		http.Error(w, "Page not available.", 500)
+		return
+	}
+	if _, ok := r.PostForm["apply"]; ok {
+		w.Write([]byte(`{"data":{"apply":"ok"}}`))
+		return
+	}
+	if page == "home_auto_edit_view" {
+		w.Write([]byte(`{"pid":"home_auto_edit_view","data":{"device":"` + r.FormValue("device") + `","ule_device_name":"device"}}`))
+		return
+	}
+	f.writeFromFs(w, f.HkrSettings)
+}
@@ -0,0 +1,4 @@
+// Package scene captures the state of smart home devices as named scenes and stores them client-side. A scene is a
+// manifest, see package manifest, in a directory of YAML files: it can be put under version control, edited and
+// applied like any other manifest.
+package scene
@@ -0,0 +1,66 @@
+package scene
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/bpicode/fritzctl/fritz"
+	"github.com/bpicode/fritzctl/manifest"
+)
+
+// Capture converts the state of the devices to a scene: the states of the switches, the goal temperatures of the
+// thermostats and the settings of the lights, addressed by AIN. Locks, thermostat settings and groups are not part of
+// a scene. Devices which are not connected and thermostats without a known goal temperature are skipped. If names are
+// given, only these devices are captured, it is an error if any of them does not exist or cannot be captured.
+func Capture(l *fritz.Devicelist, names ...string) (*manifest.Plan, error) {
+	capturable := &fritz.Devicelist{Groups: l.Groups}
+	skipped := make(map[string]bool)
+	for _, d := range l.Devices {
+		if d.Present != 1 || d.IsThermostat() && !hasGoal(d) {
+			skipped[d.Name] = true
+			continue
+		}
+		capturable.Devices = append(capturable.Devices, d)
+	}
+	all := manifest.ConvertDevicelist(capturable)
+	selected := make(map[string]bool)
+	for _, n := range names {
+		selected[n] = true
+	}
+	captures := func(name string) bool {
+		found := selected[name]
+		delete(selected, name)
+		return len(names) == 0 || found
+	}
+	p := &manifest.Plan{Switches: []manifest.Switch{}, Thermostats: []manifest.Thermostat{}}
+	for _, s := range all.Switches {
+		if captures(s.Name) {
+			p.Switches = append(p.Switches, manifest.Switch{Name: s.Name, AIN: s.AIN, State: s.State})
+		}
+	}
+	for _, t := range all.Thermostats {
+		if captures(t.Name) {
+			p.Thermostats = append(p.Thermostats, manifest.Thermostat{Name: t.Name, AIN: t.AIN, Temperature: t.Temperature})
+		}
+	}
+	for _, b := range all.Bulbs {
+		if captures(b.Name) {
+			p.Bulbs = append(p.Bulbs, b)
+		}
+	}
+	for _, n := range names {
+		if selected[n] && skipped[n] {
+			return nil, fmt.Errorf("device '%s' is not connected or its state is unknown", n)
+		}
+		if selected[n] {
+			return nil, fmt.Errorf("no switch, thermostat or light named '%s'", n)
+		}
+	}
+	return p, nil
+}
+
+// hasGoal reports whether the goal temperature of the thermostat is known.
+func hasGoal(d fritz.Device) bool {
+	_, err := strconv.ParseFloat(d.Thermostat.Goal, 64)
+	return err == nil
+}
@@ -0,0 +1,67 @@
+package scene
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/bpicode/fritzctl/fritz"
+	"github.com/bpicode/fritzctl/manifest"
+	"github.com/stretchr/testify/assert"
+)
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+var devicelist = &fritz.Devicelist{Devices: []fritz.Device{
+	{Name: "Lamp", Identifier: "111", Present: 1, Functionbitmask: "2944", Switch: fritz.Switch{State: "1", Lock: "1"}},
+	{Name: "HKR", Identifier: "222", Present: 1, Functionbitmask: "320", Thermostat: fritz.Thermostat{Goal: "42", Comfort: "44", Saving: "32"}},
+	{Name: "Bulb", Identifier: "333", Present: 1, Functionbitmask: "237572", OnOff: fritz.OnOff{State: "1"}, Level: fritz.Level{LevelPercentage: "40"}},
+	{Name: "Window", Identifier: "444", Present: 1, Functionbitmask: "16"},
+	{Name: "Cellar", Identifier: "555", Present: 0, Functionbitmask: "320", Thermostat: fritz.Thermostat{Goal: ""}},
+	{Name: "Attic", Identifier: "666", Present: 1, Functionbitmask: "320"},
+	{Name: "Garden", Identifier: "777", Present: 0, Functionbitmask: "2944", Switch: fritz.Switch{State: ""}},
+}}
+
+// TestCapture tests capturing all devices.
+func TestCapture(t *testing.T) {
+	p, err := Capture(devicelist)
+	assert.NoError(t, err)
+	assert.Equal(t, []manifest.Switch{{Name: "Lamp", AIN: "111", State: true}}, p.Switches)
+	assert.Equal(t, []manifest.Thermostat{{Name: "HKR", AIN: "222", Temperature: floatPtr(21)}}, p.Thermostats)
+	assert.Len(t, p.Bulbs, 1)
+	assert.Equal(t, "333", p.Bulbs[0].AIN)
+	assert.Equal(t, 40, *p.Bulbs[0].Level)
+	assert.Empty(t, p.Groups)
+}
+
+// TestCaptureDevices tests capturing selected devices.
+func TestCaptureDevices(t *testing.T) {
+	p, err := Capture(devicelist, "HKR")
+	assert.NoError(t, err)
+	assert.Empty(t, p.Switches)
+	assert.Len(t, p.Thermostats, 1)
+	assert.Empty(t, p.Bulbs)
+
+	_, err = Capture(devicelist, "HKR", "Window", "Door")
+	assert.EqualError(t, err, "no switch, thermostat or light named 'Window'")
+	_, err = Capture(devicelist, "Cellar")
+	assert.EqualError(t, err, "device 'Cellar' is not connected or its state is unknown")
+}
+
+// TestCaptureDisconnected tests that captured scenes of disconnected devices can be loaded again.
+func TestCaptureDisconnected(t *testing.T) {
+	p, err := Capture(devicelist)
+	assert.NoError(t, err)
+	assert.Equal(t, []manifest.Thermostat{{Name: "HKR", AIN: "222", Temperature: floatPtr(21)}}, p.Thermostats, "disconnected thermostats and unknown goals are skipped")
+	assert.Len(t, p.Switches, 1)
+
+	dir, err := ioutil.TempDir("", "scenes")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	s := NewStore(dir)
+	assert.NoError(t, s.Save("all", p, false))
+	_, err = s.Load("all")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,128 @@
+package scene
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bpicode/fritzctl/manifest"
+)
+
+// Store keeps scenes as YAML manifests in a directory, one file <name>.yml per scene.
+type Store struct {
+	dir string
+}
+
+// Info describes a stored scene.
+type Info struct {
+	Name    string    // The name of the scene.
+	Saved   time.Time // When the scene was last saved.
+	Devices int       // The number of devices in the scene.
+	Err     error     // Why the scene cannot be loaded, nil if it can.
+}
+
+// NewStore creates a Store in the given directory. The directory is created when the first scene is saved.
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+var validName = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9_.-]*$`)
+
+func (s *Store) filename(name string) (string, error) {
+	if !validName.MatchString(name) {
+		return "", fmt.Errorf("invalid scene name '%s', use letters, digits, '_', '-' and '.'", name)
+	}
+	return filepath.Join(s.dir, name+".yml"), nil
+}
+
+// Save stores the scene under the given name. An existing scene is only replaced if overwrite is set.
+func (s *Store) Save(name string, p *manifest.Plan, overwrite bool) error {
+	filename, err := s.filename(name)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(filename); err == nil && !overwrite {
+		return fmt.Errorf("scene '%s' exists already", name)
+	}
+	var buf bytes.Buffer
+	if err := manifest.ExporterTo(&buf).Export(p); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, buf.Bytes(), 0644)
+}
+
+// Load reads the scene with the given name. Scenes are manifests, so they may have been edited to use includes or
+// variables.
+func (s *Store) Load(name string) (*manifest.Plan, error) {
+	filename, err := s.exists(name)
+	if err != nil {
+		return nil, err
+	}
+	return manifest.ParseFile(filename)
+}
+
+// Read returns the content of the file of the scene with the given name.
+func (s *Store) Read(name string) ([]byte, error) {
+	filename, err := s.exists(name)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadFile(filename)
+}
+
+// Delete removes the scene with the given name.
+func (s *Store) Delete(name string) error {
+	filename, err := s.exists(name)
+	if err != nil {
+		return err
+	}
+	return os.Remove(filename)
+}
+
+func (s *Store) exists(name string) (string, error) {
+	filename, err := s.filename(name)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(filename); os.IsNotExist(err) {
+		return "", fmt.Errorf("no scene named '%s'", name)
+	}
+	return filename, nil
+}
+
+// List describes the stored scenes, sorted by name. A missing directory contains no scenes. Scenes that cannot be loaded
+// are listed with the reason in Info.Err.
+func (s *Store) List() ([]Info, error) {
+	files, err := ioutil.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return []Info{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	infos := []Info{}
+	for _, f := range files {
+		name := strings.TrimSuffix(f.Name(), ".yml")
+		if f.IsDir() || name == f.Name() || !validName.MatchString(name) {
+			continue
+		}
+		info := Info{Name: name, Saved: f.ModTime()}
+		if p, err := s.Load(name); err != nil {
+			info.Err = err
+		} else {
+			info.Devices = len(p.Switches) + len(p.Thermostats) + len(p.Groups) + len(p.Bulbs)
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos, nil
+}
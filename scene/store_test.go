@@ -0,0 +1,66 @@
+package scene
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bpicode/fritzctl/manifest"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestStore tests saving, listing, loading and deleting scenes.
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "scenes")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	s := NewStore(filepath.Join(dir, "scenes"))
+
+	infos, err := s.List()
+	assert.NoError(t, err)
+	assert.Empty(t, infos)
+
+	evening := &manifest.Plan{Switches: []manifest.Switch{{Name: "Lamp", AIN: "111", State: true}}, Thermostats: []manifest.Thermostat{{Name: "HKR", Temperature: floatPtr(21)}}}
+	assert.NoError(t, s.Save("evening", evening, false))
+	assert.NoError(t, s.Save("away", &manifest.Plan{Switches: []manifest.Switch{{Name: "Lamp"}}}, false))
+	assert.Error(t, s.Save("evening", evening, false))
+	assert.NoError(t, s.Save("evening", evening, true))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "scenes", "notes.txt"), []byte("not a scene"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "scenes", "broken.yml"), []byte("thermostats: [{name: HKR, temperature: 0}]"), 0644))
+
+	infos, err = s.List()
+	assert.NoError(t, err)
+	assert.Len(t, infos, 3)
+	assert.Equal(t, "away", infos[0].Name)
+	assert.Equal(t, 1, infos[0].Devices)
+	assert.Equal(t, "broken", infos[1].Name)
+	assert.Error(t, infos[1].Err, "broken scenes are listed")
+	assert.Equal(t, "evening", infos[2].Name)
+	assert.Equal(t, 2, infos[2].Devices)
+	assert.NoError(t, infos[2].Err)
+	assert.False(t, infos[2].Saved.IsZero())
+
+	p, err := s.Load("evening")
+	assert.NoError(t, err)
+	assert.Equal(t, evening.Switches, p.Switches)
+	assert.Equal(t, evening.Thermostats, p.Thermostats)
+	bs, err := s.Read("evening")
+	assert.NoError(t, err)
+	assert.Contains(t, string(bs), "name: Lamp")
+
+	assert.NoError(t, s.Delete("evening"))
+	_, err = s.Load("evening")
+	assert.EqualError(t, err, "no scene named 'evening'")
+	assert.Error(t, s.Delete("evening"))
+}
+
+// TestStoreInvalidNames tests that names which are no plain file names are rejected.
+func TestStoreInvalidNames(t *testing.T) {
+	s := NewStore(os.TempDir())
+	for _, name := range []string{"", "../evening", ".hidden", "a/b", "a b"} {
+		assert.Error(t, s.Save(name, &manifest.Plan{}, true), name)
+		_, err := s.Load(name)
+		assert.Error(t, err, name)
+	}
+}
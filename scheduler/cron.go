@@ -0,0 +1,182 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule determines when a rule is triggered.
+type Schedule interface {
+	// Next returns the first trigger time strictly after t. The zero time is returned if there is none within the next
+	// years.
+	Next(t time.Time) time.Time
+}
+
+// Cron is a schedule given by a cron expression with the five fields minute, hour, day of month, month and day of
+// week. Fields may contain lists, ranges, steps and "*". Months and days of week may also be given by their English
+// three-letter abbreviations. Unlike cron(8), ranges may wrap around, e.g. "fri-mon" or "22-2" in the hour field.
+// If both day of month and day of week are restricted, a day matching either one is selected, as in Vixie cron. A
+// field starting with "*", e.g. "*/2", or covering all values, e.g. "1-31", is not restricted.
+type Cron struct {
+	minute, hour, dom, month, dow uint64
+	domStar, dowStar              bool
+}
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var monthNames = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+
+var dayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// ParseCron parses a cron expression.
+func ParseCron(expr string) (*Cron, error) {
+	if macro, ok := cronMacros[strings.TrimSpace(expr)]; ok {
+		expr = macro
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression '%s' has %d fields, expected 5", expr, len(fields))
+	}
+	var c Cron
+	var err error
+	parsers := []struct {
+		target *uint64
+		field  cronField
+	}{
+		{target: &c.minute, field: cronField{min: 0, max: 59, period: 60}},
+		{target: &c.hour, field: cronField{min: 0, max: 23, period: 24}},
+		{target: &c.dom, field: cronField{min: 1, max: 31, period: 31}},
+		{target: &c.month, field: cronField{min: 1, max: 12, period: 12, names: monthNames, offset: 1}},
+		{target: &c.dow, field: cronField{min: 0, max: 7, period: 7, names: dayNames}},
+	}
+	for i, p := range parsers {
+		*p.target, err = p.field.parse(fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("cron expression '%s': %v", expr, err)
+		}
+	}
+	c.domStar = strings.HasPrefix(fields[2], "*") || c.dom == parsers[2].field.all()
+	c.dowStar = strings.HasPrefix(fields[4], "*") || c.dow == parsers[4].field.all()
+	return &c, nil
+}
+
+// cronField describes the values of a field of a cron expression. Values beyond the period are folded back, e.g. 7 in
+// the day of week field is Sunday, like 0.
+type cronField struct {
+	min, max, period int
+	names            []string
+	offset           int
+}
+
+func (f cronField) parse(field string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			s, err := strconv.Atoi(part[i+1:])
+			if err != nil || s <= 0 {
+				return 0, fmt.Errorf("invalid step in '%s'", part)
+			}
+			rng, step = part[:i], s
+		}
+		lo, hi, err := f.bounds(rng, step)
+		if err != nil {
+			return 0, err
+		}
+		if lo < f.min || hi > f.max {
+			return 0, fmt.Errorf("'%s' is out of range %d-%d", part, f.min, f.max)
+		}
+		if hi < lo {
+			hi += f.period
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(f.min+(v-f.min)%f.period)
+		}
+	}
+	return bits, nil
+}
+
+// bounds returns the first and the last value of a range. The last value is less than the first if the range wraps
+// around.
+func (f cronField) bounds(rng string, step int) (int, int, error) {
+	if rng == "*" {
+		return f.min, f.max, nil
+	}
+	bounds := strings.SplitN(rng, "-", 2)
+	lo, err := cronValue(bounds[0], f.names, f.offset)
+	if err != nil {
+		return 0, 0, err
+	}
+	switch {
+	case len(bounds) == 2:
+		hi, err := cronValue(bounds[1], f.names, f.offset)
+		return lo, hi, err
+	case step > 1:
+		return lo, f.max, nil
+	default:
+		return lo, lo, nil
+	}
+}
+
+// all returns the bits of all values of the field.
+func (f cronField) all() uint64 {
+	var bits uint64
+	for v := f.min; v < f.min+f.period; v++ {
+		bits |= 1 << uint(v)
+	}
+	return bits
+}
+
+func cronValue(s string, names []string, offset int) (int, error) {
+	for i, n := range names {
+		if strings.EqualFold(s, n) {
+			return i + offset, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value '%s'", s)
+	}
+	return v, nil
+}
+
+// Next returns the first minute strictly after t matching the expression.
+func (c *Cron) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, loc).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (c *Cron) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if !c.domStar && !c.dowStar {
+		return dom || dow
+	}
+	return dom && dow
+}
@@ -0,0 +1,53 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCronNext tests the next trigger times of various expressions.
+func TestCronNext(t *testing.T) {
+	from := time.Date(2020, 1, 31, 10, 30, 15, 0, time.UTC) // A Friday.
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{expr: "* * * * *", want: time.Date(2020, 1, 31, 10, 31, 0, 0, time.UTC)},
+		{expr: "30 10 * * *", want: time.Date(2020, 2, 1, 10, 30, 0, 0, time.UTC)},
+		{expr: "*/15 * * * *", want: time.Date(2020, 1, 31, 10, 45, 0, 0, time.UTC)},
+		{expr: "0 6-8 * * mon-fri", want: time.Date(2020, 2, 3, 6, 0, 0, 0, time.UTC)},
+		{expr: "0 0 29 feb *", want: time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 12 1 * sun", want: time.Date(2020, 2, 1, 12, 0, 0, 0, time.UTC)},
+		{expr: "0 12 * * 7", want: time.Date(2020, 2, 2, 12, 0, 0, 0, time.UTC)},
+		{expr: "5,10 11 * * *", want: time.Date(2020, 1, 31, 11, 5, 0, 0, time.UTC)},
+		{expr: "@monthly", want: time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 31 2 *", want: time.Time{}},
+		{expr: "0 12 * * fri-mon", want: time.Date(2020, 1, 31, 12, 0, 0, 0, time.UTC)},
+		{expr: "0 12 * * sat-mon", want: time.Date(2020, 2, 1, 12, 0, 0, 0, time.UTC)},
+		{expr: "0 23-1 * * *", want: time.Date(2020, 1, 31, 23, 0, 0, 0, time.UTC)},
+		{expr: "0 0 * nov-feb *", want: time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 12 */1 * mon", want: time.Date(2020, 2, 3, 12, 0, 0, 0, time.UTC)},
+		{expr: "0 12 1-31 * mon", want: time.Date(2020, 2, 3, 12, 0, 0, 0, time.UTC)},
+		{expr: "0 12 15 * 0-6", want: time.Date(2020, 2, 15, 12, 0, 0, 0, time.UTC)},
+		{expr: "0 12 15 * mon", want: time.Date(2020, 2, 3, 12, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range tests {
+		t.Run(tc.expr, func(t *testing.T) {
+			c, err := ParseCron(tc.expr)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, c.Next(from))
+		})
+	}
+}
+
+// TestParseCronInvalid tests malformed expressions.
+func TestParseCronInvalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "*/0 * * * *", "5- * * * *", "x * * * *", "* * * * fri-xyz"} {
+		t.Run(expr, func(t *testing.T) {
+			_, err := ParseCron(expr)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,3 @@
+// Package scheduler executes time-based rules on AHA devices. Rules are triggered by cron expressions or by sunrise and
+// sunset, which are computed offline from the configured coordinates.
+package scheduler
@@ -0,0 +1,138 @@
+package scheduler
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bpicode/fritzctl/fritz"
+	"gopkg.in/yaml.v2"
+)
+
+// Rules is the content of a rules file.
+type Rules struct {
+	Location *Location     // Coordinates used for sunrise and sunset, required if any rule uses them.
+	Catchup  time.Duration // Runs missed at most this long ago, e.g. during downtime, are executed late.
+	Rules    []Rule        // The rules.
+}
+
+// Location is a geographic position.
+type Location struct {
+	Latitude  float64 // In degrees, north is positive.
+	Longitude float64 // In degrees, east is positive.
+}
+
+// Rule triggers an action on devices according to a schedule.
+type Rule struct {
+	Name        string        // Unique name of the rule, used to record the last run.
+	Cron        string        // Cron expression, exclusive with Sun.
+	Sun         string        // "sunrise" or "sunset", exclusive with Cron.
+	Offset      time.Duration // Shift relative to sunrise or sunset, may be negative.
+	Action      string        // One of "on", "off", "toggle" or "temperature".
+	Temperature float64       // The temperature in °C, only for the "temperature" action.
+	Devices     []string      // Names of the devices.
+}
+
+// ParseFile reads the rules from a YAML file.
+func ParseFile(filename string) (*Rules, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return Parse(file)
+}
+
+// Parse reads the rules from YAML and validates them.
+func Parse(r io.Reader) (*Rules, error) {
+	bytes, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var rules Rules
+	if err := yaml.Unmarshal(bytes, &rules); err != nil {
+		return nil, err
+	}
+	return &rules, rules.validate()
+}
+
+func (rs *Rules) validate() error {
+	names := make(map[string]bool)
+	for i, r := range rs.Rules {
+		if r.Name == "" {
+			return fmt.Errorf("rule #%d has no name", i+1)
+		}
+		if names[r.Name] {
+			return fmt.Errorf("rule name '%s' is not unique", r.Name)
+		}
+		names[r.Name] = true
+		if _, err := r.schedule(rs.Location); err != nil {
+			return fmt.Errorf("rule '%s': %v", r.Name, err)
+		}
+		if _, ok := actions[r.Action]; !ok {
+			return fmt.Errorf("rule '%s': unknown action '%s', expected one of on, off, toggle, temperature", r.Name, r.Action)
+		}
+		if r.Action == "temperature" {
+			if err := fritz.ValidateTemperature(r.Temperature); err != nil {
+				return fmt.Errorf("rule '%s': %v", r.Name, err)
+			}
+		}
+		if len(r.Devices) == 0 {
+			return fmt.Errorf("rule '%s' has no devices", r.Name)
+		}
+	}
+	return nil
+}
+
+func (r Rule) schedule(loc *Location) (Schedule, error) {
+	switch {
+	case r.Cron != "" && r.Sun != "":
+		return nil, fmt.Errorf("cron and sun are mutually exclusive")
+	case r.Cron != "":
+		return ParseCron(r.Cron)
+	case r.Sun != "sunrise" && r.Sun != "sunset":
+		return nil, fmt.Errorf("either cron or sun (sunrise, sunset) is required")
+	case loc == nil:
+		return nil, fmt.Errorf("%s requires a location", r.Sun)
+	}
+	return &Sun{Sunset: r.Sun == "sunset", Offset: r.Offset, Latitude: loc.Latitude, Longitude: loc.Longitude}, nil
+}
+
+var actions = map[string]func(r Rule, h fritz.HomeAuto) error{
+	"on":          func(r Rule, h fritz.HomeAuto) error { return h.On(r.Devices...) },
+	"off":         func(r Rule, h fritz.HomeAuto) error { return h.Off(r.Devices...) },
+	"toggle":      func(r Rule, h fritz.HomeAuto) error { return h.Toggle(r.Devices...) },
+	"temperature": func(r Rule, h fritz.HomeAuto) error { return h.Temp(r.Temperature, r.Devices...) },
+}
+
+// Apply performs the action of the rule.
+func (r Rule) Apply(h fritz.HomeAuto) error {
+	return actions[r.Action](r, h)
+}
+
+// Repeatable reports whether applying the rule again after a partial failure has the intended effect, which is not the
+// case for toggling.
+func (r Rule) Repeatable() bool {
+	return r.Action != "toggle"
+}
+
+// When describes the schedule of the rule.
+func (r Rule) When() string {
+	if r.Cron != "" {
+		return r.Cron
+	}
+	s := Sun{Sunset: r.Sun == "sunset", Offset: r.Offset}
+	return s.String()
+}
+
+// String describes the action of the rule.
+func (r Rule) String() string {
+	action := r.Action
+	if action == "temperature" {
+		action = fmt.Sprintf("temperature %.1f°C", r.Temperature)
+	}
+	return fmt.Sprintf("%s: %s %s", r.Name, action, strings.Join(r.Devices, ", "))
+}
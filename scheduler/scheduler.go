@@ -0,0 +1,135 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/bpicode/fritzctl/logger"
+)
+
+// grace is the delay of a run that is tolerated even without catch-up.
+const grace = time.Minute
+
+// Scheduler triggers the rules at the scheduled times.
+type Scheduler struct {
+	rules    []Rule
+	schedule map[string]Schedule
+	catchup  time.Duration
+	state    State
+	execute  func(Rule) error
+	retry    bool
+}
+
+// New creates a Scheduler for validated rules. The state holds the last runs and is updated by the Scheduler. Rules
+// without a recorded last run are considered to have run just now. The rules are executed by calling execute.
+func New(rules *Rules, state State, execute func(Rule) error) *Scheduler {
+	s := &Scheduler{
+		rules:    rules.Rules,
+		schedule: make(map[string]Schedule),
+		catchup:  rules.Catchup,
+		state:    state,
+		execute:  execute,
+	}
+	for _, r := range rules.Rules {
+		s.schedule[r.Name], _ = r.schedule(rules.Location)
+	}
+	return s
+}
+
+// State returns the current state.
+func (s *Scheduler) State() State {
+	return s.state
+}
+
+// Evaluate executes the rules that were triggered since their last run, unless the trigger lies back longer than the
+// catch-up duration. Rules whose execution failed are retried by the next evaluation, unless they toggle devices: a
+// partial failure would be reverted by a retry. It returns true if the state changed.
+func (s *Scheduler) Evaluate(now time.Time) bool {
+	changed := false
+	s.retry = false
+	tolerance := s.catchup
+	if tolerance < grace {
+		tolerance = grace
+	}
+	for _, r := range s.rules {
+		last, ok := s.state[r.Name]
+		if !ok {
+			s.state[r.Name] = now
+			changed = true
+			continue
+		}
+		sched := s.schedule[r.Name]
+		earliest := now.Add(-tolerance)
+		if first := sched.Next(last); !first.IsZero() && first.Before(earliest) {
+			logger.Warn("Skipping missed run of rule", r.Name, "scheduled at", first.Format(time.RFC3339))
+			last = earliest
+			s.state[r.Name] = earliest
+			changed = true
+		}
+		trigger := latest(sched, last, now)
+		if trigger.IsZero() {
+			continue
+		}
+		if err := s.execute(r); err != nil {
+			logger.Warn("Rule", r.Name, "failed:", err)
+			if r.Repeatable() {
+				s.retry = true
+				continue
+			}
+			logger.Warn("Rule", r.Name, "toggles devices and is not retried")
+		}
+		s.state[r.Name] = trigger
+		changed = true
+	}
+	return changed
+}
+
+// latest returns the last trigger time in (after, until], or the zero time if there is none.
+func latest(sched Schedule, after, until time.Time) time.Time {
+	var last time.Time
+	for t := sched.Next(after); !t.IsZero() && !t.After(until); t = sched.Next(t) {
+		last = t
+	}
+	return last
+}
+
+// NextRun returns the next trigger time of the rule after now, or the zero time if there is none.
+func (s *Scheduler) NextRun(name string, now time.Time) time.Time {
+	sched, ok := s.schedule[name]
+	if !ok {
+		return time.Time{}
+	}
+	return sched.Next(now)
+}
+
+// Run evaluates the rules until ctx is done. After every change, the state is passed to save.
+func (s *Scheduler) Run(ctx context.Context, save func(State) error) {
+	for {
+		now := time.Now()
+		if s.Evaluate(now) {
+			if err := save(s.state); err != nil {
+				logger.Warn("Saving scheduler state failed:", err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.sleep(now)):
+		}
+	}
+}
+
+// sleep determines how long to wait until the next evaluation. It waits at most a minute, so that changes of the wall
+// clock, e.g. after suspension of the machine, are noticed.
+func (s *Scheduler) sleep(now time.Time) time.Duration {
+	wait := grace
+	if s.retry {
+		return wait
+	}
+	for _, r := range s.rules {
+		if next := s.schedule[r.Name].Next(now); !next.IsZero() && next.Sub(now) < wait {
+			wait = next.Sub(now)
+		}
+	}
+	return wait
+}
@@ -0,0 +1,148 @@
+package scheduler
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const exampleRules = `
+location:
+  latitude: 52.52
+  longitude: 13.405
+catchup: 2h
+rules:
+  - name: morning
+    cron: "0 7 * * *"
+    action: on
+    devices: [Lamp]
+  - name: evening
+    sun: sunset
+    offset: -30m
+    action: temperature
+    temperature: 21.5
+    devices: [HKR_1, HKR_2]
+`
+
+// TestParse tests the parsing of a rules file.
+func TestParse(t *testing.T) {
+	rules, err := Parse(strings.NewReader(exampleRules))
+	assert.NoError(t, err)
+	assert.Equal(t, 2*time.Hour, rules.Catchup)
+	assert.Len(t, rules.Rules, 2)
+	assert.Equal(t, -30*time.Minute, rules.Rules[1].Offset)
+	assert.Equal(t, "evening: temperature 21.5°C HKR_1, HKR_2", rules.Rules[1].String())
+}
+
+// TestParseInvalid tests the validation of rules.
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules string
+	}{
+		{name: "no name", rules: `rules: [{cron: "* * * * *", action: on, devices: [a]}]`},
+		{name: "duplicate name", rules: `rules: [{name: a, cron: "* * * * *", action: on, devices: [a]}, {name: a, cron: "* * * * *", action: on, devices: [a]}]`},
+		{name: "no schedule", rules: `rules: [{name: a, action: on, devices: [a]}]`},
+		{name: "both schedules", rules: `rules: [{name: a, cron: "* * * * *", sun: sunset, action: on, devices: [a]}]`},
+		{name: "sun without location", rules: `rules: [{name: a, sun: sunrise, action: on, devices: [a]}]`},
+		{name: "bad cron", rules: `rules: [{name: a, cron: "* *", action: on, devices: [a]}]`},
+		{name: "bad action", rules: `rules: [{name: a, cron: "* * * * *", action: dim, devices: [a]}]`},
+		{name: "bad temperature", rules: `rules: [{name: a, cron: "* * * * *", action: temperature, temperature: 35, devices: [a]}]`},
+		{name: "no temperature", rules: `rules: [{name: a, cron: "* * * * *", action: temperature, devices: [a]}]`},
+		{name: "no devices", rules: `rules: [{name: a, cron: "* * * * *", action: on}]`},
+		{name: "no yaml", rules: `rules: [`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tc.rules))
+			assert.Error(t, err)
+		})
+	}
+}
+
+// TestEvaluate tests regular runs, catch-up and skipping of missed runs.
+func TestEvaluate(t *testing.T) {
+	rules, err := Parse(strings.NewReader(`
+catchup: 2h
+rules:
+  - {name: seven, cron: "0 7 * * *", action: on, devices: [Lamp]}
+`))
+	assert.NoError(t, err)
+	day := func(h, m int) time.Time { return time.Date(2020, 1, 1, h, m, 0, 0, time.UTC) }
+	tests := []struct {
+		name    string
+		last    *time.Time
+		now     time.Time
+		fail    bool
+		runs    int
+		newLast time.Time
+	}{
+		{name: "first evaluation records baseline", now: day(7, 30), runs: 0, newLast: day(7, 30)},
+		{name: "not yet due", last: timePtr(day(6, 0)), now: day(6, 59), runs: 0, newLast: day(6, 0)},
+		{name: "due", last: timePtr(day(6, 59)), now: day(7, 0), runs: 1, newLast: day(7, 0)},
+		{name: "already run", last: timePtr(day(7, 0)), now: day(7, 1), runs: 0, newLast: day(7, 0)},
+		{name: "caught up", last: timePtr(day(5, 0)), now: day(8, 30), runs: 1, newLast: day(7, 0)},
+		{name: "missed too long ago", last: timePtr(day(5, 0)), now: day(9, 30), runs: 0, newLast: day(7, 30)},
+		{name: "failure is retried", last: timePtr(day(6, 59)), now: day(7, 0), fail: true, runs: 1, newLast: day(6, 59)},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			state := make(State)
+			if tc.last != nil {
+				state["seven"] = *tc.last
+			}
+			runs := 0
+			s := New(rules, state, func(r Rule) error {
+				runs++
+				if tc.fail {
+					return errors.New("box unreachable")
+				}
+				return nil
+			})
+			s.Evaluate(tc.now)
+			assert.Equal(t, tc.runs, runs)
+			assert.Equal(t, tc.newLast, s.State()["seven"])
+			assert.Equal(t, tc.fail, s.retry)
+		})
+	}
+}
+
+// TestEvaluateToggleNotRetried tests that failed toggle rules are recorded as run.
+func TestEvaluateToggleNotRetried(t *testing.T) {
+	rules, err := Parse(strings.NewReader(`rules: [{name: seven, cron: "0 7 * * *", action: toggle, devices: [Lamp, Fan]}]`))
+	assert.NoError(t, err)
+	seven := time.Date(2020, 1, 1, 7, 0, 0, 0, time.UTC)
+	s := New(rules, State{"seven": seven.Add(-time.Minute)}, func(r Rule) error {
+		return errors.New("not all operations could be completed")
+	})
+	assert.True(t, s.Evaluate(seven))
+	assert.Equal(t, seven, s.State()["seven"])
+	assert.False(t, s.retry)
+}
+
+// TestStateRoundTrip tests saving and loading of the state.
+func TestStateRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "scheduler")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "state.json")
+
+	s, err := LoadState(file)
+	assert.NoError(t, err)
+	assert.Empty(t, s)
+	s["a"] = time.Date(2020, 1, 1, 7, 0, 0, 0, time.UTC)
+	assert.NoError(t, s.Save(file))
+	loaded, err := LoadState(file)
+	assert.NoError(t, err)
+	assert.True(t, s["a"].Equal(loaded["a"]))
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
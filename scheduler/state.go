@@ -0,0 +1,24 @@
+package scheduler
+
+import (
+	"time"
+
+	"github.com/bpicode/fritzctl/internal/statefile"
+)
+
+// State records when every rule was last triggered, by rule name.
+type State map[string]time.Time
+
+// LoadState reads the state from a JSON file. A missing file yields an empty state.
+func LoadState(filename string) (State, error) {
+	s := make(State)
+	if err := statefile.Load(filename, &s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Save writes the state to a JSON file. The file is replaced atomically, so a crash does not leave a corrupted state.
+func (s State) Save(filename string) error {
+	return statefile.Save(filename, s)
+}
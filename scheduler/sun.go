@@ -0,0 +1,82 @@
+package scheduler
+
+import (
+	"math"
+	"time"
+)
+
+// Sun is a schedule triggered daily at sunrise or sunset, shifted by an offset. Days without the event, i.e. polar
+// days or nights, are skipped.
+type Sun struct {
+	Sunset    bool          // Sunset if true, sunrise otherwise.
+	Offset    time.Duration // Shift relative to the event, may be negative.
+	Latitude  float64       // In degrees, north is positive.
+	Longitude float64       // In degrees, east is positive.
+}
+
+// Next returns the first trigger time strictly after t.
+func (s *Sun) Next(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 12, 0, 0, 0, t.Location())
+	for i := -1; i < 366; i++ {
+		rise, set, ok := sunriseSunset(day.AddDate(0, 0, i), s.Latitude, s.Longitude)
+		if !ok {
+			continue
+		}
+		event := rise
+		if s.Sunset {
+			event = set
+		}
+		if trigger := event.Add(s.Offset).In(t.Location()); trigger.After(t) {
+			return trigger
+		}
+	}
+	return time.Time{}
+}
+
+// String describes the schedule.
+func (s *Sun) String() string {
+	event := "sunrise"
+	if s.Sunset {
+		event = "sunset"
+	}
+	switch {
+	case s.Offset > 0:
+		return event + "+" + s.Offset.String()
+	case s.Offset < 0:
+		return event + s.Offset.String()
+	}
+	return event
+}
+
+const (
+	j2000    = 2451545.0
+	unixJD   = 2440587.5
+	toRad    = math.Pi / 180
+	toDeg    = 180 / math.Pi
+	dayInSec = 86400
+)
+
+// sunriseSunset computes sunrise and sunset of the day around noon, following the sunrise equation with corrections for
+// refraction and the solar disc, see https://en.wikipedia.org/wiki/Sunrise_equation. The result is accurate to about
+// a minute at moderate latitudes. ok is false if the sun does not rise or set on that day.
+func sunriseSunset(noon time.Time, lat, lon float64) (rise, set time.Time, ok bool) {
+	jd := float64(noon.Unix())/dayInSec + unixJD
+	n := math.Floor(jd - j2000 + lon/360 + 0.5)
+	jStar := n - lon/360
+	m := math.Mod(357.5291+0.98560028*jStar, 360)
+	c := 1.9148*math.Sin(m*toRad) + 0.0200*math.Sin(2*m*toRad) + 0.0003*math.Sin(3*m*toRad)
+	lambda := math.Mod(m+c+180+102.9372, 360)
+	transit := j2000 + jStar + 0.0053*math.Sin(m*toRad) - 0.0069*math.Sin(2*lambda*toRad)
+	sinDecl := math.Sin(lambda*toRad) * math.Sin(23.4397*toRad)
+	cosDecl := math.Cos(math.Asin(sinDecl))
+	cosHourAngle := (math.Sin(-0.833*toRad) - math.Sin(lat*toRad)*sinDecl) / (math.Cos(lat*toRad) * cosDecl)
+	if cosHourAngle < -1 || cosHourAngle > 1 {
+		return time.Time{}, time.Time{}, false
+	}
+	hourAngle := math.Acos(cosHourAngle) * toDeg
+	return julianToTime(transit - hourAngle/360), julianToTime(transit + hourAngle/360), true
+}
+
+func julianToTime(jd float64) time.Time {
+	return time.Unix(int64(math.Round((jd-unixJD)*dayInSec)), 0)
+}
@@ -0,0 +1,57 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSunriseSunset compares with published times for Berlin and Sydney.
+func TestSunriseSunset(t *testing.T) {
+	berlin, sydney := time.FixedZone("CEST", 2*3600), time.FixedZone("AEST", 10*3600)
+	tests := []struct {
+		name     string
+		noon     time.Time
+		lat, lon float64
+		rise     time.Time
+		set      time.Time
+	}{
+		{name: "Berlin midsummer", noon: time.Date(2020, 6, 21, 12, 0, 0, 0, berlin), lat: 52.52, lon: 13.405,
+			rise: time.Date(2020, 6, 21, 4, 43, 0, 0, berlin), set: time.Date(2020, 6, 21, 21, 33, 0, 0, berlin)},
+		{name: "Sydney winter", noon: time.Date(2020, 6, 21, 12, 0, 0, 0, sydney), lat: -33.87, lon: 151.21,
+			rise: time.Date(2020, 6, 21, 7, 0, 0, 0, sydney), set: time.Date(2020, 6, 21, 16, 54, 0, 0, sydney)},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rise, set, ok := sunriseSunset(tc.noon, tc.lat, tc.lon)
+			assert.True(t, ok)
+			assert.WithinDuration(t, tc.rise, rise, 3*time.Minute)
+			assert.WithinDuration(t, tc.set, set, 3*time.Minute)
+		})
+	}
+}
+
+// TestSunriseSunsetPolar tests days without sunrise.
+func TestSunriseSunsetPolar(t *testing.T) {
+	_, _, ok := sunriseSunset(time.Date(2020, 12, 21, 12, 0, 0, 0, time.UTC), 78.22, 15.65)
+	assert.False(t, ok)
+}
+
+// TestSunNext tests the schedule with an offset.
+func TestSunNext(t *testing.T) {
+	loc := time.FixedZone("CEST", 2*3600)
+	s := &Sun{Sunset: true, Offset: -30 * time.Minute, Latitude: 52.52, Longitude: 13.405}
+	next := s.Next(time.Date(2020, 6, 21, 8, 0, 0, 0, loc))
+	assert.WithinDuration(t, time.Date(2020, 6, 21, 21, 3, 0, 0, loc), next, 3*time.Minute)
+	after := s.Next(next)
+	assert.Equal(t, 22, after.Day())
+	assert.Equal(t, "sunset-30m0s", s.String())
+}
+
+// TestSunString tests the description of the schedule.
+func TestSunString(t *testing.T) {
+	assert.Equal(t, "sunrise", (&Sun{}).String())
+	assert.Equal(t, "sunrise+30m0s", (&Sun{Offset: 30 * time.Minute}).String())
+	assert.Equal(t, "sunset-1h0m0s", (&Sun{Sunset: true, Offset: -time.Hour}).String())
+}